@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"errors"
+	"log"
+	"os"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"gorm.io/gorm"
+)
+
+// importPostalCodes imports the addresses of the postal code dataset of the
+// CSV file given as the only argument, replacing the addresses that already
+// exist for the same postal code. Either every row is imported or none is.
+func importPostalCodes(db *gorm.DB, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: import-postal-codes <file.csv>")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	addressService := services.NewAddressService(repositories.NewAddressRepository(db), services.AddressModeFromEnv())
+	imported, err := addressService.ImportCSV(utils.BackgroundContext(), file)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Imported %d postal codes from %s", imported, args[0])
+	return nil
+}
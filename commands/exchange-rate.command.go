@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"errors"
+	"log"
+	"os"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"gorm.io/gorm"
+)
+
+// importExchangeRates imports the exchange rates of the CSV file given as the
+// only argument, replacing the rates that already exist for the same currency
+// pair and date. Either every row is imported or none is.
+func importExchangeRates(db *gorm.DB, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: import-exchange-rates <file.csv>")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	exchangeRateService := services.NewExchangeRateService(repositories.NewExchangeRateRepository(db))
+	imported, err := exchangeRateService.ImportCSV(utils.BackgroundContext(), file)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Imported %d exchange rates from %s", imported, args[0])
+	return nil
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// command is a maintenance task run from the command line instead of the HTTP
+// server, such as `go run . import-exchange-rates rates.csv`.
+type command struct {
+	usage string                                 // arguments expected by the command
+	run   func(db *gorm.DB, args []string) error // runs the command with its arguments
+}
+
+// commands lists every command by the name used on the command line.
+var commands = map[string]command{
+	"expire-reservations":   {usage: "[minutes]", run: expireReservations},
+	"import-exchange-rates": {usage: "<file.csv>", run: importExchangeRates},
+	"import-postal-codes":   {usage: "<file.csv>", run: importPostalCodes},
+	"low-stock-report":      {usage: "[file.csv]", run: lowStockReport},
+	"normalize-phones":      {usage: "", run: normalizePhones},
+	"purge-trash":           {usage: "[days]", run: purgeTrash},
+}
+
+// Run runs the command named by the first argument with the remaining arguments.
+//
+// It returns an error listing the available commands if the command does not
+// exist, or the error returned by the command.
+func Run(db *gorm.DB, args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, available commands:\n%s", args[0], Usage())
+	}
+	return cmd.run(db, args[1:])
+}
+
+// Usage returns the usage of every available command, one per line.
+func Usage() string {
+	var lines []string
+	for name, cmd := range commands {
+		lines = append(lines, fmt.Sprintf("  %s %s", name, cmd.usage))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"errors"
+	"log"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"gorm.io/gorm"
+)
+
+// normalizePhones normalizes the phones of the existing contacts into E.164
+// and logs the phones that could not be parsed, which must be fixed by hand.
+func normalizePhones(db *gorm.DB, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: normalize-phones")
+	}
+
+	addressService := services.NewAddressService(repositories.NewAddressRepository(db), services.AddressModeFromEnv())
+	contactService := services.NewContactService(repositories.NewContactRepository(db), addressService)
+	updated, failures, err := contactService.NormalizePhones(utils.BackgroundContext())
+	for _, failure := range failures {
+		log.Printf("Contact %d: could not parse the %s %q for the country %q",
+			failure.ContactID, failure.Field, failure.Phone, failure.Country)
+	}
+	log.Printf("Normalized the phones of %d contacts, %d phones could not be parsed", updated, len(failures))
+	return err
+}
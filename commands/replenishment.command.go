@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lowStockReport writes the low-stock report to the CSV file given as the only
+// argument, or to the report of the day in REPLENISHMENT_REPORT_DIR when no
+// argument is given.
+func lowStockReport(db *gorm.DB, args []string) error {
+	switch len(args) {
+	case 0:
+		return WriteLowStockReport(db, LowStockReportPath(time.Now()))
+	case 1:
+		return WriteLowStockReport(db, args[0])
+	default:
+		return errors.New("usage: low-stock-report [file.csv]")
+	}
+}
+
+// LowStockReportPath returns the path of the low-stock report of the given
+// day, `low-stock-YYYY-MM-DD.csv` in the directory read from the
+// REPLENISHMENT_REPORT_DIR environment variable, `reports` by default.
+func LowStockReportPath(day time.Time) string {
+	return filepath.Join(utils.GetEnv("REPLENISHMENT_REPORT_DIR", "reports"), "low-stock-"+day.Format(time.DateOnly)+".csv")
+}
+
+// WriteLowStockReport writes the replenishment suggestions to a CSV file at
+// path, creating its directory if needed and replacing the file if it exists.
+// It is also run by the server every REPLENISHMENT_REPORT_HOURS.
+func WriteLowStockReport(db *gorm.DB, path string) error {
+	replenishmentService := services.NewReplenishmentService(
+		repositories.NewReplenishmentRepository(db),
+		repositories.NewSupplierRepository(db),
+		services.ReplenishmentPolicyFromEnv(),
+	)
+	replenishments, err := replenishmentService.Suggest(utils.BackgroundContext())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := services.WriteLowStockReport(file, replenishments); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	suggested := 0
+	for _, replenishment := range replenishments {
+		suggested += len(replenishment.Suggestions)
+	}
+	log.Printf("Wrote the low-stock report of %d product suppliers to %s", suggested, path)
+	return nil
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"errors"
+	"log"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// expireReservations expires the stock reservations held for more minutes than
+// the TTL given as the only argument, or than RESERVATION_TTL_MINUTES when no
+// argument is given.
+func expireReservations(db *gorm.DB, args []string) error {
+	ttl := services.ReservationTTLFromEnv()
+	switch len(args) {
+	case 0:
+	case 1:
+		minutes, err := strconv.Atoi(args[0])
+		if err != nil || minutes < 0 {
+			return errors.New("usage: expire-reservations [minutes]")
+		}
+		ttl = time.Duration(minutes) * time.Minute
+	default:
+		return errors.New("usage: expire-reservations [minutes]")
+	}
+	return ExpireReservations(db, ttl)
+}
+
+// ExpireReservations expires the stock reservations of the draft orders held
+// for longer than ttl. It is also run every minute by the server when
+// RESERVATION_TTL_MINUTES is not zero.
+func ExpireReservations(db *gorm.DB, ttl time.Duration) error {
+	expired, err := services.ExpireReservations(utils.BackgroundContext(), ttl, repositories.NewStockReservationRepository(db))
+	if expired > 0 {
+		log.Printf("Expired %d stock reservations", expired)
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"errors"
+	"log"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// purgeTrash permanently deletes the entities deleted more days ago than the
+// retention given as the only argument, or than TRASH_RETENTION_DAYS when no
+// argument is given.
+func purgeTrash(db *gorm.DB, args []string) error {
+	retention := services.TrashRetentionFromEnv()
+	switch len(args) {
+	case 0:
+	case 1:
+		days, err := strconv.Atoi(args[0])
+		if err != nil || days < 0 {
+			return errors.New("usage: purge-trash [days]")
+		}
+		retention = time.Duration(days) * 24 * time.Hour
+	default:
+		return errors.New("usage: purge-trash [days]")
+	}
+	return PurgeTrash(db, retention)
+}
+
+// PurgeTrash permanently deletes the entities of every table deleted more than
+// retention ago. Order lines and order details are purged before the orders,
+// product suppliers and contacts before the customers, suppliers and products
+// they reference. It is also run daily by the server when TRASH_RETENTION_DAYS
+// is set.
+func PurgeTrash(db *gorm.DB, retention time.Duration) error {
+	purged, err := services.PurgeTrash(utils.BackgroundContext(), retention,
+		repositories.NewOrderProductSupplierRepository(db),
+		repositories.NewPurchaseOrderRepository(db),
+		repositories.NewOrderRepository(db),
+		repositories.NewProductSupplierRepository(db),
+		repositories.NewContactRepository(db),
+		repositories.NewCustomerRepository(db),
+		repositories.NewSupplierRepository(db),
+		repositories.NewProductRepository(db),
+		repositories.NewExchangeRateRepository(db),
+		repositories.NewWarehouseRepository(db),
+	)
+	log.Printf("Purged %d deleted entities from the trash", purged)
+	return err
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+	"store/domain/postalcode"
+	"store/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddressController is an interface that defines the methods for the address
+// controller.
+//
+// The methods in this interface are used to look up the addresses of the
+// Brazilian postal codes.
+type AddressController interface {
+	GetAddressByPostalCode(ctx *gin.Context) // Get the address of a postal code
+}
+
+// addressController is a struct that contains a pointer to an addressService
+// and implements the AddressController.
+type addressController struct {
+	addressService services.AddressService
+}
+
+// NewAddressController creates a new instance of addressController with the
+// provided addressService and returns it as an AddressController.
+func NewAddressController(addressService services.AddressService) AddressController {
+	return &addressController{addressService: addressService}
+}
+
+// Handles the HTTP request for retrieving the address of a postal code.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// postal code from the URL parameters, with or without the dash of its mask.
+// If it is not 8 digits, it returns a 400 error response. It then calls the
+// GetByPostalCode method of the address service. If the postal code is not in
+// the dataset, it returns a 404 error response, and if any other error occurs,
+// a 500 error response. On success, it returns a 200 status code with the
+// street, district, city and state of the postal code.
+func (c *addressController) GetAddressByPostalCode(ctx *gin.Context) {
+	cep, err := postalcode.Parse(ctx.Param("cep"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	address, err := c.addressService.GetByPostalCode(ctx, cep)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, address)
+}
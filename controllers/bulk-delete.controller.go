@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"store/domain/apperrors"
+	"store/domain/repositories"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkDeleteRequest is the request body of a bulk delete, for lists of ids too
+// long for the query string.
+type bulkDeleteRequest struct {
+	IDs []uint `json:"ids"` // ids of the entities to delete
+}
+
+// bulkDeleteIDs reads the ids of a bulk delete from the `ids` query parameter,
+// repeated or comma separated, or from the request body when the query string
+// has none. It returns an error if an id is not a positive integer.
+func bulkDeleteIDs(ctx *gin.Context) ([]uint, error) {
+	var ids []uint
+	for _, values := range ctx.QueryArray("ids") {
+		for _, value := range strings.Split(values, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(value), 10, 0)
+			if err != nil || id == 0 {
+				return nil, apperrors.BadRequest("invalid_id", fmt.Sprintf("invalid id %q", value))
+			}
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) > 0 {
+		return ids, nil
+	}
+
+	var request bulkDeleteRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		return nil, malformedBody(err)
+	}
+	return request.IDs, nil
+}
+
+// bulkDelete handles the HTTP request for deleting multiple entities by their
+// IDs with the DeleteAll method of a service.
+//
+// The ids are read by bulkDeleteIDs. If they are invalid, missing or more than
+// repositories.MaxDeleteIDs, it returns a 400 error response, and if the
+// deletion fails, a 500 error response in which case nothing is deleted. On
+// success, it returns a 200 status code with the number of entities deleted
+// and the outcome for each id: deleted, not_found or blocked, with the reason
+// why a blocked entity was kept.
+func bulkDelete(ctx *gin.Context, deleteAll func(*gin.Context, []uint) ([]repositories.DeleteResult, error)) {
+	ids, err := bulkDeleteIDs(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	results, err := deleteAll(ctx, ids)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	deleted := 0
+	for _, result := range results {
+		if result.Status == repositories.DeleteStatusDeleted {
+			deleted++
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": deleted, "results": results})
+}
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"net/http"
-	"store/domain/entities"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
 	"store/services"
 	"store/utils"
 
@@ -14,12 +16,17 @@ import (
 // The methods in this interface are used to create, retrieve, update, and delete
 // contacts in the database.
 type ContactController interface {
-	CreateContact(ctx *gin.Context)     // Create a new contact
-	GetAllContacts(ctx *gin.Context)    // Get all contacts
-	GetContactByID(ctx *gin.Context)    // Get a contact by ID
-	UpdateContact(ctx *gin.Context)     // Update a contact
-	DeleteContact(ctx *gin.Context)     // Delete a contact
-	DeleteAllContacts(ctx *gin.Context) // Delete all contacts
+	CreateContact(ctx *gin.Context)       // Create a new contact
+	GetAllContacts(ctx *gin.Context)      // Get all contacts
+	GetContactByID(ctx *gin.Context)      // Get a contact by ID
+	UpdateContact(ctx *gin.Context)       // Update a contact
+	PatchContact(ctx *gin.Context)        // Partially update a contact
+	DeleteContact(ctx *gin.Context)       // Delete a contact
+	DeleteAllContacts(ctx *gin.Context)   // Delete all contacts
+	GetCustomerContacts(ctx *gin.Context) // Get the contacts of a customer
+	GetSupplierContacts(ctx *gin.Context) // Get the contacts of a supplier
+	GetContactTrash(ctx *gin.Context)     // Get the deleted contacts
+	RestoreContact(ctx *gin.Context)      // Restore a deleted contact
 }
 
 // contactController is a struct that contains a pointer to a contactService and
@@ -42,40 +49,49 @@ func NewContactController(contactService services.ContactService) ContactControl
 // Handles the HTTP request for creating a new contact.
 //
 // This method takes a pointer to a *gin.Context as a parameter and binds the JSON
-// request body to a contact entity. If the request body is not valid JSON, it returns
-// a 400 error response. It then calls the Create method of the contact service to
-// create the contact in the database. If the creation fails, it returns a 500 error
-// response. On success, it returns a 201 status code along with the created contact
-// in the response body.
+// request body to a dto.CreateContactRequest. If the request body is not valid
+// JSON, it returns a 400 error response, and if a field is invalid, such as a
+// missing phone or a contact of both a customer and a supplier, a 422 error
+// response, as described in bindRequest. It then calls the Create method of the
+// contact service to create the contact in the database. If the creation fails,
+// it returns a 500 error response. On success, it returns a 201 status code
+// along with the created contact in the response body.
 func (c *contactController) CreateContact(ctx *gin.Context) {
-	var contact entities.Contact
-
-	err := ctx.ShouldBindJSON(&contact)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request dto.CreateContactRequest
+	if !bindRequest(ctx, &request) {
+		return
 	}
+	contact := request.ToEntity()
 
-	err = c.contactService.Create(ctx, &contact)
+	err := c.contactService.Create(ctx, contact)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
 
 	ctx.JSON(http.StatusCreated, contact)
 }
 
-// Retrieves all contacts from the database.
+// Handles the HTTP request for retrieving a page of contacts.
 //
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.Contact and an error. If something goes wrong, the
-// method returns an empty slice and an error.
-//
-// The method returns a slice of pointers to entities.Contact and an error. If the
-// contacts are found, the method returns the contacts and nil. If no contacts are
-// found or an error occurs, the method returns an empty slice and an error.
+// This method takes a pointer to a *gin.Context as a parameter. It parses the
+// pagination, sorting and filters of the query string against the
+// ContactListSchema of the repositories and calls the GetAll method of the
+// contact service with them. If the query string is invalid, it returns a 400
+// error response, and if the retrieval fails, a 500 error response. On success,
+// it returns a 200 status code along with the page of contacts, its total count
+// and the link to the next page.
 func (c *contactController) GetAllContacts(ctx *gin.Context) {
-	contacts, err := c.contactService.GetAll(ctx)
+	q, err := query.Parse(ctx.Request.URL, repositories.ContactListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	contacts, err := c.contactService.GetAll(ctx, q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
 
 	ctx.JSON(http.StatusOK, contacts)
@@ -87,13 +103,23 @@ func (c *contactController) GetAllContacts(ctx *gin.Context) {
 // contact ID from the URL parameters. It then calls the GetByID method of the
 // contact service to retrieve the contact from the database. If the contact is
 // found, it returns a 200 status code with the contact in the response body.
-// If an error occurs during the retrieval, it returns a 500 error response.
+// If the contact is not found, it returns a 404 error response, and if any
+// other error occurs during the retrieval, a 500 error response.
+//
+// The response carries the ETag of the version of the contact, and a request
+// whose If-None-Match header already matches it is answered with a 304 Not
+// Modified status, as described in notModified.
 func (c *contactController) GetContactByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	contact, err := c.contactService.GetByID(ctx, utils.StringToUint(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, contact.Version) {
+		return
 	}
 
 	ctx.JSON(http.StatusOK, contact)
@@ -101,26 +127,90 @@ func (c *contactController) GetContactByID(ctx *gin.Context) {
 
 // Handles the HTTP request for updating a contact.
 //
-// This method takes a pointer to a *gin.Context as a parameter and extracts the
-// contact data from the request body. It then calls the Update method of the
-// contact service to update the contact in the database. If the contact is
-// updated successfully, it returns a 200 status code with the updated contact
-// in the response body. If an error occurs during the update, it returns a 500
-// error response.
+// This method takes a pointer to a *gin.Context as a parameter and binds the
+// request body to a dto.UpdateContactRequest, answered as described in
+// bindRequest when it is invalid, for the contact identified by the ID in the
+// URL parameters. It then calls the Update method of the contact service to
+// save the fields of the request. If the contact is updated successfully, it
+// returns a 200 status code with the updated contact in the response body. If
+// the contact is not found, it returns a 404 error response, and if an error
+// occurs during the update, a 500 error response.
+//
+// The request must send the ETag of the contact in its If-Match header, as
+// described in ifMatch. If the contact has been changed since that version, it
+// returns a 412 error response, and on success the new ETag is sent with the
+// response.
 func (c *contactController) UpdateContact(ctx *gin.Context) {
-	var contact entities.Contact
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 
-	err := ctx.ShouldBindJSON(&contact)
+	var request dto.UpdateContactRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+
+	contact, err := c.contactService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
+	fields := request.ApplyTo(contact)
+	contact.Version = version
 
-	err = c.contactService.Update(ctx, &contact)
+	err = c.contactService.Update(ctx, contact, fields...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, contact)
+	ctx.Header("ETag", etag(contact.Version))
+	ctx.JSON(http.StatusOK, contact)
+}
+
+// Handles the HTTP request for partially updating a contact.
+//
+// The patch document in the request body is applied to the stored contact
+// identified by the ID in the URL parameters, as described in applyPatch, and
+// only the fields changed by the patch are saved by the Update method of the
+// contact service. The ID in the URL always takes precedence over an ID in the
+// patch. The patched contact must follow the rules of a full update, otherwise
+// a 422 error response is returned as described in validatePatched.
+//
+// The request must send the ETag of the contact in its If-Match header, as
+// described in ifMatch. If the contact is not found, it returns a 404 error
+// response, if it has been changed since that version a 412 error response, and
+// if the update fails a 500 error response. On success, it returns a 200 status
+// code along with the updated contact and its new ETag.
+func (c *contactController) PatchContact(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.contactService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	contact, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateContactRequest](ctx, contact, fields) {
+		return
+	}
+	contact.ID = id
+	contact.Version = version
+
+	err = c.contactService.Update(ctx, contact, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(contact.Version))
+	ctx.JSON(http.StatusOK, contact)
 }
 
 // Handles the HTTP request for deleting a contact by its ID.
@@ -130,32 +220,92 @@ func (c *contactController) UpdateContact(ctx *gin.Context) {
 // contact service to delete the contact from the database. If the deletion is
 // successful, it returns a 200 status code with a success message in the response
 // body. If an error occurs during the deletion, it returns a 500 error response.
+//
+// The request must send the ETag of the contact in its If-Match header, as
+// described in ifMatch. If the contact has been changed since that version, it
+// returns a 412 error response.
+//
+// With the `purge=true` query parameter, the contact is permanently deleted
+// instead, which only administrators can do, as described in purgeDeleted.
 func (c *contactController) DeleteContact(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Contact", c.contactService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 
-	err := c.contactService.Delete(ctx, utils.StringToUint(id))
+	err := c.contactService.Delete(ctx, utils.StringToUint(id), version)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"message": "Contact deleted successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Contact deleted successfully"})
 }
 
 // Handles the HTTP request for deleting multiple contacts by their IDs.
 //
-// This method takes a pointer to a *gin.Context as a parameter and extracts the
-// IDs of the contacts to be deleted from the URL query parameters. It then calls
-// the DeleteAll method of the contact service to delete the contacts from the
-// database. If the deletion is successful, it returns a 200 status code with a
-// success message in the response body. If an error occurs during the deletion,
-// it returns a 500 error response.
+// The IDs are read from the `ids` query parameter, or from the `ids` field of
+// the request body for long lists. The contacts are deleted in a single
+// transaction by the DeleteAll method of the contact service, and the response
+// reports for each id whether it was deleted, not found or blocked, as
+// described in bulkDelete.
 func (c *contactController) DeleteAllContacts(ctx *gin.Context) {
-	ids := ctx.QueryArray("ids")
+	bulkDelete(ctx, c.contactService.DeleteAll)
+}
+
+// Handles the HTTP request for retrieving the contacts of a customer.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// customer ID from the URL parameters. It then calls the GetAllByCustomerID
+// method of the contact service. If the retrieval fails, it returns a 500 error
+// response. On success, it returns a 200 status code along with the contacts of
+// the customer.
+func (c *contactController) GetCustomerContacts(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	contacts, err := c.contactService.GetAllByCustomerID(ctx, utils.StringToUint(id))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, contacts)
+}
+
+// Handles the HTTP request for retrieving the contacts of a supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// supplier ID from the URL parameters. It then calls the GetAllBySupplierID
+// method of the contact service. If the retrieval fails, it returns a 500 error
+// response. On success, it returns a 200 status code along with the contacts of
+// the supplier.
+func (c *contactController) GetSupplierContacts(ctx *gin.Context) {
+	id := ctx.Param("id")
 
-	err := c.contactService.DeleteAll(ctx, utils.StringArrToUintArr(ids))
+	contacts, err := c.contactService.GetAllBySupplierID(ctx, utils.StringToUint(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"message": "All contacts deleted successfully"})
+	ctx.JSON(http.StatusOK, contacts)
+}
+
+// Handles the HTTP request for retrieving a page of the deleted contacts.
+//
+// The list query is validated against the ContactListSchema of the
+// repositories, as described in listTrash.
+func (c *contactController) GetContactTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.ContactListSchema, c.contactService)
+}
+
+// Handles the HTTP request for restoring a deleted contact by its ID, as
+// described in restoreDeleted.
+func (c *contactController) RestoreContact(ctx *gin.Context) {
+	restoreDeleted(ctx, "Contact", c.contactService)
 }
@@ -2,7 +2,10 @@ package controllers
 
 import (
 	"net/http"
-	"store/domain/entities"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
+	"store/domain/taxid"
 	"store/services"
 	"store/utils"
 
@@ -17,9 +20,13 @@ type CustomerController interface {
 	CreateCustomer(ctx *gin.Context)     // Create a new customer
 	GetAllCustomers(ctx *gin.Context)    // Get all customers
 	GetCustomerByID(ctx *gin.Context)    // Get a customer by ID
+	GetCustomerByTaxID(ctx *gin.Context) // Get a customer by its CPF or CNPJ
 	UpdateCustomer(ctx *gin.Context)     // Update a customer
+	PatchCustomer(ctx *gin.Context)      // Partially update a customer
 	DeleteCustomer(ctx *gin.Context)     // Delete a customer
 	DeleteAllCustomers(ctx *gin.Context) // Delete multiple customers
+	GetCustomerTrash(ctx *gin.Context)   // Get the deleted customers
+	RestoreCustomer(ctx *gin.Context)    // Restore a deleted customer
 }
 
 // customerController is a struct that contains a pointer to a customerService and
@@ -42,38 +49,47 @@ func NewCustomerController(customerService services.CustomerService) CustomerCon
 // Handles the HTTP request for creating a new customer.
 //
 // This method takes a pointer to a *gin.Context as a parameter and binds the JSON
-// request body to a customer entity. If the request body is not valid JSON, it returns
-// a 400 error response. It then calls the Create method of the customer service to
-// create the customer in the database. If the creation fails, it returns a 500 error
-// response. On success, it returns a 201 status code along with the created customer
-// in the response body.
+// request body to a dto.CreateCustomerRequest. If the request body is not valid
+// JSON, it returns a 400 error response, and if a field is invalid, such as an
+// empty tax id or a birthday in the future, a 422 error response, as described
+// in bindRequest. It then calls the Create method of the customer service to
+// create the customer in the database. If the creation fails, it returns a 500
+// error response. On success, it returns a 201 status code along with the
+// created customer in the response body.
 func (c *customerController) CreateCustomer(ctx *gin.Context) {
-	var customer entities.Customer
-
-	if err := ctx.ShouldBindJSON(&customer); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request dto.CreateCustomerRequest
+	if !bindRequest(ctx, &request) {
 		return
 	}
+	customer := request.ToEntity()
 
-	if err := c.customerService.Create(ctx, &customer); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.customerService.Create(ctx, customer); err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, customer)
 }
 
-// Handles the HTTP request for getting all customers from the database.
+// Handles the HTTP request for retrieving a page of customers.
 //
-// This method takes a pointer to a *gin.Context as a parameter. It calls the GetAll
-// method of the customer service to get all customers from the database. If the
-// retrieval fails, it returns a 500 error response. On success, it returns a 200 status
-// code along with the customers in the response body.
+// This method takes a pointer to a *gin.Context as a parameter. It parses the
+// pagination, sorting and filters of the query string against the
+// CustomerListSchema of the repositories and calls the GetAll method of the
+// customer service with them. If the query string is invalid, it returns a 400
+// error response, and if the retrieval fails, a 500 error response. On success,
+// it returns a 200 status code along with the page of customers, its total
+// count and the link to the next page.
 func (c *customerController) GetAllCustomers(ctx *gin.Context) {
-	customers, err := c.customerService.GetAll(ctx)
+	q, err := query.Parse(ctx.Request.URL, repositories.CustomerListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
+	customers, err := c.customerService.GetAll(ctx, q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -87,42 +103,143 @@ func (c *customerController) GetAllCustomers(ctx *gin.Context) {
 // customer service to retrieve the customer from the database. If the customer is
 // found, it returns a 200 status code with the customer in the response body.
 // If an error occurs during the retrieval, it returns a 500 error response.
+//
+// The response carries the ETag of the version of the customer, and a request
+// whose If-None-Match header already matches it is answered with a 304 Not
+// Modified status, as described in notModified.
 func (c *customerController) GetCustomerByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	contact, err := c.customerService.GetByID(ctx, utils.StringToUint(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, contact.Version) {
 		return
 	}
 
 	ctx.JSON(http.StatusOK, contact)
 }
 
+// Handles the HTTP request for retrieving a customer by its tax ID.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// CPF or CNPJ of the customer from the URL parameters, unmasked or with the
+// dots and dash of its mask. If it is not a valid CPF or CNPJ, it returns a 400
+// error response. It then calls the GetByTaxID method of the customer service.
+// If no customer has the tax ID, it returns a 404 error response, and if any
+// other error occurs, a 500 error response. On success, it returns a 200 status
+// code with the customer, along with its ETag as described in notModified.
+func (c *customerController) GetCustomerByTaxID(ctx *gin.Context) {
+	taxID, err := taxid.Parse(ctx.Param("taxId"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	customer, err := c.customerService.GetByTaxID(ctx, taxID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, customer.Version) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, customer)
+}
+
 // Handles the HTTP request for updating a customer.
 //
 // This method takes a pointer to a *gin.Context as a parameter and binds the JSON
-// request body to a customer entity. If the request body is not valid JSON, it returns
-// a 400 error response. It then calls the Update method of the customer service to
-// update the customer in the database. If the creation fails, it returns a 500 error
-// response. On success, it returns a 200 status code along with the updated customer
-// in the response body.
+// request body to a dto.UpdateCustomerRequest, answered as described in
+// bindRequest when it is invalid. It then calls the Update method of the customer
+// service to save the fields of the request on the customer identified by the ID
+// in the URL parameters. If the customer is not found, it returns a 404 error
+// response, and if the update fails, a 500 error response. On success, it
+// returns a 200 status code along with the updated customer in the response
+// body.
+//
+// The request must send the ETag of the customer in its If-Match header, as
+// described in ifMatch. If the customer has been changed since that version, it
+// returns a 412 error response, and on success the new ETag is sent with the
+// response.
 func (c *customerController) UpdateCustomer(ctx *gin.Context) {
-	var customer entities.Customer
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.UpdateCustomerRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
 
-	if err := ctx.ShouldBindJSON(&customer); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	customer, err := c.customerService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
+	fields := request.ApplyTo(customer)
+	customer.Version = version
 
-	if err := c.customerService.Update(ctx, &customer); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err = c.customerService.Update(ctx, customer, fields...)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", etag(customer.Version))
 	ctx.JSON(http.StatusCreated, customer)
 }
 
+// Handles the HTTP request for partially updating a customer.
+//
+// The patch document in the request body is applied to the stored customer
+// identified by the ID in the URL parameters, as described in applyPatch, and
+// only the fields changed by the patch are saved by the Update method of the
+// customer service. The ID in the URL always takes precedence over an ID in the
+// patch. The patched customer must follow the rules of a full update, otherwise
+// a 422 error response is returned as described in validatePatched.
+//
+// The request must send the ETag of the customer in its If-Match header, as
+// described in ifMatch. If the customer is not found, it returns a 404 error
+// response, if it has been changed since that version a 412 error response, and
+// if the update fails a 500 error response. On success, it returns a 200 status
+// code along with the updated customer and its new ETag.
+func (c *customerController) PatchCustomer(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.customerService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	customer, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateCustomerRequest](ctx, customer, fields) {
+		return
+	}
+	customer.ID = id
+	customer.Version = version
+
+	err = c.customerService.Update(ctx, customer, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(customer.Version))
+	ctx.JSON(http.StatusOK, customer)
+}
+
 // Handles the HTTP request for deleting a customer by its ID.
 //
 // This method takes a pointer to a *gin.Context as a parameter and extracts the
@@ -130,12 +247,27 @@ func (c *customerController) UpdateCustomer(ctx *gin.Context) {
 // customer service to delete the customer from the database. If the customer is
 // found, it returns a 200 status code with a message in the response body. If an
 // error occurs during the deletion, it returns a 500 error response.
+//
+// The request must send the ETag of the customer in its If-Match header, as
+// described in ifMatch. If the customer has been changed since that version, it
+// returns a 412 error response, and if it has orders a 409 error response.
+//
+// With the `purge=true` query parameter, the customer is permanently deleted
+// instead, which only administrators can do, as described in purgeDeleted.
 func (c *customerController) DeleteCustomer(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Customer", c.customerService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 
-	err := c.customerService.Delete(ctx, utils.StringToUint(id))
+	err := c.customerService.Delete(ctx, utils.StringToUint(id), version)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -144,20 +276,25 @@ func (c *customerController) DeleteCustomer(ctx *gin.Context) {
 
 // Handles the HTTP request for deleting multiple customers by their IDs.
 //
-// This method takes a pointer to a *gin.Context as a parameter and extracts the
-// customer IDs from the URL query parameters. It then calls the DeleteAll method
-// of the customer service to delete the customers from the database. If the
-// deletion is successful, it returns a 200 status code with a message in the
-// response body. If an error occurs during the deletion, it returns a 500 error
-// response.
+// The IDs are read from the `ids` query parameter, or from the `ids` field of
+// the request body for long lists. The customers are deleted in a single
+// transaction by the DeleteAll method of the customer service, and the response
+// reports for each id whether it was deleted, not found or blocked, as
+// described in bulkDelete.
 func (c *customerController) DeleteAllCustomers(ctx *gin.Context) {
-	ids := ctx.QueryArray("ids")
+	bulkDelete(ctx, c.customerService.DeleteAll)
+}
 
-	err := c.customerService.DeleteAll(ctx, utils.StringArrToUintArr(ids))
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+// Handles the HTTP request for retrieving a page of the deleted customers.
+//
+// The list query is validated against the CustomerListSchema of the
+// repositories, as described in listTrash.
+func (c *customerController) GetCustomerTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.CustomerListSchema, c.customerService)
+}
 
-	ctx.JSON(http.StatusCreated, gin.H{"message": "All customers deleted successfully"})
+// Handles the HTTP request for restoring a deleted customer by its ID, as
+// described in restoreDeleted.
+func (c *customerController) RestoreCustomer(ctx *gin.Context) {
+	restoreDeleted(ctx, "Customer", c.customerService)
 }
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"net/http"
+	"store/domain/apperrors"
+	"store/domain/repositories"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// errIfMatchRequired is the error of a request changing an entity without
+	// the If-Match header.
+	errIfMatchRequired = apperrors.PreconditionRequired("if_match_required", "the If-Match header with the ETag of the entity is required")
+	// errInvalidIfMatch is the error of an If-Match header that is neither an
+	// ETag nor `*`.
+	errInvalidIfMatch = apperrors.BadRequest("invalid_if_match", "the If-Match header must be a single ETag or *")
+)
+
+// etag returns the entity tag of the given version of an entity, as sent in
+// the ETag header, e.g. `"3"`.
+func etag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// notModified sets the ETag header of the response to the version of the
+// entity being read and reports whether the If-None-Match header of the
+// request already matches it, in which case it answers with a 304 Not Modified
+// status and the entity must not be written.
+func notModified(ctx *gin.Context, version uint) bool {
+	ctx.Header("ETag", etag(version))
+
+	header := ctx.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag(version) {
+			ctx.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch returns the version of the entity the request is based on, read from
+// its If-Match header, which every request changing or deleting an entity must
+// send with the ETag of the entity. An If-Match of `*` matches any version and
+// gives repositories.AnyVersion.
+//
+// Without the header, the request fails with a 428 Precondition Required
+// error, and with a header that is not a single ETag or `*`, with a 400 error.
+// In both cases it returns false and the request must not go on.
+func ifMatch(ctx *gin.Context) (uint, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" {
+		ctx.Error(errIfMatchRequired)
+		return 0, false
+	}
+	if header == "*" {
+		return repositories.AnyVersion, true
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(header, `"`), 10, 64)
+	if err != nil || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || version == 0 {
+		ctx.Error(errInvalidIfMatch)
+		return 0, false
+	}
+	return uint(version), true
+}
@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"net/http"
+	"store/domain/dto"
+	"store/domain/entities"
+	"store/domain/query"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExchangeRateController is an interface that defines the methods for the exchange rate controller.
+//
+// The methods in this interface are used to create, retrieve, update, delete and
+// import the exchange rates in the database.
+type ExchangeRateController interface {
+	CreateExchangeRate(ctx *gin.Context)     // Create a new exchange rate
+	GetAllExchangeRates(ctx *gin.Context)    // Get all exchange rates
+	GetExchangeRateByID(ctx *gin.Context)    // Get an exchange rate by ID
+	UpdateExchangeRate(ctx *gin.Context)     // Update an exchange rate
+	PatchExchangeRate(ctx *gin.Context)      // Partially update an exchange rate
+	DeleteExchangeRate(ctx *gin.Context)     // Delete an exchange rate
+	DeleteAllExchangeRates(ctx *gin.Context) // Delete multiple exchange rates
+	ImportExchangeRates(ctx *gin.Context)    // Import multiple exchange rates
+	GetExchangeRateTrash(ctx *gin.Context)   // Get the deleted exchange rates
+	RestoreExchangeRate(ctx *gin.Context)    // Restore a deleted exchange rate
+}
+
+// exchangeRateController is a struct that contains an exchangeRateService and
+// implements the ExchangeRateController.
+type exchangeRateController struct {
+	exchangeRateService services.ExchangeRateService
+}
+
+// NewExchangeRateController creates a new instance of exchangeRateController with
+// the provided exchangeRateService and returns it as an ExchangeRateController.
+func NewExchangeRateController(exchangeRateService services.ExchangeRateService) ExchangeRateController {
+	return &exchangeRateController{exchangeRateService: exchangeRateService}
+}
+
+// Handles the HTTP request for creating a new exchange rate.
+//
+// This method binds the JSON request body to a dto.CreateExchangeRateRequest,
+// answered as described in bindRequest when it is invalid, and calls the Create
+// method of the exchange rate service. If a currency or the rate is rejected by
+// the service, it returns a 400 error response, and if the creation fails, a
+// 500 error response. On success, it returns a 201 status code along with the
+// created exchange rate.
+func (c *exchangeRateController) CreateExchangeRate(ctx *gin.Context) {
+	var request dto.CreateExchangeRateRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	exchangeRate := request.ToEntity()
+
+	err := c.exchangeRateService.Create(ctx, exchangeRate)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, exchangeRate)
+}
+
+// Handles the HTTP request for retrieving a page of exchange rates.
+//
+// This method takes a pointer to a *gin.Context as a parameter. It parses the
+// pagination, sorting and filters of the query string against the
+// ExchangeRateListSchema of the repositories and calls the GetAll method of the
+// exchange rate service with them. If the query string is invalid, it returns a
+// 400 error response, and if the retrieval fails, a 500 error response. On
+// success, it returns a 200 status code along with the page of exchange rates,
+// its total count and the link to the next page.
+func (c *exchangeRateController) GetAllExchangeRates(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.ExchangeRateListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	exchangeRates, err := c.exchangeRateService.GetAll(ctx, q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, exchangeRates)
+}
+
+// Handles the HTTP request for retrieving an exchange rate by its ID.
+//
+// This method extracts the ID from the URL parameters and calls the GetByID
+// method of the exchange rate service. If the exchange rate is not found, it
+// returns a 404 error response, and if the retrieval fails, a 500 error
+// response. On success, it returns a 200 status code along with the exchange rate.
+//
+// The response carries the ETag of the version of the exchange rate, and a
+// request whose If-None-Match header already matches it is answered with a 304
+// Not Modified status, as described in notModified.
+func (c *exchangeRateController) GetExchangeRateByID(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	exchangeRate, err := c.exchangeRateService.GetByID(ctx, utils.StringToUint(id))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, exchangeRate.Version) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, exchangeRate)
+}
+
+// Handles the HTTP request for updating an exchange rate.
+//
+// This method binds the JSON request body to a dto.UpdateExchangeRateRequest,
+// answered as described in bindRequest when it is invalid, and calls the Update
+// method of the exchange rate service to save its fields on the exchange rate
+// identified by the ID in the URL parameters. If a currency or the rate is
+// rejected by the service, it returns a 400 error response, if the exchange
+// rate is not found a 404 error response, and if the update fails, a 500 error
+// response. On success, it returns a 200 status code along with the updated
+// exchange rate.
+//
+// The request must send the ETag of the exchange rate in its If-Match header,
+// as described in ifMatch. If the exchange rate has been changed since that
+// version, it returns a 412 error response, and on success the new ETag is sent
+// with the response.
+func (c *exchangeRateController) UpdateExchangeRate(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.UpdateExchangeRateRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+
+	exchangeRate, err := c.exchangeRateService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	fields := request.ApplyTo(exchangeRate)
+	exchangeRate.Version = version
+
+	err = c.exchangeRateService.Update(ctx, exchangeRate, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(exchangeRate.Version))
+	ctx.JSON(http.StatusOK, exchangeRate)
+}
+
+// Handles the HTTP request for partially updating an exchange rate.
+//
+// The patch document in the request body is applied to the stored exchange rate
+// identified by the ID in the URL parameters, as described in applyPatch, and
+// only the fields changed by the patch are saved by the Update method of the
+// exchange rate service. The ID in the URL always takes precedence over an ID
+// in the patch. The patched exchange rate must follow the rules of a full
+// update, otherwise a 422 error response is returned as described in
+// validatePatched.
+//
+// The request must send the ETag of the exchange rate in its If-Match header,
+// as described in ifMatch. If a currency is invalid, it returns a 400 error
+// response, if the exchange rate is not found a 404 error response, if it has
+// been changed since that version a 412 error response, and if the update fails
+// a 500 error response. On success, it returns a 200 status code along with the
+// updated exchange rate and its new ETag.
+func (c *exchangeRateController) PatchExchangeRate(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.exchangeRateService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	exchangeRate, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateExchangeRateRequest](ctx, exchangeRate, fields) {
+		return
+	}
+	exchangeRate.ID = id
+	exchangeRate.Version = version
+
+	err = c.exchangeRateService.Update(ctx, exchangeRate, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(exchangeRate.Version))
+	ctx.JSON(http.StatusOK, exchangeRate)
+}
+
+// Handles the HTTP request for deleting an exchange rate by its ID.
+//
+// This method extracts the ID from the URL parameters and calls the Delete
+// method of the exchange rate service. If the deletion fails, it returns a 500
+// error response. On success, it returns a 200 status code with a message.
+//
+// The request must send the ETag of the exchange rate in its If-Match header,
+// as described in ifMatch. If the exchange rate has been changed since that
+// version, it returns a 412 error response.
+//
+// With the `purge=true` query parameter, the exchange rate is permanently
+// deleted instead, which only administrators can do, as described in
+// purgeDeleted.
+func (c *exchangeRateController) DeleteExchangeRate(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Exchange rate", c.exchangeRateService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := ctx.Param("id")
+
+	err := c.exchangeRateService.Delete(ctx, utils.StringToUint(id), version)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+}
+
+// Handles the HTTP request for deleting multiple exchange rates by their IDs.
+//
+// The IDs are read from the `ids` query parameter, or from the `ids` field of
+// the request body for long lists. The exchange rates are deleted in a single
+// transaction by the DeleteAll method of the exchange rate service, and the
+// response reports for each id whether it was deleted, not found or blocked, as
+// described in bulkDelete.
+func (c *exchangeRateController) DeleteAllExchangeRates(ctx *gin.Context) {
+	bulkDelete(ctx, c.exchangeRateService.DeleteAll)
+}
+
+// Handles the HTTP request for importing multiple exchange rates.
+//
+// This method binds the JSON request body to a list of
+// dto.CreateExchangeRateRequest and calls the Import method of the exchange
+// rate service, which replaces the rates that already exist for the same
+// currency pair and date. If any rate is invalid, nothing is imported and it
+// returns a 422 error response listing the invalid fields of every rate, as
+// described in bindRequests, or a 400 error response if the body is not valid
+// JSON or a rate is rejected by the service. If the import fails, it returns a
+// 500 error response. On success, it returns a 200 status code with the number
+// of imported rates.
+func (c *exchangeRateController) ImportExchangeRates(ctx *gin.Context) {
+	requests, ok := bindRequests[dto.CreateExchangeRateRequest](ctx)
+	if !ok {
+		return
+	}
+	exchangeRates := make([]*entities.ExchangeRate, 0, len(requests))
+	for i := range requests {
+		exchangeRates = append(exchangeRates, requests[i].ToEntity())
+	}
+
+	err := c.exchangeRateService.Import(ctx, exchangeRates)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"imported": len(exchangeRates)})
+}
+
+// Handles the HTTP request for retrieving a page of the deleted exchange rates.
+//
+// The list query is validated against the ExchangeRateListSchema of the
+// repositories, as described in listTrash.
+func (c *exchangeRateController) GetExchangeRateTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.ExchangeRateListSchema, c.exchangeRateService)
+}
+
+// Handles the HTTP request for restoring a deleted exchange rate by its ID, as
+// described in restoreDeleted.
+func (c *exchangeRateController) RestoreExchangeRate(ctx *gin.Context) {
+	restoreDeleted(ctx, "Exchange rate", c.exchangeRateService)
+}
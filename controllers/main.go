@@ -18,21 +18,39 @@ import (
 //
 // - GET /customers/:id: Retrieve a customer by its ID.
 //
+// - GET /customers/by-tax-id/:taxId: Retrieve a customer by its CPF or CNPJ.
+//
 // - POST /customers: Create a new customer.
 //
 // - PUT /customers/:id: Update an existing customer by its ID.
 //
-// - DELETE /customers/:id: Delete a customer by its ID.
+// - PATCH /customers/:id: Partially update a existing customer by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /customers/:id: Delete a customer by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /customers: Delete multiple customers by their IDs, given as `ids`.
+//
+// - GET /customers/trash: Retrieve a list of the deleted customers.
+//
+// - POST /customers/:id/restore: Restore a deleted customer.
 func customerRoutes(app *gin.Engine, db *gorm.DB) {
 	customerRepository := repositories.NewCustomerRepository(db)
-	customerService := services.NewCustomerService(customerRepository)
+	addressService := services.NewAddressService(repositories.NewAddressRepository(db), services.AddressModeFromEnv())
+	customerService := services.NewCustomerService(customerRepository, addressService)
 	controller := NewCustomerController(customerService)
 
 	app.GET("/customers", controller.GetAllCustomers)
 	app.GET("/customers/:id", controller.GetCustomerByID)
+	app.GET("/customers/by-tax-id/:taxId", controller.GetCustomerByTaxID)
 	app.POST("/customers", controller.CreateCustomer)
 	app.PUT("/customers/:id", controller.UpdateCustomer)
+	app.PATCH("/customers/:id", controller.PatchCustomer)
 	app.DELETE("/customers/:id", controller.DeleteCustomer)
+	app.DELETE("/customers", controller.DeleteAllCustomers)
+	app.GET("/customers/trash", controller.GetCustomerTrash)
+	app.POST("/customers/:id/restore", controller.RestoreCustomer)
 }
 
 // Sets up the HTTP route handlers for supplier-related operations.
@@ -49,17 +67,32 @@ func customerRoutes(app *gin.Engine, db *gorm.DB) {
 //
 // - PUT /suppliers/:id: Update an existing supplier by its ID.
 //
-// - DELETE /suppliers/:id: Delete a supplier by its ID.
+// - PATCH /suppliers/:id: Partially update a existing supplier by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /suppliers/:id: Delete a supplier by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /suppliers: Delete multiple suppliers by their IDs, given as `ids`.
+//
+// - GET /suppliers/trash: Retrieve a list of the deleted suppliers.
+//
+// - POST /suppliers/:id/restore: Restore a deleted supplier.
 func supplierRoutes(app *gin.Engine, db *gorm.DB) {
 	supplierRepository := repositories.NewSupplierRepository(db)
-	supplierService := services.NewSupplierService(supplierRepository)
+	addressService := services.NewAddressService(repositories.NewAddressRepository(db), services.AddressModeFromEnv())
+	supplierService := services.NewSupplierService(supplierRepository, addressService)
 	controller := NewSupplierController(supplierService)
 
 	app.GET("/suppliers", controller.GetAllSuppliers)
 	app.GET("/suppliers/:id", controller.GetSupplierByID)
 	app.POST("/suppliers", controller.CreateSupplier)
 	app.PUT("/suppliers/:id", controller.UpdateSupplier)
+	app.PATCH("/suppliers/:id", controller.PatchSupplier)
 	app.DELETE("/suppliers/:id", controller.DeleteSupplier)
+	app.DELETE("/suppliers", controller.DeleteAllSuppliers)
+	app.GET("/suppliers/trash", controller.GetSupplierTrash)
+	app.POST("/suppliers/:id/restore", controller.RestoreSupplier)
 }
 
 // Sets up the HTTP route handlers for product-related operations.
@@ -76,7 +109,17 @@ func supplierRoutes(app *gin.Engine, db *gorm.DB) {
 //
 // - PUT /products/:id: Update an existing product by its ID.
 //
-// - DELETE /products/:id: Delete a product by its ID.
+// - PATCH /products/:id: Partially update a existing product by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /products/:id: Delete a product by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /products: Delete multiple products by their IDs, given as `ids`.
+//
+// - GET /products/trash: Retrieve a list of the deleted products.
+//
+// - POST /products/:id/restore: Restore a deleted product.
 func productRoutes(app *gin.Engine, db *gorm.DB) {
 	productRepository := repositories.NewProductRepository(db)
 	productService := services.NewProductService(productRepository)
@@ -86,7 +129,11 @@ func productRoutes(app *gin.Engine, db *gorm.DB) {
 	app.GET("/products/:id", controller.GetProductByID)
 	app.POST("/products", controller.CreateProduct)
 	app.PUT("/products/:id", controller.UpdateProduct)
+	app.PATCH("/products/:id", controller.PatchProduct)
 	app.DELETE("/products/:id", controller.DeleteProduct)
+	app.DELETE("/products", controller.DeleteAllProducts)
+	app.GET("/products/trash", controller.GetProductTrash)
+	app.POST("/products/:id/restore", controller.RestoreProduct)
 }
 
 // Sets up the HTTP route handlers for order-related operations.
@@ -99,29 +146,475 @@ func productRoutes(app *gin.Engine, db *gorm.DB) {
 //
 // - GET /orders/:id: Retrieve an order by its ID.
 //
+// - GET /orders/by-number/:number: Retrieve an order by its order number.
+//
 // - POST /orders: Create a new order.
 //
 // - PUT /orders/:id: Update an existing order by its ID.
 //
-// - DELETE /orders/:id: Delete an order by its ID.
+// - PATCH /orders/:id: Partially update an existing order by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /orders/:id: Delete an order by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /orders: Delete multiple orders by their IDs, given as `ids`.
+//
+// - GET /orders/trash: Retrieve a list of the deleted orders.
+//
+// - POST /orders/:id/restore: Restore an deleted order.
+//
+// - POST /orders/:id/transitions/:transition: Move an order through its lifecycle.
+//
+// - GET /orders/:id/status-history: Retrieve the status history of an order.
 func orderRoutes(app *gin.Engine, db *gorm.DB) {
 	orderRepository := repositories.NewOrderRepository(db)
-	orderService := services.NewOrderService(orderRepository)
+	customerRepository := repositories.NewCustomerRepository(db)
+	contactRepository := repositories.NewContactRepository(db)
+	productSupplierRepository := repositories.NewProductSupplierRepository(db)
+	exchangeRateRepository := repositories.NewExchangeRateRepository(db)
+	orderService := services.NewOrderService(
+		orderRepository,
+		customerRepository,
+		contactRepository,
+		productSupplierRepository,
+		exchangeRateRepository,
+		repositories.NewWarehouseRepository(db),
+		services.OrderNumberPatternFromEnv(),
+	)
 	controller := NewOrderController(orderService)
 
 	app.GET("/orders", controller.GetAllOrders)
 	app.GET("/orders/:id", controller.GetOrderByID)
+	app.GET("/orders/by-number/:number", controller.GetOrderByNumber)
 	app.POST("/orders", controller.CreateOrder)
 	app.PUT("/orders/:id", controller.UpdateOrder)
+	app.PATCH("/orders/:id", controller.PatchOrder)
 	app.DELETE("/orders/:id", controller.DeleteOrder)
+	app.DELETE("/orders", controller.DeleteAllOrders)
+	app.GET("/orders/trash", controller.GetOrderTrash)
+	app.POST("/orders/:id/restore", controller.RestoreOrder)
+	app.POST("/orders/:id/transitions/:transition", controller.TransitionOrder)
+	app.GET("/orders/:id/status-history", controller.GetOrderStatusHistory)
+}
+
+// Sets up the HTTP route handlers for exchange-rate-related operations.
+//
+// It initializes the exchange rate repository, service, and controller, and
+// binds the HTTP endpoints to their corresponding handler functions. The
+// following routes are registered:
+//
+// - GET /exchange-rates: Retrieve a list of all exchange rates.
+//
+// - GET /exchange-rates/:id: Retrieve an exchange rate by its ID.
+//
+// - POST /exchange-rates: Create a new exchange rate.
+//
+// - POST /exchange-rates/import: Create or replace multiple exchange rates.
+//
+// - PUT /exchange-rates/:id: Update an existing exchange rate by its ID.
+//
+// - PATCH /exchange-rates/:id: Partially update an existing exchange rate by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /exchange-rates/:id: Delete an exchange rate by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /exchange-rates: Delete multiple exchange rates by their IDs, given as `ids`.
+//
+// - GET /exchange-rates/trash: Retrieve a list of the deleted exchange rates.
+//
+// - POST /exchange-rates/:id/restore: Restore an deleted exchange rate.
+func exchangeRateRoutes(app *gin.Engine, db *gorm.DB) {
+	exchangeRateRepository := repositories.NewExchangeRateRepository(db)
+	exchangeRateService := services.NewExchangeRateService(exchangeRateRepository)
+	controller := NewExchangeRateController(exchangeRateService)
+
+	app.GET("/exchange-rates", controller.GetAllExchangeRates)
+	app.GET("/exchange-rates/:id", controller.GetExchangeRateByID)
+	app.POST("/exchange-rates", controller.CreateExchangeRate)
+	app.POST("/exchange-rates/import", controller.ImportExchangeRates)
+	app.PUT("/exchange-rates/:id", controller.UpdateExchangeRate)
+	app.PATCH("/exchange-rates/:id", controller.PatchExchangeRate)
+	app.DELETE("/exchange-rates/:id", controller.DeleteExchangeRate)
+	app.DELETE("/exchange-rates", controller.DeleteAllExchangeRates)
+	app.GET("/exchange-rates/trash", controller.GetExchangeRateTrash)
+	app.POST("/exchange-rates/:id/restore", controller.RestoreExchangeRate)
+}
+
+// Sets up the HTTP route handlers for contact-related operations.
+//
+// It initializes the contact repository, service, and controller, and binds
+// the HTTP endpoints to their corresponding handler functions. The following
+// routes are registered:
+//
+// - GET /contacts: Retrieve a list of all contacts.
+//
+// - GET /contacts/:id: Retrieve a contact by its ID.
+//
+// - POST /contacts: Create a new contact.
+//
+// - PUT /contacts/:id: Update an existing contact by its ID.
+//
+// - PATCH /contacts/:id: Partially update a existing contact by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /contacts/:id: Delete a contact by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /contacts: Delete multiple contacts by their IDs, given as `ids`.
+//
+// - GET /contacts/trash: Retrieve a list of the deleted contacts.
+//
+// - POST /contacts/:id/restore: Restore a deleted contact.
+//
+// - GET /customers/:id/contacts: Retrieve the contacts of a customer.
+//
+// - GET /suppliers/:id/contacts: Retrieve the contacts of a supplier.
+func contactRoutes(app *gin.Engine, db *gorm.DB) {
+	contactRepository := repositories.NewContactRepository(db)
+	addressService := services.NewAddressService(repositories.NewAddressRepository(db), services.AddressModeFromEnv())
+	contactService := services.NewContactService(contactRepository, addressService)
+	controller := NewContactController(contactService)
+
+	app.GET("/contacts", controller.GetAllContacts)
+	app.GET("/contacts/:id", controller.GetContactByID)
+	app.POST("/contacts", controller.CreateContact)
+	app.PUT("/contacts/:id", controller.UpdateContact)
+	app.PATCH("/contacts/:id", controller.PatchContact)
+	app.DELETE("/contacts/:id", controller.DeleteContact)
+	app.DELETE("/contacts", controller.DeleteAllContacts)
+	app.GET("/contacts/trash", controller.GetContactTrash)
+	app.POST("/contacts/:id/restore", controller.RestoreContact)
+	app.GET("/customers/:id/contacts", controller.GetCustomerContacts)
+	app.GET("/suppliers/:id/contacts", controller.GetSupplierContacts)
+}
+
+// Sets up the HTTP route handlers for address-related operations.
+//
+// It initializes the address repository, service, and controller, and binds
+// the HTTP endpoints to their corresponding handler functions. The following
+// routes are registered:
+//
+// - GET /addresses/postal-codes/:cep: Retrieve the address of a postal code.
+func addressRoutes(app *gin.Engine, db *gorm.DB) {
+	addressRepository := repositories.NewAddressRepository(db)
+	addressService := services.NewAddressService(addressRepository, services.AddressModeFromEnv())
+	controller := NewAddressController(addressService)
+
+	app.GET("/addresses/postal-codes/:cep", controller.GetAddressByPostalCode)
+}
+
+// Sets up the HTTP route handlers for product-supplier-related operations.
+//
+// It initializes the product supplier repository, service, and controller, and
+// binds the HTTP endpoints to their corresponding handler functions. The
+// following routes are registered:
+//
+// - GET /product-suppliers: Retrieve a list of all product suppliers.
+//
+// - GET /product-suppliers/:id: Retrieve a product supplier by its ID.
+//
+// - POST /product-suppliers: Create a new product supplier.
+//
+// - PUT /product-suppliers/:id: Update an existing product supplier by its ID.
+//
+// - PATCH /product-suppliers/:id: Partially update a existing product supplier by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /product-suppliers/:id: Delete a product supplier by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /product-suppliers: Delete multiple product suppliers by their IDs, given as `ids`.
+//
+// - GET /product-suppliers/trash: Retrieve a list of the deleted product suppliers.
+//
+// - POST /product-suppliers/:id/restore: Restore a deleted product supplier.
+//
+// - GET /products/:id/suppliers: Retrieve the offers of every supplier of a product.
+//
+// - GET /suppliers/:id/offers: Retrieve the products offered by a supplier.
+//
+// - POST /suppliers/:id/offers: Create a new offer of a supplier.
+func productSupplierRoutes(app *gin.Engine, db *gorm.DB) {
+	productSupplierRepository := repositories.NewProductSupplierRepository(db)
+	productSupplierService := services.NewProductSupplierService(productSupplierRepository)
+	controller := NewProductSupplierController(productSupplierService)
+
+	app.GET("/product-suppliers", controller.GetAllProductSuppliers)
+	app.GET("/product-suppliers/:id", controller.GetProductSupplierByID)
+	app.POST("/product-suppliers", controller.CreateProductSupplier)
+	app.PUT("/product-suppliers/:id", controller.UpdateProductSupplier)
+	app.PATCH("/product-suppliers/:id", controller.PatchProductSupplier)
+	app.DELETE("/product-suppliers/:id", controller.DeleteProductSupplier)
+	app.DELETE("/product-suppliers", controller.DeleteAllProductSuppliers)
+	app.GET("/product-suppliers/trash", controller.GetProductSupplierTrash)
+	app.POST("/product-suppliers/:id/restore", controller.RestoreProductSupplier)
+	app.GET("/products/:id/suppliers", controller.GetProductSuppliers)
+	app.GET("/suppliers/:id/offers", controller.GetSupplierOffers)
+	app.POST("/suppliers/:id/offers", controller.CreateSupplierOffer)
+}
+
+// Sets up the HTTP route handlers for stock-ledger-related operations.
+//
+// It initializes the stock movement, reservation and lot repositories, service,
+// and controller, and binds the HTTP endpoints to their corresponding handler
+// functions. The following routes are registered:
+//
+// - GET /product-suppliers/:id/movements: Retrieve a list of the movements of the stock of a product supplier.
+//
+// - POST /product-suppliers/:id/movements: Record a receipt or an adjustment of the stock of a product supplier.
+//
+// - GET /product-suppliers/reconciliation: Retrieve the product suppliers and suppliers whose stock drifted
+// from the ledger.
+//
+// - GET /orders/:id/reservations: Retrieve the stock reservations of an order.
+//
+// - GET /product-suppliers/:id/lots: Retrieve a list of the lots of a product supplier.
+//
+// - GET /inventory/expiring: Retrieve the lots on hand expiring within the number of days given as `within`.
+func stockRoutes(app *gin.Engine, db *gorm.DB) {
+	stockService := services.NewStockService(
+		repositories.NewStockMovementRepository(db),
+		repositories.NewStockReservationRepository(db),
+		repositories.NewStockLotRepository(db),
+		repositories.NewProductSupplierRepository(db),
+		repositories.NewOrderRepository(db),
+	)
+	controller := NewStockController(stockService)
+
+	app.GET("/product-suppliers/:id/movements", controller.GetStockMovements)
+	app.POST("/product-suppliers/:id/movements", controller.CreateStockMovement)
+	app.GET("/product-suppliers/reconciliation", controller.GetStockReconciliation)
+	app.GET("/orders/:id/reservations", controller.GetOrderReservations)
+	app.GET("/product-suppliers/:id/lots", controller.GetStockLots)
+	app.GET("/inventory/expiring", controller.GetExpiringStock)
+}
+
+// Sets up the HTTP route handlers for order-line-related operations.
+//
+// It initializes the order product supplier repository, service, and
+// controller, and binds the HTTP endpoints to their corresponding handler
+// functions. Lines can only be created, updated or deleted while their order is
+// a draft. The following routes are registered:
+//
+// - GET /order-product-suppliers: Retrieve a list of all order lines.
+//
+// - GET /order-product-suppliers/:id: Retrieve an order line by its ID.
+//
+// - POST /order-product-suppliers: Create a new order line.
+//
+// - PUT /order-product-suppliers/:id: Update an existing order line by its ID.
+//
+// - PATCH /order-product-suppliers/:id: Partially update an existing order line by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /order-product-suppliers/:id: Delete an order line by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /order-product-suppliers: Delete multiple order lines by their IDs, given as `ids`.
+//
+// - GET /order-product-suppliers/trash: Retrieve a list of the deleted order lines.
+//
+// - POST /order-product-suppliers/:id/restore: Restore an deleted order line.
+//
+// - GET /orders/:id/lines: Retrieve the lines of an order.
+//
+// - POST /orders/:id/lines: Add a line to a draft order.
+//
+// - POST /orders/:id/lines/best-offer: Add lines for a product to a draft order, from the offers chosen by a strategy.
+func orderProductSupplierRoutes(app *gin.Engine, db *gorm.DB) {
+	orderProductSupplierRepository := repositories.NewOrderProductSupplierRepository(db)
+	orderRepository := repositories.NewOrderRepository(db)
+	productSupplierRepository := repositories.NewProductSupplierRepository(db)
+	orderProductSupplierService := services.NewOrderProductSupplierService(
+		orderProductSupplierRepository,
+		orderRepository,
+		productSupplierRepository,
+		repositories.NewExchangeRateRepository(db),
+		services.OfferStrategyFromEnv(),
+	)
+	controller := NewOrderProductSupplierController(orderProductSupplierService)
+
+	app.GET("/order-product-suppliers", controller.GetAllOrderProductSuppliers)
+	app.GET("/order-product-suppliers/:id", controller.GetOrderProductSupplierByID)
+	app.POST("/order-product-suppliers", controller.CreateOrderProductSupplier)
+	app.PUT("/order-product-suppliers/:id", controller.UpdateOrderProductSupplier)
+	app.PATCH("/order-product-suppliers/:id", controller.PatchOrderProductSupplier)
+	app.DELETE("/order-product-suppliers/:id", controller.DeleteOrderProductSupplier)
+	app.DELETE("/order-product-suppliers", controller.DeleteAllOrderProductSuppliers)
+	app.GET("/order-product-suppliers/trash", controller.GetOrderProductSupplierTrash)
+	app.POST("/order-product-suppliers/:id/restore", controller.RestoreOrderProductSupplier)
+	app.GET("/orders/:id/lines", controller.GetOrderLines)
+	app.POST("/orders/:id/lines", controller.CreateOrderLine)
+	app.POST("/orders/:id/lines/best-offer", controller.CreateBestOfferLines)
+}
+
+// Sets up the HTTP route handlers for the purchase orders placed with the
+// suppliers and their goods receipts.
+//
+// It initializes the purchase order repository, service, and controller, and
+// binds the HTTP endpoints to their corresponding handler functions. The
+// following routes are registered:
+//
+// - GET /purchase-orders: Retrieve a list of all purchase orders.
+//
+// - GET /purchase-orders/:id: Retrieve a purchase order with its lines and receipts by its ID.
+//
+// - POST /purchase-orders: Create a new draft purchase order.
+//
+// - PUT /purchase-orders/:id: Update a draft purchase order and replace its lines.
+//
+// - DELETE /purchase-orders/:id: Delete a draft purchase order by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /purchase-orders: Delete multiple draft purchase orders by their IDs, given as `ids`.
+//
+// - GET /purchase-orders/trash: Retrieve a list of the deleted purchase orders.
+//
+// - POST /purchase-orders/:id/restore: Restore a deleted purchase order.
+//
+// - POST /purchase-orders/:id/transitions/:transition: Send or close a purchase order.
+//
+// - POST /purchase-orders/:id/receipts: Receive goods for a purchase order, adding them to the stock.
+//
+// - GET /purchase-orders/:id/receipts: Retrieve the goods receipts of a purchase order.
+func purchaseOrderRoutes(app *gin.Engine, db *gorm.DB) {
+	purchaseOrderService := services.NewPurchaseOrderService(
+		repositories.NewPurchaseOrderRepository(db),
+		repositories.NewSupplierRepository(db),
+		repositories.NewProductSupplierRepository(db),
+	)
+	controller := NewPurchaseOrderController(purchaseOrderService)
+
+	app.GET("/purchase-orders", controller.GetAllPurchaseOrders)
+	app.GET("/purchase-orders/:id", controller.GetPurchaseOrderByID)
+	app.POST("/purchase-orders", controller.CreatePurchaseOrder)
+	app.PUT("/purchase-orders/:id", controller.UpdatePurchaseOrder)
+	app.DELETE("/purchase-orders/:id", controller.DeletePurchaseOrder)
+	app.DELETE("/purchase-orders", controller.DeleteAllPurchaseOrders)
+	app.GET("/purchase-orders/trash", controller.GetPurchaseOrderTrash)
+	app.POST("/purchase-orders/:id/restore", controller.RestorePurchaseOrder)
+	app.POST("/purchase-orders/:id/transitions/:transition", controller.TransitionPurchaseOrder)
+	app.POST("/purchase-orders/:id/receipts", controller.ReceivePurchaseOrder)
+	app.GET("/purchase-orders/:id/receipts", controller.GetPurchaseOrderReceipts)
+}
+
+// Sets up the HTTP route handlers for the replenishment planner.
+//
+// It initializes the replenishment repository, service, and controller, with
+// the policy read from the environment, and binds the HTTP endpoints to their
+// corresponding handler functions. The following routes are registered:
+//
+// - GET /replenishment/suggestions: Retrieve the quantities suggested to purchase, grouped by supplier.
+func replenishmentRoutes(app *gin.Engine, db *gorm.DB) {
+	replenishmentService := services.NewReplenishmentService(
+		repositories.NewReplenishmentRepository(db),
+		repositories.NewSupplierRepository(db),
+		services.ReplenishmentPolicyFromEnv(),
+	)
+	controller := NewReplenishmentController(replenishmentService)
+
+	app.GET("/replenishment/suggestions", controller.GetReplenishmentSuggestions)
+}
+
+// Sets up the HTTP route handlers for the warehouses the stock is split
+// between.
+//
+// It initializes the warehouse repository, service, and controller, and binds
+// the HTTP endpoints to their corresponding handler functions. The following
+// routes are registered:
+//
+// - GET /warehouses: Retrieve a list of all warehouses.
+//
+// - GET /warehouses/:id: Retrieve a warehouse by its ID.
+//
+// - POST /warehouses: Create a new warehouse.
+//
+// - PUT /warehouses/:id: Update an existing warehouse by its ID.
+//
+// - PATCH /warehouses/:id: Partially update an existing warehouse by its ID, with a JSON
+// Merge Patch or a JSON Patch.
+//
+// - DELETE /warehouses/:id: Delete a warehouse by its ID, or permanently delete it with
+// `purge=true` as an administrator.
+//
+// - DELETE /warehouses: Delete multiple warehouses by their IDs, given as `ids`.
+//
+// - GET /warehouses/trash: Retrieve a list of the deleted warehouses.
+//
+// - POST /warehouses/:id/restore: Restore a deleted warehouse.
+//
+// - GET /warehouses/:id/stock: Retrieve the stock on hand in a warehouse.
+//
+// - GET /product-suppliers/:id/stock: Retrieve the stock of a product supplier in every warehouse.
+func warehouseRoutes(app *gin.Engine, db *gorm.DB) {
+	warehouseService := services.NewWarehouseService(
+		repositories.NewWarehouseRepository(db),
+		repositories.NewStockTransferRepository(db),
+		repositories.NewProductSupplierRepository(db),
+	)
+	controller := NewWarehouseController(warehouseService)
+
+	app.GET("/warehouses", controller.GetAllWarehouses)
+	app.GET("/warehouses/:id", controller.GetWarehouseByID)
+	app.POST("/warehouses", controller.CreateWarehouse)
+	app.PUT("/warehouses/:id", controller.UpdateWarehouse)
+	app.PATCH("/warehouses/:id", controller.PatchWarehouse)
+	app.DELETE("/warehouses/:id", controller.DeleteWarehouse)
+	app.DELETE("/warehouses", controller.DeleteAllWarehouses)
+	app.GET("/warehouses/trash", controller.GetWarehouseTrash)
+	app.POST("/warehouses/:id/restore", controller.RestoreWarehouse)
+	app.GET("/warehouses/:id/stock", controller.GetWarehouseStock)
+	app.GET("/product-suppliers/:id/stock", controller.GetProductSupplierStock)
+}
+
+// Sets up the HTTP route handlers for the stock transfers moving units between
+// the warehouses.
+//
+// It initializes the stock transfer repository, service, and controller, and
+// binds the HTTP endpoints to their corresponding handler functions. The
+// following routes are registered:
+//
+// - GET /stock-transfers: Retrieve a list of all stock transfers.
+//
+// - GET /stock-transfers/:id: Retrieve a stock transfer with its lines by its ID.
+//
+// - POST /stock-transfers: Ship a new stock transfer, taking the units from the source warehouse.
+//
+// - POST /stock-transfers/:id/transitions/:transition: Receive or cancel a stock transfer in transit.
+func stockTransferRoutes(app *gin.Engine, db *gorm.DB) {
+	stockTransferService := services.NewStockTransferService(
+		repositories.NewStockTransferRepository(db),
+		repositories.NewWarehouseRepository(db),
+	)
+	controller := NewStockTransferController(stockTransferService)
+
+	app.GET("/stock-transfers", controller.GetAllStockTransfers)
+	app.GET("/stock-transfers/:id", controller.GetStockTransferByID)
+	app.POST("/stock-transfers", controller.CreateStockTransfer)
+	app.POST("/stock-transfers/:id/transitions/:transition", controller.TransitionStockTransfer)
 }
 
 // InitRoutes initializes all routes for the application.
 //
-// It sets up the routes for customers, suppliers, products, and orders.
+// It sets up the routes for customers, suppliers, products, orders,
+// exchange rates, contacts, product suppliers, the stock ledger, order lines,
+// addresses, purchase orders, the replenishment planner, warehouses and stock
+// transfers.
 func InitRoutes(app *gin.Engine, db *gorm.DB) {
 	customerRoutes(app, db)
 	supplierRoutes(app, db)
 	productRoutes(app, db)
 	orderRoutes(app, db)
+	exchangeRateRoutes(app, db)
+	contactRoutes(app, db)
+	productSupplierRoutes(app, db)
+	stockRoutes(app, db)
+	orderProductSupplierRoutes(app, db)
+	addressRoutes(app, db)
+	purchaseOrderRoutes(app, db)
+	replenishmentRoutes(app, db)
+	warehouseRoutes(app, db)
+	stockTransferRoutes(app, db)
 }
@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"net/http"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderProductSupplierController is an interface that defines the methods for handling HTTP requests related to order product supplier operations.
+//
+// An order product supplier is a line of an order, referencing the offer of a
+// supplier. The methods in this interface are utilized to create, retrieve,
+// update, and delete order lines in the database, as well as to list and add
+// the lines of an order.
+type OrderProductSupplierController interface {
+	CreateOrderProductSupplier(ctx *gin.Context)     // Create a new order product supplier
+	GetAllOrderProductSuppliers(ctx *gin.Context)    // Get all order product suppliers
+	GetOrderProductSupplierByID(ctx *gin.Context)    // Get an order product supplier by ID
+	UpdateOrderProductSupplier(ctx *gin.Context)     // Update an order product supplier
+	PatchOrderProductSupplier(ctx *gin.Context)      // Partially update an order product supplier
+	DeleteOrderProductSupplier(ctx *gin.Context)     // Delete an order product supplier
+	DeleteAllOrderProductSuppliers(ctx *gin.Context) // Delete all order product suppliers
+	GetOrderLines(ctx *gin.Context)                  // Get the lines of an order
+	CreateOrderLine(ctx *gin.Context)                // Add a line to an order
+	CreateBestOfferLines(ctx *gin.Context)           // Add lines for a product from the best offers
+	GetOrderProductSupplierTrash(ctx *gin.Context)   // Get the deleted order product suppliers
+	RestoreOrderProductSupplier(ctx *gin.Context)    // Restore a deleted order product supplier
+}
+
+// orderProductSupplierController is a struct that contains a pointer to an
+// orderProductSupplierService and implements the OrderProductSupplierController
+// interface.
+//
+// The struct contains a pointer to an orderProductSupplierService which is used
+// to interact with the order_product_suppliers table in the database.
+type orderProductSupplierController struct {
+	orderProductSupplierService services.OrderProductSupplierService
+}
+
+// NewOrderProductSupplierController creates a new instance of orderProductSupplierController with the provided
+// orderProductSupplierService and returns it as an OrderProductSupplierController. This function is used to
+// initialize a new order product supplier controller that can handle HTTP requests for CRUD operations
+// on order lines by utilizing the order product supplier service.
+func NewOrderProductSupplierController(orderProductSupplierService services.OrderProductSupplierService) OrderProductSupplierController {
+	return &orderProductSupplierController{orderProductSupplierService: orderProductSupplierService}
+}
+
+// Handles the HTTP request for creating a new order product supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter and binds the
+// JSON request body to a dto.CreateOrderProductSupplierRequest. If the request
+// body is not valid JSON, it returns a 400 error response, and if a field is
+// invalid, such as a quantity below one, a 422 error response, as described in
+// bindRequest. It then calls the Create method of the order product supplier
+// service. The errors of the service, such as a change to an order that is no
+// longer a draft, are reported with the status of their kind. On success, it
+// returns a 201 status code along with the created line.
+func (c *orderProductSupplierController) CreateOrderProductSupplier(ctx *gin.Context) {
+	var request dto.CreateOrderProductSupplierRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	line := request.ToEntity()
+
+	if err := c.orderProductSupplierService.Create(ctx, line); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, line)
+}
+
+// Handles the HTTP request for retrieving a page of order product suppliers.
+//
+// This method takes a pointer to a *gin.Context as a parameter. It parses the
+// pagination, sorting and filters of the query string against the
+// OrderProductSupplierListSchema of the repositories and calls the GetAll method
+// of the order product supplier service with them. If the query string is
+// invalid, it returns a 400 error response, and if the retrieval fails, a 500
+// error response. On success, it returns a 200 status code along with the page
+// of lines.
+func (c *orderProductSupplierController) GetAllOrderProductSuppliers(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.OrderProductSupplierListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	lines, err := c.orderProductSupplierService.GetAll(ctx, q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lines)
+}
+
+// Handles the HTTP request for retrieving an order product supplier by its ID.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// ID of the line from the URL parameters. It then calls the GetByID method of the
+// order product supplier service. If the line is found, it returns a 200 status
+// code with the line in the response body. If it is not found it returns a 404
+// error response, and any other error results in a 500 error response.
+//
+// The response carries the ETag of the version of the order product supplier,
+// and a request whose If-None-Match header already matches it is answered with
+// a 304 Not Modified status, as described in notModified.
+func (c *orderProductSupplierController) GetOrderProductSupplierByID(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	line, err := c.orderProductSupplierService.GetByID(ctx, utils.StringToUint(id))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, line.Version) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, line)
+}
+
+// Handles the HTTP request for updating an order product supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter and binds the
+// JSON request body to a dto.UpdateOrderProductSupplierRequest, answered as
+// described in bindRequest when it is invalid. It then calls the Update method
+// of the order product supplier service to save the fields of the request on
+// the line identified by the ID in the URL. The errors of the service, such as
+// a change to an order that is no longer a draft, are reported with the status
+// of their kind. On success, it returns a 200 status code along with the
+// updated line.
+//
+// The request must send the ETag of the order product supplier in its If-Match
+// header, as described in ifMatch. If the order product supplier has been
+// changed since that version, it returns a 412 error response, and on success
+// the new ETag is sent with the response.
+func (c *orderProductSupplierController) UpdateOrderProductSupplier(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.UpdateOrderProductSupplierRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+
+	line, err := c.orderProductSupplierService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	fields := request.ApplyTo(line)
+	line.Version = version
+
+	if err = c.orderProductSupplierService.Update(ctx, line, fields...); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(line.Version))
+	ctx.JSON(http.StatusOK, line)
+}
+
+// Handles the HTTP request for partially updating an order product supplier.
+//
+// The patch document in the request body is applied to the stored order product
+// supplier identified by the ID in the URL parameters, as described in
+// applyPatch, and only the fields changed by the patch are saved by the Update
+// method of the order product supplier service. The ID in the URL always takes
+// precedence over an ID in the patch. The patched line must follow the rules of
+// a full update, otherwise a 422 error response is returned as described in
+// validatePatched.
+//
+// The request must send the ETag of the order product supplier in its If-Match
+// header, as described in ifMatch. The errors of the update are reported with
+// the status of their kind, such as a 412 error for a stale version. On
+// success, it returns a 200 status code along with the updated order product
+// supplier and its new ETag.
+func (c *orderProductSupplierController) PatchOrderProductSupplier(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.orderProductSupplierService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	line, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateOrderProductSupplierRequest](ctx, line, fields) {
+		return
+	}
+	line.ID = id
+	line.Version = version
+
+	if err := c.orderProductSupplierService.Update(ctx, line, fields...); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(line.Version))
+	ctx.JSON(http.StatusOK, line)
+}
+
+// Handles the HTTP request for deleting an order product supplier by its ID.
+//
+// The method takes a pointer to a *gin.Context as a parameter and extracts the
+// ID of the line to be deleted from the URL parameters. It then calls the
+// Delete method of the order product supplier service. The errors of the
+// service, such as a change to an order that is no longer a draft, are reported
+// with the status of their kind. On success, it returns a 200 status code with
+// a message in the response body.
+//
+// The request must send the ETag of the order product supplier in its If-Match
+// header, as described in ifMatch. If the order product supplier has been
+// changed since that version, it returns a 412 error response.
+//
+// With the `purge=true` query parameter, the order product supplier is
+// permanently deleted instead, which only administrators can do, as described
+// in purgeDeleted.
+func (c *orderProductSupplierController) DeleteOrderProductSupplier(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Order product supplier", c.orderProductSupplierService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := ctx.Param("id")
+
+	if err := c.orderProductSupplierService.Delete(ctx, utils.StringToUint(id), version); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Order product supplier deleted successfully"})
+}
+
+// Handles the HTTP request for deleting multiple order product suppliers by their IDs.
+//
+// The IDs are read from the `ids` query parameter, or from the `ids` field of
+// the request body for long lists. The order product suppliers are deleted in a
+// single transaction by the DeleteAll method of the order product supplier
+// service, and the response reports for each id whether it was deleted, not
+// found or blocked, as described in bulkDelete.
+func (c *orderProductSupplierController) DeleteAllOrderProductSuppliers(ctx *gin.Context) {
+	bulkDelete(ctx, c.orderProductSupplierService.DeleteAll)
+}
+
+// Handles the HTTP request for retrieving the lines of an order.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// order ID from the URL parameters. It parses the list query of the request
+// against the OrderProductSupplierListSchema and calls the GetAllByOrderID method
+// of the order product supplier service. If the query string is invalid, it
+// returns a 400 error response, and if the retrieval fails, a 500 error response.
+// On success, it returns a 200 status code along with the page of lines.
+func (c *orderProductSupplierController) GetOrderLines(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	q, err := query.Parse(ctx.Request.URL, repositories.OrderProductSupplierListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	lines, err := c.orderProductSupplierService.GetAllByOrderID(ctx, utils.StringToUint(id), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lines)
+}
+
+// Handles the HTTP request for adding a line to an order.
+//
+// This method takes a pointer to a *gin.Context as a parameter and binds the
+// JSON request body to a dto.OrderLineRequest for the order identified by the
+// ID in the URL, answered as described in bindRequest when it is invalid. It
+// then calls the Create method of the order product supplier service. The
+// errors of the service, such as a change to an order that is no longer a
+// draft, are reported with the status of their kind. On success, it returns a
+// 201 status code along with the created line.
+func (c *orderProductSupplierController) CreateOrderLine(ctx *gin.Context) {
+	var request dto.OrderLineRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	line := request.ToEntity()
+	line.OrderID = utils.StringToUint(ctx.Param("id"))
+
+	if err := c.orderProductSupplierService.Create(ctx, line); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, line)
+}
+
+// Handles the HTTP request for adding lines for a product to an order.
+//
+// This method binds the JSON request body to a dto.BestOfferLineRequest for
+// the order identified by the ID in the URL, answered as described in
+// bindRequest when it is invalid. It then calls the AddBestOffer method of the
+// order product supplier service, which chooses the offers of the suppliers
+// of the product with the requested strategy. On success, it returns a 201
+// status code along with the strategy, the lines created and the offers
+// chosen, with why. If the order is no longer a draft or the offers have not
+// enough units available it returns a 409 error response, and if the product
+// is not offered a 422 error response.
+func (c *orderProductSupplierController) CreateBestOfferLines(ctx *gin.Context) {
+	var request dto.BestOfferLineRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+
+	selection, err := c.orderProductSupplierService.AddBestOffer(ctx, utils.StringToUint(ctx.Param("id")), services.OfferRequest{
+		ProductID:           request.ProductID,
+		Quantity:            request.Quantity,
+		Strategy:            services.OfferStrategy(request.Strategy),
+		PreferredSupplierID: request.PreferredSupplierID,
+		Split:               request.Split,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, selection)
+}
+
+// Handles the HTTP request for retrieving a page of the deleted order product suppliers.
+//
+// The list query is validated against the OrderProductSupplierListSchema of the
+// repositories, as described in listTrash.
+func (c *orderProductSupplierController) GetOrderProductSupplierTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.OrderProductSupplierListSchema, c.orderProductSupplierService)
+}
+
+// Handles the HTTP request for restoring a deleted order product supplier by
+// its ID, as described in restoreDeleted.
+func (c *orderProductSupplierController) RestoreOrderProductSupplier(ctx *gin.Context) {
+	restoreDeleted(ctx, "Order product supplier", c.orderProductSupplierService)
+}
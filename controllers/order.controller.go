@@ -1,8 +1,12 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"net/http"
-	"store/domain/entities"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
 	"store/services"
 	"store/utils"
 
@@ -14,12 +18,24 @@ import (
 // The methods in this interface are utilized to create, retrieve, update, and delete
 // orders in the database.
 type OrderController interface {
-	CreateOrder(ctx *gin.Context)     // Create a new order
-	GetOrderByID(ctx *gin.Context)    // Get an order by id
-	UpdateOrder(ctx *gin.Context)     // Update an order
-	DeleteOrder(ctx *gin.Context)     // Delete an order
-	GetAllOrders(ctx *gin.Context)    // Get all orders
-	DeleteAllOrders(ctx *gin.Context) // Delete all orders
+	CreateOrder(ctx *gin.Context)           // Create a new order
+	GetOrderByID(ctx *gin.Context)          // Get an order by id
+	GetOrderByNumber(ctx *gin.Context)      // Get an order by its order number
+	UpdateOrder(ctx *gin.Context)           // Update an order
+	PatchOrder(ctx *gin.Context)            // Partially update an order
+	DeleteOrder(ctx *gin.Context)           // Delete an order
+	GetAllOrders(ctx *gin.Context)          // Get all orders
+	DeleteAllOrders(ctx *gin.Context)       // Delete all orders
+	TransitionOrder(ctx *gin.Context)       // Move an order through its lifecycle
+	GetOrderStatusHistory(ctx *gin.Context) // Get the status history of an order
+	GetOrderTrash(ctx *gin.Context)         // Get the deleted orders
+	RestoreOrder(ctx *gin.Context)          // Restore a deleted order
+}
+
+// orderTransitionRequest is the optional request body of a status transition.
+type orderTransitionRequest struct {
+	ChangedBy string `json:"changed_by"` // user applying the transition
+	Note      string `json:"note"`       // optional note about the transition
 }
 
 // orderController is a struct that contains a pointer to an OrderService.
@@ -38,21 +54,28 @@ func NewOrderController(orderService services.OrderService) OrderController {
 // Handles the HTTP request for creating a new order.
 //
 // The method takes a pointer to a *gin.Context as a parameter. It binds the
-// request body to a new entities.Order and calls the Create method of the
-// order service to create a new order in the database. If the order is created
-// successfully, the method returns a 201 status code with the created order in
-// the response body. If an error occurs during the creation, the method returns
-// a 500 error response.
+// request body to a dto.CreateOrderRequest, answered as described in
+// bindRequest when it is invalid, such as an order without lines or delivered
+// before its order date, and calls the Create method of the order service to
+// place the order in the database. If the order is created successfully, the
+// method returns a 201 status code with the created order in the response body.
+// If the order has no lines, a line has an invalid quantity, a discount is
+// invalid, the amounts are in different currencies or the initial status is
+// other than draft or placed, the method returns a 400 error response. If the
+// customer or a referenced product supplier does not exist, it returns a 404
+// error response, if no exchange rate converts a line into the billing currency
+// of the customer a 422 error response, and if there is not enough stock a 409
+// error response. Any other error results in a 500 error response.
 func (c *orderController) CreateOrder(ctx *gin.Context) {
-	var order entities.Order
-
-	if err := ctx.ShouldBindJSON(&order); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request dto.CreateOrderRequest
+	if !bindRequest(ctx, &request) {
 		return
 	}
+	order := request.ToEntity()
 
-	if err := c.orderService.Create(ctx, &order); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err := c.orderService.Create(ctx, order)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 	ctx.JSON(http.StatusCreated, order)
@@ -62,46 +85,149 @@ func (c *orderController) CreateOrder(ctx *gin.Context) {
 //
 // The method takes a pointer to a *gin.Context as a parameter and extracts the
 // ID of the order to be retrieved from the URL parameters. It then calls the
-// GetByID method of the order service to retrieve the order from the database.
-// If the order is found, the method returns a 200 status code with the order in
-// the response body. If an error occurs during the retrieval, the method
-// returns a 500 error response.
+// GetDetails method of the order service to retrieve the order with its order
+// products and computed totals. If the order is found, the method returns a 200
+// status code with the order in the response body. If the order is not found it
+// returns a 404 error response, and if any other error occurs during the
+// retrieval, the method returns a 500 error response.
+//
+// The response carries the ETag of the version of the order, and a request
+// whose If-None-Match header already matches it is answered with a 304 Not
+// Modified status, as described in notModified.
 func (c *orderController) GetOrderByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 
-	order, err := c.orderService.GetByID(ctx, utils.StringToUint(id))
+	order, err := c.orderService.GetDetails(ctx, utils.StringToUint(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, order.Version) {
 		return
 	}
 
 	ctx.JSON(http.StatusOK, order)
 }
 
-// Handles the HTTP request for updating an order.
+// Handles the HTTP request for retrieving an order by its order number.
 //
 // The method takes a pointer to a *gin.Context as a parameter and extracts the
-// order to be updated from the request body. It then calls the Update method
-// of the order service to update the order in the database. If the order is
-// updated successfully, the method returns a 200 status code with the updated
-// order in the response body. If an error occurs during the update, the method
-// returns a 500 error response.
+// order number from the URL parameters. It then calls the GetByOrderNumber
+// method of the order service to retrieve the order with its order products
+// and computed totals. If the order is found, the method returns a 200 status
+// code with the order in the response body. If no order has the given number
+// it returns a 404 error response, and if any other error occurs, a 500 error
+// response.
+//
+// The response carries the ETag of the version of the order, and a request
+// whose If-None-Match header already matches it is answered with a 304 Not
+// Modified status, as described in notModified.
+func (c *orderController) GetOrderByNumber(ctx *gin.Context) {
+	number := ctx.Param("number")
+
+	order, err := c.orderService.GetByOrderNumber(ctx, number)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, order.Version) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// Handles the HTTP request for updating an order.
+//
+// The method takes a pointer to a *gin.Context as a parameter and binds the
+// request body to a dto.UpdateOrderRequest, answered as described in
+// bindRequest when it is invalid. It then calls the Update method of the order
+// service to save the fields of the request on the order identified by the ID
+// in the URL parameters. If the order is updated successfully, the method
+// returns a 200 status code with the updated order in the response body. If
+// the order is not found, it returns a 404 error response, if its discount is
+// in a currency the order has no exchange rate for a 422 error response, and
+// if an error occurs during the update, a 500 error response.
+//
+// The request must send the ETag of the order in its If-Match header, as
+// described in ifMatch. If the order has been changed since that version, it
+// returns a 412 error response, and on success the new ETag is sent with the
+// response.
 func (c *orderController) UpdateOrder(ctx *gin.Context) {
-	var order entities.Order
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 
-	if err := ctx.ShouldBindJSON(&order); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request dto.UpdateOrderRequest
+	if !bindRequest(ctx, &request) {
 		return
 	}
 
-	if err := c.orderService.Update(ctx, &order); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	order, err := c.orderService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
+	fields := request.ApplyTo(order)
+	order.Version = version
 
+	err = c.orderService.Update(ctx, order, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(order.Version))
 	ctx.JSON(http.StatusCreated, order)
 }
 
+// Handles the HTTP request for partially updating an order.
+//
+// The patch document in the request body is applied to the stored order
+// identified by the ID in the URL parameters, as described in applyPatch, and
+// only the fields changed by the patch are saved by the Update method of the
+// order service. The ID in the URL always takes precedence over an ID in the
+// patch. The patched order must follow the rules of a full update, otherwise a
+// 422 error response is returned as described in validatePatched.
+//
+// The request must send the ETag of the order in its If-Match header, as
+// described in ifMatch. If the order is not found, it returns a 404 error
+// response, if it has been changed since that version a 412 error response, and
+// if the update fails a 500 error response. On success, it returns a 200 status
+// code along with the updated order and its new ETag.
+func (c *orderController) PatchOrder(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.orderService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	order, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateOrderRequest](ctx, order, fields) {
+		return
+	}
+	order.ID = id
+	order.Version = version
+
+	err = c.orderService.Update(ctx, order, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(order.Version))
+	ctx.JSON(http.StatusOK, order)
+}
+
 // Handles the HTTP request for deleting an order by its ID.
 //
 // The method takes a pointer to a *gin.Context as a parameter and extracts the
@@ -110,28 +236,53 @@ func (c *orderController) UpdateOrder(ctx *gin.Context) {
 // If the order is deleted successfully, the method returns a 200 status code
 // with a message in the response body. If an error occurs during the deletion,
 // the method returns a 500 error response.
+//
+// The request must send the ETag of the order in its If-Match header, as
+// described in ifMatch. If the order has been changed since that version, it
+// returns a 412 error response, and if it is neither a draft nor cancelled a
+// 409 error response.
+//
+// With the `purge=true` query parameter, the order is permanently deleted
+// instead, which only administrators can do, as described in purgeDeleted.
 func (c *orderController) DeleteOrder(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Order", c.orderService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 
-	if err := c.orderService.Delete(ctx, utils.StringToUint(id)); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err := c.orderService.Delete(ctx, utils.StringToUint(id), version)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
 }
 
-// Handles the HTTP request for retrieving all orders from the database.
+// Handles the HTTP request for retrieving a page of orders.
 //
-// This method takes a pointer to a *gin.Context as a parameter. It calls the GetAll
-// method of the order service to retrieve all orders from the database. If the
-// retrieval fails, it returns a 500 error response. On success, it returns a 200 status
-// code along with the orders in the response body.
+// This method takes a pointer to a *gin.Context as a parameter. It parses the
+// pagination, sorting and filters of the query string against the
+// OrderListSchema of the repositories and calls the GetAll method of the order
+// service with them. If the query string is invalid, it returns a 400 error
+// response, and if the retrieval fails, a 500 error response. On success, it
+// returns a 200 status code along with the page of orders, its total count and
+// the link to the next page.
 func (c *orderController) GetAllOrders(ctx *gin.Context) {
-	orders, err := c.orderService.GetAll(ctx)
+	q, err := query.Parse(ctx.Request.URL, repositories.OrderListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
+	orders, err := c.orderService.GetAll(ctx, q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -140,18 +291,75 @@ func (c *orderController) GetAllOrders(ctx *gin.Context) {
 
 // Handles the HTTP request for deleting multiple orders by their IDs.
 //
-// This method takes a pointer to a *gin.Context as a parameter and extracts the
-// order IDs from the URL query parameters. It then calls the DeleteAll method
-// of the order service to delete the orders from the database. If the deletion
-// is successful, it returns a 200 status code with a message in the response body.
-// If an error occurs during the deletion, it returns a 500 error response.
+// The IDs are read from the `ids` query parameter, or from the `ids` field of
+// the request body for long lists. The orders are deleted in a single
+// transaction by the DeleteAll method of the order service, and the response
+// reports for each id whether it was deleted, not found or blocked, as
+// described in bulkDelete.
 func (c *orderController) DeleteAllOrders(ctx *gin.Context) {
-	ids := ctx.QueryArray("ids")
+	bulkDelete(ctx, c.orderService.DeleteAll)
+}
+
+// Handles the HTTP request for moving an order through its lifecycle.
+//
+// The method takes a pointer to a *gin.Context as a parameter and extracts the
+// ID of the order and the transition name from the URL parameters. The request
+// body may carry who applied the transition and a note. It then calls the
+// Transition method of the order service. If the transition is applied, the
+// method returns a 200 status code with the updated order. If the transition
+// does not exist it returns a 400 error response, if the order is not found a
+// 404 error response, and if the transition is not allowed from the current
+// status a 409 error response with the current and requested status. Any other
+// error results in a 500 error response.
+func (c *orderController) TransitionOrder(ctx *gin.Context) {
+	id := ctx.Param("id")
+	transition := ctx.Param("transition")
+
+	var request orderTransitionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		ctx.Error(malformedBody(err))
+		return
+	}
 
-	if err := c.orderService.DeleteAll(ctx, utils.StringArrToUintArr(ids)); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	order, err := c.orderService.Transition(ctx, utils.StringToUint(id), transition, request.ChangedBy, request.Note)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "All orders deleted successfully"})
+	ctx.JSON(http.StatusOK, order)
+}
+
+// Handles the HTTP request for retrieving the status history of an order.
+//
+// The method takes a pointer to a *gin.Context as a parameter and extracts the
+// ID of the order from the URL parameters. It then calls the GetStatusHistory
+// method of the order service. On success it returns a 200 status code with the
+// history entries, from the oldest to the newest. If the order is not found it
+// returns a 404 error response, and any other error results in a 500 error
+// response.
+func (c *orderController) GetOrderStatusHistory(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	history, err := c.orderService.GetStatusHistory(ctx, utils.StringToUint(id))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// Handles the HTTP request for retrieving a page of the deleted orders.
+//
+// The list query is validated against the OrderListSchema of the repositories,
+// as described in listTrash.
+func (c *orderController) GetOrderTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.OrderListSchema, c.orderService)
+}
+
+// Handles the HTTP request for restoring a deleted order by its ID, as
+// described in restoreDeleted.
+func (c *orderController) RestoreOrder(ctx *gin.Context) {
+	restoreDeleted(ctx, "Order", c.orderService)
 }
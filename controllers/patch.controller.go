@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"store/domain/apperrors"
+	"store/domain/repositories"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+)
+
+// The media types of the patch documents accepted by the PATCH endpoints.
+const (
+	mergePatchType = "application/merge-patch+json" // JSON Merge Patch, RFC 7396
+	jsonPatchType  = "application/json-patch+json"  // JSON Patch, RFC 6902
+)
+
+// applyPatch applies the patch document in the body of a PATCH request to the
+// stored entity, and returns the patched entity along with the names of its
+// struct fields changed by the patch.
+//
+// The stored entity must be at the version given by the If-Match header of the
+// request, which is checked before the patch is applied. The patch is a JSON
+// Merge Patch or a JSON Patch, according to the Content-Type of the request,
+// and applies to the JSON representation of the entity, e.g. a merge patch of
+// `{"quantity": 3}` only changes the quantity.
+//
+// It returns false when the request has already failed or been answered: with
+// a 412 error if the stored entity is at another version, a 415 error for any
+// other Content-Type, a 400 error for a malformed patch, a 409 error for a JSON
+// Patch that cannot be applied, such as a failed `test` operation, and a 422
+// error if the patched document is not a valid entity. A patch that changes
+// nothing is answered with the stored entity and a 200 status code.
+func applyPatch[E any](ctx *gin.Context, stored *E, current, version uint) (*E, []string, bool) {
+	if version != current && version != repositories.AnyVersion {
+		ctx.Error(repositories.ErrVersionConflict)
+		return nil, nil, false
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.Error(malformedBody(err))
+		return nil, nil, false
+	}
+	original, err := json.Marshal(stored)
+	if err != nil {
+		ctx.Error(err)
+		return nil, nil, false
+	}
+
+	var document []byte
+	switch ctx.ContentType() {
+	case mergePatchType:
+		document, err = jsonpatch.MergePatch(original, body)
+		if err != nil {
+			ctx.Error(apperrors.BadRequest("malformed_patch", "the merge patch is malformed: "+err.Error()).Wrap(err))
+			return nil, nil, false
+		}
+	case jsonPatchType:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			ctx.Error(apperrors.BadRequest("malformed_patch", "the JSON patch is malformed: "+err.Error()).Wrap(err))
+			return nil, nil, false
+		}
+		document, err = patch.Apply(original)
+		if err != nil {
+			ctx.Error(apperrors.Conflict("patch_failed", "the JSON patch cannot be applied: "+err.Error()).Wrap(err))
+			return nil, nil, false
+		}
+	default:
+		ctx.Header("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		ctx.Error(apperrors.UnsupportedMediaType("unsupported_media_type", "Content-Type must be "+mergePatchType+" or "+jsonPatchType))
+		return nil, nil, false
+	}
+
+	var patched E
+	if err := json.Unmarshal(document, &patched); err != nil {
+		ctx.Error(bindingError(err, ""))
+		return nil, nil, false
+	}
+
+	fields := changedFields[E](original, document)
+	if len(fields) == 0 {
+		ctx.Header("ETag", etag(current))
+		ctx.JSON(http.StatusOK, stored)
+		return nil, nil, false
+	}
+	return &patched, fields, true
+}
+
+// changedFields returns the names of the struct fields of E whose value
+// differs between the original and the patched JSON documents of an entity.
+// The ID, the version and the embedded structs, such as gorm.Model with the
+// timestamps, are managed by the server and are never reported.
+func changedFields[E any](original, patched []byte) []string {
+	var before, after map[string]interface{}
+	_ = json.Unmarshal(original, &before)
+	_ = json.Unmarshal(patched, &after)
+
+	var fields []string
+	t := reflect.TypeOf((*E)(nil)).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous || !field.IsExported() || field.Name == "ID" || field.Name == "Version" {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		if !reflect.DeepEqual(before[name], after[name]) {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
+
+// jsonName returns the name of a struct field in the JSON representation of
+// its struct, or "-" if the field is never encoded.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"net/http"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProductSupplierController is an interface that defines the methods for handling HTTP requests related to product supplier operations.
+//
+// A product supplier is the offer of a product by a supplier, with its cost,
+// value and stock. The methods in this interface are utilized to create,
+// retrieve, update, and delete product suppliers in the database, as well as to
+// list the offers of a product or of a supplier.
+type ProductSupplierController interface {
+	CreateProductSupplier(ctx *gin.Context)     // Create a new product supplier
+	GetAllProductSuppliers(ctx *gin.Context)    // Get all product suppliers
+	GetProductSupplierByID(ctx *gin.Context)    // Get a product supplier by ID
+	UpdateProductSupplier(ctx *gin.Context)     // Update a product supplier
+	PatchProductSupplier(ctx *gin.Context)      // Partially update a product supplier
+	DeleteProductSupplier(ctx *gin.Context)     // Delete a product supplier
+	DeleteAllProductSuppliers(ctx *gin.Context) // Delete all product suppliers
+	GetProductSuppliers(ctx *gin.Context)       // Get the suppliers offering a product
+	GetSupplierOffers(ctx *gin.Context)         // Get the products offered by a supplier
+	CreateSupplierOffer(ctx *gin.Context)       // Create a new offer of a supplier
+	GetProductSupplierTrash(ctx *gin.Context)   // Get the deleted product suppliers
+	RestoreProductSupplier(ctx *gin.Context)    // Restore a deleted product supplier
+}
+
+// productSupplierController is a struct that contains a pointer to a
+// productSupplierService and implements the ProductSupplierController interface.
+//
+// The struct contains a pointer to a productSupplierService which is used to
+// interact with the product_suppliers table in the database.
+type productSupplierController struct {
+	productSupplierService services.ProductSupplierService
+}
+
+// NewProductSupplierController creates a new instance of productSupplierController with the provided
+// productSupplierService and returns it as a ProductSupplierController. This function is used to
+// initialize a new product supplier controller that can handle HTTP requests for CRUD operations
+// on product suppliers by utilizing the product supplier service.
+func NewProductSupplierController(productSupplierService services.ProductSupplierService) ProductSupplierController {
+	return &productSupplierController{productSupplierService: productSupplierService}
+}
+
+// Handles the HTTP request for creating a new product supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter and binds the
+// JSON request body to a dto.CreateProductSupplierRequest. If the request body
+// is not valid JSON, it returns a 400 error response, and if a field is
+// invalid, such as a negative quantity, a 422 error response, as described in
+// bindRequest. It then calls the Create method of the product supplier service.
+// If the creation fails, it returns a 500 error response. On success, it
+// returns a 201 status code along with the created product supplier.
+func (c *productSupplierController) CreateProductSupplier(ctx *gin.Context) {
+	var request dto.CreateProductSupplierRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	productSupplier := request.ToEntity()
+
+	if err := c.productSupplierService.Create(ctx, productSupplier); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, productSupplier)
+}
+
+// Handles the HTTP request for retrieving a page of product suppliers.
+//
+// This method takes a pointer to a *gin.Context as a parameter. It parses the
+// pagination, sorting and filters of the query string against the
+// ProductSupplierListSchema of the repositories and calls the GetAll method of
+// the product supplier service with them. If the query string is invalid, it
+// returns a 400 error response, and if the retrieval fails, a 500 error
+// response. On success, it returns a 200 status code along with the page of
+// product suppliers.
+func (c *productSupplierController) GetAllProductSuppliers(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.ProductSupplierListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	productSuppliers, err := c.productSupplierService.GetAll(ctx, q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, productSuppliers)
+}
+
+// Handles the HTTP request for retrieving a product supplier by its ID.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// product supplier ID from the URL parameters. It then calls the GetByID method
+// of the product supplier service. If the product supplier is found, it returns
+// a 200 status code with the product supplier in the response body. If it is not
+// found it returns a 404 error response, and any other error results in a 500
+// error response.
+//
+// The response carries the ETag of the version of the product supplier, and a
+// request whose If-None-Match header already matches it is answered with a 304
+// Not Modified status, as described in notModified.
+func (c *productSupplierController) GetProductSupplierByID(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	productSupplier, err := c.productSupplierService.GetByID(ctx, utils.StringToUint(id))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, productSupplier.Version) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, productSupplier)
+}
+
+// Handles the HTTP request for updating a product supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter and binds the JSON
+// request body to a dto.UpdateProductSupplierRequest, answered as described in
+// bindRequest when it is invalid. It then calls the Update method of the
+// product supplier service to save the fields of the request on the product
+// supplier identified by the ID in the URL. If the product supplier is not
+// found, it returns a 404 error response, and if the update fails, a 500 error
+// response. On success, it returns a 200 status code along with the updated
+// product supplier.
+//
+// The request must send the ETag of the product supplier in its If-Match
+// header, as described in ifMatch. If the product supplier has been changed
+// since that version, it returns a 412 error response, and on success the new
+// ETag is sent with the response.
+func (c *productSupplierController) UpdateProductSupplier(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.UpdateProductSupplierRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+
+	productSupplier, err := c.productSupplierService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	fields := request.ApplyTo(productSupplier)
+	productSupplier.Version = version
+
+	err = c.productSupplierService.Update(ctx, productSupplier, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(productSupplier.Version))
+	ctx.JSON(http.StatusOK, productSupplier)
+}
+
+// Handles the HTTP request for partially updating a product supplier.
+//
+// The patch document in the request body is applied to the stored product
+// supplier identified by the ID in the URL parameters, as described in
+// applyPatch, and only the fields changed by the patch are saved by the Update
+// method of the product supplier service. The ID in the URL always takes
+// precedence over an ID in the patch. The patched product supplier must follow
+// the rules of a full update, otherwise a 422 error response is returned as
+// described in validatePatched.
+//
+// The request must send the ETag of the product supplier in its If-Match
+// header, as described in ifMatch. If the product supplier is not found, it
+// returns a 404 error response, if it has been changed since that version a 412
+// error response, and if the update fails a 500 error response. On success, it
+// returns a 200 status code along with the updated product supplier and its new
+// ETag.
+func (c *productSupplierController) PatchProductSupplier(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.productSupplierService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	productSupplier, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateProductSupplierRequest](ctx, productSupplier, fields) {
+		return
+	}
+	productSupplier.ID = id
+	productSupplier.Version = version
+
+	err = c.productSupplierService.Update(ctx, productSupplier, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(productSupplier.Version))
+	ctx.JSON(http.StatusOK, productSupplier)
+}
+
+// Handles the HTTP request for deleting a product supplier by its ID.
+//
+// The method takes a pointer to a *gin.Context as a parameter and extracts the
+// ID of the product supplier to be deleted from the URL parameters. It then
+// calls the Delete method of the product supplier service. If the product
+// supplier is deleted successfully, the method returns a 200 status code with a
+// message in the response body. If an error occurs during the deletion, the
+// method returns a 500 error response.
+//
+// The request must send the ETag of the product supplier in its If-Match
+// header, as described in ifMatch. If the product supplier has been changed
+// since that version, it returns a 412 error response, and if it is used by
+// order lines or purchase order lines a 409 error response.
+//
+// With the `purge=true` query parameter, the product supplier is permanently
+// deleted instead, which only administrators can do, as described in
+// purgeDeleted.
+func (c *productSupplierController) DeleteProductSupplier(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Product supplier", c.productSupplierService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := ctx.Param("id")
+
+	err := c.productSupplierService.Delete(ctx, utils.StringToUint(id), version)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Product supplier deleted successfully"})
+}
+
+// Handles the HTTP request for deleting multiple product suppliers by their IDs.
+//
+// The IDs are read from the `ids` query parameter, or from the `ids` field of
+// the request body for long lists. The product suppliers are deleted in a
+// single transaction by the DeleteAll method of the product supplier service,
+// and the response reports for each id whether it was deleted, not found or
+// blocked, as described in bulkDelete.
+func (c *productSupplierController) DeleteAllProductSuppliers(ctx *gin.Context) {
+	bulkDelete(ctx, c.productSupplierService.DeleteAll)
+}
+
+// Handles the HTTP request for retrieving the suppliers offering a product.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// product ID from the URL parameters. It parses the list query of the request
+// against the ProductSupplierListSchema and calls the GetAllByProductID method
+// of the product supplier service. If the query string is invalid, it returns a
+// 400 error response, and if the retrieval fails, a 500 error response. On
+// success, it returns a 200 status code along with the page of product
+// suppliers of the product.
+func (c *productSupplierController) GetProductSuppliers(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	q, err := query.Parse(ctx.Request.URL, repositories.ProductSupplierListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	productSuppliers, err := c.productSupplierService.GetAllByProductID(ctx, utils.StringToUint(id), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, productSuppliers)
+}
+
+// Handles the HTTP request for retrieving the products offered by a supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// supplier ID from the URL parameters. It parses the list query of the request
+// against the ProductSupplierListSchema and calls the GetAllBySupplierID method
+// of the product supplier service. If the query string is invalid, it returns a
+// 400 error response, and if the retrieval fails, a 500 error response. On
+// success, it returns a 200 status code along with the page of offers of the
+// supplier.
+func (c *productSupplierController) GetSupplierOffers(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	q, err := query.Parse(ctx.Request.URL, repositories.ProductSupplierListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	offers, err := c.productSupplierService.GetAllBySupplierID(ctx, utils.StringToUint(id), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, offers)
+}
+
+// Handles the HTTP request for creating a new offer of a supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter and binds the
+// JSON request body to a dto.CreateSupplierOfferRequest for the supplier
+// identified by the ID in the URL, answered as described in bindRequest when it
+// is invalid. It then calls the Create method of the product supplier service.
+// If the creation fails, it returns a 500 error response. On success, it
+// returns a 201 status code along with the created offer.
+func (c *productSupplierController) CreateSupplierOffer(ctx *gin.Context) {
+	var request dto.CreateSupplierOfferRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	offer := request.ToEntity(utils.StringToUint(ctx.Param("id")))
+
+	if err := c.productSupplierService.Create(ctx, offer); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, offer)
+}
+
+// Handles the HTTP request for retrieving a page of the deleted product suppliers.
+//
+// The list query is validated against the ProductSupplierListSchema of the
+// repositories, as described in listTrash.
+func (c *productSupplierController) GetProductSupplierTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.ProductSupplierListSchema, c.productSupplierService)
+}
+
+// Handles the HTTP request for restoring a deleted product supplier by its ID,
+// as described in restoreDeleted.
+func (c *productSupplierController) RestoreProductSupplier(ctx *gin.Context) {
+	restoreDeleted(ctx, "Product supplier", c.productSupplierService)
+}
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"net/http"
-	"store/domain/entities"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
 	"store/services"
 	"store/utils"
 
@@ -18,8 +20,11 @@ type ProductController interface {
 	GetAllProducts(ctx *gin.Context)    // Get all products
 	GetProductByID(ctx *gin.Context)    // Get a product by ID
 	UpdateProduct(ctx *gin.Context)     // Update a product
+	PatchProduct(ctx *gin.Context)      // Partially update a product
 	DeleteProduct(ctx *gin.Context)     // Delete a product
 	DeleteAllProducts(ctx *gin.Context) // Delete all products
+	GetProductTrash(ctx *gin.Context)   // Get the deleted products
+	RestoreProduct(ctx *gin.Context)    // Restore a deleted product
 }
 
 // productController is a struct that contains a pointer to a productService
@@ -39,33 +44,47 @@ func NewProductController(productService services.ProductService) ProductControl
 	return &productController{productService: productService}
 }
 
+// Handles the HTTP request for creating a new product.
+//
+// This method binds the JSON request body to a dto.CreateProductRequest,
+// answered as described in bindRequest when it is invalid, and calls the
+// Create method of the product service. If the creation fails, it returns a
+// 500 error response. On success, it returns a 201 status code along with the
+// created product.
 func (c *productController) CreateProduct(ctx *gin.Context) {
-	product := &entities.Product{}
-
-	if err := ctx.ShouldBindJSON(product); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request dto.CreateProductRequest
+	if !bindRequest(ctx, &request) {
 		return
 	}
+	product := request.ToEntity()
 
 	if err := c.productService.Create(ctx, product); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, product)
 }
 
-// Handles the HTTP request for retrieving all products from the database.
+// Handles the HTTP request for retrieving a page of products.
 //
-// This method takes a pointer to a *gin.Context as a parameter. It calls the GetAll
-// method of the product service to retrieve all products from the database. If the
-// retrieval fails, it returns a 500 error response. On success, it returns a 200 status
-// code along with the products in the response body.
+// This method takes a pointer to a *gin.Context as a parameter. It parses the
+// pagination, sorting and filters of the query string against the
+// ProductListSchema of the repositories and calls the GetAll method of the
+// product service with them. If the query string is invalid, it returns a 400
+// error response, and if the retrieval fails, a 500 error response. On success,
+// it returns a 200 status code along with the page of products, its total count
+// and the link to the next page.
 func (c *productController) GetAllProducts(ctx *gin.Context) {
-	products, err := c.productService.GetAll(ctx)
+	q, err := query.Parse(ctx.Request.URL, repositories.ProductListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
+	products, err := c.productService.GetAll(ctx, q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -79,12 +98,20 @@ func (c *productController) GetAllProducts(ctx *gin.Context) {
 // product service to retrieve the product from the database. If the product is
 // found, it returns a 200 status code with the product in the response body.
 // If an error occurs during the retrieval, it returns a 500 error response.
+//
+// The response carries the ETag of the version of the product, and a request
+// whose If-None-Match header already matches it is answered with a 304 Not
+// Modified status, as described in notModified.
 func (c *productController) GetProductByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	product, err := c.productService.GetByID(ctx, utils.StringToUint(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, product.Version) {
 		return
 	}
 
@@ -94,28 +121,91 @@ func (c *productController) GetProductByID(ctx *gin.Context) {
 // Handles the HTTP request for updating a product.
 //
 // This method takes a pointer to a *gin.Context as a parameter and binds the JSON
-// request body to a product entity. If the request body is not valid JSON, it returns
-// a 400 error response. It then calls the Update method of the product service to
-// update the product in the database. If the update fails, it returns a 500 error
-// response. On success, it returns a 200 status code along with the updated product
-// in the response body.
-
+// request body to a dto.UpdateProductRequest, answered as described in
+// bindRequest when it is invalid. It then calls the Update method of the product
+// service to save the fields of the request on the product identified by the ID
+// in the URL parameters. If the product is not found, it returns a 404 error
+// response, and if the update fails, a 500 error response. On success, it
+// returns a 200 status code along with the updated product in the response
+// body.
+//
+// The request must send the ETag of the product in its If-Match header, as
+// described in ifMatch. If the product has been changed since that version, it
+// returns a 412 error response, and on success the new ETag is sent with the
+// response.
 func (c *productController) UpdateProduct(ctx *gin.Context) {
-	product := &entities.Product{}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 
-	if err := ctx.ShouldBindJSON(product); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request dto.UpdateProductRequest
+	if !bindRequest(ctx, &request) {
 		return
 	}
 
-	if err := c.productService.Update(ctx, product); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	product, err := c.productService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	fields := request.ApplyTo(product)
+	product.Version = version
+
+	err = c.productService.Update(ctx, product, fields...)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", etag(product.Version))
 	ctx.JSON(http.StatusCreated, product)
 }
 
+// Handles the HTTP request for partially updating a product.
+//
+// The patch document in the request body is applied to the stored product
+// identified by the ID in the URL parameters, as described in applyPatch, and
+// only the fields changed by the patch are saved by the Update method of the
+// product service. The ID in the URL always takes precedence over an ID in the
+// patch. The patched product must follow the rules of a full update, otherwise
+// a 422 error response is returned as described in validatePatched.
+//
+// The request must send the ETag of the product in its If-Match header, as
+// described in ifMatch. If the product is not found, it returns a 404 error
+// response, if it has been changed since that version a 412 error response, and
+// if the update fails a 500 error response. On success, it returns a 200 status
+// code along with the updated product and its new ETag.
+func (c *productController) PatchProduct(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.productService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	product, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateProductRequest](ctx, product, fields) {
+		return
+	}
+	product.ID = id
+	product.Version = version
+
+	err = c.productService.Update(ctx, product, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(product.Version))
+	ctx.JSON(http.StatusOK, product)
+}
+
 // Handles the HTTP request for deleting a product by its ID.
 //
 // The method takes a pointer to a *gin.Context as a parameter and extracts the
@@ -124,32 +214,55 @@ func (c *productController) UpdateProduct(ctx *gin.Context) {
 // If the product is deleted successfully, the method returns a 200 status code
 // with a message in the response body. If an error occurs during the deletion,
 // the method returns a 500 error response.
+//
+// The request must send the ETag of the product in its If-Match header, as
+// described in ifMatch. If the product has been changed since that version, it
+// returns a 412 error response, and if it has product suppliers a 409 error
+// response.
+//
+// With the `purge=true` query parameter, the product is permanently deleted
+// instead, which only administrators can do, as described in purgeDeleted.
 func (c *productController) DeleteProduct(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Product", c.productService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 
-	if err := c.productService.Delete(ctx, utils.StringToUint(id)); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err := c.productService.Delete(ctx, utils.StringToUint(id), version)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
-// Handles the HTTP request for deleting all products by their IDs.
+// Handles the HTTP request for deleting multiple products by their IDs.
 //
-// The method takes a pointer to a *gin.Context as a parameter and extracts the
-// IDs of the products to be deleted from the URL query parameters. It then calls
-// the DeleteAll method of the product service to delete the products from the
-// database. If the deletion is successful, it returns a 200 status code with a
-// success message in the response body. If an error occurs during the deletion,
-// it returns a 500 error response.
+// The IDs are read from the `ids` query parameter, or from the `ids` field of
+// the request body for long lists. The products are deleted in a single
+// transaction by the DeleteAll method of the product service, and the response
+// reports for each id whether it was deleted, not found or blocked, as
+// described in bulkDelete.
 func (c *productController) DeleteAllProducts(ctx *gin.Context) {
-	ids := ctx.QueryArray("ids")
+	bulkDelete(ctx, c.productService.DeleteAll)
+}
 
-	if err := c.productService.DeleteAll(ctx, utils.StringArrToUintArr(ids)); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+// Handles the HTTP request for retrieving a page of the deleted products.
+//
+// The list query is validated against the ProductListSchema of the
+// repositories, as described in listTrash.
+func (c *productController) GetProductTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.ProductListSchema, c.productService)
+}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "All products deleted successfully"})
+// Handles the HTTP request for restoring a deleted product by its ID, as
+// described in restoreDeleted.
+func (c *productController) RestoreProduct(ctx *gin.Context) {
+	restoreDeleted(ctx, "Product", c.productService)
 }
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"net/http"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PurchaseOrderController is an interface that defines the methods for
+// handling HTTP requests related to the purchase orders placed with the
+// suppliers and their goods receipts.
+type PurchaseOrderController interface {
+	CreatePurchaseOrder(ctx *gin.Context)      // Create a new purchase order
+	GetPurchaseOrderByID(ctx *gin.Context)     // Get a purchase order by id
+	GetAllPurchaseOrders(ctx *gin.Context)     // Get all purchase orders
+	UpdatePurchaseOrder(ctx *gin.Context)      // Update a draft purchase order
+	DeletePurchaseOrder(ctx *gin.Context)      // Delete a draft purchase order
+	DeleteAllPurchaseOrders(ctx *gin.Context)  // Delete multiple draft purchase orders
+	TransitionPurchaseOrder(ctx *gin.Context)  // Move a purchase order through its lifecycle
+	ReceivePurchaseOrder(ctx *gin.Context)     // Receive goods for a purchase order
+	GetPurchaseOrderReceipts(ctx *gin.Context) // Get the goods receipts of a purchase order
+	GetPurchaseOrderTrash(ctx *gin.Context)    // Get the deleted purchase orders
+	RestorePurchaseOrder(ctx *gin.Context)     // Restore a deleted purchase order
+}
+
+// purchaseOrderController is a struct that contains a PurchaseOrderService,
+// which is used to manage the purchase orders in the application.
+type purchaseOrderController struct {
+	purchaseOrderService services.PurchaseOrderService
+}
+
+func NewPurchaseOrderController(purchaseOrderService services.PurchaseOrderService) PurchaseOrderController {
+	return &purchaseOrderController{purchaseOrderService: purchaseOrderService}
+}
+
+// Handles the HTTP request for creating a new purchase order.
+//
+// The method binds the request body to a dto.CreatePurchaseOrderRequest,
+// answered as described in bindRequest when it is invalid, and calls the
+// Create method of the purchase order service to save it as a draft. On
+// success it returns a 201 status code with the created purchase order. If the
+// supplier or a product supplier does not exist it returns a 404 error
+// response, and if a product supplier is offered by another supplier a 422
+// error response.
+func (c *purchaseOrderController) CreatePurchaseOrder(ctx *gin.Context) {
+	var request dto.CreatePurchaseOrderRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	purchaseOrder := request.ToEntity()
+
+	err := c.purchaseOrderService.Create(ctx, purchaseOrder)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, purchaseOrder)
+}
+
+// Handles the HTTP request for retrieving a purchase order with its lines and
+// its goods receipts by its ID.
+//
+// If the purchase order is not found it returns a 404 error response. The
+// response carries the ETag of the version of the purchase order, and a
+// request whose If-None-Match header already matches it is answered with a
+// 304 Not Modified status, as described in notModified.
+func (c *purchaseOrderController) GetPurchaseOrderByID(ctx *gin.Context) {
+	purchaseOrder, err := c.purchaseOrderService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, purchaseOrder.Version) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, purchaseOrder)
+}
+
+// Handles the HTTP request for retrieving a page of purchase orders.
+//
+// The pagination, sorting and filters of the query string are parsed against
+// the PurchaseOrderListSchema of the repositories. If the query string is
+// invalid, it returns a 400 error response. On success, it returns a 200
+// status code along with the page of purchase orders, its total count and the
+// link to the next page.
+func (c *purchaseOrderController) GetAllPurchaseOrders(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.PurchaseOrderListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	purchaseOrders, err := c.purchaseOrderService.GetAll(ctx, q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, purchaseOrders)
+}
+
+// Handles the HTTP request for updating a draft purchase order and replacing
+// its lines.
+//
+// The method binds the request body to a dto.UpdatePurchaseOrderRequest,
+// answered as described in bindRequest when it is invalid, and calls the
+// Update method of the purchase order service. If the purchase order is not
+// found it returns a 404 error response, and if it was already sent a 409
+// error response.
+//
+// The request must send the ETag of the purchase order in its If-Match header,
+// as described in ifMatch. If the purchase order has been changed since that
+// version, it returns a 412 error response, and on success the new ETag is
+// sent with the response.
+func (c *purchaseOrderController) UpdatePurchaseOrder(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.UpdatePurchaseOrderRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+
+	purchaseOrder, err := c.purchaseOrderService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	request.ApplyTo(purchaseOrder)
+	purchaseOrder.Receipts = nil
+	purchaseOrder.Version = version
+
+	err = c.purchaseOrderService.Update(ctx, purchaseOrder)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(purchaseOrder.Version))
+	ctx.JSON(http.StatusOK, purchaseOrder)
+}
+
+// Handles the HTTP request for deleting a draft purchase order by its ID.
+//
+// The request must send the ETag of the purchase order in its If-Match header,
+// as described in ifMatch. If the purchase order has been changed since that
+// version, it returns a 412 error response, and if it was already sent a 409
+// error response.
+//
+// With the `purge=true` query parameter, the purchase order is permanently
+// deleted instead, which only administrators can do, as described in
+// purgeDeleted.
+func (c *purchaseOrderController) DeletePurchaseOrder(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Purchase order", c.purchaseOrderService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	err := c.purchaseOrderService.Delete(ctx, utils.StringToUint(ctx.Param("id")), version)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Purchase order deleted successfully"})
+}
+
+// Handles the HTTP request for deleting multiple purchase orders by their IDs,
+// as described in bulkDelete. Purchase orders that were already sent are
+// reported as blocked.
+func (c *purchaseOrderController) DeleteAllPurchaseOrders(ctx *gin.Context) {
+	bulkDelete(ctx, c.purchaseOrderService.DeleteAll)
+}
+
+// Handles the HTTP request for moving a purchase order through its lifecycle.
+//
+// The method extracts the ID of the purchase order and the transition name,
+// send or close, from the URL parameters and calls the Transition method of
+// the purchase order service. If the transition is applied, the method returns
+// a 200 status code with the updated purchase order. If the transition does
+// not exist it returns a 400 error response, if the purchase order is not
+// found a 404 error response, and if the transition is not allowed from the
+// current status a 409 error response with the current and requested status.
+func (c *purchaseOrderController) TransitionPurchaseOrder(ctx *gin.Context) {
+	id := ctx.Param("id")
+	transition := ctx.Param("transition")
+
+	purchaseOrder, err := c.purchaseOrderService.Transition(ctx, utils.StringToUint(id), transition)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, purchaseOrder)
+}
+
+// Handles the HTTP request for receiving goods for a purchase order.
+//
+// The method binds the request body to a dto.CreateGoodsReceiptRequest,
+// answered as described in bindRequest when it is invalid, and calls the
+// Receive method of the purchase order service, which adds the received
+// quantities to the stock. On success it returns a 201 status code with the
+// purchase order in its new status, including the new receipt. If the
+// purchase order is not found it returns a 404 error response, if it is not
+// waiting for goods a 409 error response, and if a line is not a line of the
+// purchase order a 422 error response.
+func (c *purchaseOrderController) ReceivePurchaseOrder(ctx *gin.Context) {
+	var request dto.CreateGoodsReceiptRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+
+	purchaseOrder, err := c.purchaseOrderService.Receive(ctx, utils.StringToUint(ctx.Param("id")), request.ToEntity())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, purchaseOrder)
+}
+
+// Handles the HTTP request for retrieving the goods receipts of a purchase
+// order, from the oldest to the newest one. If the purchase order is not
+// found it returns a 404 error response.
+func (c *purchaseOrderController) GetPurchaseOrderReceipts(ctx *gin.Context) {
+	receipts, err := c.purchaseOrderService.GetReceipts(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, receipts)
+}
+
+// Handles the HTTP request for retrieving a page of the deleted purchase
+// orders.
+//
+// The list query is validated against the PurchaseOrderListSchema of the
+// repositories, as described in listTrash.
+func (c *purchaseOrderController) GetPurchaseOrderTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.PurchaseOrderListSchema, c.purchaseOrderService)
+}
+
+// Handles the HTTP request for restoring a deleted purchase order by its ID,
+// as described in restoreDeleted.
+func (c *purchaseOrderController) RestorePurchaseOrder(ctx *gin.Context) {
+	restoreDeleted(ctx, "Purchase order", c.purchaseOrderService)
+}
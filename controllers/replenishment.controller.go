@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+	"store/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplenishmentController is an interface that defines the methods for the
+// replenishment controller.
+//
+// The methods in this interface are used to plan the purchases that replenish
+// the stock of the product suppliers.
+type ReplenishmentController interface {
+	GetReplenishmentSuggestions(ctx *gin.Context) // Get the quantities suggested to purchase
+}
+
+// replenishmentController is a struct that contains a ReplenishmentService and
+// implements the ReplenishmentController.
+type replenishmentController struct {
+	replenishmentService services.ReplenishmentService
+}
+
+// NewReplenishmentController creates a new instance of replenishmentController
+// with the provided replenishmentService and returns it as a
+// ReplenishmentController.
+func NewReplenishmentController(replenishmentService services.ReplenishmentService) ReplenishmentController {
+	return &replenishmentController{replenishmentService: replenishmentService}
+}
+
+// Handles the HTTP request for retrieving the replenishment suggestions.
+//
+// This method calls the Suggest method of the replenishment service. On
+// success, it returns a 200 status code with the quantities suggested to
+// purchase of the product suppliers whose stock reached their reorder point,
+// grouped by supplier, and an empty list when no product supplier needs to be
+// replenished. Any error results in a 500 error response.
+func (c *replenishmentController) GetReplenishmentSuggestions(ctx *gin.Context) {
+	replenishments, err := c.replenishmentService.Suggest(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, replenishments)
+}
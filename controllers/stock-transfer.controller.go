@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StockTransferController is an interface that defines the methods for
+// handling HTTP requests related to the stock transfers moving units between
+// the warehouses.
+type StockTransferController interface {
+	CreateStockTransfer(ctx *gin.Context)     // Ship a new stock transfer
+	GetStockTransferByID(ctx *gin.Context)    // Get a stock transfer by id
+	GetAllStockTransfers(ctx *gin.Context)    // Get all stock transfers
+	TransitionStockTransfer(ctx *gin.Context) // Receive or cancel a stock transfer
+}
+
+// stockTransferTransitionRequest is the optional request body of a stock
+// transfer transition.
+type stockTransferTransitionRequest struct {
+	ChangedBy string `json:"changed_by"` // user receiving or cancelling the transfer
+}
+
+// stockTransferController is a struct that contains a StockTransferService,
+// which is used to move the stock between the warehouses.
+type stockTransferController struct {
+	stockTransferService services.StockTransferService
+}
+
+func NewStockTransferController(stockTransferService services.StockTransferService) StockTransferController {
+	return &stockTransferController{stockTransferService: stockTransferService}
+}
+
+// Handles the HTTP request for shipping a new stock transfer.
+//
+// The method binds the request body to a dto.CreateStockTransferRequest,
+// answered as described in bindRequest when it is invalid, and calls the
+// Create method of the stock transfer service. On success it returns a 201
+// status code with the transfer in transit. If a warehouse does not exist or
+// both are the same it returns a 422 error response, if a product supplier is
+// not found a 404 error response, and if the source warehouse does not have
+// enough units available a 409 error response.
+func (c *stockTransferController) CreateStockTransfer(ctx *gin.Context) {
+	var request dto.CreateStockTransferRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	transfer := request.ToEntity()
+
+	if err := c.stockTransferService.Create(ctx, transfer); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, transfer)
+}
+
+// Handles the HTTP request for retrieving a stock transfer with its lines by
+// its ID.
+//
+// If the stock transfer is not found it returns a 404 error response. The
+// response carries the ETag of the version of the transfer, and a request
+// whose If-None-Match header already matches it is answered with a 304 Not
+// Modified status, as described in notModified.
+func (c *stockTransferController) GetStockTransferByID(ctx *gin.Context) {
+	transfer, err := c.stockTransferService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, transfer.Version) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+}
+
+// Handles the HTTP request for retrieving a page of stock transfers.
+//
+// The pagination, sorting and filters of the query string are parsed against
+// the StockTransferListSchema of the repositories, e.g.
+// `?status=in_transit&to_warehouse_id=2`. If the query string is invalid, it
+// returns a 400 error response. On success, it returns a 200 status code along
+// with the page of stock transfers, its total count and the link to the next
+// page.
+func (c *stockTransferController) GetAllStockTransfers(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.StockTransferListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	transfers, err := c.stockTransferService.GetAll(ctx, q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
+
+// Handles the HTTP request for receiving or cancelling a stock transfer.
+//
+// The method extracts the ID of the stock transfer and the transition name,
+// receive or cancel, from the URL parameters, and the user applying it from
+// the optional request body, and calls the Transition method of the stock
+// transfer service. If the transition is applied, the method returns a 200
+// status code with the updated transfer. If the transition does not exist it
+// returns a 400 error response, if the transfer is not found a 404 error
+// response, and if it was already received or cancelled a 409 error response.
+func (c *stockTransferController) TransitionStockTransfer(ctx *gin.Context) {
+	id := ctx.Param("id")
+	transition := ctx.Param("transition")
+
+	var request stockTransferTransitionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		ctx.Error(malformedBody(err))
+		return
+	}
+
+	transfer, err := c.stockTransferService.Transition(ctx, utils.StringToUint(id), transition, request.ChangedBy)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+}
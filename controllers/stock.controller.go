@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StockController is an interface that defines the methods for handling HTTP
+// requests related to the stock ledger of the product suppliers.
+//
+// The methods in this interface are used to list and record the movements of
+// the stock of a product supplier, to reconcile the stock counters with the
+// ledger, to list the stock reservations of an order, and to list the lots of
+// the stock and the ones expiring soon.
+type StockController interface {
+	GetStockMovements(ctx *gin.Context)      // Get the movements of the stock of a product supplier
+	CreateStockMovement(ctx *gin.Context)    // Record a movement of the stock of a product supplier
+	GetStockReconciliation(ctx *gin.Context) // Get the stock counters that drifted from the ledger
+	GetOrderReservations(ctx *gin.Context)   // Get the stock reservations of an order
+	GetStockLots(ctx *gin.Context)           // Get the lots of a product supplier
+	GetExpiringStock(ctx *gin.Context)       // Get the lots expiring soon
+}
+
+// defaultExpiryWindow is the number of days reported by GetExpiringStock when
+// the request gives none.
+const defaultExpiryWindow = 30
+
+// stockController is a struct that contains a pointer to a stockService and
+// implements the StockController.
+type stockController struct {
+	stockService services.StockService
+}
+
+// NewStockController creates a new instance of stockController with the
+// provided stockService and returns it as a StockController.
+func NewStockController(stockService services.StockService) StockController {
+	return &stockController{stockService: stockService}
+}
+
+// Handles the HTTP request for retrieving a page of the movements of the stock
+// of a product supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// product supplier ID from the URL parameters. It parses the pagination,
+// sorting and filters of the query string against the StockMovementListSchema
+// of the repositories and calls the GetMovements method of the stock service
+// with them. If the query string is invalid, it returns a 400 error response,
+// if the product supplier is not found, a 404 error response, and if the
+// retrieval fails, a 500 error response. On success, it returns a 200 status
+// code along with the page of movements.
+func (c *stockController) GetStockMovements(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.StockMovementListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	movements, err := c.stockService.GetMovements(ctx, utils.StringToUint(ctx.Param("id")), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, movements)
+}
+
+// Handles the HTTP request for recording a movement of the stock of a product
+// supplier.
+//
+// This method takes a pointer to a *gin.Context as a parameter, extracts the
+// product supplier ID from the URL parameters and binds the JSON request body
+// to a dto.CreateStockMovementRequest. The movement takes place in the given
+// warehouse, or in the default one. If the request body is not valid JSON, it
+// returns a 400 error response, and if a field is invalid, the quantity does
+// not suit the type of the movement or the warehouse does not exist, a 422
+// error response. If the product supplier is not found, it returns a 404 error
+// response, and if the movement takes more units than the warehouse has in
+// stock, a 409 error response. The units move in the given lot, or in the lots
+// chosen first expired, first out, and an unknown lot or a lot received with
+// another expiry date is answered with a 422 error response. On success, it
+// returns a 201 status code along with the recorded movement and its lots.
+func (c *stockController) CreateStockMovement(ctx *gin.Context) {
+	var request dto.CreateStockMovementRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	movement := request.ToEntity(utils.StringToUint(ctx.Param("id")))
+
+	if err := c.stockService.RecordMovement(ctx, movement); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, movement)
+}
+
+// Handles the HTTP request for reconciling the stock counters with the ledger.
+//
+// This method takes a pointer to a *gin.Context as a parameter and calls the
+// Reconcile method of the stock service. If the reconciliation fails, it
+// returns a 500 error response. On success, it returns a 200 status code along
+// with the product suppliers and the suppliers whose stock drifted from the
+// ledger.
+func (c *stockController) GetStockReconciliation(ctx *gin.Context) {
+	reconciliation, err := c.stockService.Reconcile(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reconciliation)
+}
+
+// Handles the HTTP request for retrieving the stock reservations of an order.
+//
+// This method takes a pointer to a *gin.Context as a parameter and extracts the
+// order ID from the URL parameters. It then calls the GetReservations method of
+// the stock service. If the order is not found, it returns a 404 error
+// response, and if the retrieval fails, a 500 error response. On success, it
+// returns a 200 status code along with the reservations of the order.
+func (c *stockController) GetOrderReservations(ctx *gin.Context) {
+	reservations, err := c.stockService.GetReservations(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reservations)
+}
+
+// Handles the HTTP request for retrieving a page of the lots of a product
+// supplier in every warehouse.
+//
+// The pagination, sorting and filters of the query string are parsed against
+// the StockLotListSchema of the repositories, e.g.
+// `?warehouse_id=2&quantity[gt]=0`. If the query string is invalid, it returns
+// a 400 error response, and if the product supplier is not found, a 404 error
+// response. On success, it returns a 200 status code along with the page of
+// lots.
+func (c *stockController) GetStockLots(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.StockLotListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	lots, err := c.stockService.GetLots(ctx, utils.StringToUint(ctx.Param("id")), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lots)
+}
+
+// Handles the HTTP request for retrieving the lots on hand expiring soon.
+//
+// The `within` query parameter gives the number of days reported, such as
+// `30d` or `30`, 30 days by default, and any other value is answered with a
+// 400 error response. On success, it returns a 200 status code along with the
+// lots expiring up to that day, the expired ones included, from the first to
+// expire.
+func (c *stockController) GetExpiringStock(ctx *gin.Context) {
+	days := defaultExpiryWindow
+	if within := ctx.Query("within"); within != "" {
+		var err error
+		days, err = strconv.Atoi(strings.TrimSuffix(within, "d"))
+		if err != nil || days < 0 {
+			ctx.Error(fmt.Errorf("%w: within must be a number of days, such as 30d", query.ErrInvalidQuery))
+			return
+		}
+	}
+
+	report, err := c.stockService.GetExpiring(ctx, days)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -2,12 +2,13 @@ package controllers
 
 import (
 	"net/http"
-	"store/domain/entities"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
 	"store/services"
 	"store/utils"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // SupplierController is an interface that defines the methods for the supplier controller.
@@ -19,8 +20,11 @@ type SupplierController interface {
 	GetAllSuppliers(ctx *gin.Context)
 	GetSupplierByID(ctx *gin.Context)
 	UpdateSupplier(ctx *gin.Context)
+	PatchSupplier(ctx *gin.Context) // Partially update a supplier
 	DeleteSupplier(ctx *gin.Context)
 	DeleteAllSuppliers(ctx *gin.Context)
+	GetSupplierTrash(ctx *gin.Context) // Get the deleted suppliers
+	RestoreSupplier(ctx *gin.Context)  // Restore a deleted supplier
 }
 
 // supplierController is a struct that contains a pointer to a supplierService and
@@ -43,44 +47,49 @@ func NewSupplierController(supplierService services.SupplierService) SupplierCon
 // Handles the HTTP POST request to create a new supplier.
 //
 // The method expects a JSON payload representing the supplier details
-// in the request body. It binds the JSON payload to a Supplier entity and
-// invokes the supplier service to create a new supplier in the database.
+// in the request body. It binds the JSON payload to a dto.CreateSupplierRequest
+// and invokes the supplier service to create a new supplier in the database.
 //
-// If the JSON binding fails, it responds with a 400 status code and an error message.
+// If the JSON binding fails, it responds with a 400 status code and an error message,
+// and if a field is invalid, such as an empty name or a negative stock, with a 422
+// status code and the invalid fields, as described in bindRequest.
 // If the creation fails due to a server error, it responds with a 500 status code
 // and an error message. Upon successful creation, it responds with a 201 status code
 // and the created supplier entity as JSON.
 func (c *supplierController) CreateSupplier(ctx *gin.Context) {
-	var supplier entities.Supplier
-
-	if err := ctx.ShouldBindJSON(&supplier); err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	var request dto.CreateSupplierRequest
+	if !bindRequest(ctx, &request) {
 		return
 	}
+	supplier := request.ToEntity()
 
-	if err := c.supplierService.Create(ctx, &supplier); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.supplierService.Create(ctx, supplier); err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, supplier)
 }
 
-// Handles the HTTP GET request to retrieve all suppliers from the database.
+// Handles the HTTP request for retrieving a page of suppliers.
 //
-// The method invokes the supplier service to retrieve all suppliers from the
-// database. If the retrieval fails due to a server error, it responds with a
-// 500 status code and an error message. Otherwise, it responds with a 200 status
-// code and the list of suppliers as JSON.
+// This method takes a pointer to a *gin.Context as a parameter. It parses the
+// pagination, sorting and filters of the query string against the
+// SupplierListSchema of the repositories and calls the GetAll method of the
+// supplier service with them. If the query string is invalid, it returns a 400
+// error response, and if the retrieval fails, a 500 error response. On success,
+// it returns a 200 status code along with the page of suppliers, its total
+// count and the link to the next page.
 func (c *supplierController) GetAllSuppliers(ctx *gin.Context) {
-	suppliers, err := c.supplierService.GetAll(ctx)
-
-	if err == gorm.ErrRecordNotFound {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": "No suppliers found"})
+	q, err := query.Parse(ctx.Request.URL, repositories.SupplierListSchema)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
+
+	suppliers, err := c.supplierService.GetAll(ctx, q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -94,16 +103,20 @@ func (c *supplierController) GetAllSuppliers(ctx *gin.Context) {
 // If the supplier is found, it responds with a 200 status code and the supplier entity
 // as JSON. If an error occurs during the retrieval, it responds with a 500 status code
 // and an error message.
+//
+// The response carries the ETag of the version of the supplier, and a request
+// whose If-None-Match header already matches it is answered with a 304 Not
+// Modified status, as described in notModified.
 func (c *supplierController) GetSupplierByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	supplier, err := c.supplierService.GetByID(ctx, utils.StringToUint(id))
-	if err == gorm.ErrRecordNotFound {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": "Supplier not found"})
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	if notModified(ctx, supplier.Version) {
 		return
 	}
 
@@ -113,29 +126,94 @@ func (c *supplierController) GetSupplierByID(ctx *gin.Context) {
 // Handles the HTTP PUT request to update a supplier in the database.
 //
 // The method expects a JSON payload representing the supplier details
-// in the request body. It binds the JSON payload to a Supplier entity and
-// invokes the supplier service to update the supplier in the database.
+// in the request body. It binds the JSON payload to a dto.UpdateSupplierRequest
+// and invokes the supplier service to save its fields on the supplier in the
+// database.
+//
+// If the JSON binding fails, it responds with a 400 status code and an error
+// message, and if a field is invalid, with a 422 status code, as described in
+// bindRequest. If the supplier is not found, it responds with a 404 status
+// code, and if the update fails due to a server error, it responds with a 500
+// status code and an error message. Upon successful update, it responds with a
+// 200 status code and the updated supplier entity as JSON.
 //
-// If the JSON binding fails, it responds with a 400 status code and an error message.
-// If the update fails due to a server error, it responds with a 500 status code
-// and an error message. Upon successful update, it responds with a 200 status code
-// and the updated supplier entity as JSON.
+// The request must send the ETag of the supplier in its If-Match header, as
+// described in ifMatch. If the supplier has been changed since that version, it
+// returns a 412 error response, and on success the new ETag is sent with the
+// response.
 func (c *supplierController) UpdateSupplier(ctx *gin.Context) {
-	var supplier entities.Supplier
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 
-	if err := ctx.ShouldBindJSON(&supplier); err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	var request dto.UpdateSupplierRequest
+	if !bindRequest(ctx, &request) {
 		return
 	}
 
-	if err := c.supplierService.Update(ctx, &supplier); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	supplier, err := c.supplierService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
+	fields := request.ApplyTo(supplier)
+	supplier.Version = version
 
+	err = c.supplierService.Update(ctx, supplier, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(supplier.Version))
 	ctx.JSON(http.StatusCreated, supplier)
 }
 
+// Handles the HTTP request for partially updating a supplier.
+//
+// The patch document in the request body is applied to the stored supplier
+// identified by the ID in the URL parameters, as described in applyPatch, and
+// only the fields changed by the patch are saved by the Update method of the
+// supplier service. The ID in the URL always takes precedence over an ID in the
+// patch. The patched supplier must follow the rules of a full update, otherwise
+// a 422 error response is returned as described in validatePatched.
+//
+// The request must send the ETag of the supplier in its If-Match header, as
+// described in ifMatch. If the supplier is not found, it returns a 404 error
+// response, if it has been changed since that version a 412 error response, and
+// if the update fails a 500 error response. On success, it returns a 200 status
+// code along with the updated supplier and its new ETag.
+func (c *supplierController) PatchSupplier(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.supplierService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	supplier, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateSupplierRequest](ctx, supplier, fields) {
+		return
+	}
+	supplier.ID = id
+	supplier.Version = version
+
+	err = c.supplierService.Update(ctx, supplier, fields...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(supplier.Version))
+	ctx.JSON(http.StatusOK, supplier)
+}
+
 // Handles the HTTP DELETE request for removing a supplier by its ID.
 //
 // This method extracts the supplier ID from the URL parameters and
@@ -143,33 +221,55 @@ func (c *supplierController) UpdateSupplier(ctx *gin.Context) {
 // from the database. If the supplier is successfully deleted, it responds
 // with a 200 status code and a success message. If an error occurs during
 // the deletion, it responds with a 500 status code and an error message.
+//
+// The request must send the ETag of the supplier in its If-Match header, as
+// described in ifMatch. If the supplier has been changed since that version, it
+// returns a 412 error response, and if it has product suppliers or purchase
+// orders a 409 error response.
+//
+// With the `purge=true` query parameter, the supplier is permanently deleted
+// instead, which only administrators can do, as described in purgeDeleted.
 func (c *supplierController) DeleteSupplier(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Supplier", c.supplierService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 
-	err := c.supplierService.Delete(ctx, utils.StringToUint(id))
+	err := c.supplierService.Delete(ctx, utils.StringToUint(id), version)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Supplier deleted successfully"})
 }
 
-// Handles the HTTP DELETE request for removing multiple suppliers by their IDs.
+// Handles the HTTP request for deleting multiple suppliers by their IDs.
 //
-// This method extracts the supplier IDs from the URL query parameters and
-// calls the DeleteAll method of the supplier service to remove the suppliers
-// from the database. If the suppliers are successfully deleted, it responds
-// with a 200 status code and a success message. If an error occurs during
-// the deletion, it responds with a 500 status code and an error message.
+// The IDs are read from the `ids` query parameter, or from the `ids` field of
+// the request body for long lists. The suppliers are deleted in a single
+// transaction by the DeleteAll method of the supplier service, and the response
+// reports for each id whether it was deleted, not found or blocked, as
+// described in bulkDelete.
 func (c *supplierController) DeleteAllSuppliers(ctx *gin.Context) {
-	ids := ctx.QueryArray("ids")
+	bulkDelete(ctx, c.supplierService.DeleteAll)
+}
 
-	err := c.supplierService.DeleteAll(ctx, utils.StringArrToUintArr(ids))
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+// Handles the HTTP request for retrieving a page of the deleted suppliers.
+//
+// The list query is validated against the SupplierListSchema of the
+// repositories, as described in listTrash.
+func (c *supplierController) GetSupplierTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.SupplierListSchema, c.supplierService)
+}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "All suppliers deleted successfully"})
+// Handles the HTTP request for restoring a deleted supplier by its ID, as
+// described in restoreDeleted.
+func (c *supplierController) RestoreSupplier(ctx *gin.Context) {
+	restoreDeleted(ctx, "Supplier", c.supplierService)
 }
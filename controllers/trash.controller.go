@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"store/domain/apperrors"
+	"store/domain/query"
+	"store/services"
+	"store/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminTokenHeader is the request header carrying the token of an administrator.
+const adminTokenHeader = "X-Admin-Token"
+
+// errAdminRequired is the error of a purge requested without the administrator
+// token.
+var errAdminRequired = apperrors.Forbidden("admin_required", "purging requires an administrator token")
+
+// isAdmin reports whether the request carries the administrator token set in
+// the ADMIN_TOKEN environment variable. No request is an administrator one
+// when the variable is not set.
+func isAdmin(ctx *gin.Context) bool {
+	token := utils.GetEnv("ADMIN_TOKEN", "")
+	given := ctx.GetHeader(adminTokenHeader)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(given)) == 1
+}
+
+// isPurge reports whether a delete request asks for the entity to be
+// permanently deleted, with the `purge=true` query parameter.
+func isPurge(ctx *gin.Context) bool {
+	return ctx.Query("purge") == "true"
+}
+
+// Handles the HTTP request for retrieving a page of the deleted entities of a
+// resource.
+//
+// The list query of the request is parsed against the schema of the entity and
+// given to the GetTrash method of the service. If the query string is invalid,
+// it returns a 400 error response, and if the retrieval fails, a 500 error
+// response. On success, it returns a 200 status code along with the page of
+// deleted entities.
+func listTrash[E any](ctx *gin.Context, schema query.Schema, service services.TrashService[E]) {
+	q, err := query.Parse(ctx.Request.URL, schema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	trash, err := service.GetTrash(ctx, q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, trash)
+}
+
+// Handles the HTTP request for restoring a deleted entity by its ID.
+//
+// The ID is read from the URL parameters and given to the Restore method of
+// the service, which also restores the dependents of the entity. If no deleted
+// entity has the ID, it returns a 404 error response, and if the restore fails,
+// a 500 error response. On success, it returns a 200 status code with a
+// message naming the entity.
+func restoreDeleted[E any](ctx *gin.Context, name string, service services.TrashService[E]) {
+	id := ctx.Param("id")
+
+	err := service.Restore(ctx, utils.StringToUint(id))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": name + " restored successfully"})
+}
+
+// Handles the HTTP request for permanently deleting an entity by its ID.
+//
+// Only administrators can purge entities: without the administrator token it
+// returns a 403 error response. The ID is read from the URL parameters and
+// given to the Purge method of the service. If no entity has the ID, it returns
+// a 404 error response, if other rows still reference the entity a 409 error
+// response, and if the purge fails a 500 error response. On success, it returns
+// a 200 status code with a message naming the entity.
+func purgeDeleted[E any](ctx *gin.Context, name string, service services.TrashService[E]) {
+	if !isAdmin(ctx) {
+		ctx.Error(errAdminRequired)
+		return
+	}
+	id := ctx.Param("id")
+
+	err := service.Purge(ctx, utils.StringToUint(id))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": name + " purged successfully"})
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"store/domain/apperrors"
+	"store/domain/dto"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindRequest binds the JSON request body to request, one of the request DTOs,
+// and validates it against its rules.
+//
+// It returns false when the request has already failed: with a 400 error if
+// the body is not valid JSON, and with a 422 validation error listing every
+// invalid field, as described in invalidFields, if a field has the wrong type
+// or breaks a rule.
+func bindRequest(ctx *gin.Context, request interface{}) bool {
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		ctx.Error(bindingError(err, ""))
+		return false
+	}
+	return true
+}
+
+// bindRequests binds the JSON array of the request body to a list of request
+// DTOs, and validates each of them. The invalid fields are reported with the
+// index of their element, e.g. `[2].rate`, and the request fails as described
+// in bindRequest.
+func bindRequests[R any](ctx *gin.Context) ([]R, bool) {
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.Error(malformedBody(err))
+		return nil, false
+	}
+	var requests []R
+	if err := json.Unmarshal(body, &requests); err != nil {
+		ctx.Error(bindingError(err, ""))
+		return nil, false
+	}
+
+	var fields []apperrors.FieldError
+	for i := range requests {
+		err := binding.Validator.ValidateStruct(&requests[i])
+		if invalid, ok := invalidFields(err, "["+strconv.Itoa(i)+"]."); ok {
+			fields = append(fields, invalid...)
+		}
+	}
+	if len(fields) > 0 {
+		ctx.Error(apperrors.Validation(fields))
+		return nil, false
+	}
+	return requests, true
+}
+
+// validatePatched validates a patched entity against the rules of the request
+// DTO R of its full update, so a PATCH cannot store what a PUT would reject.
+// The fields changed by the patch must also be among the fields set by the
+// ApplyTo method of R, so fields a PUT never changes, such as the customer of
+// an order or the supplier of a product supplier, cannot be patched either.
+// It returns false when the entity is invalid or changes another field, after
+// failing the request with a 422 validation error.
+func validatePatched[R any, E any, PR interface {
+	*R
+	ApplyTo(*E) []string
+}](ctx *gin.Context, patched *E, fields []string) bool {
+	allowed := map[string]bool{}
+	for _, name := range PR(new(R)).ApplyTo(new(E)) {
+		allowed[name] = true
+	}
+	var forbidden []apperrors.FieldError
+	t := reflect.TypeOf(patched).Elem()
+	for _, name := range fields {
+		if !allowed[name] {
+			field, _ := t.FieldByName(name)
+			forbidden = append(forbidden, apperrors.FieldError{Field: jsonName(field), Rule: "readonly", Message: "cannot be changed"})
+		}
+	}
+	if len(forbidden) > 0 {
+		ctx.Error(apperrors.Validation(forbidden))
+		return false
+	}
+
+	document, err := json.Marshal(patched)
+	if err != nil {
+		ctx.Error(err)
+		return false
+	}
+	var request R
+	if err := json.Unmarshal(document, &request); err != nil {
+		ctx.Error(err)
+		return false
+	}
+
+	if fields, ok := invalidFields(binding.Validator.ValidateStruct(&request), ""); ok {
+		ctx.Error(apperrors.Validation(fields))
+		return false
+	}
+	return true
+}
+
+// bindingError returns the error a request fails with when its body cannot be
+// bound: a validation error when fields are invalid, or a 400 error.
+func bindingError(err error, prefix string) error {
+	if fields, ok := invalidFields(err, prefix); ok {
+		return apperrors.Validation(fields)
+	}
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return malformedBody(err)
+}
+
+// malformedBody returns the error of a request body that is not valid JSON.
+func malformedBody(err error) error {
+	return apperrors.BadRequest("malformed_body", "the request body is not valid JSON: "+err.Error()).Wrap(err)
+}
+
+// invalidFields returns the invalid fields reported by err, prefixed with the
+// given path, if err is a validation error or a JSON value of the wrong type.
+func invalidFields(err error, prefix string) ([]apperrors.FieldError, bool) {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return []apperrors.FieldError{{
+			Field:   prefix + typeError.Field,
+			Rule:    "type",
+			Message: "must not be a JSON " + typeError.Value,
+		}}, true
+	}
+
+	fields, ok := dto.FieldErrors(err)
+	for i := range fields {
+		fields[i].Field = prefix + fields[i].Field
+	}
+	return fields, ok
+}
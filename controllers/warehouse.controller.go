@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"net/http"
+	"store/domain/dto"
+	"store/domain/query"
+	"store/domain/repositories"
+	"store/services"
+	"store/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WarehouseController is an interface that defines the methods for handling
+// HTTP requests related to the warehouses the stock is split between and the
+// stock on hand in them.
+type WarehouseController interface {
+	CreateWarehouse(ctx *gin.Context)         // Create a new warehouse
+	GetAllWarehouses(ctx *gin.Context)        // Get all warehouses
+	GetWarehouseByID(ctx *gin.Context)        // Get a warehouse by id
+	UpdateWarehouse(ctx *gin.Context)         // Update a warehouse
+	PatchWarehouse(ctx *gin.Context)          // Partially update a warehouse
+	DeleteWarehouse(ctx *gin.Context)         // Delete a warehouse
+	DeleteAllWarehouses(ctx *gin.Context)     // Delete multiple warehouses
+	GetWarehouseTrash(ctx *gin.Context)       // Get the deleted warehouses
+	RestoreWarehouse(ctx *gin.Context)        // Restore a deleted warehouse
+	GetWarehouseStock(ctx *gin.Context)       // Get the stock on hand in a warehouse
+	GetProductSupplierStock(ctx *gin.Context) // Get the stock of a product supplier in every warehouse
+}
+
+// warehouseController is a struct that contains a WarehouseService, which is
+// used to manage the warehouses in the application.
+type warehouseController struct {
+	warehouseService services.WarehouseService
+}
+
+// NewWarehouseController creates a new instance of warehouseController with
+// the provided warehouseService and returns it as a WarehouseController.
+func NewWarehouseController(warehouseService services.WarehouseService) WarehouseController {
+	return &warehouseController{warehouseService: warehouseService}
+}
+
+// Handles the HTTP request for creating a new warehouse.
+//
+// The method binds the request body to a dto.CreateWarehouseRequest, answered
+// as described in bindRequest when it is invalid, and calls the Create method
+// of the warehouse service. On success it returns a 201 status code with the
+// created warehouse. If another warehouse already has its code it returns a
+// 409 error response.
+func (c *warehouseController) CreateWarehouse(ctx *gin.Context) {
+	var request dto.CreateWarehouseRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+	warehouse := request.ToEntity()
+
+	if err := c.warehouseService.Create(ctx, warehouse); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, warehouse)
+}
+
+// Handles the HTTP request for retrieving a page of warehouses.
+//
+// The pagination, sorting and filters of the query string are parsed against
+// the WarehouseListSchema of the repositories. If the query string is invalid,
+// it returns a 400 error response. On success, it returns a 200 status code
+// along with the page of warehouses, its total count and the link to the next
+// page.
+func (c *warehouseController) GetAllWarehouses(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.WarehouseListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	warehouses, err := c.warehouseService.GetAll(ctx, q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, warehouses)
+}
+
+// Handles the HTTP request for retrieving a warehouse by its ID.
+//
+// If the warehouse is not found it returns a 404 error response. The response
+// carries the ETag of the version of the warehouse, and a request whose
+// If-None-Match header already matches it is answered with a 304 Not Modified
+// status, as described in notModified.
+func (c *warehouseController) GetWarehouseByID(ctx *gin.Context) {
+	warehouse, err := c.warehouseService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, warehouse.Version) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, warehouse)
+}
+
+// Handles the HTTP request for updating a warehouse.
+//
+// The method binds the request body to a dto.UpdateWarehouseRequest, answered
+// as described in bindRequest when it is invalid, and calls the Update method
+// of the warehouse service. If the warehouse is not found it returns a 404
+// error response, and if it is the default warehouse and the request makes it
+// no longer the default one a 409 error response.
+//
+// The request must send the ETag of the warehouse in its If-Match header, as
+// described in ifMatch. If the warehouse has been changed since that version,
+// it returns a 412 error response, and on success the new ETag is sent with
+// the response.
+func (c *warehouseController) UpdateWarehouse(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	var request dto.UpdateWarehouseRequest
+	if !bindRequest(ctx, &request) {
+		return
+	}
+
+	warehouse, err := c.warehouseService.GetByID(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	fields := request.ApplyTo(warehouse)
+	warehouse.Version = version
+
+	if err := c.warehouseService.Update(ctx, warehouse, fields...); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(warehouse.Version))
+	ctx.JSON(http.StatusOK, warehouse)
+}
+
+// Handles the HTTP request for partially updating a warehouse.
+//
+// The patch document in the request body is applied to the stored warehouse
+// identified by the ID in the URL parameters, as described in applyPatch, and
+// only the fields changed by the patch are saved by the Update method of the
+// warehouse service. The patched warehouse must follow the rules of a full
+// update, otherwise a 422 error response is returned as described in
+// validatePatched.
+//
+// The request must send the ETag of the warehouse in its If-Match header, as
+// described in ifMatch. If the warehouse is not found, it returns a 404 error
+// response, if it has been changed since that version a 412 error response,
+// and if the patch makes the default warehouse no longer the default one a 409
+// error response. On success, it returns a 200 status code along with the
+// updated warehouse and its new ETag.
+func (c *warehouseController) PatchWarehouse(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	id := utils.StringToUint(ctx.Param("id"))
+
+	stored, err := c.warehouseService.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	warehouse, fields, ok := applyPatch(ctx, stored, stored.Version, version)
+	if !ok || !validatePatched[dto.UpdateWarehouseRequest](ctx, warehouse, fields) {
+		return
+	}
+	warehouse.ID = id
+	warehouse.Version = version
+
+	if err := c.warehouseService.Update(ctx, warehouse, fields...); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(warehouse.Version))
+	ctx.JSON(http.StatusOK, warehouse)
+}
+
+// Handles the HTTP request for deleting a warehouse by its ID.
+//
+// The request must send the ETag of the warehouse in its If-Match header, as
+// described in ifMatch. If the warehouse has been changed since that version,
+// it returns a 412 error response, and if it is the default warehouse, has
+// stock on hand or has transfers in transit a 409 error response.
+//
+// With the `purge=true` query parameter, the warehouse is permanently deleted
+// instead, which only administrators can do, as described in purgeDeleted.
+func (c *warehouseController) DeleteWarehouse(ctx *gin.Context) {
+	if isPurge(ctx) {
+		purgeDeleted(ctx, "Warehouse", c.warehouseService)
+		return
+	}
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	err := c.warehouseService.Delete(ctx, utils.StringToUint(ctx.Param("id")), version)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Warehouse deleted successfully"})
+}
+
+// Handles the HTTP request for deleting multiple warehouses by their IDs, as
+// described in bulkDelete. The default warehouse and the warehouses with stock
+// on hand or with transfers in transit are reported as blocked.
+func (c *warehouseController) DeleteAllWarehouses(ctx *gin.Context) {
+	bulkDelete(ctx, c.warehouseService.DeleteAll)
+}
+
+// Handles the HTTP request for retrieving a page of the deleted warehouses.
+//
+// The list query is validated against the WarehouseListSchema of the
+// repositories, as described in listTrash.
+func (c *warehouseController) GetWarehouseTrash(ctx *gin.Context) {
+	listTrash(ctx, repositories.WarehouseListSchema, c.warehouseService)
+}
+
+// Handles the HTTP request for restoring a deleted warehouse by its ID, as
+// described in restoreDeleted.
+func (c *warehouseController) RestoreWarehouse(ctx *gin.Context) {
+	restoreDeleted(ctx, "Warehouse", c.warehouseService)
+}
+
+// Handles the HTTP request for retrieving a page of the stock on hand in a
+// warehouse.
+//
+// The pagination, sorting and filters of the query string are parsed against
+// the WarehouseStockListSchema of the repositories, e.g.
+// `?product_supplier_id=3` or `?quantity[gt]=0`. If the query string is
+// invalid, it returns a 400 error response, and if the warehouse is not found
+// a 404 error response. On success, it returns a 200 status code along with
+// the page of the quantities of the product suppliers in the warehouse.
+func (c *warehouseController) GetWarehouseStock(ctx *gin.Context) {
+	q, err := query.Parse(ctx.Request.URL, repositories.WarehouseStockListSchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	stock, err := c.warehouseService.GetStock(ctx, utils.StringToUint(ctx.Param("id")), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, stock)
+}
+
+// Handles the HTTP request for retrieving the stock of a product supplier in
+// every warehouse, with the units on hand and in transit to each of them. If
+// the product supplier is not found it returns a 404 error response.
+func (c *warehouseController) GetProductSupplierStock(ctx *gin.Context) {
+	levels, err := c.warehouseService.GetProductSupplierStock(ctx, utils.StringToUint(ctx.Param("id")))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, levels)
+}
@@ -0,0 +1,189 @@
+// Package apperrors defines the typed errors returned by the repositories and
+// services of the store, and their rendering as RFC 7807 problem details.
+//
+// Every error has a Kind, which gives its HTTP status, and a stable Code that
+// clients can rely on, e.g. `version_conflict` or `insufficient_stock`,
+// whatever the wording of its message. Sentinel errors are declared with the
+// constructors of this package, e.g.
+//
+//	var ErrOrderNotDraft = apperrors.Conflict("order_not_draft", "order lines can only be changed while the order is a draft")
+//
+// and are still matched with errors.Is, even when wrapped with fmt.Errorf to
+// add context to their message.
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind is the category of an error, which gives its HTTP status.
+type Kind string
+
+const (
+	KindBadRequest           Kind = "bad_request"            // the request is malformed
+	KindForbidden            Kind = "forbidden"              // the request is not allowed for the caller
+	KindNotFound             Kind = "not_found"              // the entity does not exist
+	KindConflict             Kind = "conflict"               // the request conflicts with the state of the entities
+	KindPreconditionFailed   Kind = "precondition_failed"    // the entity changed since the version the request is based on
+	KindUnsupportedMediaType Kind = "unsupported_media_type" // the body has a media type that is not accepted
+	KindValidation           Kind = "validation"             // the request is well formed but has invalid values
+	KindPreconditionRequired Kind = "precondition_required"  // the request must be based on a version of the entity
+	KindInternal             Kind = "internal"               // the server failed to handle the request
+)
+
+// statuses maps each kind to its HTTP status.
+var statuses = map[Kind]int{
+	KindBadRequest:           http.StatusBadRequest,
+	KindForbidden:            http.StatusForbidden,
+	KindNotFound:             http.StatusNotFound,
+	KindConflict:             http.StatusConflict,
+	KindPreconditionFailed:   http.StatusPreconditionFailed,
+	KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	KindValidation:           http.StatusUnprocessableEntity,
+	KindPreconditionRequired: http.StatusPreconditionRequired,
+	KindInternal:             http.StatusInternalServerError,
+}
+
+// Status returns the HTTP status of the errors of the kind, 500 for an unknown
+// kind.
+func (k Kind) Status() int {
+	if status, ok := statuses[k]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError describes a field of a request that breaks one of its
+// validation rules, as listed in the validation errors.
+type FieldError struct {
+	Field   string `json:"field"`   // path of the field in the JSON request, e.g. `contacts[0].phone` or `order_products[0].quantity`
+	Rule    string `json:"rule"`    // name of the broken rule, e.g. `required` or `gtefield`
+	Message string `json:"message"` // human readable description of the rule
+}
+
+// Error is an error of the store with the kind and the stable code it is
+// reported with to clients.
+type Error struct {
+	Kind       Kind                   // category of the error, giving its HTTP status
+	Code       string                 // stable code of the error, e.g. `order_not_draft`
+	Message    string                 // human readable description, safe to show to clients
+	Fields     []FieldError           // invalid fields of a validation error
+	Extensions map[string]interface{} // additional members of the problem details, e.g. the current status of an order
+	Err        error                  // underlying error, never shown to clients
+}
+
+// New returns an error of the given kind, code and message.
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// BadRequest returns an error for a malformed request.
+func BadRequest(code, message string) *Error {
+	return New(KindBadRequest, code, message)
+}
+
+// Forbidden returns an error for a request the caller is not allowed to make.
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// NotFound returns an error for an entity that does not exist.
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Conflict returns an error for a request conflicting with the current state
+// of the entities, such as a missing stock or a duplicated key.
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// PreconditionFailed returns an error for a request based on a version of an
+// entity that is no longer its current one.
+func PreconditionFailed(code, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+// PreconditionRequired returns an error for a request that must be based on a
+// version of an entity but does not say which.
+func PreconditionRequired(code, message string) *Error {
+	return New(KindPreconditionRequired, code, message)
+}
+
+// UnsupportedMediaType returns an error for a body of a media type that is not
+// accepted.
+func UnsupportedMediaType(code, message string) *Error {
+	return New(KindUnsupportedMediaType, code, message)
+}
+
+// Invalid returns a validation error that is not tied to a single field of
+// the request, such as a reference to a missing entity.
+func Invalid(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+// Validation returns the validation error of a request with the given invalid
+// fields.
+func Validation(fields []FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: "validation_failed", Message: "the request has invalid fields", Fields: fields}
+}
+
+// Internal returns the error reported for an unexpected failure. The cause is
+// kept for the logs, but never shown to clients.
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: "internal", Message: "an unexpected error occurred", Err: err}
+}
+
+// Error returns the message of the error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying error, so errors.Is matches it too, e.g.
+// gorm.ErrRecordNotFound for a translated not found error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error of the same kind and code, so a
+// sentinel error also matches its copies made by Wrap or With.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by err.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// With returns a copy of the error with an additional member of its problem
+// details.
+func (e *Error) With(key string, value interface{}) *Error {
+	with := *e
+	with.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		with.Extensions[k] = v
+	}
+	with.Extensions[key] = value
+	return &with
+}
+
+// From returns the *Error reported for err. An error wrapping an *Error, e.g.
+// with fmt.Errorf, gives a copy of it whose message is the one of err, so the
+// context added by the wrapping is kept. Any other error is an internal one.
+func From(err error) *Error {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return Internal(err)
+	}
+	if appErr == err || appErr.Kind == KindInternal {
+		return appErr
+	}
+	wrapped := *appErr
+	wrapped.Message = err.Error()
+	return &wrapped
+}
@@ -0,0 +1,65 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemMediaType is the media type of the problem details of RFC 7807.
+const ProblemMediaType = "application/problem+json"
+
+// Problem is the RFC 7807 problem details document of an error, as written in
+// the body of every error response, e.g.
+//
+//	{"type": "about:blank", "title": "Conflict", "status": 409, "detail": "insufficient stock",
+//	 "instance": "/orders", "code": "insufficient_stock", "request_id": "3f9c..."}
+type Problem struct {
+	Type       string                 `json:"type"`                 // URI of the type of problem, about:blank as the code identifies it
+	Title      string                 `json:"title"`                // summary of the type of problem, the HTTP status text
+	Status     int                    `json:"status"`               // HTTP status of the response
+	Detail     string                 `json:"detail"`               // message of the error
+	Instance   string                 `json:"instance,omitempty"`   // path of the request
+	Code       string                 `json:"code"`                 // stable code of the error
+	RequestID  string                 `json:"request_id,omitempty"` // id of the request, as sent in the X-Request-ID header
+	Errors     []FieldError           `json:"errors,omitempty"`     // invalid fields of a validation error
+	Extensions map[string]interface{} `json:"-"`                    // additional members of the error
+}
+
+// Problem returns the problem details of the error, for the request with the
+// given path and id.
+func (e *Error) Problem(instance, requestID string) *Problem {
+	status := e.Kind.Status()
+	return &Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     e.Message,
+		Instance:   instance,
+		Code:       e.Code,
+		RequestID:  requestID,
+		Errors:     e.Fields,
+		Extensions: e.Extensions,
+	}
+}
+
+// MarshalJSON encodes the problem with its extensions as top-level members,
+// which never replace the standard ones.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	if len(p.Extensions) == 0 {
+		return json.Marshal((*problem)(p))
+	}
+
+	standard, err := json.Marshal((*problem)(p))
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]interface{}, len(p.Extensions)+9)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	if err := json.Unmarshal(standard, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
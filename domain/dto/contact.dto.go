@@ -0,0 +1,94 @@
+package dto
+
+import "store/domain/entities"
+
+// ContactRequest is the request body updating a contact, also used to create
+// the contacts of a customer or a supplier along with it. The owner of a
+// contact never changes once it is created.
+//
+// The street (area), district, city, state and country of a Brazilian address
+// may be left out, to be filled from its postal code, and are only required
+// once the address is completed by services.AddressService.
+type ContactRequest struct {
+	Phone          string               `json:"phone" binding:"required,max=30"`                                        // phone number of the contact, normalized to E.164 for its country
+	SecondaryPhone string               `json:"secondary_phone" binding:"omitempty,max=30"`                             // secondary phone number of the contact, normalized to E.164 for its country
+	PostalCode     string               `json:"postal_code" binding:"required,max=20"`                                  // postal code of the contact for address
+	Area           string               `json:"area" binding:"omitempty,max=200"`                                       // area of the contact for address
+	District       string               `json:"district" binding:"omitempty,max=100"`                                   // district of the contact for address
+	AddressNumber  string               `json:"address_number" binding:"required,max=20"`                               // address number of the contact for address
+	City           string               `json:"city" binding:"omitempty,max=100"`                                       // city of the contact for address
+	State          string               `json:"state" binding:"omitempty,max=100"`                                      // state of the contact for address
+	Country        string               `json:"country" binding:"omitempty,max=100"`                                    // country of the contact for address
+	Email          string               `json:"email" binding:"omitempty,email,max=254"`                                // email address of the contact
+	Type           entities.ContactType `json:"type" binding:"omitempty,oneof=billing shipping sales accounts_payable"` // purpose of the contact, billing by default
+	IsDefault      bool                 `json:"is_default"`                                                             // whether the contact becomes the default of its type
+}
+
+// UpdateContactRequest is the request body of a full update of a contact.
+type UpdateContactRequest = ContactRequest
+
+// CreateContactRequest is the request body creating a contact of either a
+// customer or a supplier. Its address is completed from its postal code as the
+// one of a ContactRequest.
+type CreateContactRequest struct {
+	CustomerID     uint                 `json:"customer_id" binding:"required_without=SupplierID,excluded_with=SupplierID"` // customer owning the contact
+	SupplierID     uint                 `json:"supplier_id" binding:"required_without=CustomerID"`                          // supplier owning the contact
+	Phone          string               `json:"phone" binding:"required,max=30"`                                            // phone number of the contact, normalized to E.164 for its country
+	SecondaryPhone string               `json:"secondary_phone" binding:"omitempty,max=30"`                                 // secondary phone number of the contact, normalized to E.164 for its country
+	PostalCode     string               `json:"postal_code" binding:"required,max=20"`                                      // postal code of the contact for address
+	Area           string               `json:"area" binding:"omitempty,max=200"`                                           // area of the contact for address
+	District       string               `json:"district" binding:"omitempty,max=100"`                                       // district of the contact for address
+	AddressNumber  string               `json:"address_number" binding:"required,max=20"`                                   // address number of the contact for address
+	City           string               `json:"city" binding:"omitempty,max=100"`                                           // city of the contact for address
+	State          string               `json:"state" binding:"omitempty,max=100"`                                          // state of the contact for address
+	Country        string               `json:"country" binding:"omitempty,max=100"`                                        // country of the contact for address
+	Email          string               `json:"email" binding:"omitempty,email,max=254"`                                    // email address of the contact
+	Type           entities.ContactType `json:"type" binding:"omitempty,oneof=billing shipping sales accounts_payable"`     // purpose of the contact, billing by default
+	IsDefault      bool                 `json:"is_default"`                                                                 // whether the contact becomes the default of its type
+}
+
+// ToEntity returns the contact created by the request.
+func (r *CreateContactRequest) ToEntity() *entities.Contact {
+	return &entities.Contact{
+		CustomerID:     r.CustomerID,
+		SupplierID:     r.SupplierID,
+		Phone:          r.Phone,
+		SecondaryPhone: r.SecondaryPhone,
+		PostalCode:     r.PostalCode,
+		Area:           r.Area,
+		District:       r.District,
+		AddressNumber:  r.AddressNumber,
+		City:           r.City,
+		State:          r.State,
+		Country:        r.Country,
+		Email:          r.Email,
+		Type:           r.Type,
+		IsDefault:      r.IsDefault,
+	}
+}
+
+// ToEntity returns the contact created by the request, without owner, which
+// is set when it is created along with its customer or supplier.
+func (r *ContactRequest) ToEntity() *entities.Contact {
+	contact := &entities.Contact{}
+	r.ApplyTo(contact)
+	return contact
+}
+
+// ApplyTo sets the fields of the request on the contact and returns their
+// names, to be saved by the update of the contact.
+func (r *ContactRequest) ApplyTo(contact *entities.Contact) []string {
+	contact.Phone = r.Phone
+	contact.SecondaryPhone = r.SecondaryPhone
+	contact.PostalCode = r.PostalCode
+	contact.Area = r.Area
+	contact.District = r.District
+	contact.AddressNumber = r.AddressNumber
+	contact.City = r.City
+	contact.State = r.State
+	contact.Country = r.Country
+	contact.Email = r.Email
+	contact.Type = r.Type
+	contact.IsDefault = r.IsDefault
+	return []string{"Phone", "SecondaryPhone", "PostalCode", "Area", "District", "AddressNumber", "City", "State", "Country", "Email", "Type", "IsDefault"}
+}
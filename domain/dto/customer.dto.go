@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/taxid"
+	"time"
+)
+
+// CreateCustomerRequest is the request body creating a customer, optionally
+// along with its contacts.
+type CreateCustomerRequest struct {
+	FirstName       string           `json:"first_name" binding:"required,max=100"`         // first name of customer
+	LastName        string           `json:"last_name" binding:"required,max=100"`          // last name of customer
+	Birthday        time.Time        `json:"birthday" binding:"required,past"`              // birthday of customer
+	TaxID           string           `json:"tax_id" binding:"required,taxid"`               // CPF or CNPJ of customer, masked or not
+	BillingCurrency string           `json:"billing_currency" binding:"omitempty,currency"` // currency the customer is billed in, BRL by default
+	Contacts        []ContactRequest `json:"contacts" binding:"omitempty,dive"`             // contacts of customer, the first of each type being its default unless another is
+}
+
+// ToEntity returns the customer created by the request.
+func (r *CreateCustomerRequest) ToEntity() *entities.Customer {
+	customer := &entities.Customer{
+		FirstName:       r.FirstName,
+		LastName:        r.LastName,
+		Birthday:        r.Birthday,
+		TaxID:           taxid.Normalize(r.TaxID),
+		BillingCurrency: r.BillingCurrency,
+	}
+	for i := range r.Contacts {
+		customer.Contacts = append(customer.Contacts, *r.Contacts[i].ToEntity())
+	}
+	return customer
+}
+
+// UpdateCustomerRequest is the request body of a full update of a customer.
+// Its orders and contacts are changed through their own endpoints.
+type UpdateCustomerRequest struct {
+	FirstName       string    `json:"first_name" binding:"required,max=100"`        // first name of customer
+	LastName        string    `json:"last_name" binding:"required,max=100"`         // last name of customer
+	Birthday        time.Time `json:"birthday" binding:"required,past"`             // birthday of customer
+	TaxID           string    `json:"tax_id" binding:"required,taxid"`              // CPF or CNPJ of customer, masked or not
+	BillingCurrency string    `json:"billing_currency" binding:"required,currency"` // currency the customer is billed in
+}
+
+// ApplyTo sets the fields of the request on the customer and returns their
+// names, to be saved by the update of the customer.
+func (r *UpdateCustomerRequest) ApplyTo(customer *entities.Customer) []string {
+	customer.FirstName = r.FirstName
+	customer.LastName = r.LastName
+	customer.Birthday = r.Birthday
+	customer.TaxID = taxid.Normalize(r.TaxID)
+	customer.BillingCurrency = r.BillingCurrency
+	return []string{"FirstName", "LastName", "Birthday", "TaxID", "BillingCurrency"}
+}
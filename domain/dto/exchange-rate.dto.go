@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/money"
+	"time"
+)
+
+// CreateExchangeRateRequest is the request body creating an exchange rate,
+// also used for each rate of an import.
+type CreateExchangeRateRequest struct {
+	BaseCurrency  string     `json:"base_currency" binding:"required,currency"`                       // currency being converted
+	QuoteCurrency string     `json:"quote_currency" binding:"required,currency,nefield=BaseCurrency"` // currency converted into
+	Rate          money.Rate `json:"rate" binding:"gt=0"`                                             // units of the quote currency worth one unit of the base currency
+	EffectiveDate time.Time  `json:"effective_date" binding:"required"`                               // date from which the rate is effective
+}
+
+// UpdateExchangeRateRequest is the request body of a full update of an
+// exchange rate.
+type UpdateExchangeRateRequest = CreateExchangeRateRequest
+
+// ToEntity returns the exchange rate created by the request.
+func (r *CreateExchangeRateRequest) ToEntity() *entities.ExchangeRate {
+	exchangeRate := &entities.ExchangeRate{}
+	r.ApplyTo(exchangeRate)
+	return exchangeRate
+}
+
+// ApplyTo sets the fields of the request on the exchange rate and returns
+// their names, to be saved by the update of the exchange rate.
+func (r *CreateExchangeRateRequest) ApplyTo(exchangeRate *entities.ExchangeRate) []string {
+	exchangeRate.BaseCurrency = r.BaseCurrency
+	exchangeRate.QuoteCurrency = r.QuoteCurrency
+	exchangeRate.Rate = r.Rate
+	exchangeRate.EffectiveDate = r.EffectiveDate
+	return []string{"BaseCurrency", "QuoteCurrency", "Rate", "EffectiveDate"}
+}
@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/money"
+)
+
+// OrderLineRequest is the request body of a line of an order, used to add it
+// to the order given by the URL or to the order being placed, and to update
+// it. A zero value takes the value of the product supplier.
+type OrderLineRequest struct {
+	ProductSupplierID  uint             `json:"product_supplier_id" binding:"required"`   // product of a supplier sold by the line
+	Quantity           int              `json:"quantity" binding:"gte=1"`                 // quantity of the product of a supplier for this specific order
+	Value              money.Money      `json:"value" binding:"money"`                    // unit value of the product of a supplier for this specific order
+	DiscountPercentage money.Percentage `json:"discount_percentage" binding:"percentage"` // percentage discount of the line, applied to its gross value
+	Discount           money.Money      `json:"discount" binding:"money"`                 // absolute discount of the line, applied after the percentage
+}
+
+// UpdateOrderProductSupplierRequest is the request body of a full update of a
+// line of an order. The order of a line never changes.
+type UpdateOrderProductSupplierRequest = OrderLineRequest
+
+// ToEntity returns the line created by the request, without order, which is
+// set from the URL or when it is placed along with its order.
+func (r *OrderLineRequest) ToEntity() *entities.OrderProductSupplier {
+	line := &entities.OrderProductSupplier{}
+	r.ApplyTo(line)
+	return line
+}
+
+// ApplyTo sets the fields of the request on the line and returns their names,
+// to be saved by the update of the line.
+func (r *OrderLineRequest) ApplyTo(line *entities.OrderProductSupplier) []string {
+	line.ProductSupplierID = r.ProductSupplierID
+	line.Quantity = r.Quantity
+	line.Value = r.Value
+	line.DiscountPercentage = r.DiscountPercentage
+	line.Discount = r.Discount
+	return []string{"ProductSupplierID", "Quantity", "Value", "DiscountPercentage", "Discount"}
+}
+
+// CreateOrderProductSupplierRequest is the request body creating a line of
+// the order it names.
+type CreateOrderProductSupplierRequest struct {
+	OrderID            uint             `json:"order_id" binding:"required"`              // order of the line
+	ProductSupplierID  uint             `json:"product_supplier_id" binding:"required"`   // product of a supplier sold by the line
+	Quantity           int              `json:"quantity" binding:"gte=1"`                 // quantity of the product of a supplier for this specific order
+	Value              money.Money      `json:"value" binding:"money"`                    // unit value of the product of a supplier for this specific order
+	DiscountPercentage money.Percentage `json:"discount_percentage" binding:"percentage"` // percentage discount of the line, applied to its gross value
+	Discount           money.Money      `json:"discount" binding:"money"`                 // absolute discount of the line, applied after the percentage
+}
+
+// ToEntity returns the line created by the request.
+func (r *CreateOrderProductSupplierRequest) ToEntity() *entities.OrderProductSupplier {
+	return &entities.OrderProductSupplier{
+		OrderID:            r.OrderID,
+		ProductSupplierID:  r.ProductSupplierID,
+		Quantity:           r.Quantity,
+		Value:              r.Value,
+		DiscountPercentage: r.DiscountPercentage,
+		Discount:           r.Discount,
+	}
+}
+
+// BestOfferLineRequest is the request body adding a line for a product to the
+// order given by the URL, letting the server choose the offers of its
+// suppliers the quantity is taken from.
+type BestOfferLineRequest struct {
+	ProductID           uint   `json:"product_id" binding:"required"`                                      // product ordered
+	Quantity            int    `json:"quantity" binding:"gte=1"`                                           // quantity ordered
+	Strategy            string `json:"strategy" binding:"omitempty,oneof=cheapest margin stock preferred"` // how the offers are ranked, the default strategy of the server when empty
+	PreferredSupplierID uint   `json:"preferred_supplier_id" binding:"required_if=Strategy preferred"`     // supplier whose offers come first with the preferred strategy
+	Split               bool   `json:"split"`                                                              // whether the quantity may be split across several offers
+}
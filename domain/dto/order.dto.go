@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/money"
+	"time"
+)
+
+// CreateOrderRequest is the request body placing an order with its lines. The
+// order number and the currency of the order are set by the server.
+type CreateOrderRequest struct {
+	CustomerID         uint                 `json:"customer_id" binding:"required"`                      // customer placing the order
+	OrderDate          time.Time            `json:"order_date"`                                          // order date for the order, now by default
+	DeliveryDate       time.Time            `json:"delivery_date" binding:"required,gtefield=OrderDate"` // delivery date for the order
+	DeliveryOrder      bool                 `json:"delivery_order"`                                      // delivery order for the order
+	DiscountPercentage money.Percentage     `json:"discount_percentage" binding:"percentage"`            // percentage discount for the order, applied to the subtotal
+	Discount           money.Money          `json:"discount" binding:"money"`                            // absolute discount for the order, applied after the percentage
+	Status             entities.OrderStatus `json:"status" binding:"omitempty,oneof=draft placed"`       // initial status of the order, placed by default
+	ShippingContactID  uint                 `json:"shipping_contact_id"`                                 // contact of the customer the order is delivered to, its default shipping contact by default
+	BillingContactID   uint                 `json:"billing_contact_id"`                                  // contact of the customer the order is billed to, its default billing contact by default
+	WarehouseID        *uint                `json:"warehouse_id"`                                        // warehouse the order is fulfilled from, the nearest one with stock by default
+	OrderProducts      []OrderLineRequest   `json:"order_products" binding:"required,min=1,dive"`        // lines of the order
+}
+
+// ToEntity returns the order created by the request.
+func (r *CreateOrderRequest) ToEntity() *entities.Order {
+	order := &entities.Order{
+		CustomerID:         r.CustomerID,
+		OrderDate:          r.OrderDate,
+		DeliveryDate:       r.DeliveryDate,
+		DeliveryOrder:      r.DeliveryOrder,
+		DiscountPercentage: r.DiscountPercentage,
+		Discount:           r.Discount,
+		Status:             r.Status,
+		ShippingContactID:  r.ShippingContactID,
+		BillingContactID:   r.BillingContactID,
+		WarehouseID:        r.WarehouseID,
+	}
+	for i := range r.OrderProducts {
+		order.OrderProducts = append(order.OrderProducts, *r.OrderProducts[i].ToEntity())
+	}
+	return order
+}
+
+// UpdateOrderRequest is the request body of a full update of an order. The
+// customer, status and lines of an order are changed through their own
+// endpoints, and its shipping and billing contacts are chosen when it is
+// created. Its order date is also fixed once it is created, since its order
+// number and the exchange rates of its totals depend on it.
+type UpdateOrderRequest struct {
+	DeliveryDate       time.Time        `json:"delivery_date" binding:"required"`         // delivery date for the order, not before its order date
+	DeliveryOrder      bool             `json:"delivery_order"`                           // delivery order for the order
+	DiscountPercentage money.Percentage `json:"discount_percentage" binding:"percentage"` // percentage discount for the order, applied to the subtotal
+	Discount           money.Money      `json:"discount" binding:"money"`                 // absolute discount for the order, applied after the percentage
+}
+
+// ApplyTo sets the fields of the request on the order and returns their names,
+// to be saved by the update of the order.
+func (r *UpdateOrderRequest) ApplyTo(order *entities.Order) []string {
+	order.DeliveryDate = r.DeliveryDate
+	order.DeliveryOrder = r.DeliveryOrder
+	order.DiscountPercentage = r.DiscountPercentage
+	order.Discount = r.Discount
+	return []string{"DeliveryDate", "DeliveryOrder", "DiscountPercentage", "Discount"}
+}
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/money"
+)
+
+// CreateProductSupplierRequest is the request body creating a product
+// supplier, the offer of a product by a supplier. Its sales are counted by the
+// server.
+type CreateProductSupplierRequest struct {
+	ProductID           uint        `json:"product_id" binding:"required"`                     // product offered
+	SupplierID          uint        `json:"supplier_id" binding:"required"`                    // supplier offering the product
+	Cost                money.Money `json:"cost" binding:"money"`                              // cost paid to the supplier
+	Value               money.Money `json:"value" binding:"money"`                             // value the product is sold for
+	Quantity            int         `json:"quantity" binding:"gte=0"`                          // quantity in stock
+	ReorderPoint        int         `json:"reorder_point" binding:"gte=0"`                     // available quantity at or below which the product supplier is replenished, derived from its sales when zero
+	SafetyStock         int         `json:"safety_stock" binding:"gte=0"`                      // quantity kept on top of the sales expected during the lead time
+	ReorderQuantity     int         `json:"reorder_quantity" binding:"gte=0"`                  // quantity purchased at once
+	SupplierProductCode string      `json:"supplier_product_code" binding:"omitempty,max=50"`  // code of the product for the supplier
+	SupplierProductName string      `json:"supplier_product_name" binding:"omitempty,max=200"` // name of the product for the supplier
+}
+
+// ToEntity returns the product supplier created by the request.
+func (r *CreateProductSupplierRequest) ToEntity() *entities.ProductSupplier {
+	return &entities.ProductSupplier{
+		ProductID:           r.ProductID,
+		SupplierID:          r.SupplierID,
+		Cost:                r.Cost,
+		Value:               r.Value,
+		Quantity:            r.Quantity,
+		ReorderPoint:        r.ReorderPoint,
+		SafetyStock:         r.SafetyStock,
+		ReorderQuantity:     r.ReorderQuantity,
+		SupplierProductCode: r.SupplierProductCode,
+		SupplierProductName: r.SupplierProductName,
+	}
+}
+
+// CreateSupplierOfferRequest is the request body creating an offer of the
+// supplier given by the URL.
+type CreateSupplierOfferRequest struct {
+	ProductID           uint        `json:"product_id" binding:"required"`                     // product offered
+	Cost                money.Money `json:"cost" binding:"money"`                              // cost paid to the supplier
+	Value               money.Money `json:"value" binding:"money"`                             // value the product is sold for
+	Quantity            int         `json:"quantity" binding:"gte=0"`                          // quantity in stock
+	ReorderPoint        int         `json:"reorder_point" binding:"gte=0"`                     // available quantity at or below which the product supplier is replenished, derived from its sales when zero
+	SafetyStock         int         `json:"safety_stock" binding:"gte=0"`                      // quantity kept on top of the sales expected during the lead time
+	ReorderQuantity     int         `json:"reorder_quantity" binding:"gte=0"`                  // quantity purchased at once
+	SupplierProductCode string      `json:"supplier_product_code" binding:"omitempty,max=50"`  // code of the product for the supplier
+	SupplierProductName string      `json:"supplier_product_name" binding:"omitempty,max=200"` // name of the product for the supplier
+}
+
+// ToEntity returns the offer of the given supplier created by the request.
+func (r *CreateSupplierOfferRequest) ToEntity(supplierID uint) *entities.ProductSupplier {
+	return &entities.ProductSupplier{
+		ProductID:           r.ProductID,
+		SupplierID:          supplierID,
+		Cost:                r.Cost,
+		Value:               r.Value,
+		Quantity:            r.Quantity,
+		ReorderPoint:        r.ReorderPoint,
+		SafetyStock:         r.SafetyStock,
+		ReorderQuantity:     r.ReorderQuantity,
+		SupplierProductCode: r.SupplierProductCode,
+		SupplierProductName: r.SupplierProductName,
+	}
+}
+
+// UpdateProductSupplierRequest is the request body of a full update of a
+// product supplier. The product and the supplier of an offer never change.
+type UpdateProductSupplierRequest struct {
+	Cost                money.Money `json:"cost" binding:"money"`                              // cost paid to the supplier
+	Value               money.Money `json:"value" binding:"money"`                             // value the product is sold for
+	Quantity            int         `json:"quantity" binding:"gte=0"`                          // quantity in stock
+	ReorderPoint        int         `json:"reorder_point" binding:"gte=0"`                     // available quantity at or below which the product supplier is replenished, derived from its sales when zero
+	SafetyStock         int         `json:"safety_stock" binding:"gte=0"`                      // quantity kept on top of the sales expected during the lead time
+	ReorderQuantity     int         `json:"reorder_quantity" binding:"gte=0"`                  // quantity purchased at once
+	SupplierProductCode string      `json:"supplier_product_code" binding:"omitempty,max=50"`  // code of the product for the supplier
+	SupplierProductName string      `json:"supplier_product_name" binding:"omitempty,max=200"` // name of the product for the supplier
+}
+
+// ApplyTo sets the fields of the request on the product supplier and returns
+// their names, to be saved by the update of the product supplier.
+func (r *UpdateProductSupplierRequest) ApplyTo(productSupplier *entities.ProductSupplier) []string {
+	productSupplier.Cost = r.Cost
+	productSupplier.Value = r.Value
+	productSupplier.Quantity = r.Quantity
+	productSupplier.ReorderPoint = r.ReorderPoint
+	productSupplier.SafetyStock = r.SafetyStock
+	productSupplier.ReorderQuantity = r.ReorderQuantity
+	productSupplier.SupplierProductCode = r.SupplierProductCode
+	productSupplier.SupplierProductName = r.SupplierProductName
+	return []string{"Cost", "Value", "Quantity", "ReorderPoint", "SafetyStock", "ReorderQuantity", "SupplierProductCode", "SupplierProductName"}
+}
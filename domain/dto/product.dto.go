@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/money"
+)
+
+// CreateProductRequest is the request body creating a product. Its sales are
+// counted by the server.
+type CreateProductRequest struct {
+	Name        string      `json:"name" binding:"required,max=200"` // general name of the product
+	Code        string      `json:"code" binding:"required,max=50"`  // general code of the product
+	MarketValue money.Money `json:"market_value" binding:"money"`    // default market value of product for current market (EMC)
+}
+
+// ToEntity returns the product created by the request.
+func (r *CreateProductRequest) ToEntity() *entities.Product {
+	return &entities.Product{
+		Name:        r.Name,
+		Code:        r.Code,
+		MarketValue: r.MarketValue,
+	}
+}
+
+// UpdateProductRequest is the request body of a full update of a product.
+// Its suppliers are changed through the product supplier endpoints.
+type UpdateProductRequest struct {
+	Name        string      `json:"name" binding:"required,max=200"` // general name of the product
+	Code        string      `json:"code" binding:"required,max=50"`  // general code of the product
+	MarketValue money.Money `json:"market_value" binding:"money"`    // default market value of product for current market (EMC)
+}
+
+// ApplyTo sets the fields of the request on the product and returns their
+// names, to be saved by the update of the product.
+func (r *UpdateProductRequest) ApplyTo(product *entities.Product) []string {
+	product.Name = r.Name
+	product.Code = r.Code
+	product.MarketValue = r.MarketValue
+	return []string{"Name", "Code", "MarketValue"}
+}
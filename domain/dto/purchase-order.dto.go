@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/money"
+	"time"
+)
+
+// PurchaseOrderLineRequest is the request body of a line of a purchase order.
+// A zero cost takes the cost of the product supplier.
+type PurchaseOrderLineRequest struct {
+	ProductSupplierID uint        `json:"product_supplier_id" binding:"required"` // product supplier ordered, offered by the supplier of the purchase order
+	Quantity          int         `json:"quantity" binding:"gte=1"`               // quantity ordered
+	Cost              money.Money `json:"cost" binding:"money"`                   // unit cost agreed with the supplier
+}
+
+// ToEntity returns the line created by the request, without purchase order,
+// which is set when it is saved along with its purchase order.
+func (r *PurchaseOrderLineRequest) ToEntity() entities.PurchaseOrderLine {
+	return entities.PurchaseOrderLine{
+		ProductSupplierID: r.ProductSupplierID,
+		Quantity:          r.Quantity,
+		Cost:              r.Cost,
+	}
+}
+
+// CreatePurchaseOrderRequest is the request body creating a draft purchase
+// order with its lines.
+type CreatePurchaseOrderRequest struct {
+	SupplierID   uint                       `json:"supplier_id" binding:"required"`                       // supplier the purchase order is placed with
+	OrderDate    time.Time                  `json:"order_date"`                                           // date the purchase order is issued, now by default
+	ExpectedDate *time.Time                 `json:"expected_date" binding:"omitempty,gtefield=OrderDate"` // date the goods are expected
+	Note         string                     `json:"note" binding:"omitempty,max=500"`                     // optional note for the supplier
+	Lines        []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`                  // lines of the purchase order
+}
+
+// ToEntity returns the purchase order created by the request.
+func (r *CreatePurchaseOrderRequest) ToEntity() *entities.PurchaseOrder {
+	purchaseOrder := &entities.PurchaseOrder{
+		SupplierID:   r.SupplierID,
+		OrderDate:    r.OrderDate,
+		ExpectedDate: r.ExpectedDate,
+		Note:         r.Note,
+	}
+	for i := range r.Lines {
+		purchaseOrder.Lines = append(purchaseOrder.Lines, r.Lines[i].ToEntity())
+	}
+	return purchaseOrder
+}
+
+// UpdatePurchaseOrderRequest is the request body of a full update of a draft
+// purchase order, replacing its lines. The supplier of a purchase order never
+// changes, and its status is changed through its transitions and receipts.
+type UpdatePurchaseOrderRequest struct {
+	OrderDate    time.Time                  `json:"order_date" binding:"required"`                        // date the purchase order is issued
+	ExpectedDate *time.Time                 `json:"expected_date" binding:"omitempty,gtefield=OrderDate"` // date the goods are expected
+	Note         string                     `json:"note" binding:"omitempty,max=500"`                     // optional note for the supplier
+	Lines        []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`                  // lines of the purchase order
+}
+
+// ApplyTo sets the fields and the lines of the request on the purchase order.
+func (r *UpdatePurchaseOrderRequest) ApplyTo(purchaseOrder *entities.PurchaseOrder) {
+	purchaseOrder.OrderDate = r.OrderDate
+	purchaseOrder.ExpectedDate = r.ExpectedDate
+	purchaseOrder.Note = r.Note
+	purchaseOrder.Lines = nil
+	for i := range r.Lines {
+		purchaseOrder.Lines = append(purchaseOrder.Lines, r.Lines[i].ToEntity())
+	}
+}
+
+// GoodsReceiptLineRequest is the request body of the quantity received for a
+// line of the purchase order.
+type GoodsReceiptLineRequest struct {
+	PurchaseOrderLineID uint       `json:"purchase_order_line_id" binding:"required"`           // line of the purchase order received
+	Quantity            int        `json:"quantity" binding:"gte=1"`                            // quantity received, which may exceed the quantity outstanding
+	LotNumber           string     `json:"lot_number" binding:"required_with=ExpiresAt,max=50"` // batch number of the units received
+	ExpiresAt           *time.Time `json:"expires_at"`                                          // expiry date of the units received
+}
+
+// CreateGoodsReceiptRequest is the request body receiving goods for the
+// purchase order given by the URL.
+type CreateGoodsReceiptRequest struct {
+	WarehouseID *uint                     `json:"warehouse_id"`                            // warehouse the goods were received in, the default warehouse by default
+	ReceivedAt  time.Time                 `json:"received_at"`                             // moment the goods were received, now by default
+	ReceivedBy  string                    `json:"received_by" binding:"omitempty,max=100"` // user who received the goods
+	Note        string                    `json:"note" binding:"omitempty,max=500"`        // optional note about the delivery
+	Lines       []GoodsReceiptLineRequest `json:"lines" binding:"required,min=1,dive"`     // quantities received
+}
+
+// ToEntity returns the goods receipt created by the request.
+func (r *CreateGoodsReceiptRequest) ToEntity() *entities.GoodsReceipt {
+	receipt := &entities.GoodsReceipt{
+		WarehouseID: r.WarehouseID,
+		ReceivedAt:  r.ReceivedAt,
+		ReceivedBy:  r.ReceivedBy,
+		Note:        r.Note,
+	}
+	for _, line := range r.Lines {
+		receipt.Lines = append(receipt.Lines, entities.GoodsReceiptLine{
+			PurchaseOrderLineID: line.PurchaseOrderLineID,
+			Quantity:            line.Quantity,
+			LotNumber:           line.LotNumber,
+			ExpiresAt:           line.ExpiresAt,
+		})
+	}
+	return receipt
+}
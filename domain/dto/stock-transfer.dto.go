@@ -0,0 +1,37 @@
+package dto
+
+import "store/domain/entities"
+
+// StockTransferLineRequest is the request body of the units of a product
+// supplier moved by a stock transfer.
+type StockTransferLineRequest struct {
+	ProductSupplierID uint `json:"product_supplier_id" binding:"required"` // product supplier moved
+	Quantity          int  `json:"quantity" binding:"gte=1"`               // units moved
+}
+
+// CreateStockTransferRequest is the request body shipping units of product
+// suppliers from a warehouse to another.
+type CreateStockTransferRequest struct {
+	FromWarehouseID uint                       `json:"from_warehouse_id" binding:"required"`                       // warehouse the units leave
+	ToWarehouseID   uint                       `json:"to_warehouse_id" binding:"required,nefield=FromWarehouseID"` // warehouse the units go to
+	Note            string                     `json:"note" binding:"omitempty,max=500"`                           // optional note about the transfer
+	CreatedBy       string                     `json:"created_by" binding:"omitempty,max=100"`                     // user who ships the transfer
+	Lines           []StockTransferLineRequest `json:"lines" binding:"required,min=1,dive"`                        // units moved
+}
+
+// ToEntity returns the stock transfer shipped by the request.
+func (r *CreateStockTransferRequest) ToEntity() *entities.StockTransfer {
+	transfer := &entities.StockTransfer{
+		FromWarehouseID: r.FromWarehouseID,
+		ToWarehouseID:   r.ToWarehouseID,
+		Note:            r.Note,
+		CreatedBy:       r.CreatedBy,
+	}
+	for _, line := range r.Lines {
+		transfer.Lines = append(transfer.Lines, entities.StockTransferLine{
+			ProductSupplierID: line.ProductSupplierID,
+			Quantity:          line.Quantity,
+		})
+	}
+	return transfer
+}
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"time"
+)
+
+// CreateStockMovementRequest is the request body recording by hand a movement
+// of the stock of the product supplier given by the URL. Sales and returns are
+// only recorded by the orders, and the balance after the movement is computed
+// by the server.
+type CreateStockMovementRequest struct {
+	WarehouseID   *uint                      `json:"warehouse_id"`                                        // warehouse the stock moved in, the default warehouse by default
+	Type          entities.StockMovementType `json:"type" binding:"required,oneof=receipt adjustment"`    // receipt from the supplier or adjustment of the stock
+	Quantity      int                        `json:"quantity" binding:"required"`                         // units moved, positive into stock and negative out of it
+	Reason        string                     `json:"reason" binding:"required,max=200"`                   // why the stock moved
+	ReferenceType string                     `json:"reference_type" binding:"omitempty,max=50"`           // kind of the document that moved the stock, e.g. `invoice`
+	Reference     string                     `json:"reference" binding:"omitempty,max=100"`               // number of the document that moved the stock
+	CreatedBy     string                     `json:"created_by" binding:"omitempty,max=100"`              // user who moved the stock
+	LotNumber     string                     `json:"lot_number" binding:"required_with=ExpiresAt,max=50"` // lot the units moved in, chosen first expired, first out by default
+	ExpiresAt     *time.Time                 `json:"expires_at"`                                          // expiry date of the units received in a new lot
+}
+
+// ToEntity returns the movement of the stock of the given product supplier
+// recorded by the request.
+func (r *CreateStockMovementRequest) ToEntity(productSupplierID uint) *entities.StockMovement {
+	movement := &entities.StockMovement{
+		ProductSupplierID: productSupplierID,
+		WarehouseID:       r.WarehouseID,
+		Type:              r.Type,
+		Quantity:          r.Quantity,
+		Reason:            r.Reason,
+		ReferenceType:     r.ReferenceType,
+		Reference:         r.Reference,
+		CreatedBy:         r.CreatedBy,
+	}
+	if r.LotNumber != "" {
+		movement.Lots = []entities.StockMovementLot{{LotNumber: r.LotNumber, ExpiresAt: r.ExpiresAt}}
+	}
+	return movement
+}
@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/taxid"
+)
+
+// CreateSupplierRequest is the request body creating a supplier, optionally
+// along with its contacts. Its sales are counted by the server.
+type CreateSupplierRequest struct {
+	Name          string           `json:"name" binding:"required,max=200"`          // supplier name
+	TaxID         string           `json:"tax_id" binding:"required,taxid"`          // supplier CPF or CNPJ, masked or not
+	FantasyName   string           `json:"fantasy_name" binding:"omitempty,max=200"` // supplier fantasy name
+	QuantityStock int              `json:"quantity_stock" binding:"gte=0"`           // supplier quantity stock
+	Contacts      []ContactRequest `json:"contacts" binding:"omitempty,dive"`        // contacts of supplier, the first of each type being its default unless another is
+}
+
+// ToEntity returns the supplier created by the request.
+func (r *CreateSupplierRequest) ToEntity() *entities.Supplier {
+	supplier := &entities.Supplier{
+		Name:          r.Name,
+		TaxID:         taxid.Normalize(r.TaxID),
+		FantasyName:   r.FantasyName,
+		QuantityStock: r.QuantityStock,
+	}
+	for i := range r.Contacts {
+		supplier.Contacts = append(supplier.Contacts, *r.Contacts[i].ToEntity())
+	}
+	return supplier
+}
+
+// UpdateSupplierRequest is the request body of a full update of a supplier.
+// Its products and contacts are changed through their own endpoints.
+type UpdateSupplierRequest struct {
+	Name          string `json:"name" binding:"required,max=200"`          // supplier name
+	TaxID         string `json:"tax_id" binding:"required,taxid"`          // supplier CPF or CNPJ, masked or not
+	FantasyName   string `json:"fantasy_name" binding:"omitempty,max=200"` // supplier fantasy name
+	QuantityStock int    `json:"quantity_stock" binding:"gte=0"`           // supplier quantity stock
+}
+
+// ApplyTo sets the fields of the request on the supplier and returns their
+// names, to be saved by the update of the supplier.
+func (r *UpdateSupplierRequest) ApplyTo(supplier *entities.Supplier) []string {
+	supplier.Name = r.Name
+	supplier.TaxID = taxid.Normalize(r.TaxID)
+	supplier.FantasyName = r.FantasyName
+	supplier.QuantityStock = r.QuantityStock
+	return []string{"Name", "TaxID", "FantasyName", "QuantityStock"}
+}
@@ -0,0 +1,199 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"store/domain/apperrors"
+	"store/domain/money"
+	"store/domain/postalcode"
+	"store/domain/taxid"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// init registers the rules specific to the requests of the store on the
+// validator used by gin to bind requests, and makes it report fields by their
+// JSON names.
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	_ = validate.RegisterValidation("past", isPast)
+	_ = validate.RegisterValidation("currency", isCurrency)
+	_ = validate.RegisterValidation("money", isMoney)
+	_ = validate.RegisterValidation("percentage", isPercentage)
+	_ = validate.RegisterValidation("taxid", isTaxID)
+	_ = validate.RegisterValidation("postalcode", isPostalCode)
+}
+
+// isPast validates that a time is before the current time, e.g. a birthday.
+func isPast(fl validator.FieldLevel) bool {
+	t, ok := fl.Field().Interface().(time.Time)
+	return ok && t.Before(time.Now())
+}
+
+// isCurrency validates that a string is a three letter ISO 4217 currency code,
+// in any case, as accepted by money.ParseCurrency.
+func isCurrency(fl validator.FieldLevel) bool {
+	_, err := money.ParseCurrency(fl.Field().String())
+	return err == nil && fl.Field().Len() == 3
+}
+
+// isMoney validates that a money value is not negative and is in a valid
+// currency. A zero value without currency is accepted, as the currency then
+// defaults to money.DefaultCurrency.
+func isMoney(fl validator.FieldLevel) bool {
+	m, ok := fl.Field().Interface().(money.Money)
+	if !ok || m.IsNegative() {
+		return false
+	}
+	_, err := money.ParseCurrency(m.Currency)
+	return err == nil
+}
+
+// isPercentage validates that a percentage is between 0% and 100%.
+func isPercentage(fl validator.FieldLevel) bool {
+	p, ok := fl.Field().Interface().(money.Percentage)
+	return ok && p >= 0 && p <= money.OneHundredPercent
+}
+
+// isTaxID validates that a string is a valid CPF or CNPJ, masked or not, as
+// accepted by taxid.Parse.
+func isTaxID(fl validator.FieldLevel) bool {
+	_, err := taxid.Parse(fl.Field().String())
+	return err == nil
+}
+
+// isPostalCode validates that a string is a Brazilian postal code (CEP),
+// masked or not, as accepted by postalcode.Parse.
+func isPostalCode(fl validator.FieldLevel) bool {
+	_, err := postalcode.Parse(fl.Field().String())
+	return err == nil
+}
+
+// FieldErrors converts the error returned by the validation of a request into
+// the list of its invalid fields. It returns false if err is not a validation
+// error, such as a malformed JSON body.
+func FieldErrors(err error) ([]apperrors.FieldError, bool) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil, false
+	}
+
+	fields := make([]apperrors.FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields = append(fields, apperrors.FieldError{
+			Field:   fieldPath(fieldError),
+			Rule:    fieldError.Tag(),
+			Message: message(fieldError),
+		})
+	}
+	return fields, true
+}
+
+// fieldPath returns the path of the invalid field from the root of the
+// request, e.g. `order_products[0].quantity`.
+func fieldPath(fieldError validator.FieldError) string {
+	_, path, found := strings.Cut(fieldError.Namespace(), ".")
+	if !found {
+		return fieldError.Field()
+	}
+	return path
+}
+
+// message describes the rule broken by an invalid field.
+func message(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required when " + snakeCase(param) + " is not given"
+	case "required_with":
+		return "is required when " + snakeCase(param) + " is given"
+	case "excluded_with":
+		return "must not be given along with " + snakeCase(param)
+	case "min", "gte":
+		if unit := lengthUnit(fieldError.Kind()); unit != "" {
+			return "must have at least " + param + " " + unit
+		}
+		return "must be greater than or equal to " + param
+	case "max", "lte":
+		if unit := lengthUnit(fieldError.Kind()); unit != "" {
+			return "must have at most " + param + " " + unit
+		}
+		return "must be less than or equal to " + param
+	case "gt":
+		return "must be greater than " + param
+	case "gtefield":
+		return "must not be before " + snakeCase(param)
+	case "nefield":
+		return "must differ from " + snakeCase(param)
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(param, " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	case "currency":
+		return "must be a three letter ISO 4217 currency code"
+	case "past":
+		return "must be in the past"
+	case "money":
+		return "must be a non-negative amount in a valid ISO 4217 currency"
+	case "percentage":
+		return "must be a percentage between 0 and 100"
+	case "taxid":
+		return "must be a valid CPF or CNPJ"
+	case "postalcode":
+		return "must be a valid CEP"
+	default:
+		return fmt.Sprintf("breaks the %s rule", fieldError.Tag())
+	}
+}
+
+// lengthUnit returns what the length of a field of the given kind counts, or
+// an empty string if the rules on the field compare its value.
+func lengthUnit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	default:
+		return ""
+	}
+}
+
+// snakeCase converts the name of a struct field given as the parameter of a
+// cross-field rule, e.g. OrderDate or CustomerID, into its JSON name, e.g.
+// order_date or customer_id.
+func snakeCase(name string) string {
+	var b strings.Builder
+	lower := false
+	for _, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if lower {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+			lower = false
+		} else {
+			lower = true
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
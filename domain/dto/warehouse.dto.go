@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"store/domain/entities"
+	"store/domain/postalcode"
+)
+
+// CreateWarehouseRequest is the request body creating a warehouse. Its stock
+// is moved in by the stock movements, goods receipts and stock transfers.
+type CreateWarehouseRequest struct {
+	Code       string `json:"code" binding:"required,max=20"`             // short code of the warehouse, unique among the warehouses
+	Name       string `json:"name" binding:"required,max=200"`            // name of the warehouse
+	PostalCode string `json:"postal_code" binding:"omitempty,postalcode"` // postal code of the warehouse, masked or not
+	City       string `json:"city" binding:"omitempty,max=100"`           // city of the warehouse
+	State      string `json:"state" binding:"omitempty,max=100"`          // state of the warehouse
+	IsDefault  bool   `json:"is_default"`                                 // whether the warehouse replaces the default one
+}
+
+// ToEntity returns the warehouse created by the request.
+func (r *CreateWarehouseRequest) ToEntity() *entities.Warehouse {
+	cep, _ := postalcode.Parse(r.PostalCode)
+	return &entities.Warehouse{
+		Code:       r.Code,
+		Name:       r.Name,
+		PostalCode: cep,
+		City:       r.City,
+		State:      r.State,
+		IsDefault:  r.IsDefault,
+	}
+}
+
+// UpdateWarehouseRequest is the request body of a full update of a warehouse.
+// The default warehouse stays the default one until another warehouse is made
+// the default one.
+type UpdateWarehouseRequest struct {
+	Code       string `json:"code" binding:"required,max=20"`             // short code of the warehouse, unique among the warehouses
+	Name       string `json:"name" binding:"required,max=200"`            // name of the warehouse
+	PostalCode string `json:"postal_code" binding:"omitempty,postalcode"` // postal code of the warehouse, masked or not
+	City       string `json:"city" binding:"omitempty,max=100"`           // city of the warehouse
+	State      string `json:"state" binding:"omitempty,max=100"`          // state of the warehouse
+	IsDefault  bool   `json:"is_default"`                                 // whether the warehouse is the default one
+}
+
+// ApplyTo sets the fields of the request on the warehouse and returns their
+// names, to be saved by the update of the warehouse.
+func (r *UpdateWarehouseRequest) ApplyTo(warehouse *entities.Warehouse) []string {
+	warehouse.Code = r.Code
+	warehouse.Name = r.Name
+	warehouse.PostalCode, _ = postalcode.Parse(r.PostalCode)
+	warehouse.City = r.City
+	warehouse.State = r.State
+	warehouse.IsDefault = r.IsDefault
+	return []string{"Code", "Name", "PostalCode", "City", "State", "IsDefault"}
+}
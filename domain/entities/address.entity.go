@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"store/domain/postalcode"
+
+	"gorm.io/gorm"
+)
+
+// Address represents the address of a Brazilian postal code (CEP), loaded from
+// a local dataset by the import-postal-codes command and used to look up and
+// fill the addresses of the contacts.
+//
+// Table name: addresses
+type Address struct {
+	gorm.Model
+	ID         uint           `gorm:"primaryKey;autoIncrement" json:"id"`                                             // primary key
+	PostalCode postalcode.CEP `gorm:"type:char(8);not null;uniqueIndex:idx_addresses_postal_code" json:"postal_code"` // postal code, unique
+	Street     string         `json:"street"`                                                                         // street of the postal code, empty when it covers a whole city
+	District   string         `json:"district"`                                                                       // district of the postal code, empty when it covers a whole city
+	City       string         `gorm:"not null" json:"city"`                                                           // city of the postal code
+	State      string         `gorm:"type:char(2);not null" json:"state"`                                             // abbreviation (UF) of the state of the postal code
+}
+
+// TableName overrides the table name used by Address to `sales.addresses`.
+func (Address) TableName() string {
+	return "sales.addresses"
+}
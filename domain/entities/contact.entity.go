@@ -1,28 +1,80 @@
 package entities
 
-import "gorm.io/gorm"
+import (
+	"encoding/json"
+	"store/domain/phone"
+
+	"gorm.io/gorm"
+)
+
+// ContactType is the purpose of a contact of a customer or supplier.
+type ContactType string
+
+const (
+	ContactTypeBilling         ContactType = "billing"          // address invoices are sent to
+	ContactTypeShipping        ContactType = "shipping"         // address orders are delivered to
+	ContactTypeSales           ContactType = "sales"            // contact of the sales team
+	ContactTypeAccountsPayable ContactType = "accounts_payable" // contact paying the invoices
+)
 
 // Contact represents contact information for a customer or supplier.
 //
+// A customer or supplier may own several contacts of each type, one of which
+// is its default contact of that type.
+//
 // Table name: contacts
 type Contact struct {
 	gorm.Model
-	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"` // primary key
-	Phone          string `gorm:"not null" json:"phone"`              // phone number of the contact
-	SecondaryPhone string `json:"secondary_phone"`                    // secondary phone number of the contact
-	PostalCode     string `gorm:"not null" json:"postal_code"`        // postal code of the contact for address
-	Area           string `gorm:"not null" json:"area"`               // area of the contact for address
-	District       string `gorm:"not null" json:"district"`           // district of the contact for address
-	AddressNumber  string `gorm:"not null" json:"address_number"`     // address number of the contact for address
-	City           string `gorm:"not null" json:"city"`               // city of the contact for address
-	State          string `gorm:"not null" json:"state"`              // state of the contact for address
-	Country        string `gorm:"not null" json:"country"`            // country of the contact for address
-	Email          string `json:"email"`                              // email address of the contact
-	CustomerID     uint   `gorm:"index" json:"customer_id"`           // Foreign key for Customer (one-to-one)
-	SupplierID     uint   `gorm:"index" json:"supplier_id"`           // Foreign key for Supplier (one-to-one)
+	ID             uint        `gorm:"primaryKey;autoIncrement" json:"id"`                                                                            // primary key
+	Version        uint        `gorm:"not null;default:1" json:"version"`                                                                             // version of the contact, incremented on every change
+	Phone          string      `gorm:"not null" json:"phone"`                                                                                         // phone number of the contact in E.164, e.g. `+5511987654321`
+	SecondaryPhone string      `json:"secondary_phone"`                                                                                               // secondary phone number of the contact in E.164
+	PostalCode     string      `gorm:"not null" json:"postal_code"`                                                                                   // postal code of the contact for address
+	Area           string      `gorm:"not null" json:"area"`                                                                                          // area of the contact for address
+	District       string      `gorm:"not null" json:"district"`                                                                                      // district of the contact for address
+	AddressNumber  string      `gorm:"not null" json:"address_number"`                                                                                // address number of the contact for address
+	City           string      `gorm:"not null" json:"city"`                                                                                          // city of the contact for address
+	State          string      `gorm:"not null" json:"state"`                                                                                         // state of the contact for address
+	Country        string      `gorm:"not null" json:"country"`                                                                                       // country of the contact for address
+	Email          string      `json:"email"`                                                                                                         // email address of the contact
+	CustomerID     uint        `gorm:"index;index:idx_contacts_default,unique,priority:1,where:is_default AND deleted_at IS NULL" json:"customer_id"` // Foreign key for Customer (one-to-many)
+	SupplierID     uint        `gorm:"index;index:idx_contacts_default,unique,priority:2,where:is_default AND deleted_at IS NULL" json:"supplier_id"` // Foreign key for Supplier (one-to-many)
+	Type           ContactType `gorm:"type:varchar(20);not null;default:billing;index:idx_contacts_default,unique,priority:3" json:"type"`            // purpose of the contact
+	IsDefault      bool        `gorm:"not null;default:false" json:"is_default"`                                                                      // whether the contact is the default of its type for its owner
 }
 
 // TableName overrides the table name used by Contact to `sales.contacts`.
 func (Contact) TableName() string {
 	return "sales.contacts"
 }
+
+// MarshalJSON encodes the contact along with its phones formatted for reading,
+// e.g. `+55 11 98765-4321`, and their type, mobile or landline, as
+// `phone_formatted`, `phone_type`, `secondary_phone_formatted` and
+// `secondary_phone_type`. Phones that cannot be parsed, such as the ones not
+// normalized yet, are formatted as they are stored, with an unknown type.
+func (c Contact) MarshalJSON() ([]byte, error) {
+	type contact Contact // without the MarshalJSON method
+	primary, secondary := formatPhone(c.Phone, c.Country), formatPhone(c.SecondaryPhone, c.Country)
+	return json.Marshal(struct {
+		contact
+		PhoneFormatted          string     `json:"phone_formatted"`
+		PhoneType               phone.Type `json:"phone_type"`
+		SecondaryPhoneFormatted string     `json:"secondary_phone_formatted,omitempty"`
+		SecondaryPhoneType      phone.Type `json:"secondary_phone_type,omitempty"`
+	}{contact(c), primary.Format(), primary.Type, secondary.Format(), secondary.Type})
+}
+
+// formatPhone parses a stored phone of a contact of the given country, or
+// returns it as an unparsed number of an unknown type if it is not valid. An
+// empty phone is returned as an empty number.
+func formatPhone(value, country string) phone.Number {
+	if value == "" {
+		return phone.Number{}
+	}
+	number, err := phone.Parse(value, country)
+	if err != nil {
+		return phone.Number{E164: value, Type: phone.Unknown}
+	}
+	return number
+}
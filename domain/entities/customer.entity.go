@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"store/domain/taxid"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,14 +12,15 @@ import (
 // Table name: customers
 type Customer struct {
 	gorm.Model
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`                               // primary key
-	FirstName string    `gorm:"not null" json:"first_name"`                                       // first name of customer
-	LastName  string    `gorm:"not null" json:"last_name"`                                        // last name of customer
-	Birthday  time.Time `gorm:"not null" json:"birthday"`                                         // birthday of customer
-	TaxID     string    `gorm:"not null" json:"tax_id"`                                           // tax id of customer
-	Orders    []Order   `gorm:"foreignKey:CustomerID" json:"orders"`                              // One-to-many relationship with Order
-	ContactID uint      `json:"contact_id"`                                                       // contact id of customer
-	Contact   *Contact  `gorm:"foreignKey:CustomerID;constraint:OnDelete:CASCADE" json:"contact"` // One-to-one relationship with Contact
+	ID              uint        `gorm:"primaryKey;autoIncrement" json:"id"`                                                // primary key
+	Version         uint        `gorm:"not null;default:1" json:"version"`                                                 // version of the customer, incremented on every change
+	FirstName       string      `gorm:"not null" json:"first_name"`                                                        // first name of customer
+	LastName        string      `gorm:"not null" json:"last_name"`                                                         // last name of customer
+	Birthday        time.Time   `gorm:"not null" json:"birthday"`                                                          // birthday of customer
+	TaxID           taxid.TaxID `gorm:"not null;index:idx_customers_tax_id,unique,where:deleted_at IS NULL" json:"tax_id"` // CPF or CNPJ of customer, unique among the customers
+	BillingCurrency string      `gorm:"type:char(3);not null;default:BRL" json:"billing_currency"`                         // currency the customer is billed in
+	Orders          []Order     `gorm:"foreignKey:CustomerID" json:"orders"`                                               // One-to-many relationship with Order
+	Contacts        []Contact   `gorm:"foreignKey:CustomerID;constraint:OnDelete:CASCADE" json:"contacts,omitempty"`       // One-to-many relationship with Contact
 }
 
 // TableName overrides the table name used by Customer to `sales.customers`.
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"store/domain/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRate represents the rate between two currencies from a given date on.
+//
+// Table name: exchange_rates
+type ExchangeRate struct {
+	gorm.Model
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`                                                   // primary key
+	Version       uint       `gorm:"not null;default:1" json:"version"`                                                    // version of the exchange rate, incremented on every change
+	BaseCurrency  string     `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_pair_date" json:"base_currency"`  // currency being converted
+	QuoteCurrency string     `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_pair_date" json:"quote_currency"` // currency converted into
+	Rate          money.Rate `gorm:"not null" json:"rate"`                                                                 // units of the quote currency worth one unit of the base currency
+	EffectiveDate time.Time  `gorm:"type:date;not null;uniqueIndex:idx_exchange_rates_pair_date" json:"effective_date"`    // date from which the rate is effective
+}
+
+// TableName overrides the table name used by ExchangeRate to `sales.exchange_rates`.
+func (ExchangeRate) TableName() string {
+	return "sales.exchange_rates"
+}
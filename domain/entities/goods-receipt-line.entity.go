@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GoodsReceiptLine represents the quantity of a purchase order line received
+// by a goods receipt.
+//
+// Table name: goods_receipt_lines
+type GoodsReceiptLine struct {
+	gorm.Model
+	ID                  uint       `gorm:"primaryKey;autoIncrement" json:"id"`           // primary key
+	Version             uint       `gorm:"not null;default:1" json:"version"`            // version of the receipt line, incremented on every change
+	GoodsReceiptID      uint       `gorm:"not null;index" json:"goods_receipt_id"`       // foreign key for GoodsReceipt
+	PurchaseOrderLineID uint       `gorm:"not null;index" json:"purchase_order_line_id"` // foreign key for the PurchaseOrderLine received
+	Quantity            int        `gorm:"not null" json:"quantity"`                     // quantity received
+	LotNumber           string     `gorm:"type:varchar(50)" json:"lot_number"`           // batch number of the units received, empty when they have none
+	ExpiresAt           *time.Time `gorm:"type:date" json:"expires_at"`                  // expiry date of the units received
+}
+
+// TableName overrides the table name used by GoodsReceiptLine to `sales.goods_receipt_lines`.
+func (GoodsReceiptLine) TableName() string {
+	return "sales.goods_receipt_lines"
+}
@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GoodsReceipt represents a delivery of goods received for a purchase order.
+// Every receipt adds the quantities of its lines to the stock of the ordered
+// product suppliers.
+//
+// Table name: goods_receipts
+type GoodsReceipt struct {
+	gorm.Model
+	ID              uint               `gorm:"primaryKey;autoIncrement" json:"id"`      // primary key
+	Version         uint               `gorm:"not null;default:1" json:"version"`       // version of the receipt, incremented on every change
+	PurchaseOrderID uint               `gorm:"not null;index" json:"purchase_order_id"` // foreign key for PurchaseOrder
+	WarehouseID     *uint              `gorm:"index" json:"warehouse_id"`               // foreign key for the Warehouse the goods were received in
+	ReceivedAt      time.Time          `gorm:"not null" json:"received_at"`             // moment the goods were received
+	ReceivedBy      string             `json:"received_by"`                             // user who received the goods
+	Note            string             `json:"note"`                                    // optional note about the delivery
+	Lines           []GoodsReceiptLine `gorm:"foreignKey:GoodsReceiptID" json:"lines"`  // one-to-many relationship with GoodsReceiptLine
+}
+
+// TableName overrides the table name used by GoodsReceipt to `sales.goods_receipts`.
+func (GoodsReceipt) TableName() string {
+	return "sales.goods_receipts"
+}
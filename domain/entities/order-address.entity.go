@@ -0,0 +1,31 @@
+package entities
+
+import "gorm.io/gorm"
+
+// OrderAddress represents the snapshot of the shipping or billing contact of
+// an order, taken when the order is placed, so later changes of the contact
+// never rewrite the address of the order.
+//
+// Table name: order_addresses
+type OrderAddress struct {
+	gorm.Model
+	ID            uint        `gorm:"primaryKey;autoIncrement" json:"id"`                                                          // primary key
+	Version       uint        `gorm:"not null;default:1" json:"version"`                                                           // version of the order address, incremented on every change
+	OrderID       uint        `gorm:"not null;uniqueIndex:idx_order_addresses_order_type,priority:1" json:"order_id"`              // foreign key for Order
+	Type          ContactType `gorm:"type:varchar(20);not null;uniqueIndex:idx_order_addresses_order_type,priority:2" json:"type"` // shipping or billing
+	ContactID     uint        `gorm:"not null" json:"contact_id"`                                                                  // contact the address was copied from
+	Phone         string      `gorm:"not null" json:"phone"`                                                                       // phone number of the contact
+	PostalCode    string      `gorm:"not null" json:"postal_code"`                                                                 // postal code of the address
+	Area          string      `gorm:"not null" json:"area"`                                                                        // area of the address
+	District      string      `gorm:"not null" json:"district"`                                                                    // district of the address
+	AddressNumber string      `gorm:"not null" json:"address_number"`                                                              // address number of the address
+	City          string      `gorm:"not null" json:"city"`                                                                        // city of the address
+	State         string      `gorm:"not null" json:"state"`                                                                       // state of the address
+	Country       string      `gorm:"not null" json:"country"`                                                                     // country of the address
+	Email         string      `json:"email"`                                                                                       // email address of the contact
+}
+
+// TableName overrides the table name used by OrderAddress to `sales.order_addresses`.
+func (OrderAddress) TableName() string {
+	return "sales.order_addresses"
+}
@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"store/domain/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderExchangeRate represents the snapshot of an exchange rate used to convert
+// the amounts of an order into its currency, so historical totals never change.
+//
+// Table name: order_exchange_rates
+type OrderExchangeRate struct {
+	gorm.Model
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`          // primary key
+	Version       uint       `gorm:"not null;default:1" json:"version"`           // version of the order exchange rate, incremented on every change
+	OrderID       uint       `gorm:"not null;index" json:"order_id"`              // foreign key for Order
+	BaseCurrency  string     `gorm:"type:char(3);not null" json:"base_currency"`  // currency of the converted amounts
+	QuoteCurrency string     `gorm:"type:char(3);not null" json:"quote_currency"` // currency of the order
+	Rate          money.Rate `gorm:"not null" json:"rate"`                        // rate effective on the order date
+	EffectiveDate time.Time  `gorm:"type:date;not null" json:"effective_date"`    // date from which the rate was effective
+}
+
+// TableName overrides the table name used by OrderExchangeRate to `sales.order_exchange_rates`.
+func (OrderExchangeRate) TableName() string {
+	return "sales.order_exchange_rates"
+}
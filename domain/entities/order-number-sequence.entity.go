@@ -0,0 +1,16 @@
+package entities
+
+// OrderNumberSequence represents the counter of the order numbers issued in a
+// year. It is only ever incremented atomically, so it does not embed
+// gorm.Model and is never soft deleted.
+//
+// Table name: order_number_sequences
+type OrderNumberSequence struct {
+	Year      int   `gorm:"primaryKey;autoIncrement:false" json:"year"` // year the order numbers belong to
+	LastValue int64 `gorm:"not null;default:0" json:"last_value"`       // last sequence number issued in the year
+}
+
+// TableName overrides the table name used by OrderNumberSequence to `sales.order_number_sequences`.
+func (OrderNumberSequence) TableName() string {
+	return "sales.order_number_sequences"
+}
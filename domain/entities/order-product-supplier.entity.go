@@ -1,17 +1,24 @@
 package entities
 
-import "gorm.io/gorm"
+import (
+	"store/domain/money"
+
+	"gorm.io/gorm"
+)
 
 // OrderProductSupplier represents the association between orders, products, and suppliers.
 //
 // Table name: order_product_suppliers
 type OrderProductSupplier struct {
-	gorm.Model                // Adds ID, CreatedAt, UpdatedAt, DeletedAt
-	ID                uint    `gorm:"primaryKey;autoIncrement" json:"id"`  // primary key
-	OrderID           uint    `gorm:"not null" json:"order_id"`            // foreign key for Order
-	ProductSupplierID uint    `gorm:"not null" json:"product_supplier_id"` // foreign key for ProductSupplier
-	Value             float32 `gorm:"not null" json:"value"`               // value of the product of a supplier for this specific order
-	Discount          float32 `gorm:"not null;default:0" json:"discount"`  // discount of the product of a supplier for this specific order
+	gorm.Model                          // Adds ID, CreatedAt, UpdatedAt, DeletedAt
+	ID                 uint             `gorm:"primaryKey;autoIncrement" json:"id"`                // primary key
+	Version            uint             `gorm:"not null;default:1" json:"version"`                 // version of the order line, incremented on every change
+	OrderID            uint             `gorm:"not null" json:"order_id"`                          // foreign key for Order
+	ProductSupplierID  uint             `gorm:"not null" json:"product_supplier_id"`               // foreign key for ProductSupplier
+	Quantity           int              `gorm:"not null" json:"quantity"`                          // quantity of the product of a supplier for this specific order
+	Value              money.Money      `gorm:"embedded;embeddedPrefix:value_" json:"value"`       // unit value of the product of a supplier for this specific order
+	DiscountPercentage money.Percentage `gorm:"not null;default:0" json:"discount_percentage"`     // percentage discount of the line, applied to its gross value
+	Discount           money.Money      `gorm:"embedded;embeddedPrefix:discount_" json:"discount"` // absolute discount of the line, applied after the percentage
 }
 
 // TableName overrides the table name used by OrderProductSupplier to `sales.order_product_suppliers`.
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderStatusHistory represents a status change of an order.
+//
+// Table name: order_status_histories
+type OrderStatusHistory struct {
+	gorm.Model
+	ID         uint        `gorm:"primaryKey;autoIncrement" json:"id"` // primary key
+	Version    uint        `gorm:"not null;default:1" json:"version"`  // version of the history entry, incremented on every change
+	OrderID    uint        `gorm:"not null;index" json:"order_id"`     // foreign key for Order
+	FromStatus OrderStatus `json:"from_status"`                        // status before the change, empty when the order was created
+	ToStatus   OrderStatus `gorm:"not null" json:"to_status"`          // status after the change
+	ChangedBy  string      `json:"changed_by"`                         // user who changed the status
+	ChangedAt  time.Time   `gorm:"not null" json:"changed_at"`         // moment the status was changed
+	Note       string      `json:"note"`                               // optional note about the change
+}
+
+// TableName overrides the table name used by OrderStatusHistory to `sales.order_status_histories`.
+func (OrderStatusHistory) TableName() string {
+	return "sales.order_status_histories"
+}
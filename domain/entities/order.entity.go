@@ -1,24 +1,49 @@
 package entities
 
 import (
+	"store/domain/money"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// OrderStatus represents a step of the order lifecycle.
+type OrderStatus string
+
+const (
+	OrderStatusDraft     OrderStatus = "draft"     // order being prepared, stock untouched
+	OrderStatusPlaced    OrderStatus = "placed"    // order confirmed, stock consumed
+	OrderStatusPaid      OrderStatus = "paid"      // order paid by the customer
+	OrderStatusPicking   OrderStatus = "picking"   // order being picked in the warehouse
+	OrderStatusShipped   OrderStatus = "shipped"   // order handed to the carrier
+	OrderStatusDelivered OrderStatus = "delivered" // order delivered to the customer
+	OrderStatusCancelled OrderStatus = "cancelled" // order cancelled before shipping
+	OrderStatusReturned  OrderStatus = "returned"  // order returned after delivery
+)
+
 // Order represents an order placed by a customer.
 //
 // Table name: orders
 type Order struct {
 	gorm.Model
-	ID            uint                   `gorm:"primaryKey;autoIncrement" json:"id"`       // primary key
-	CustomerID    uint                   `gorm:"not null" json:"customer_id"`              // foreign key for Customer
-	OrderDate     time.Time              `gorm:"not null" json:"order_date"`               // order date for the order
-	DeliveryDate  time.Time              `gorm:"not null" json:"delivery_date"`            // delivery date for the order
-	DeliveryOrder bool                   `gorm:"not null" json:"delivery_order"`           // delivery order for the order
-	Discount      float32                `gorm:"not null;default:0" json:"discount"`       // discount for the order
-	UKOrderNumber string                 `gorm:"not null" json:"uk_order_number"`          // uk order number for the order
-	OrderProducts []OrderProductSupplier `gorm:"foreignKey:OrderID" json:"order_products"` // one-to-many relationship with OrderProductSupplier
+	ID                 uint                   `gorm:"primaryKey;autoIncrement" json:"id"`                                                               // primary key
+	Version            uint                   `gorm:"not null;default:1" json:"version"`                                                                // version of the order, incremented on every change
+	CustomerID         uint                   `gorm:"not null" json:"customer_id"`                                                                      // foreign key for Customer
+	OrderDate          time.Time              `gorm:"not null" json:"order_date"`                                                                       // order date for the order
+	DeliveryDate       time.Time              `gorm:"not null" json:"delivery_date"`                                                                    // delivery date for the order
+	DeliveryOrder      bool                   `gorm:"not null" json:"delivery_order"`                                                                   // delivery order for the order
+	DiscountPercentage money.Percentage       `gorm:"not null;default:0" json:"discount_percentage"`                                                    // percentage discount for the order, applied to the subtotal
+	Discount           money.Money            `gorm:"embedded;embeddedPrefix:discount_" json:"discount"`                                                // absolute discount for the order, applied after the percentage
+	UKOrderNumber      string                 `gorm:"not null;index:idx_orders_uk_order_number,unique,where:deleted_at IS NULL" json:"uk_order_number"` // uk order number for the order, generated by the server, unique among the orders
+	Status             OrderStatus            `gorm:"not null;default:placed;index" json:"status"`                                                      // current status of the order
+	Currency           string                 `gorm:"type:char(3);not null;default:BRL" json:"currency"`                                                // billing currency the order totals are computed in
+	ShippingContactID  uint                   `json:"shipping_contact_id"`                                                                              // contact of the customer the order is delivered to
+	BillingContactID   uint                   `json:"billing_contact_id"`                                                                               // contact of the customer the order is billed to
+	WarehouseID        *uint                  `gorm:"index" json:"warehouse_id"`                                                                        // warehouse the order is fulfilled from, chosen when it is placed
+	OrderProducts      []OrderProductSupplier `gorm:"foreignKey:OrderID" json:"order_products"`                                                         // one-to-many relationship with OrderProductSupplier
+	StatusHistory      []OrderStatusHistory   `gorm:"foreignKey:OrderID" json:"status_history,omitempty"`                                               // one-to-many relationship with OrderStatusHistory
+	ExchangeRates      []OrderExchangeRate    `gorm:"foreignKey:OrderID" json:"exchange_rates,omitempty"`                                               // one-to-many relationship with OrderExchangeRate
+	Addresses          []OrderAddress         `gorm:"foreignKey:OrderID" json:"addresses,omitempty"`                                                    // one-to-many relationship with OrderAddress, snapshotted when the order is placed
 }
 
 // TableName overrides the table name used by Order to `sales.orders`.
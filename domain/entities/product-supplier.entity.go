@@ -1,6 +1,11 @@
 package entities
 
-import "gorm.io/gorm"
+import (
+	"encoding/json"
+	"store/domain/money"
+
+	"gorm.io/gorm"
+)
 
 // ProductSupplier represents the association between products and suppliers (offering specific products).
 //
@@ -8,14 +13,20 @@ import "gorm.io/gorm"
 type ProductSupplier struct {
 	gorm.Model
 	ID                  uint                   `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProductID           uint                   `gorm:"not null" json:"product_id"`  // Foreign key for Product
-	SupplierID          uint                   `gorm:"not null" json:"supplier_id"` // Foreign key for Supplier
-	Cost                float32                `gorm:"not null" json:"cost"`
-	Value               float32                `gorm:"not null" json:"value"`
-	Quantity            int                    `gorm:"not null" json:"quantity"`
+	Version             uint                   `gorm:"not null;default:1" json:"version"`           // version of the product supplier, incremented on every change
+	ProductID           uint                   `gorm:"not null" json:"product_id"`                  // Foreign key for Product
+	SupplierID          uint                   `gorm:"not null" json:"supplier_id"`                 // Foreign key for Supplier
+	Cost                money.Money            `gorm:"embedded;embeddedPrefix:cost_" json:"cost"`   // cost paid to the supplier
+	Value               money.Money            `gorm:"embedded;embeddedPrefix:value_" json:"value"` // value the product is sold for
+	Quantity            int                    `gorm:"not null" json:"quantity"`                    // quantity on hand
+	Reserved            int                    `gorm:"not null;default:0" json:"reserved"`          // quantity held by the active reservations of draft orders
+	ReorderPoint        int                    `gorm:"not null;default:0" json:"reorder_point"`     // available quantity at or below which the product supplier is replenished, derived from its sales when zero
+	SafetyStock         int                    `gorm:"not null;default:0" json:"safety_stock"`      // quantity kept on top of the sales expected during the lead time of the supplier
+	ReorderQuantity     int                    `gorm:"not null;default:0" json:"reorder_quantity"`  // quantity purchased at once, the suggestions being rounded up to a multiple of it
 	SupplierProductCode string                 `json:"supplier_product_code"`
 	SupplierProductName string                 `json:"supplier_product_name"`
 	Sales               int                    `gorm:"not null;default:0" json:"sales"`
+	Expired             int                    `gorm:"-" json:"-"`                                         // quantity on hand in expired lots, only loaded where units are promised to orders
 	OrderProducts       []OrderProductSupplier `gorm:"foreignKey:ProductSupplierID" json:"order_products"` // One-to-many relationship with OrderProductSupplier
 }
 
@@ -23,3 +34,27 @@ type ProductSupplier struct {
 func (ProductSupplier) TableName() string {
 	return "sales.product_suppliers"
 }
+
+// Available returns the quantity that can still be promised to new orders,
+// the quantity on hand minus the quantity reserved for draft orders.
+func (ps ProductSupplier) Available() int {
+	return ps.Quantity - ps.Reserved
+}
+
+// Sellable returns the quantity available to promise that is not in expired
+// lots, as loaded in Expired, which is what orders can still take.
+func (ps ProductSupplier) Sellable() int {
+	return ps.Available() - ps.Expired
+}
+
+// MarshalJSON encodes the product supplier along with its quantity on hand and
+// its quantity available to promise, as `on_hand` and `available`, next to its
+// `reserved` quantity.
+func (ps ProductSupplier) MarshalJSON() ([]byte, error) {
+	type productSupplier ProductSupplier // without the MarshalJSON method
+	return json.Marshal(struct {
+		productSupplier
+		OnHand    int `json:"on_hand"`
+		Available int `json:"available"`
+	}{productSupplier(ps), ps.Quantity, ps.Available()})
+}
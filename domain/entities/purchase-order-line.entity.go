@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"store/domain/money"
+
+	"gorm.io/gorm"
+)
+
+// PurchaseOrderLine represents a product supplier ordered by a purchase order,
+// at the cost agreed with the supplier.
+//
+// Table name: purchase_order_lines
+type PurchaseOrderLine struct {
+	gorm.Model
+	ID                uint        `gorm:"primaryKey;autoIncrement" json:"id"`          // primary key
+	Version           uint        `gorm:"not null;default:1" json:"version"`           // version of the line, incremented on every change
+	PurchaseOrderID   uint        `gorm:"not null;index" json:"purchase_order_id"`     // foreign key for PurchaseOrder
+	ProductSupplierID uint        `gorm:"not null;index" json:"product_supplier_id"`   // foreign key for the ProductSupplier ordered
+	Quantity          int         `gorm:"not null" json:"quantity"`                    // quantity ordered
+	ReceivedQuantity  int         `gorm:"not null;default:0" json:"received_quantity"` // quantity received so far, which may exceed the quantity ordered
+	Cost              money.Money `gorm:"embedded;embeddedPrefix:cost_" json:"cost"`   // unit cost agreed with the supplier
+}
+
+// TableName overrides the table name used by PurchaseOrderLine to `sales.purchase_order_lines`.
+func (PurchaseOrderLine) TableName() string {
+	return "sales.purchase_order_lines"
+}
+
+// Outstanding returns the quantity of the line still expected from the
+// supplier, zero once the ordered quantity was received.
+func (l PurchaseOrderLine) Outstanding() int {
+	return max(l.Quantity-l.ReceivedQuantity, 0)
+}
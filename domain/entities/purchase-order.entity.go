@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurchaseOrderStatus represents a step of the purchase order lifecycle.
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusDraft             PurchaseOrderStatus = "draft"              // purchase order being prepared, lines can change
+	PurchaseOrderStatusSent              PurchaseOrderStatus = "sent"               // purchase order sent to the supplier, waiting for the goods
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received" // some of the ordered quantity was received
+	PurchaseOrderStatusReceived          PurchaseOrderStatus = "received"           // the ordered quantity of every line was received
+	PurchaseOrderStatusClosed            PurchaseOrderStatus = "closed"             // purchase order closed, no more goods are expected
+)
+
+// PurchaseOrder represents an order of products placed with a supplier to
+// replenish the stock of its product suppliers.
+//
+// Table name: purchase_orders
+type PurchaseOrder struct {
+	gorm.Model
+	ID           uint                `gorm:"primaryKey;autoIncrement" json:"id"`                          // primary key
+	Version      uint                `gorm:"not null;default:1" json:"version"`                           // version of the purchase order, incremented on every change
+	SupplierID   uint                `gorm:"not null;index" json:"supplier_id"`                           // foreign key for Supplier
+	Status       PurchaseOrderStatus `gorm:"type:varchar(20);not null;default:draft;index" json:"status"` // current status of the purchase order
+	OrderDate    time.Time           `gorm:"not null" json:"order_date"`                                  // date the purchase order was issued
+	ExpectedDate *time.Time          `json:"expected_date,omitempty"`                                     // date the goods are expected
+	Note         string              `json:"note"`                                                        // optional note for the supplier
+	SentAt       *time.Time          `json:"sent_at,omitempty"`                                           // moment the purchase order was sent
+	ClosedAt     *time.Time          `json:"closed_at,omitempty"`                                         // moment the purchase order was closed
+	Lines        []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID" json:"lines"`                     // one-to-many relationship with PurchaseOrderLine
+	Receipts     []GoodsReceipt      `gorm:"foreignKey:PurchaseOrderID" json:"receipts,omitempty"`        // one-to-many relationship with GoodsReceipt
+}
+
+// TableName overrides the table name used by PurchaseOrder to `sales.purchase_orders`.
+func (PurchaseOrder) TableName() string {
+	return "sales.purchase_orders"
+}
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StockLot represents the units of a product supplier received in a warehouse
+// under the same lot number, with their expiry date. The stock of a product
+// supplier in a warehouse is the sum of the quantities of its lots; units
+// received without a lot number are kept in a lot with an empty number and no
+// expiry date.
+//
+// Table name: stock_lots
+type StockLot struct {
+	gorm.Model
+	ID                uint       `gorm:"primaryKey;autoIncrement" json:"id"`                                                    // primary key
+	Version           uint       `gorm:"not null;default:1" json:"version"`                                                     // version of the lot, incremented on every change
+	WarehouseID       uint       `gorm:"not null;uniqueIndex:idx_stock_lots_lot,priority:1" json:"warehouse_id"`                // foreign key for the Warehouse holding the lot
+	ProductSupplierID uint       `gorm:"not null;uniqueIndex:idx_stock_lots_lot,priority:2;index" json:"product_supplier_id"`   // foreign key for ProductSupplier
+	LotNumber         string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_stock_lots_lot,priority:3" json:"lot_number"` // batch number of the lot, empty for units received without one
+	ExpiresAt         *time.Time `gorm:"type:date;index" json:"expires_at"`                                                     // last day the units of the lot can be sold, empty when they do not expire
+	Quantity          int        `gorm:"not null;default:0" json:"quantity"`                                                    // units of the lot on hand in the warehouse
+	ReceivedAt        time.Time  `gorm:"not null" json:"received_at"`                                                           // moment the first units of the lot were received
+}
+
+// TableName overrides the table name used by StockLot to `sales.stock_lots`.
+func (StockLot) TableName() string {
+	return "sales.stock_lots"
+}
+
+// Expired reports whether the units of the lot can no longer be sold at the
+// given moment, the day after its expiry date.
+func (l *StockLot) Expired(at time.Time) bool {
+	return l.ExpiresAt != nil && l.ExpiresAt.Before(at.UTC().Truncate(24*time.Hour))
+}
@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StockMovementLot represents the units of a stock movement that went into or
+// out of a lot. The quantities of the lots of a movement add up to the
+// quantity of the movement.
+//
+// Table name: stock_movement_lots
+type StockMovementLot struct {
+	gorm.Model
+	ID                uint       `gorm:"primaryKey;autoIncrement" json:"id"`          // primary key
+	Version           uint       `gorm:"not null;default:1" json:"version"`           // version of the movement lot, incremented on every change
+	StockMovementID   uint       `gorm:"not null;index" json:"stock_movement_id"`     // foreign key for StockMovement
+	ProductSupplierID uint       `gorm:"not null;index" json:"product_supplier_id"`   // foreign key for the ProductSupplier of the movement
+	StockLotID        uint       `gorm:"not null;index" json:"stock_lot_id"`          // foreign key for the StockLot the units moved in
+	LotNumber         string     `gorm:"type:varchar(50);not null" json:"lot_number"` // batch number of the lot, empty for units without one
+	ExpiresAt         *time.Time `gorm:"type:date" json:"expires_at"`                 // expiry date of the lot
+	Quantity          int        `gorm:"not null" json:"quantity"`                    // units moved, positive into the lot and negative out of it
+}
+
+// TableName overrides the table name used by StockMovementLot to `sales.stock_movement_lots`.
+func (StockMovementLot) TableName() string {
+	return "sales.stock_movement_lots"
+}
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StockMovementType is the cause of a movement of the stock of a product
+// supplier.
+type StockMovementType string
+
+const (
+	StockMovementReceipt    StockMovementType = "receipt"    // units received from the supplier
+	StockMovementSale       StockMovementType = "sale"       // units sold by an order
+	StockMovementReturn     StockMovementType = "return"     // units given back by a cancelled or returned order
+	StockMovementAdjustment StockMovementType = "adjustment" // correction of the stock, such as a count or a loss
+	StockMovementTransfer   StockMovementType = "transfer"   // units moved between stock locations
+)
+
+// StockMovement represents an entry of the append-only ledger of the stock of
+// a product supplier. The quantity in stock of a product supplier is the sum of
+// the quantities of its movements, and the lots the units moved in are listed
+// in its Lots.
+//
+// Table name: stock_movements
+type StockMovement struct {
+	gorm.Model
+	ID                uint               `gorm:"primaryKey;autoIncrement" json:"id"`          // primary key
+	Version           uint               `gorm:"not null;default:1" json:"version"`           // version of the movement, incremented on every change
+	ProductSupplierID uint               `gorm:"not null;index" json:"product_supplier_id"`   // foreign key for ProductSupplier
+	WarehouseID       *uint              `gorm:"index" json:"warehouse_id"`                   // foreign key for the Warehouse the stock moved in
+	Type              StockMovementType  `gorm:"type:varchar(20);not null;index" json:"type"` // cause of the movement
+	Quantity          int                `gorm:"not null" json:"quantity"`                    // units moved, positive into stock and negative out of it
+	BalanceAfter      int                `gorm:"not null" json:"balance_after"`               // quantity in stock of the product supplier after the movement
+	Reason            string             `json:"reason"`                                      // why the stock moved
+	ReferenceType     string             `json:"reference_type"`                              // kind of the document that moved the stock, e.g. `order`
+	Reference         string             `gorm:"index" json:"reference"`                      // number of the document that moved the stock, e.g. an order number
+	CreatedBy         string             `json:"created_by"`                                  // user who moved the stock
+	MovedAt           time.Time          `gorm:"not null;index" json:"moved_at"`              // moment the stock moved
+	Lots              []StockMovementLot `gorm:"foreignKey:StockMovementID" json:"lots"`      // one-to-many relationship with StockMovementLot
+}
+
+// TableName overrides the table name used by StockMovement to `sales.stock_movements`.
+func (StockMovement) TableName() string {
+	return "sales.stock_movements"
+}
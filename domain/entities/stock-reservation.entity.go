@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StockReservationStatus represents a step of the lifecycle of a stock
+// reservation.
+type StockReservationStatus string
+
+const (
+	StockReservationActive    StockReservationStatus = "active"    // units held for the draft order
+	StockReservationConverted StockReservationStatus = "converted" // units sold when the draft order was placed
+	StockReservationReleased  StockReservationStatus = "released"  // units given back when the draft order changed, was cancelled or deleted
+	StockReservationExpired   StockReservationStatus = "expired"   // units given back by the sweeper after the reservation TTL
+)
+
+// StockReservation represents units of a product supplier held for a line of
+// a draft order. Active reservations reduce the units available to promise of
+// the product supplier, without reducing its quantity on hand.
+//
+// Table name: stock_reservations
+type StockReservation struct {
+	gorm.Model
+	ID                     uint                   `gorm:"primaryKey;autoIncrement" json:"id"`              // primary key
+	Version                uint                   `gorm:"not null;default:1" json:"version"`               // version of the reservation, incremented on every change
+	OrderID                uint                   `gorm:"not null;index" json:"order_id"`                  // foreign key for Order
+	OrderProductSupplierID uint                   `gorm:"not null;index" json:"order_product_supplier_id"` // foreign key for the OrderProductSupplier holding the units
+	ProductSupplierID      uint                   `gorm:"not null;index" json:"product_supplier_id"`       // foreign key for ProductSupplier
+	Quantity               int                    `gorm:"not null" json:"quantity"`                        // units held
+	Status                 StockReservationStatus `gorm:"type:varchar(20);not null;index" json:"status"`   // step of the reservation lifecycle
+	ReservedAt             time.Time              `gorm:"not null;index" json:"reserved_at"`               // moment the units were held, from which the TTL runs
+	ReleasedAt             *time.Time             `json:"released_at,omitempty"`                           // moment the reservation stopped being active
+}
+
+// TableName overrides the table name used by StockReservation to `sales.stock_reservations`.
+func (StockReservation) TableName() string {
+	return "sales.stock_reservations"
+}
@@ -0,0 +1,21 @@
+package entities
+
+import "gorm.io/gorm"
+
+// StockTransferLine represents the units of a product supplier moved by a
+// stock transfer.
+//
+// Table name: stock_transfer_lines
+type StockTransferLine struct {
+	gorm.Model
+	ID                uint `gorm:"primaryKey;autoIncrement" json:"id"`        // primary key
+	Version           uint `gorm:"not null;default:1" json:"version"`         // version of the line, incremented on every change
+	StockTransferID   uint `gorm:"not null;index" json:"stock_transfer_id"`   // foreign key for StockTransfer
+	ProductSupplierID uint `gorm:"not null;index" json:"product_supplier_id"` // foreign key for the ProductSupplier moved
+	Quantity          int  `gorm:"not null" json:"quantity"`                  // units moved
+}
+
+// TableName overrides the table name used by StockTransferLine to `sales.stock_transfer_lines`.
+func (StockTransferLine) TableName() string {
+	return "sales.stock_transfer_lines"
+}
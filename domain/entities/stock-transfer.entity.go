@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StockTransferStatus represents a step of the lifecycle of a stock transfer.
+type StockTransferStatus string
+
+const (
+	StockTransferStatusInTransit StockTransferStatus = "in_transit" // units left the source warehouse and are on their way
+	StockTransferStatusReceived  StockTransferStatus = "received"   // units arrived in the destination warehouse
+	StockTransferStatusCancelled StockTransferStatus = "cancelled"  // units went back to the source warehouse
+)
+
+// StockTransfer represents units of product suppliers moved from a warehouse
+// to another. The units leave the stock on hand when the transfer is shipped
+// and are in transit until it is received or cancelled.
+//
+// Table name: stock_transfers
+type StockTransfer struct {
+	gorm.Model
+	ID              uint                `gorm:"primaryKey;autoIncrement" json:"id"`                               // primary key
+	Version         uint                `gorm:"not null;default:1" json:"version"`                                // version of the transfer, incremented on every change
+	FromWarehouseID uint                `gorm:"not null;index" json:"from_warehouse_id"`                          // foreign key for the Warehouse the units leave
+	ToWarehouseID   uint                `gorm:"not null;index" json:"to_warehouse_id"`                            // foreign key for the Warehouse the units go to
+	Status          StockTransferStatus `gorm:"type:varchar(20);not null;default:in_transit;index" json:"status"` // current status of the transfer
+	Note            string              `json:"note"`                                                             // optional note about the transfer
+	CreatedBy       string              `json:"created_by"`                                                       // user who shipped the transfer
+	ShippedAt       time.Time           `gorm:"not null" json:"shipped_at"`                                       // moment the units left the source warehouse
+	ReceivedAt      *time.Time          `json:"received_at,omitempty"`                                            // moment the units arrived in the destination warehouse
+	CancelledAt     *time.Time          `json:"cancelled_at,omitempty"`                                           // moment the transfer was cancelled
+	Lines           []StockTransferLine `gorm:"foreignKey:StockTransferID" json:"lines"`                          // one-to-many relationship with StockTransferLine
+}
+
+// TableName overrides the table name used by StockTransfer to `sales.stock_transfers`.
+func (StockTransfer) TableName() string {
+	return "sales.stock_transfers"
+}
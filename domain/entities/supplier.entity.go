@@ -1,21 +1,25 @@
 package entities
 
-import "gorm.io/gorm"
+import (
+	"store/domain/taxid"
+
+	"gorm.io/gorm"
+)
 
 // Supplier represents a supplier of products.
 //
 // Table name: suppliers
 type Supplier struct {
 	gorm.Model
-	ID            uint              `gorm:"primaryKey;autoIncrement" json:"id"`       // primary key
-	Name          string            `gorm:"not null" json:"name"`                     // supplier name
-	TaxID         string            `gorm:"not null" json:"tax_id"`                   // supplier tax ID
-	FantasyName   string            `json:"fantasy_name"`                             // supplier fantasy name
-	Sales         int               `gorm:"not null;default:0" json:"sales"`          // supplier quantity of sales
-	QuantityStock int               `gorm:"not null;default:0" json:"quantity_stock"` // supplier quantity stock
-	Products      []ProductSupplier `gorm:"foreignKey:SupplierID" json:"products"`    // One-to-many relationship with ProductSupplier
-	ContactID     uint              `json:"contact_id"`
-	Contact       *Contact          `gorm:"foreignKey:SupplierID;constraint:OnDelete:CASCADE" json:"contact"` // One-to-one relationship with Contact
+	ID            uint              `gorm:"primaryKey;autoIncrement" json:"id"`                                                // primary key
+	Version       uint              `gorm:"not null;default:1" json:"version"`                                                 // version of the supplier, incremented on every change
+	Name          string            `gorm:"not null" json:"name"`                                                              // supplier name
+	TaxID         taxid.TaxID       `gorm:"not null;index:idx_suppliers_tax_id,unique,where:deleted_at IS NULL" json:"tax_id"` // supplier CPF or CNPJ, unique among the suppliers
+	FantasyName   string            `json:"fantasy_name"`                                                                      // supplier fantasy name
+	Sales         int               `gorm:"not null;default:0" json:"sales"`                                                   // supplier quantity of sales
+	QuantityStock int               `gorm:"not null;default:0" json:"quantity_stock"`                                          // supplier quantity stock
+	Products      []ProductSupplier `gorm:"foreignKey:SupplierID" json:"products"`                                             // One-to-many relationship with ProductSupplier
+	Contacts      []Contact         `gorm:"foreignKey:SupplierID;constraint:OnDelete:CASCADE" json:"contacts,omitempty"`       // One-to-many relationship with Contact
 }
 
 // TableName overrides the table name used by Supplier to `sales.suppliers`.
@@ -0,0 +1,22 @@
+package entities
+
+import "gorm.io/gorm"
+
+// WarehouseStock represents the quantity on hand of a product supplier in a
+// warehouse. The quantity of a product supplier is the sum of its quantities
+// in every warehouse.
+//
+// Table name: warehouse_stocks
+type WarehouseStock struct {
+	gorm.Model
+	ID                uint `gorm:"primaryKey;autoIncrement" json:"id"`                                                             // primary key
+	Version           uint `gorm:"not null;default:1" json:"version"`                                                              // version of the stock, incremented on every change
+	WarehouseID       uint `gorm:"not null;uniqueIndex:idx_warehouse_stocks_location,priority:1" json:"warehouse_id"`              // foreign key for Warehouse
+	ProductSupplierID uint `gorm:"not null;uniqueIndex:idx_warehouse_stocks_location,priority:2;index" json:"product_supplier_id"` // foreign key for ProductSupplier
+	Quantity          int  `gorm:"not null;default:0" json:"quantity"`                                                             // quantity on hand in the warehouse
+}
+
+// TableName overrides the table name used by WarehouseStock to `sales.warehouse_stocks`.
+func (WarehouseStock) TableName() string {
+	return "sales.warehouse_stocks"
+}
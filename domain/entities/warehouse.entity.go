@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"store/domain/postalcode"
+
+	"gorm.io/gorm"
+)
+
+// Warehouse represents a stock location the product suppliers are stored in
+// and the orders are fulfilled from. The stock of a product supplier is split
+// between the warehouses, and movements without a warehouse take place in the
+// default one.
+//
+// Table name: warehouses
+type Warehouse struct {
+	gorm.Model
+	ID         uint           `gorm:"primaryKey;autoIncrement" json:"id"`                                                                                   // primary key
+	Version    uint           `gorm:"not null;default:1" json:"version"`                                                                                    // version of the warehouse, incremented on every change
+	Code       string         `gorm:"type:varchar(20);not null;index:idx_warehouses_code,unique,where:deleted_at IS NULL" json:"code"`                      // short code of the warehouse, unique among the warehouses
+	Name       string         `gorm:"not null" json:"name"`                                                                                                 // name of the warehouse
+	PostalCode postalcode.CEP `gorm:"type:varchar(8)" json:"postal_code"`                                                                                   // postal code of the warehouse, used to find the nearest one to a customer
+	City       string         `json:"city"`                                                                                                                 // city of the warehouse
+	State      string         `json:"state"`                                                                                                                // state of the warehouse
+	IsDefault  bool           `gorm:"not null;default:false;index:idx_warehouses_default,unique,where:is_default AND deleted_at IS NULL" json:"is_default"` // whether movements without warehouse take place in this warehouse
+}
+
+// TableName overrides the table name used by Warehouse to `sales.warehouses`.
+func (Warehouse) TableName() string {
+	return "sales.warehouses"
+}
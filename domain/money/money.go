@@ -0,0 +1,244 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"store/domain/apperrors"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code used when no currency is given.
+const DefaultCurrency = "BRL"
+
+// ErrInvalidAmount is returned when a decimal amount cannot be parsed.
+var ErrInvalidAmount = apperrors.BadRequest("invalid_amount", "money: invalid amount")
+
+// ErrInvalidCurrency is returned when a currency is not a three letter ISO 4217 code.
+var ErrInvalidCurrency = apperrors.BadRequest("invalid_currency", "money: invalid currency")
+
+// minorUnits holds the ISO 4217 exponent of the currencies that do not use
+// two decimal places. Any currency not listed here uses two.
+var minorUnits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"CLP": 0,
+	"PYG": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+// Money represents an exact amount of money, stored as an integer number of
+// minor units (e.g. cents) of its ISO 4217 currency.
+//
+// Entities embed it with a column prefix, e.g. `gorm:"embedded;embeddedPrefix:cost_"`,
+// which maps it to a `cost_amount` bigint and a `cost_currency` column.
+type Money struct {
+	Amount   int64  `gorm:"not null;default:0"`                // amount in minor units of the currency
+	Currency string `gorm:"type:char(3);not null;default:BRL"` // ISO 4217 currency code
+}
+
+// New creates a Money of amount minor units of the given currency. An empty
+// currency is replaced by DefaultCurrency.
+func New(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Zero returns a zero amount of the given currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// FromFloat converts a decimal value, such as a legacy float price, into Money,
+// rounding to the nearest minor unit with banker's rounding.
+func FromFloat(value float64, currency string) Money {
+	m := New(0, currency)
+	m.Amount = int64(math.RoundToEven(value * math.Pow10(m.Exponent())))
+	return m
+}
+
+// Parse converts a decimal string, such as "1234.56", into Money of the given
+// currency. Digits beyond the currency minor unit are rounded with banker's
+// rounding. It returns ErrInvalidAmount if the string is not a decimal number
+// and ErrInvalidCurrency if the currency is not a valid code.
+func Parse(value, currency string) (Money, error) {
+	m := New(0, currency)
+	if !validCurrency(m.Currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || strings.ContainsAny(value, "/eE") {
+		return Money{}, ErrInvalidAmount
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(m.Exponent())))
+	m.Amount = roundHalfEven(r)
+	return m, nil
+}
+
+// Exponent returns the number of decimal places of the currency minor unit.
+func (m Money) Exponent() int {
+	if exponent, ok := minorUnits[m.Currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is lower than zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// SameCurrency reports whether m and other share the same currency.
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == other.Currency
+}
+
+// Add returns the sum of m and other. Both must share the same currency;
+// adding different currencies is a programming error and panics.
+func (m Money) Add(other Money) Money {
+	m.mustMatch(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+// Sub returns m minus other. Both must share the same currency; subtracting
+// different currencies is a programming error and panics.
+func (m Money) Sub(other Money) Money {
+	m.mustMatch(other)
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// Mul returns m multiplied by an integer quantity.
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Percent returns the given percentage of m, rounded to the nearest minor unit
+// with banker's rounding.
+func (m Money) Percent(percentage Percentage) Money {
+	r := big.NewRat(m.Amount*int64(percentage), 100*percentageScale)
+	return Money{Amount: roundHalfEven(r), Currency: m.Currency}
+}
+
+// Min returns the smaller of m and other, which must share the same currency.
+func (m Money) Min(other Money) Money {
+	m.mustMatch(other)
+	if other.Amount < m.Amount {
+		return other
+	}
+	return m
+}
+
+// String formats the amount as a decimal string, such as "1234.56".
+func (m Money) String() string {
+	return formatDecimal(m.Amount, m.Exponent())
+}
+
+// MarshalJSON encodes the money as an object with the amount as a decimal
+// string, so no precision is lost by JSON number parsing on the client.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{Amount: m.String(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes money from an object such as
+// {"amount": "1234.56", "currency": "BRL"}, where the amount may also be a JSON
+// number. A bare decimal string or number is accepted as an amount in
+// DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var object struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}
+	if len(data) > 0 && data[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&object); err != nil {
+			return err
+		}
+	} else if err := json.Unmarshal(data, &object.Amount); err != nil {
+		return ErrInvalidAmount
+	}
+
+	if object.Amount == "" {
+		object.Amount = "0"
+	}
+	parsed, err := Parse(object.Amount.String(), object.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) mustMatch(other Money) {
+	if m.Currency != other.Currency {
+		panic(fmt.Sprintf("money: currency mismatch %s and %s", m.Currency, other.Currency))
+	}
+}
+
+// validCurrency reports whether code looks like an ISO 4217 code.
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatDecimal formats an integer scaled by 10^exponent as a decimal string.
+func formatDecimal(value int64, exponent int) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d", sign, value)
+	}
+	unit := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, value/unit, exponent, value%unit)
+}
+
+// roundHalfEven rounds a rational number to the nearest integer, rounding
+// halves to the nearest even integer (banker's rounding).
+func roundHalfEven(r *big.Rat) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	// compare twice the remainder with the denominator to find the nearest integer
+	twice := new(big.Int).Abs(rem)
+	twice.Mul(twice, big.NewInt(2))
+	switch twice.Cmp(den) {
+	case 1:
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	case 0:
+		if quo.Bit(0) == 1 {
+			quo.Add(quo, big.NewInt(int64(num.Sign())))
+		}
+	}
+	return quo.Int64()
+}
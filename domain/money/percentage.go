@@ -0,0 +1,62 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+)
+
+// percentageScale is the number of Percentage units in one percent.
+const percentageScale = 100
+
+// OneHundredPercent is the Percentage of a whole amount.
+const OneHundredPercent Percentage = 100 * percentageScale
+
+// Percentage represents an exact percentage in hundredths of a percent, so
+// 1250 means 12.50%. It is stored as an integer column and encoded in JSON as
+// a decimal string.
+type Percentage int64
+
+// ParsePercentage converts a decimal string, such as "12.5", into a Percentage,
+// rounding to the hundredth of a percent with banker's rounding.
+func ParsePercentage(value string) (Percentage, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || strings.ContainsAny(value, "/eE") {
+		return 0, ErrInvalidAmount
+	}
+	r.Mul(r, big.NewRat(percentageScale, 1))
+	return Percentage(roundHalfEven(r)), nil
+}
+
+// String formats the percentage as a decimal string, such as "12.50".
+func (p Percentage) String() string {
+	return formatDecimal(int64(p), 2)
+}
+
+// MarshalJSON encodes the percentage as a decimal string.
+func (p Percentage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON decodes the percentage from a decimal string or a JSON number.
+func (p *Percentage) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return ErrInvalidAmount
+	}
+	if number == "" {
+		number = "0"
+	}
+	parsed, err := ParsePercentage(number.String())
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
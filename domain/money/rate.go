@@ -0,0 +1,105 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"store/domain/apperrors"
+	"strings"
+)
+
+// rateScale is the number of Rate units in one, so rates keep ten decimal places.
+const rateScale = 10_000_000_000
+
+// ErrInvalidRate is returned when an exchange rate is not a positive decimal.
+var ErrInvalidRate = apperrors.BadRequest("invalid_rate", "money: invalid exchange rate")
+
+// Rate represents an exact exchange rate with ten decimal places, stored as an
+// integer number of 10^-10 units and encoded in JSON as a decimal string.
+// A Rate from a base to a quote currency tells how many units of the quote
+// currency one unit of the base currency is worth.
+type Rate int64
+
+// ParseRate converts a positive decimal string, such as "5.4321", into a Rate,
+// rounding to ten decimal places with banker's rounding.
+func ParseRate(value string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || strings.ContainsAny(value, "/eE") {
+		return 0, ErrInvalidRate
+	}
+	r.Mul(r, big.NewRat(rateScale, 1))
+	rate := Rate(roundHalfEven(r))
+	if rate <= 0 {
+		return 0, ErrInvalidRate
+	}
+	return rate, nil
+}
+
+// Inverse returns the rate from the quote to the base currency, rounded to ten
+// decimal places with banker's rounding.
+func (r Rate) Inverse() Rate {
+	num := new(big.Int).Mul(big.NewInt(rateScale), big.NewInt(rateScale))
+	return Rate(roundHalfEven(new(big.Rat).SetFrac(num, big.NewInt(int64(r)))))
+}
+
+// String formats the rate as a decimal string without trailing zeros.
+func (r Rate) String() string {
+	s := formatDecimal(int64(r), 10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON encodes the rate as a decimal string.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON decodes the rate from a decimal string or a JSON number.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return ErrInvalidRate
+	}
+	parsed, err := ParseRate(number.String())
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Convert converts m into the given currency at the given rate, rounding to the
+// minor unit of the target currency with banker's rounding. Converting into the
+// currency m already has returns m unchanged.
+func (m Money) Convert(currency string, rate Rate) Money {
+	target := New(0, currency)
+	if target.Currency == m.Currency {
+		return m
+	}
+
+	// amount / 10^from * rate / rateScale * 10^to
+	num := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(int64(rate)))
+	num.Mul(num, pow10(target.Exponent()))
+	den := new(big.Int).Mul(big.NewInt(rateScale), pow10(m.Exponent()))
+	target.Amount = roundHalfEven(new(big.Rat).SetFrac(num, den))
+	return target
+}
+
+// ParseCurrency normalizes and validates an ISO 4217 currency code. An empty
+// code is replaced by DefaultCurrency.
+func ParseCurrency(code string) (string, error) {
+	currency := New(0, strings.TrimSpace(code)).Currency
+	if !validCurrency(currency) {
+		return "", ErrInvalidCurrency
+	}
+	return currency, nil
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
@@ -0,0 +1,193 @@
+// Package phone parses the phone numbers of the contacts into E.164, the
+// international format used by the SMS and dialer integrations, e.g.
+// `+5511987654321`, and detects whether they are mobile or landline numbers.
+//
+// Brazilian numbers are fully validated: their area code (DDD) must exist, and
+// their subscriber number must be a mobile number of nine digits starting with
+// 9 or a landline number of eight digits starting with 2 to 5. Numbers of other
+// countries are only checked for the length allowed by E.164, and their type
+// is unknown.
+package phone
+
+import (
+	"store/domain/apperrors"
+	"strings"
+)
+
+// ErrInvalidPhone is returned when a phone number cannot be parsed for the
+// country of its contact.
+var ErrInvalidPhone = apperrors.Invalid("invalid_phone", "phone: invalid phone number")
+
+// Type is the type of line of a phone number.
+type Type string
+
+const (
+	Mobile   Type = "mobile"   // mobile phone, able to receive SMS
+	Landline Type = "landline" // fixed line
+	Unknown  Type = "unknown"  // number of a country whose types are not detected
+)
+
+// brazil is the calling code of Brazil, the country of the contacts without
+// country.
+const brazil = "55"
+
+// callingCodes maps the names and ISO 3166 codes of the countries of the
+// contacts, in lower case, to their calling code.
+var callingCodes = map[string]string{
+	"": brazil, "br": brazil, "bra": brazil, "brasil": brazil, "brazil": brazil,
+	"ar": "54", "arg": "54", "argentina": "54",
+	"cl": "56", "chl": "56", "chile": "56",
+	"co": "57", "col": "57", "colombia": "57", "colômbia": "57",
+	"py": "595", "pry": "595", "paraguay": "595", "paraguai": "595",
+	"uy": "598", "ury": "598", "uruguay": "598", "uruguai": "598",
+	"mx": "52", "mex": "52", "mexico": "52", "méxico": "52",
+	"us": "1", "usa": "1", "united states": "1", "estados unidos": "1",
+	"ca": "1", "can": "1", "canada": "1", "canadá": "1",
+	"pt": "351", "prt": "351", "portugal": "351",
+	"es": "34", "esp": "34", "spain": "34", "espanha": "34",
+	"fr": "33", "fra": "33", "france": "33", "frança": "33",
+	"it": "39", "ita": "39", "italy": "39", "itália": "39",
+	"de": "49", "deu": "49", "germany": "49", "alemanha": "49",
+	"gb": "44", "gbr": "44", "uk": "44", "united kingdom": "44", "reino unido": "44",
+	"cn": "86", "chn": "86", "china": "86",
+	"jp": "81", "jpn": "81", "japan": "81", "japão": "81",
+}
+
+// areaCodes lists the Brazilian area codes (DDD).
+var areaCodes = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true, "22": true, "24": true, "27": true, "28": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "37": true, "38": true,
+	"41": true, "42": true, "43": true, "44": true, "45": true, "46": true, "47": true, "48": true, "49": true,
+	"51": true, "53": true, "54": true, "55": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true, "66": true, "67": true, "68": true, "69": true,
+	"71": true, "73": true, "74": true, "75": true, "77": true, "79": true,
+	"81": true, "82": true, "83": true, "84": true, "85": true, "86": true, "87": true, "88": true, "89": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true, "97": true, "98": true, "99": true,
+}
+
+// Number is a parsed phone number.
+type Number struct {
+	E164        string // number in E.164, e.g. `+5511987654321`
+	CallingCode string // calling code of its country, e.g. `55`
+	National    string // number without the calling code, e.g. `11987654321`
+	Type        Type   // type of line of the number
+}
+
+// Parse parses a phone number of a contact of the given country, a name or
+// ISO 3166 code such as "Brazil" or "BR", Brazil when empty.
+//
+// The number may be in international format, starting with `+` or `00`, or in
+// the national format of the country, with a trunk prefix `0` and, for Brazil,
+// a carrier code, e.g. `0 15 11 98765-4321`. Spaces, dots, dashes and
+// parentheses are ignored. It returns ErrInvalidPhone if the number is not
+// valid, or is in national format for a country whose calling code is unknown.
+func Parse(value, country string) (Number, error) {
+	digits, international := clean(value)
+	if digits == "" {
+		return Number{}, ErrInvalidPhone
+	}
+
+	callingCode := ""
+	if international {
+		callingCode = prefixCode(digits)
+		if callingCode == "" && len(digits) >= 8 && len(digits) <= 15 {
+			return Number{E164: "+" + digits, National: digits, Type: Unknown}, nil
+		}
+		digits = digits[len(callingCode):]
+	} else {
+		code, ok := callingCodes[strings.Join(strings.Fields(strings.ToLower(country)), " ")]
+		if !ok {
+			return Number{}, ErrInvalidPhone
+		}
+		callingCode = code
+		digits = strings.TrimPrefix(digits, "0")
+		if callingCode == brazil && (len(digits) == 12 || len(digits) == 13) {
+			digits = digits[2:] // carrier code
+		}
+	}
+
+	if callingCode == brazil {
+		return parseBrazilian(digits)
+	}
+	if total := len(callingCode) + len(digits); total < 8 || total > 15 {
+		return Number{}, ErrInvalidPhone
+	}
+	return Number{E164: "+" + callingCode + digits, CallingCode: callingCode, National: digits, Type: Unknown}, nil
+}
+
+// Format returns the number in international format, grouped for reading,
+// e.g. `+55 11 98765-4321` or `+1 415-555-0123`.
+func (n Number) Format() string {
+	national := n.National
+	switch {
+	case n.CallingCode == brazil:
+		split := len(national) - 4
+		return "+55 " + national[:2] + " " + national[2:split] + "-" + national[split:]
+	case n.CallingCode == "1" && len(national) == 10:
+		return "+1 " + national[:3] + "-" + national[3:6] + "-" + national[6:]
+	case n.CallingCode != "":
+		return "+" + n.CallingCode + " " + national
+	default:
+		return n.E164
+	}
+}
+
+// parseBrazilian parses the national number of a Brazilian phone, its area
+// code followed by its subscriber number.
+func parseBrazilian(national string) (Number, error) {
+	if len(national) < 10 || !areaCodes[national[:2]] {
+		return Number{}, ErrInvalidPhone
+	}
+
+	var lineType Type
+	subscriber := national[2:]
+	switch {
+	case len(subscriber) == 9 && subscriber[0] == '9':
+		lineType = Mobile
+	case len(subscriber) == 8 && subscriber[0] >= '2' && subscriber[0] <= '5':
+		lineType = Landline
+	default:
+		return Number{}, ErrInvalidPhone
+	}
+	return Number{E164: "+" + brazil + national, CallingCode: brazil, National: national, Type: lineType}, nil
+}
+
+// clean returns the digits of a phone number without its punctuation, and
+// whether it is in international format. It returns no digits if the number
+// has other characters.
+func clean(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	international := strings.HasPrefix(value, "+")
+	value = strings.TrimPrefix(value, "+")
+
+	var digits strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '.' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", false
+		}
+	}
+
+	s := digits.String()
+	if !international && strings.HasPrefix(s, "00") {
+		return s[2:], true
+	}
+	return s, international
+}
+
+// prefixCode returns the calling code of callingCodes the international number
+// starts with, or an empty string if it starts with none of them.
+func prefixCode(digits string) string {
+	for length := 1; length <= 3 && length < len(digits); length++ {
+		for _, code := range callingCodes {
+			if code == digits[:length] {
+				return code
+			}
+		}
+	}
+	return ""
+}
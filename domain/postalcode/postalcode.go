@@ -0,0 +1,159 @@
+// Package postalcode validates and formats the Brazilian postal codes (CEP) of
+// the addresses of the contacts, and compares the names of their cities and
+// states regardless of case and accents.
+//
+// Postal codes are stored as their 8 digits, e.g. `01001000`, and written in
+// JSON with the dash of their mask, e.g. `01001-000`.
+package postalcode
+
+import (
+	"encoding/json"
+	"store/domain/apperrors"
+	"strings"
+)
+
+// Country is the country of the addresses of the Brazilian postal codes, as
+// filled in the contacts.
+const Country = "Brazil"
+
+// length is the number of digits of a postal code.
+const length = 8
+
+// ErrInvalidPostalCode is returned when a postal code is not 8 digits.
+var ErrInvalidPostalCode = apperrors.BadRequest("invalid_postal_code", "postalcode: invalid CEP")
+
+// CEP is a Brazilian postal code in its canonical form, its 8 digits.
+type CEP string
+
+// Parse converts a postal code, with or without the dash of its mask, into
+// its canonical form. It returns ErrInvalidPostalCode if it is not 8 digits.
+func Parse(value string) (CEP, error) {
+	digits := strings.NewReplacer("-", "", ".", "", " ", "").Replace(strings.TrimSpace(value))
+	if len(digits) != length {
+		return "", ErrInvalidPostalCode
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return "", ErrInvalidPostalCode
+		}
+	}
+	return CEP(digits), nil
+}
+
+// Format returns the postal code with the dash of its mask, e.g. `01001-000`.
+// A postal code that is not valid is returned as it is stored.
+func (c CEP) Format() string {
+	if len(c) != length {
+		return string(c)
+	}
+	return string(c[:5]) + "-" + string(c[5:])
+}
+
+// Distance returns how far apart two postal codes are, the difference between
+// their numbers. The CEPs are assigned in ranges by region, state and city, so
+// closer numbers are usually closer places. It returns false if either postal
+// code is not valid.
+func (c CEP) Distance(other CEP) (int, bool) {
+	a, okA := c.number()
+	b, okB := other.number()
+	if !okA || !okB {
+		return 0, false
+	}
+	if a > b {
+		return a - b, true
+	}
+	return b - a, true
+}
+
+// number returns the postal code as a number, or false if it is not valid.
+func (c CEP) number() (int, bool) {
+	if len(c) != length {
+		return 0, false
+	}
+	n := 0
+	for i := 0; i < len(c); i++ {
+		if c[i] < '0' || c[i] > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c[i]-'0')
+	}
+	return n, true
+}
+
+// MarshalJSON encodes the postal code with the dash of its mask.
+func (c CEP) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Format())
+}
+
+// UnmarshalJSON decodes a postal code, masked or not, into its canonical form.
+// A value that is not a valid postal code is kept as it is.
+func (c *CEP) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if cep, err := Parse(value); err == nil {
+		value = string(cep)
+	}
+	*c = CEP(value)
+	return nil
+}
+
+// states maps the abbreviation (UF) of every Brazilian state to its name.
+var states = map[string]string{
+	"AC": "Acre", "AL": "Alagoas", "AP": "Amapá", "AM": "Amazonas",
+	"BA": "Bahia", "CE": "Ceará", "DF": "Distrito Federal", "ES": "Espírito Santo",
+	"GO": "Goiás", "MA": "Maranhão", "MT": "Mato Grosso", "MS": "Mato Grosso do Sul",
+	"MG": "Minas Gerais", "PA": "Pará", "PB": "Paraíba", "PR": "Paraná",
+	"PE": "Pernambuco", "PI": "Piauí", "RJ": "Rio de Janeiro", "RN": "Rio Grande do Norte",
+	"RS": "Rio Grande do Sul", "RO": "Rondônia", "RR": "Roraima", "SC": "Santa Catarina",
+	"SP": "São Paulo", "SE": "Sergipe", "TO": "Tocantins",
+}
+
+// ParseState returns the abbreviation (UF) of a Brazilian state given by its
+// abbreviation or its name, in any case and with or without accents, e.g. `SP`
+// for "sao paulo". It returns false if no state has the given name.
+func ParseState(value string) (string, bool) {
+	uf := strings.ToUpper(strings.TrimSpace(value))
+	if _, ok := states[uf]; ok {
+		return uf, true
+	}
+	for uf, name := range states {
+		if SameName(name, value) {
+			return uf, true
+		}
+	}
+	return "", false
+}
+
+// IsBrazil reports whether the country of an address is Brazil, or is not
+// given.
+func IsBrazil(country string) bool {
+	switch fold(country) {
+	case "", "br", "bra", "brasil", "brazil":
+		return true
+	default:
+		return false
+	}
+}
+
+// SameName reports whether two names of a place are the same regardless of
+// case, accents and surrounding spaces, e.g. "São Paulo" and "SAO PAULO".
+func SameName(a, b string) bool {
+	return fold(a) == fold(b)
+}
+
+// accents replaces the accented letters of Portuguese by their base letter.
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// fold converts a name into the form compared by SameName.
+func fold(name string) string {
+	return accents.Replace(strings.Join(strings.Fields(strings.ToLower(name)), " "))
+}
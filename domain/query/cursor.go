@@ -0,0 +1,55 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// cursor is the position of the last item of a page paginated by keyset. It
+// holds the id of the item and, when sorting by created_at, its creation time.
+type cursor struct {
+	ID        uint       `json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// decodeCursor decodes a cursor returned as next_cursor by a previous page. An
+// empty string is the cursor of the first page and decodes to nil.
+func decodeCursor(raw string, sort Sort) (*cursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if sort.Name == "created_at" && c.CreatedAt == nil {
+		return nil, fmt.Errorf("%w: cursor does not match the sort", ErrInvalidQuery)
+	}
+	return &c, nil
+}
+
+// encode returns the opaque string sent to clients as next_cursor.
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// cursorOf returns the cursor of an entity, read from the ID and CreatedAt
+// fields every entity has through gorm.Model.
+func cursorOf(item interface{}, sort Sort) cursor {
+	v := reflect.Indirect(reflect.ValueOf(item))
+	c := cursor{ID: uint(v.FieldByName("ID").Uint())}
+	if sort.Name == "created_at" {
+		createdAt := v.FieldByName("CreatedAt").Interface().(time.Time)
+		c.CreatedAt = &createdAt
+	}
+	return c
+}
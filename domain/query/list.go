@@ -0,0 +1,198 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"store/domain/apperrors"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is the page size used when none is given.
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page size a client may request.
+	MaxPageSize = 100
+)
+
+// ErrInvalidQuery is returned when a list query has an unknown field, operator
+// or parameter, or a value that does not match the type of its field.
+var ErrInvalidQuery = apperrors.BadRequest("invalid_query", "invalid list query")
+
+// Operator is a comparison used by a filter.
+type Operator string
+
+const (
+	Eq   Operator = "eq"   // equal to the value
+	Ne   Operator = "ne"   // not equal to the value
+	Lt   Operator = "lt"   // lower than the value
+	Lte  Operator = "lte"  // lower than or equal to the value
+	Gt   Operator = "gt"   // greater than the value
+	Gte  Operator = "gte"  // greater than or equal to the value
+	In   Operator = "in"   // equal to any of the comma separated values
+	Like Operator = "like" // matches the value, case insensitive, with `*` as wildcard
+)
+
+// sqlOperators maps the comparison operators to SQL.
+var sqlOperators = map[Operator]string{
+	Eq:  "=",
+	Ne:  "<>",
+	Lt:  "<",
+	Lte: "<=",
+	Gt:  ">",
+	Gte: ">=",
+}
+
+// reserved lists the query string parameters that are not filters.
+var reserved = map[string]bool{
+	"page":      true,
+	"page_size": true,
+	"cursor":    true,
+	"sort":      true,
+}
+
+// filterKey matches a filter parameter such as `name` or `created_at[gte]`.
+var filterKey = regexp.MustCompile(`^([a-z_]+)(?:\[([a-z]+)\])?$`)
+
+// Filter is a condition on a field of the entity.
+type Filter struct {
+	Field    Field         // field being filtered
+	Operator Operator      // comparison applied to the field
+	Values   []interface{} // values compared with, only more than one for In
+}
+
+// Sort is an ordering on a field of the entity.
+type Sort struct {
+	Name  string // name of the field in the query string
+	Field Field  // field being sorted on
+	Desc  bool   // whether the order is descending
+}
+
+// ListQuery is the pagination, sorting and filtering requested for a list
+// endpoint, validated against the Schema of the listed entity.
+//
+// It is parsed from query strings such as
+// `?page=2&page_size=50&sort=-created_at,name&name[like]=john&id[in]=1,2,3`.
+// When the `cursor` parameter is present, even empty, the list is paginated by
+// keyset instead of page number, which requires the first sort field to be
+// `id` or `created_at`.
+type ListQuery struct {
+	Page     int      // page number, starting at 1, when paginating by offset
+	PageSize int      // number of items per page
+	Cursor   *cursor  // position after which the page starts, when paginating by keyset
+	Sorts    []Sort   // ordering of the items, by id when none is given
+	Filters  []Filter // conditions the items must match
+	url      *url.URL // URL the query was parsed from, used to build the page links
+}
+
+// Parse parses and validates the list query of the given URL against the
+// schema of the listed entity. It returns an error wrapping ErrInvalidQuery
+// that describes the first invalid parameter.
+func Parse(u *url.URL, schema Schema) (*ListQuery, error) {
+	values := u.Query()
+	q := &ListQuery{Page: 1, PageSize: DefaultPageSize, url: u}
+
+	var err error
+	if q.Page, err = positiveInt(values, "page", 1); err != nil {
+		return nil, err
+	}
+	if q.PageSize, err = positiveInt(values, "page_size", DefaultPageSize); err != nil {
+		return nil, err
+	}
+	if q.PageSize > MaxPageSize {
+		return nil, fmt.Errorf("%w: page_size must be at most %d", ErrInvalidQuery, MaxPageSize)
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		for _, name := range strings.Split(sort, ",") {
+			desc := strings.HasPrefix(name, "-")
+			name = strings.TrimPrefix(name, "-")
+			field, ok := schema[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, name)
+			}
+			q.Sorts = append(q.Sorts, Sort{Name: name, Field: field, Desc: desc})
+		}
+	}
+	if len(q.Sorts) == 0 {
+		q.Sorts = []Sort{{Name: "id", Field: Field{Column: "id", Type: Number}}}
+	}
+
+	if q.Keyset() {
+		if q.Sorts[0].Name != "id" && q.Sorts[0].Name != "created_at" {
+			return nil, fmt.Errorf("%w: cursor pagination requires sorting by id or created_at first", ErrInvalidQuery)
+		}
+		if q.Cursor, err = decodeCursor(values.Get("cursor"), q.Sorts[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, vals := range values {
+		if reserved[key] {
+			continue
+		}
+		filter, err := parseFilter(key, vals, schema)
+		if err != nil {
+			return nil, err
+		}
+		q.Filters = append(q.Filters, filter...)
+	}
+
+	return q, nil
+}
+
+// parseFilter parses the values of a filter parameter such as `name[like]`.
+func parseFilter(key string, vals []string, schema Schema) ([]Filter, error) {
+	match := filterKey.FindStringSubmatch(key)
+	if match == nil {
+		return nil, fmt.Errorf("%w: unknown parameter %q", ErrInvalidQuery, key)
+	}
+	field, ok := schema[match[1]]
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot filter by %q", ErrInvalidQuery, match[1])
+	}
+	operator := Operator(match[2])
+	if operator == "" {
+		operator = Eq
+	}
+	if _, ok := sqlOperators[operator]; !ok && operator != In && operator != Like {
+		return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidQuery, operator)
+	}
+	if operator == Like && field.Type != String {
+		return nil, fmt.Errorf("%w: like can only filter text fields", ErrInvalidQuery)
+	}
+
+	var filters []Filter
+	for _, raw := range vals {
+		rawValues := []string{raw}
+		if operator == In {
+			rawValues = strings.Split(raw, ",")
+		}
+
+		filter := Filter{Field: field, Operator: operator}
+		for _, rawValue := range rawValues {
+			value, ok := field.parse(rawValue)
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid value %q for %q", ErrInvalidQuery, rawValue, match[1])
+			}
+			filter.Values = append(filter.Values, value)
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// positiveInt returns the positive integer query parameter named by key, or
+// fallback if it is not set.
+func positiveInt(values url.Values, key string, fallback int) (int, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("%w: %s must be a positive integer", ErrInvalidQuery, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,125 @@
+package query
+
+import (
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Page is the response envelope of a list endpoint.
+type Page[T any] struct {
+	Data       []T    `json:"data"`                  // items of the page
+	Page       int    `json:"page,omitempty"`        // page number, when paginating by offset
+	PageSize   int    `json:"page_size"`             // maximum number of items per page
+	Total      int64  `json:"total"`                 // number of items matching the filters
+	NextCursor string `json:"next_cursor,omitempty"` // cursor of the next page, when paginating by keyset
+	Links      Links  `json:"links"`                 // links to this and the next page
+}
+
+// Links holds the URLs of the current and the next page of a list.
+type Links struct {
+	Self string `json:"self"`           // URL of the current page
+	Next string `json:"next,omitempty"` // URL of the next page, empty on the last page
+}
+
+// Find lists the entities of type E matching the list query, using db as the
+// base statement so repositories can add preloads or conditions of their own.
+//
+// The filters are applied to both the total count and the page. Pages are
+// fetched by offset, or by keyset when the query has a cursor, always ordered
+// by id last so the order is stable.
+func Find[E any](db *gorm.DB, q *ListQuery) (*Page[*E], error) {
+	db = db.Session(&gorm.Session{})
+	page := &Page[*E]{Data: []*E{}, PageSize: q.PageSize, Links: Links{Self: q.url.String()}}
+
+	if err := q.filter(db.Model(new(E))).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	stmt := q.filter(db.Model(new(E)))
+	if q.Keyset() {
+		stmt = q.seek(stmt).Limit(q.PageSize + 1)
+	} else {
+		stmt = stmt.Limit(q.PageSize).Offset((q.Page - 1) * q.PageSize)
+	}
+	if err := q.order(stmt).Find(&page.Data).Error; err != nil {
+		return nil, err
+	}
+
+	if q.Keyset() {
+		if len(page.Data) > q.PageSize {
+			page.Data = page.Data[:q.PageSize]
+			page.NextCursor = cursorOf(page.Data[q.PageSize-1], q.Sorts[0]).encode()
+			page.Links.Next = q.link("cursor", page.NextCursor)
+		}
+	} else {
+		page.Page = q.Page
+		if int64(q.Page*q.PageSize) < page.Total {
+			page.Links.Next = q.link("page", strconv.Itoa(q.Page+1))
+		}
+	}
+	return page, nil
+}
+
+// Keyset reports whether the query paginates by keyset instead of offset.
+func (q *ListQuery) Keyset() bool {
+	_, ok := q.url.Query()["cursor"]
+	return ok
+}
+
+// filter adds the conditions of the filters to the statement.
+func (q *ListQuery) filter(stmt *gorm.DB) *gorm.DB {
+	for _, f := range q.Filters {
+		column := clause.Column{Name: f.Field.Column}
+		switch f.Operator {
+		case In:
+			stmt = stmt.Where("? IN ?", column, f.Values)
+		case Like:
+			stmt = stmt.Where("? ILIKE ?", column, likePattern(f.Values[0].(string)))
+		default:
+			stmt = stmt.Where("? "+sqlOperators[f.Operator]+" ?", column, f.Values[0])
+		}
+	}
+	return stmt
+}
+
+// order adds the sorting of the query to the statement. When paginating by
+// keyset, only the first sort field is used, followed by id in the same direction.
+func (q *ListQuery) order(stmt *gorm.DB) *gorm.DB {
+	sorts := q.Sorts
+	if q.Keyset() {
+		sorts = sorts[:1]
+	}
+	for _, s := range sorts {
+		stmt = stmt.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Field.Column}, Desc: s.Desc})
+	}
+	if sorts[len(sorts)-1].Name != "id" {
+		stmt = stmt.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: sorts[0].Desc && q.Keyset()})
+	}
+	return stmt
+}
+
+// seek adds the keyset condition that starts the page after the cursor.
+func (q *ListQuery) seek(stmt *gorm.DB) *gorm.DB {
+	if q.Cursor == nil {
+		return stmt
+	}
+	comparison := ">"
+	if q.Sorts[0].Desc {
+		comparison = "<"
+	}
+	if q.Sorts[0].Name == "created_at" {
+		return stmt.Where("(created_at, id) "+comparison+" (?, ?)", *q.Cursor.CreatedAt, q.Cursor.ID)
+	}
+	return stmt.Where("id "+comparison+" ?", q.Cursor.ID)
+}
+
+// link returns the URL of the query with the given parameter replaced.
+func (q *ListQuery) link(key, value string) string {
+	u := *q.url
+	values := u.Query()
+	values.Set(key, value)
+	u.RawQuery = values.Encode()
+	return u.String()
+}
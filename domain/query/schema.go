@@ -0,0 +1,80 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the type of a field that can be filtered or sorted on, used to
+// validate and convert the values given in the query string.
+type FieldType int
+
+const (
+	String FieldType = iota // text column
+	Number                  // integer or decimal column
+	Time                    // timestamp or date column, given as RFC 3339 or YYYY-MM-DD
+	Bool                    // boolean column
+)
+
+// Field describes a field of an entity that clients may filter and sort on.
+type Field struct {
+	Column    string              // database column of the field
+	Type      FieldType           // type of the values of the field
+	Normalize func(string) string // optional conversion of the values into their stored form, e.g. unmasking a tax ID
+}
+
+// Schema is the allow-list of the fields of an entity that can be used in a
+// list query, keyed by the name used in the query string.
+type Schema map[string]Field
+
+// Model returns a Schema with the id, created_at and updated_at fields every
+// entity inherits from gorm.Model, plus the given fields.
+func Model(fields Schema) Schema {
+	schema := Schema{
+		"id":         {Column: "id", Type: Number},
+		"created_at": {Column: "created_at", Type: Time},
+		"updated_at": {Column: "updated_at", Type: Time},
+	}
+	for name, field := range fields {
+		schema[name] = field
+	}
+	return schema
+}
+
+// parse converts a query string value into a value of the field type, after
+// its normalization when the field has one.
+func (f Field) parse(value string) (interface{}, bool) {
+	if f.Normalize != nil {
+		value = f.Normalize(value)
+	}
+	switch f.Type {
+	case Number:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i, true
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		return n, err == nil
+	case Time:
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, true
+		}
+		t, err := time.Parse("2006-01-02", value)
+		return t, err == nil
+	case Bool:
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	default:
+		return value, true
+	}
+}
+
+// likePattern converts a like filter value into an SQL pattern. A `*` matches
+// any text; a value without `*` matches anywhere in the column.
+func likePattern(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+	if !strings.Contains(value, "*") {
+		return "%" + value + "%"
+	}
+	return strings.ReplaceAll(value, "*", "%")
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"store/domain/entities"
+	"store/domain/postalcode"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// importBatchSize is the number of addresses inserted by each statement of an
+// import, as a postal code dataset has hundreds of thousands of rows.
+const importBatchSize = 1000
+
+// AddressRepository is an interface that defines the methods that must be
+// implemented by any data store that wants to interact with the addresses
+// table in the database.
+//
+// It provides methods for finding the address of a postal code and importing
+// the addresses of a postal code dataset.
+type AddressRepository interface {
+	GetByPostalCode(ctx *gin.Context, postalCode postalcode.CEP) (*entities.Address, error) // Get the address of a postal code
+	Import(ctx *gin.Context, addresses []*entities.Address) error                           // Create or replace multiple addresses
+}
+
+// addressRepository is a struct that contains a pointer to a gorm DB instance
+// and implements the AddressRepository.
+type addressRepository struct {
+	db *gorm.DB
+}
+
+// NewAddressRepository creates a new instance of addressRepository with the
+// provided database instance and returns it as an AddressRepository.
+func NewAddressRepository(db *gorm.DB) AddressRepository {
+	return &addressRepository{db: db}
+}
+
+// Retrieves the address of a postal code.
+//
+// The method takes a pointer to a *gin.Context and the canonical postal code as
+// parameters. It returns the address, or gorm.ErrRecordNotFound if the postal
+// code is not in the dataset.
+func (r *addressRepository) GetByPostalCode(ctx *gin.Context, postalCode postalcode.CEP) (*entities.Address, error) {
+	var address entities.Address
+	err := r.db.WithContext(ctx).Where("postal_code = ?", postalCode).First(&address).Error
+	return &address, err
+}
+
+// Creates or replaces multiple addresses in a single transaction.
+//
+// Addresses whose postal code already exists replace the stored ones, restoring
+// them if they were deleted, so a newer dataset can be imported over an older
+// one. Either every address is saved or none is.
+func (r *addressRepository) Import(ctx *gin.Context, addresses []*entities.Address) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "postal_code"}},
+				DoUpdates: clause.AssignmentColumns([]string{"street", "district", "city", "state", "updated_at", "deleted_at"}),
+			}).
+			CreateInBatches(&addresses, importBatchSize).
+			Error
+	})
+}
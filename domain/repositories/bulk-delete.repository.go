@@ -0,0 +1,286 @@
+package repositories
+
+import (
+	"fmt"
+	"store/domain/apperrors"
+	"store/domain/entities"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxDeleteIDs is the largest number of ids accepted by a single bulk delete.
+const MaxDeleteIDs = 1000
+
+var (
+	// ErrNoIDs is returned when a bulk delete is requested without any id, so
+	// an empty list never reaches the database.
+	ErrNoIDs = apperrors.BadRequest("no_ids", "at least one id is required")
+	// ErrTooManyIDs is returned when a bulk delete has more than MaxDeleteIDs ids.
+	ErrTooManyIDs = apperrors.BadRequest("too_many_ids", fmt.Sprintf("at most %d ids can be deleted at once", MaxDeleteIDs))
+)
+
+// DeleteStatus is the outcome of the deletion of one id in a bulk delete.
+type DeleteStatus string
+
+const (
+	DeleteStatusDeleted  DeleteStatus = "deleted"   // the entity was deleted
+	DeleteStatusNotFound DeleteStatus = "not_found" // no entity has the id
+	DeleteStatusBlocked  DeleteStatus = "blocked"   // the entity is still referenced and was kept
+)
+
+// DeleteResult is the outcome of the deletion of one id in a bulk delete.
+type DeleteResult struct {
+	ID     uint         `json:"id"`               // id requested
+	Status DeleteStatus `json:"status"`           // outcome of the deletion
+	Reason string       `json:"reason,omitempty"` // why the entity was kept, when blocked
+}
+
+// blocker returns, for the ids among the given ones whose entity cannot be
+// deleted, the reason why.
+type blocker func(tx *gorm.DB, ids []uint) (map[uint]string, error)
+
+// referencedBy returns a blocker that keeps the entities referenced by the
+// column of the rows of model that are not deleted. The reason given is
+// "referenced by" followed by name.
+func referencedBy(model interface{}, column, name string) blocker {
+	return func(tx *gorm.DB, ids []uint) (map[uint]string, error) {
+		var referenced []uint
+		err := tx.Model(model).
+			Where(column+" IN ?", ids).
+			Distinct().
+			Pluck(column, &referenced).
+			Error
+		if err != nil {
+			return nil, err
+		}
+
+		blocked := make(map[uint]string, len(referenced))
+		for _, id := range referenced {
+			blocked[id] = "referenced by " + name
+		}
+		return blocked, nil
+	}
+}
+
+// deleteAll deletes the entities of model with the given ids in a single
+// transaction and returns the outcome for each distinct id, in the order they
+// were given.
+//
+// The existing entities are locked, so no reference can be added while the
+// blockers are checked. Entities that do not exist are reported as not found,
+// and entities for which a blocker gives a reason are kept and reported as
+// blocked. Every other entity is deleted. It returns ErrNoIDs if ids is empty
+// and ErrTooManyIDs if it has more than MaxDeleteIDs ids.
+func deleteAll(db *gorm.DB, model interface{}, ids []uint, blockers ...blocker) ([]DeleteResult, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil, ErrNoIDs
+	}
+	if len(ids) > MaxDeleteIDs {
+		return nil, ErrTooManyIDs
+	}
+
+	results := make([]DeleteResult, len(ids))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		err := tx.Model(model).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).
+			Pluck("id", &existing).
+			Error
+		if err != nil {
+			return err
+		}
+
+		blocked := map[uint]string{}
+		if len(existing) > 0 {
+			for _, block := range blockers {
+				reasons, err := block(tx, existing)
+				if err != nil {
+					return err
+				}
+				for id, reason := range reasons {
+					if _, ok := blocked[id]; !ok {
+						blocked[id] = reason
+					}
+				}
+			}
+		}
+
+		found := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			found[id] = true
+		}
+		var deletable []uint
+		for i, id := range ids {
+			reason, isBlocked := blocked[id]
+			switch {
+			case !found[id]:
+				results[i] = DeleteResult{ID: id, Status: DeleteStatusNotFound}
+			case isBlocked:
+				results[i] = DeleteResult{ID: id, Status: DeleteStatusBlocked, Reason: reason}
+			default:
+				results[i] = DeleteResult{ID: id, Status: DeleteStatusDeleted}
+				deletable = append(deletable, id)
+			}
+		}
+
+		if len(deletable) == 0 {
+			return nil
+		}
+		return tx.Delete(model, deletable).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// deleteUnlessBlocked soft deletes the entity of type E with the given ID if it
+// is still at the given version, as done by deleteVersioned, applying the
+// blockers of its bulk delete. The entity is locked before the blockers are
+// checked, as done by deleteAll, and the first reason given by a blocker is
+// returned as blocked with the reason.
+func deleteUnlessBlocked[E any](db *gorm.DB, id uint, version uint, blocked *apperrors.Error, blockers ...blocker) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockVersion[E](tx, id, version); err != nil {
+			return err
+		}
+		for _, block := range blockers {
+			reasons, err := block(tx, []uint{id})
+			if err != nil {
+				return err
+			}
+			if reason, ok := reasons[id]; ok {
+				return blocked.With("reason", reason)
+			}
+		}
+		return tx.Delete(new(E), id).Error
+	})
+}
+
+// orderNotDraft is the blocker of order lines, which can only be deleted while
+// their order is a draft.
+func orderNotDraft(tx *gorm.DB, ids []uint) (map[uint]string, error) {
+	var locked []uint
+	err := tx.Model(&entities.OrderProductSupplier{}).
+		Joins("JOIN sales.orders ON orders.id = order_product_suppliers.order_id").
+		Where("order_product_suppliers.id IN ? AND orders.status <> ?", ids, entities.OrderStatusDraft).
+		Pluck("order_product_suppliers.id", &locked).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make(map[uint]string, len(locked))
+	for _, id := range locked {
+		blocked[id] = "order is not a draft"
+	}
+	return blocked, nil
+}
+
+// orderInProgress is the blocker of orders, which can only be deleted while
+// they are drafts or once they are cancelled, as the stock of the other ones
+// has been consumed.
+func orderInProgress(tx *gorm.DB, ids []uint) (map[uint]string, error) {
+	var locked []uint
+	err := tx.Model(&entities.Order{}).
+		Where("id IN ? AND status NOT IN ?", ids,
+			[]entities.OrderStatus{entities.OrderStatusDraft, entities.OrderStatusCancelled}).
+		Pluck("id", &locked).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make(map[uint]string, len(locked))
+	for _, id := range locked {
+		blocked[id] = "order is neither a draft nor cancelled"
+	}
+	return blocked, nil
+}
+
+// purchaseOrderNotDraft is the blocker of purchase orders, which can only be
+// deleted while they are drafts.
+func purchaseOrderNotDraft(tx *gorm.DB, ids []uint) (map[uint]string, error) {
+	var locked []uint
+	err := tx.Model(&entities.PurchaseOrder{}).
+		Where("id IN ? AND status <> ?", ids, entities.PurchaseOrderStatusDraft).
+		Pluck("id", &locked).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make(map[uint]string, len(locked))
+	for _, id := range locked {
+		blocked[id] = "purchase order is not a draft"
+	}
+	return blocked, nil
+}
+
+// defaultWarehouse is the blocker of the default warehouse, which is kept
+// until another warehouse is made the default one.
+func defaultWarehouse(tx *gorm.DB, ids []uint) (map[uint]string, error) {
+	var defaults []uint
+	err := tx.Model(&entities.Warehouse{}).
+		Where("id IN ? AND is_default", ids).
+		Pluck("id", &defaults).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make(map[uint]string, len(defaults))
+	for _, id := range defaults {
+		blocked[id] = "default warehouse"
+	}
+	return blocked, nil
+}
+
+// warehouseHasStock is the blocker of the warehouses that still have units on
+// hand, or that units in transit leave or go to.
+func warehouseHasStock(tx *gorm.DB, ids []uint) (map[uint]string, error) {
+	var stocked []uint
+	err := tx.Model(&entities.WarehouseStock{}).
+		Where("warehouse_id IN ? AND quantity <> 0", ids).
+		Distinct().
+		Pluck("warehouse_id", &stocked).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []entities.StockTransfer
+	err = tx.Select("from_warehouse_id, to_warehouse_id").
+		Where("(from_warehouse_id IN ? OR to_warehouse_id IN ?) AND status = ?", ids, ids, entities.StockTransferStatusInTransit).
+		Find(&transfers).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make(map[uint]string, len(stocked))
+	for _, transfer := range transfers {
+		blocked[transfer.FromWarehouseID] = "stock transfers in transit"
+		blocked[transfer.ToWarehouseID] = "stock transfers in transit"
+	}
+	for _, id := range stocked {
+		blocked[id] = "stock on hand"
+	}
+	return blocked, nil
+}
+
+// uniqueIDs returns the ids without duplicates, keeping their order.
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -15,14 +16,17 @@ import (
 // getting all customers, updating a contact, deleting a contact, and getting a
 // contact with its orders or contact.
 type ContactRepository interface {
-	Create(ctx *gin.Context, contact *entities.Contact) error                          // Create a new contact
-	GetByID(ctx *gin.Context, id uint) (*entities.Contact, error)                      // Get a contact by ID
-	GetAll(ctx *gin.Context) ([]*entities.Contact, error)                              // Get all contacts
-	Update(ctx *gin.Context, contact *entities.Contact) error                          // Update a contact
-	Delete(ctx *gin.Context, id uint) error                                            // Delete a contact
-	DeleteAll(ctx *gin.Context, ids []uint) error                                      // Delete multiple contacts
-	GetAllByCustomerID(ctx *gin.Context, customerID uint) ([]*entities.Contact, error) // Get all contacts by customer ID
-	GetAllBySupplierID(ctx *gin.Context, supplierID uint) ([]*entities.Contact, error) // Get all contacts by supplier ID
+	Create(ctx *gin.Context, contact *entities.Contact) error                                                              // Create a new contact
+	GetByID(ctx *gin.Context, id uint) (*entities.Contact, error)                                                          // Get a contact by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Contact], error)                                   // Get all contacts
+	Update(ctx *gin.Context, contact *entities.Contact, fields ...string) error                                            // Update a contact
+	Delete(ctx *gin.Context, id uint, version uint) error                                                                  // Delete a contact
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                                                        // Delete multiple contacts
+	GetAllByCustomerID(ctx *gin.Context, customerID uint) ([]*entities.Contact, error)                                     // Get all contacts by customer ID
+	GetAllBySupplierID(ctx *gin.Context, supplierID uint) ([]*entities.Contact, error)                                     // Get all contacts by supplier ID
+	FindInBatches(ctx *gin.Context, size int, fn func([]*entities.Contact) error) error                                    // Go through all contacts in batches
+	GetDefaultByCustomerID(ctx *gin.Context, customerID uint, contactType entities.ContactType) (*entities.Contact, error) // Get the default contact of a type of a customer
+	TrashRepository[entities.Contact]                                                                                      // Get, restore and purge deleted contacts
 }
 
 // contactRepository is a struct that contains a pointer to a gorm DB instance and
@@ -32,6 +36,7 @@ type ContactRepository interface {
 // with the contacts table in the database.
 type contactRepository struct {
 	db *gorm.DB
+	trashRepository[entities.Contact]
 }
 
 // NewContactRepository creates a new instance of contactRepository with the provided
@@ -39,7 +44,7 @@ type contactRepository struct {
 // This function is used to initialize a new contact repository that can perform
 // CRUD operations and other queries on the contacts table.
 func NewContactRepository(db *gorm.DB) ContactRepository {
-	return &contactRepository{db: db}
+	return &contactRepository{db: db, trashRepository: trashRepository[entities.Contact]{db: db}}
 }
 
 // Creates a new contact in the database.
@@ -53,8 +58,22 @@ func NewContactRepository(db *gorm.DB) ContactRepository {
 //
 // The method returns an error if something goes wrong. If the contact is created
 // successfully, the method returns nil.
+//
+// A contact created as the default of its type replaces the previous default
+// of its owner, and the first contact of a type of an owner always becomes its
+// default, in the same transaction.
 func (r *contactRepository) Create(ctx *gin.Context, contact *entities.Contact) error {
-	return r.db.WithContext(ctx).Create(contact).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if contact.IsDefault {
+			if err := clearDefault(tx, contact); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(contact).Error; err != nil {
+			return err
+		}
+		return ensureDefault(tx, contact, contact)
+	})
 }
 
 // Retrieves a contact by its ID from the database.
@@ -73,19 +92,30 @@ func (r *contactRepository) GetByID(ctx *gin.Context, id uint) (*entities.Contac
 	return &contact, err
 }
 
-// Retrieves all contacts from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.Contact and an error. If something goes wrong, the
-// method returns an empty slice and an error.
+// ContactListSchema lists the fields of a contact that can be used to filter
+// and sort the contacts in a list query.
+var ContactListSchema = query.Model(query.Schema{
+	"phone":       {Column: "phone", Type: query.String},
+	"email":       {Column: "email", Type: query.String},
+	"postal_code": {Column: "postal_code", Type: query.String},
+	"city":        {Column: "city", Type: query.String},
+	"state":       {Column: "state", Type: query.String},
+	"country":     {Column: "country", Type: query.String},
+	"customer_id": {Column: "customer_id", Type: query.Number},
+	"supplier_id": {Column: "supplier_id", Type: query.Number},
+	"type":        {Column: "type", Type: query.String},
+	"is_default":  {Column: "is_default", Type: query.Bool},
+})
+
+// Retrieves a page of contacts from the database.
 //
-// The method retrieves all contacts from the database. If the contacts are found,
-// the method returns the contacts and nil. If no contacts are found or an error
-// occurs, the method returns an empty slice and an error.
-func (r *contactRepository) GetAll(ctx *gin.Context) ([]*entities.Contact, error) {
-	var contacts []*entities.Contact
-	err := r.db.WithContext(ctx).Find(&contacts).Error
-	return contacts, err
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against ContactListSchema. It returns the page of
+// contacts matching the filters of the query, in the requested order, along
+// with the total count and the link to the next page. If something goes wrong,
+// the method returns nil and an error.
+func (r *contactRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Contact], error) {
+	return query.Find[entities.Contact](r.db.WithContext(ctx), q)
 }
 
 // Updates a contact in the database.
@@ -99,8 +129,37 @@ func (r *contactRepository) GetAll(ctx *gin.Context) ([]*entities.Contact, error
 //
 // The method returns an error if something goes wrong. If the contact is updated
 // successfully, the method returns nil.
-func (r *contactRepository) Update(ctx *gin.Context, contact *entities.Contact) error {
-	return r.db.WithContext(ctx).Save(contact).Error
+//
+// The update is based on the Version of the contact, which is incremented. It
+// returns gorm.ErrRecordNotFound if the contact does not exist, or
+// ErrVersionConflict if it has been changed since that version.
+//
+// When fields are given, only those fields of the contact, named as in its
+// struct, are saved, as done for partial updates.
+//
+// A contact made the default of its type replaces the previous default of its
+// owner. A type left without default, because its default contact is no longer
+// the default or changed type, gets its oldest contact as default, which may
+// be the updated contact itself.
+func (r *contactRepository) Update(ctx *gin.Context, contact *entities.Contact, fields ...string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous entities.Contact
+		if err := tx.First(&previous, contact.ID).Error; err != nil {
+			return err
+		}
+		if contact.IsDefault {
+			if err := clearDefault(tx, contact); err != nil {
+				return err
+			}
+		}
+		if err := updateVersioned(tx, contact, contact.ID, &contact.Version, fields...); err != nil {
+			return err
+		}
+		if err := ensureDefault(tx, &previous, contact); err != nil {
+			return err
+		}
+		return ensureDefault(tx, contact, contact)
+	})
 }
 
 // Deletes a contact from the database.
@@ -111,20 +170,51 @@ func (r *contactRepository) Update(ctx *gin.Context, contact *entities.Contact)
 // The method deletes a contact from the database using the given ID.
 // If the contact is deleted successfully, the method returns nil. If the contact
 // is not found or an error occurs, the method returns an error.
-func (r *contactRepository) Delete(ctx *gin.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.Contact{}, id).Error
+//
+// The contact is only deleted if it is still at the given version, or whatever
+// its version is with AnyVersion. It returns gorm.ErrRecordNotFound if the
+// contact does not exist, or ErrVersionConflict if it has been changed since
+// that version.
+//
+// A deleted default contact is no longer the default of its type, which gets
+// the oldest remaining contact of its owner as default.
+func (r *contactRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteVersioned[entities.Contact](tx, id, version); err != nil {
+			return err
+		}
+		return reassignDefaults(tx, []uint{id})
+	})
 }
 
 // Deletes multiple contacts from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple contacts from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the contacts are
-// deleted successfully, the method returns nil.
-func (r *contactRepository) DeleteAll(ctx *gin.Context, ids []uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.Contact{}, ids).Error
+// The method takes a pointer to a *gin.Context and a slice of uints as
+// parameters. It deletes the contacts in a single transaction and returns the
+// outcome for each id: deleted or not found. The default contacts deleted are
+// replaced as done by Delete. It returns ErrNoIDs if no id is given, or an
+// error if something goes wrong, in which case nothing is deleted.
+func (r *contactRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	var results []DeleteResult
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		results, err = deleteAll(tx, &entities.Contact{}, ids)
+		if err != nil {
+			return err
+		}
+
+		var deleted []uint
+		for _, result := range results {
+			if result.Status == DeleteStatusDeleted {
+				deleted = append(deleted, result.ID)
+			}
+		}
+		return reassignDefaults(tx, deleted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
 // Retrieves all contacts from the database that belong to the
@@ -158,3 +248,108 @@ func (r *contactRepository) GetAllBySupplierID(ctx *gin.Context, supplierID uint
 	err := r.db.WithContext(ctx).Where("supplier_id = ?", supplierID).Find(&contacts).Error
 	return contacts, err
 }
+
+// Retrieves all contacts from the database in batches.
+//
+// The method loads the contacts that are not deleted in batches of the given
+// size, ordered by ID, and calls fn with each batch. It stops at the first
+// error returned by fn or by the retrieval and returns it.
+func (r *contactRepository) FindInBatches(ctx *gin.Context, size int, fn func([]*entities.Contact) error) error {
+	var contacts []*entities.Contact
+	return r.db.WithContext(ctx).FindInBatches(&contacts, size, func(*gorm.DB, int) error {
+		return fn(contacts)
+	}).Error
+}
+
+// Retrieves the default contact of the given type of a customer.
+//
+// The method takes a pointer to a *gin.Context, the ID of the customer and the
+// type of contact. It returns gorm.ErrRecordNotFound if the customer has no
+// contact of that type.
+func (r *contactRepository) GetDefaultByCustomerID(ctx *gin.Context, customerID uint, contactType entities.ContactType) (*entities.Contact, error) {
+	var contact entities.Contact
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ? AND type = ? AND is_default", customerID, contactType).
+		First(&contact).
+		Error
+	return &contact, err
+}
+
+// ownedAs restricts tx to the contacts with the same owner and type as the
+// given contact.
+func ownedAs(tx *gorm.DB, contact *entities.Contact) *gorm.DB {
+	return tx.Model(&entities.Contact{}).Where("customer_id = ? AND supplier_id = ? AND type = ?",
+		contact.CustomerID, contact.SupplierID, contact.Type)
+}
+
+// clearDefault removes the default flag of the other contacts with the same
+// owner and type as the given contact, which is made their default. It must
+// be called inside a transaction.
+func clearDefault(tx *gorm.DB, contact *entities.Contact) error {
+	return ownedAs(tx, contact).
+		Where("id <> ? AND is_default", contact.ID).
+		UpdateColumns(map[string]interface{}{"is_default": false, "version": nextVersion}).
+		Error
+}
+
+// ensureDefault makes the oldest contact with the same owner and type as the
+// given group contact their default, if none of them is. When the saved contact
+// is the one made default, its flag and version are updated accordingly. It
+// must be called inside a transaction.
+func ensureDefault(tx *gorm.DB, group, saved *entities.Contact) error {
+	var defaults int64
+	if err := ownedAs(tx, group).Where("is_default").Count(&defaults).Error; err != nil {
+		return err
+	}
+	if defaults > 0 {
+		return nil
+	}
+
+	var oldest []uint
+	if err := ownedAs(tx, group).Order("id").Limit(1).Pluck("id", &oldest).Error; err != nil {
+		return err
+	}
+	if len(oldest) == 0 {
+		return nil
+	}
+	err := tx.Model(&entities.Contact{}).
+		Where("id = ?", oldest[0]).
+		UpdateColumns(map[string]interface{}{"is_default": true, "version": nextVersion}).
+		Error
+	if err != nil {
+		return err
+	}
+	if oldest[0] == saved.ID {
+		saved.IsDefault = true
+		saved.Version++
+	}
+	return nil
+}
+
+// reassignDefaults removes the default flag of the deleted contacts with the
+// given ids, so they are never restored as a second default, and gives their
+// types a new default. It must be called inside a transaction.
+func reassignDefaults(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var contacts []*entities.Contact
+	if err := tx.Unscoped().Where("id IN ? AND is_default", ids).Find(&contacts).Error; err != nil {
+		return err
+	}
+	for _, contact := range contacts {
+		err := tx.Unscoped().
+			Model(&entities.Contact{}).
+			Where("id = ?", contact.ID).
+			UpdateColumn("is_default", false).
+			Error
+		if err != nil {
+			return err
+		}
+		if err := ensureDefault(tx, contact, contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
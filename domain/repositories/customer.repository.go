@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
+	"store/domain/taxid"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -15,13 +17,16 @@ import (
 // getting all customers, updating a customer, deleting a customer, and getting a
 // customer with its orders or contact.
 type CustomerRepository interface {
-	Create(ctx *gin.Context, customer *entities.Customer) error                   // Create a new customer
-	GetByID(ctx *gin.Context, id uint) (*entities.Customer, error)                // Get a customer by ID
-	GetAll(ctx *gin.Context) ([]*entities.Customer, error)                        // Get all customers
-	Update(ctx *gin.Context, customer *entities.Customer) error                   // Update a customer
-	Delete(ctx *gin.Context, id uint) error                                       // Delete a customer
-	DeleteAll(ctx *gin.Context, ids []uint) error                                 // Delete multiple customers	GetCustomerWithOrders(ctx *gin.Context, id uint) (*entities.Customer, error)  // Get a customer with orders
-	GetCustomerWithContact(ctx *gin.Context, id uint) (*entities.Customer, error) // Get a customer with contact
+	Create(ctx *gin.Context, customer *entities.Customer) error                           // Create a new customer
+	GetByID(ctx *gin.Context, id uint) (*entities.Customer, error)                        // Get a customer by ID
+	GetByTaxID(ctx *gin.Context, taxID taxid.TaxID) (*entities.Customer, error)           // Get a customer by its CPF or CNPJ
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Customer], error) // Get all customers
+	Update(ctx *gin.Context, customer *entities.Customer, fields ...string) error         // Update a customer
+	Delete(ctx *gin.Context, id uint, version uint) error                                 // Delete a customer
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                       // Delete multiple customers
+	GetCustomerWithOrders(ctx *gin.Context, id uint) (*entities.Customer, error)          // Get a customer with orders
+	GetCustomerWithContacts(ctx *gin.Context, id uint) (*entities.Customer, error)        // Get a customer with contacts
+	TrashRepository[entities.Customer]                                                    // Get, restore and purge deleted customers
 }
 
 // customerRepository is a struct that contains a pointer to a gorm DB instance and
@@ -31,6 +36,7 @@ type CustomerRepository interface {
 // with the customers table in the database.
 type customerRepository struct {
 	db *gorm.DB
+	trashRepository[entities.Customer]
 }
 
 // NewCustomerRepository creates a new instance of contactRepository with the provided
@@ -38,7 +44,14 @@ type customerRepository struct {
 // This function is used to initialize a new product repository that can perform
 // CRUD operations and other queries on the products table.
 func NewCustomerRepository(db *gorm.DB) CustomerRepository {
-	return &customerRepository{db: db}
+	return &customerRepository{
+		db: db,
+		trashRepository: trashRepository[entities.Customer]{
+			db:       db,
+			cascades: []dependent{{model: &entities.Contact{}, column: "customer_id"}},
+			purges:   []dependent{{model: &entities.Contact{}, column: "customer_id"}},
+		},
+	}
 }
 
 // Creates a new customer in the database.
@@ -78,23 +91,42 @@ func (r *customerRepository) GetByID(ctx *gin.Context, id uint) (*entities.Custo
 	return &customer, err
 }
 
-// Retrieves all customers from the database.
+// Retrieves a customer by its tax ID.
 //
-// This method is a part of the CustomerRepository and is used to get all
-// customers from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a
-// slice of pointers to entities.Customer and an error. If something goes wrong,
-// the method returns an empty slice and an error.
+// The method takes a pointer to a *gin.Context and the canonical CPF or CNPJ of
+// the customer as parameters. It returns the customer, or
+// gorm.ErrRecordNotFound if no customer has the given tax ID.
+func (r *customerRepository) GetByTaxID(ctx *gin.Context, taxID taxid.TaxID) (*entities.Customer, error) {
+	var customer entities.Customer
+	err := r.db.WithContext(ctx).Where("tax_id = ?", taxID).First(&customer).Error
+	return &customer, err
+}
+
+// normalizeTaxID converts a tax ID given in a list query, masked or not, into
+// the canonical form it is stored in.
+func normalizeTaxID(value string) string {
+	return string(taxid.Normalize(value))
+}
+
+// CustomerListSchema lists the fields of a customer that can be used to filter
+// and sort the customers in a list query.
+var CustomerListSchema = query.Model(query.Schema{
+	"first_name":       {Column: "first_name", Type: query.String},
+	"last_name":        {Column: "last_name", Type: query.String},
+	"birthday":         {Column: "birthday", Type: query.Time},
+	"tax_id":           {Column: "tax_id", Type: query.String, Normalize: normalizeTaxID},
+	"billing_currency": {Column: "billing_currency", Type: query.String},
+})
+
+// Retrieves a page of customers from the database.
 //
-// The method gets all customers from the database. The method returns a slice of
-// pointers to entities.Customer and an error. If the customers are found, the
-// method returns the customers and nil. If no customers are found, the method
-// returns an empty slice and an error.
-func (r *customerRepository) GetAll(ctx *gin.Context) ([]*entities.Customer, error) {
-	var customers []*entities.Customer
-	err := r.db.WithContext(ctx).Find(&customers).Error
-	return customers, err
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against CustomerListSchema. It returns the page of
+// customers matching the filters of the query, in the requested order, along
+// with the total count and the link to the next page. If something goes wrong,
+// the method returns nil and an error.
+func (r *customerRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Customer], error) {
+	return query.Find[entities.Customer](r.db.WithContext(ctx), q)
 }
 
 // Updates a customer in the database.
@@ -111,8 +143,15 @@ func (r *customerRepository) GetAll(ctx *gin.Context) ([]*entities.Customer, err
 //
 // The method returns an error if something goes wrong. If the customer is updated
 // successfully, the method returns nil.
-func (r *customerRepository) Update(ctx *gin.Context, customer *entities.Customer) error {
-	return r.db.WithContext(ctx).Save(customer).Error
+//
+// The update is based on the Version of the customer, which is incremented. It
+// returns gorm.ErrRecordNotFound if the customer does not exist, or
+// ErrVersionConflict if it has been changed since that version.
+//
+// When fields are given, only those fields of the customer, named as in its
+// struct, are saved, as done for partial updates.
+func (r *customerRepository) Update(ctx *gin.Context, customer *entities.Customer, fields ...string) error {
+	return updateVersioned(r.db.WithContext(ctx), customer, customer.ID, &customer.Version, fields...)
 }
 
 // Deletes a customer from the database.
@@ -126,22 +165,55 @@ func (r *customerRepository) Update(ctx *gin.Context, customer *entities.Custome
 // The method deletes a customer from the database using the given ID.
 // The method returns an error if something goes wrong. If the customer is deleted
 // successfully, the method returns nil.
-func (r *customerRepository) Delete(ctx *gin.Context, id uint) error {
-	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&entities.Customer{}).Error
+//
+// The customer is only deleted if it is still at the given version, or whatever
+// its version is with AnyVersion. It returns gorm.ErrRecordNotFound if the
+// customer does not exist, ErrVersionConflict if it has been changed since
+// that version, or ErrStillReferenced if it has orders. The contacts of the
+// customer are deleted along with it, and restored with it.
+func (r *customerRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteUnlessBlocked[entities.Customer](tx, id, version, ErrStillReferenced, customerBlockers...); err != nil {
+			return err
+		}
+		return r.deleteCascades(tx, []uint{id})
+	})
 }
 
 // Deletes multiple customers from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple customers from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the customers
-// are deleted successfully, the method returns nil.
-func (r *customerRepository) DeleteAll(ctx *gin.Context, ids []uint) error {
-	return r.db.WithContext(ctx).Where("id in (?)", ids).Delete(&entities.Customer{}).Error
+// The method takes a pointer to a *gin.Context and a slice of uints as
+// parameters. It deletes the customers in a single transaction and returns the
+// outcome for each id: deleted, not found or blocked. Customers with orders are
+// kept, and the contacts of the deleted ones are deleted along with them. It
+// returns ErrNoIDs if no id is given, or an error if something goes
+// wrong, in which case nothing is deleted.
+func (r *customerRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	var results []DeleteResult
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		results, err = deleteAll(tx, &entities.Customer{}, ids, customerBlockers...)
+		if err != nil {
+			return err
+		}
+
+		var deleted []uint
+		for _, result := range results {
+			if result.Status == DeleteStatusDeleted {
+				deleted = append(deleted, result.ID)
+			}
+		}
+		return r.deleteCascades(tx, deleted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
+// customerBlockers keep the customers with orders.
+var customerBlockers = []blocker{referencedBy(&entities.Order{}, "customer_id", "orders")}
+
 // Retrieves a customer by its ID with its orders.
 //
 // The method takes a pointer to a *gin.Context and a uint as parameters. It
@@ -162,20 +234,20 @@ func (r *customerRepository) GetCustomerWithOrders(ctx *gin.Context, id uint) (*
 	return &customer, err
 }
 
-// Retrieves a customer by its ID with its contact information.
+// Retrieves a customer by its ID with its contacts.
 //
 // The method takes a pointer to a *gin.Context and a uint as parameters. It
 // returns a pointer to a entities.Customer and an error. If something goes wrong,
 // the method returns nil and an error.
 //
 // The method gets a customer by its ID from the database using the given ID, and
-// preloads the Contact field. The method returns a pointer to a entities.Customer
+// preloads the Contacts field. The method returns a pointer to a entities.Customer
 // and an error. If the customer is found, the method returns the customer and nil.
 // If the customer is not found, the method returns nil and an error.
-func (r *customerRepository) GetCustomerWithContact(ctx *gin.Context, id uint) (*entities.Customer, error) {
+func (r *customerRepository) GetCustomerWithContacts(ctx *gin.Context, id uint) (*entities.Customer, error) {
 	var customer entities.Customer
 	err := r.db.WithContext(ctx).
-		Preload("Contact").
+		Preload("Contacts").
 		Where("id = ?", id).
 		First(&customer).
 		Error
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"store/domain/apperrors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// The PostgreSQL error codes translated into typed errors.
+const (
+	notNullViolation     = "23502"
+	foreignKeyViolation  = "23503"
+	uniqueViolation      = "23505"
+	checkViolation       = "23514"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// ErrorTranslator is the GORM plugin translating the errors of every
+// statement into the typed errors of the apperrors package, so repositories
+// never return raw GORM or PostgreSQL errors:
+//
+//   - gorm.ErrRecordNotFound becomes a not found error naming the entity, e.g.
+//     "Product supplier not found".
+//   - A unique violation becomes a conflict.
+//   - A foreign key violation becomes ErrStillReferenced when deleting a row,
+//     and a validation error when a row references a missing one.
+//   - Check and not null violations become validation errors.
+//   - Serialization failures and deadlocks become conflicts to retry.
+//
+// The original error is kept as the cause of the typed error, so errors.Is
+// still matches it, e.g. gorm.ErrRecordNotFound.
+type ErrorTranslator struct{}
+
+// Name returns the name of the plugin.
+func (ErrorTranslator) Name() string {
+	return "store:error-translator"
+}
+
+// Initialize registers the translation after the callbacks of every kind of
+// statement.
+func (ErrorTranslator) Initialize(db *gorm.DB) error {
+	translate := func(tx *gorm.DB) {
+		if tx.Error != nil {
+			tx.Error = translateError(tx.Error, tx.Statement)
+		}
+	}
+
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().After("*").Register("store:translate_errors", translate),
+		callbacks.Query().After("*").Register("store:translate_errors", translate),
+		callbacks.Update().After("*").Register("store:translate_errors", translate),
+		callbacks.Delete().After("*").Register("store:translate_errors", translate),
+		callbacks.Row().After("*").Register("store:translate_errors", translate),
+		callbacks.Raw().After("*").Register("store:translate_errors", translate),
+	)
+}
+
+// translateError translates err, returned by the given statement, into a typed
+// error, as described in ErrorTranslator. Errors that are already typed and
+// errors that are not translated are returned unchanged.
+func translateError(err error, stmt *gorm.Statement) error {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	name := entityName(stmt)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound(name).Wrap(err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case uniqueViolation:
+		return apperrors.Conflict("already_exists", fmt.Sprintf("%s with the same %s already exists", name, constraintSubject(pgErr))).Wrap(err)
+	case foreignKeyViolation:
+		if strings.HasPrefix(pgErr.Message, "update or delete") {
+			return ErrStillReferenced.Wrap(err)
+		}
+		return apperrors.Invalid("reference_not_found", fmt.Sprintf("%s references a missing entity (%s)", name, pgErr.ConstraintName)).Wrap(err)
+	case checkViolation:
+		return apperrors.Invalid("check_violation", fmt.Sprintf("%s breaks the %s rule", name, pgErr.ConstraintName)).Wrap(err)
+	case notNullViolation:
+		return apperrors.Invalid("required", fmt.Sprintf("%s must have a %s", name, pgErr.ColumnName)).Wrap(err)
+	case serializationFailure, deadlockDetected:
+		return apperrors.Conflict("concurrent_update", "the request conflicted with a concurrent one, try again").Wrap(err)
+	default:
+		return err
+	}
+}
+
+// notFound returns the not found error of the entity with the given name.
+func notFound(name string) *apperrors.Error {
+	return apperrors.NotFound("not_found", strings.ToUpper(name[:1])+name[1:]+" not found")
+}
+
+// notFoundOf returns the not found error of the entities of type E, caused by
+// gorm.ErrRecordNotFound, for the lookups of the repositories that do not go
+// through a statement returning it.
+func notFoundOf[E any]() error {
+	return notFound(humanize(reflect.TypeOf((*E)(nil)).Elem().Name())).Wrap(gorm.ErrRecordNotFound)
+}
+
+// entityName returns the name of the entity of the statement in words, e.g.
+// "order product supplier", or "entity" when the statement has no model.
+func entityName(stmt *gorm.Statement) string {
+	if stmt == nil || stmt.Schema == nil {
+		return "entity"
+	}
+	return humanize(stmt.Schema.Name)
+}
+
+// constraintSubject returns the columns of the key of a unique violation, e.g.
+// "base_currency, quote_currency, effective_date", or the name of its
+// constraint when the details of the error do not give them.
+func constraintSubject(pgErr *pgconn.PgError) string {
+	if strings.HasPrefix(pgErr.Detail, "Key (") {
+		if columns, _, found := strings.Cut(strings.TrimPrefix(pgErr.Detail, "Key ("), ")="); found {
+			return columns
+		}
+	}
+	return pgErr.ConstraintName
+}
+
+// humanize converts the name of a struct, e.g. OrderProductSupplier, into
+// lower case words, e.g. "order product supplier".
+func humanize(name string) string {
+	return strings.ReplaceAll(schema.NamingStrategy{}.ColumnName("", name), "_", " ")
+}
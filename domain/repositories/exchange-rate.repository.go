@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"store/domain/entities"
+	"store/domain/query"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExchangeRateRepository is an interface that defines the methods that must
+// be implemented by any data store that wants to interact with the exchange_rates
+// table in the database.
+//
+// It provides methods for creating, getting, updating and deleting exchange
+// rates, importing them in bulk and finding the rate effective on a date.
+type ExchangeRateRepository interface {
+	Create(ctx *gin.Context, exchangeRate *entities.ExchangeRate) error                                // Create a new exchange rate
+	GetByID(ctx *gin.Context, id uint) (*entities.ExchangeRate, error)                                 // Get an exchange rate by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.ExchangeRate], error)          // Get all exchange rates
+	Update(ctx *gin.Context, exchangeRate *entities.ExchangeRate, fields ...string) error              // Update an exchange rate
+	Delete(ctx *gin.Context, id uint, version uint) error                                              // Delete an exchange rate
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                                    // Delete multiple exchange rates
+	Import(ctx *gin.Context, exchangeRates []*entities.ExchangeRate) error                             // Create or replace multiple exchange rates
+	GetEffective(ctx *gin.Context, base, quote string, date time.Time) (*entities.ExchangeRate, error) // Get the rate effective on a date
+	TrashRepository[entities.ExchangeRate]                                                             // Get, restore and purge deleted exchange rates
+}
+
+// exchangeRateRepository is a struct that contains a pointer to a gorm DB instance
+// and implements the ExchangeRateRepository.
+//
+// The struct contains a pointer to a gorm DB instance which is used to interact
+// with the exchange_rates table in the database.
+type exchangeRateRepository struct {
+	db *gorm.DB
+	trashRepository[entities.ExchangeRate]
+}
+
+// NewExchangeRateRepository creates a new instance of exchangeRateRepository with
+// the provided database instance and returns it as an ExchangeRateRepository.
+// This function is used to initialize a new exchange rate repository that can
+// perform CRUD operations and other queries on the exchange_rates table.
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepository{db: db, trashRepository: trashRepository[entities.ExchangeRate]{db: db}}
+}
+
+// Creates a new exchange rate in the database.
+//
+// The method takes a pointer to a *gin.Context and a pointer to an
+// entities.ExchangeRate as parameters. It returns an error if something goes
+// wrong, such as another rate for the same currency pair and date.
+func (r *exchangeRateRepository) Create(ctx *gin.Context, exchangeRate *entities.ExchangeRate) error {
+	return r.db.WithContext(ctx).Create(exchangeRate).Error
+}
+
+// Retrieves an exchange rate by its ID from the database.
+//
+// The method takes a pointer to a *gin.Context and a uint as parameters. It
+// returns a pointer to an entities.ExchangeRate and an error. If the exchange
+// rate is not found, the method returns gorm.ErrRecordNotFound.
+func (r *exchangeRateRepository) GetByID(ctx *gin.Context, id uint) (*entities.ExchangeRate, error) {
+	var exchangeRate entities.ExchangeRate
+	err := r.db.WithContext(ctx).First(&exchangeRate, id).Error
+	return &exchangeRate, err
+}
+
+// ExchangeRateListSchema lists the fields of an exchange rate that can be used
+// to filter and sort the exchange rates in a list query.
+var ExchangeRateListSchema = query.Model(query.Schema{
+	"base_currency":  {Column: "base_currency", Type: query.String},
+	"quote_currency": {Column: "quote_currency", Type: query.String},
+	"effective_date": {Column: "effective_date", Type: query.Time},
+})
+
+// Retrieves a page of exchange rates from the database.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against ExchangeRateListSchema. It returns the page of
+// exchange rates matching the filters of the query, in the requested order,
+// along with the total count and the link to the next page. If something goes
+// wrong, the method returns nil and an error.
+func (r *exchangeRateRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.ExchangeRate], error) {
+	return query.Find[entities.ExchangeRate](r.db.WithContext(ctx), q)
+}
+
+// Updates an exchange rate in the database.
+//
+// The method takes a pointer to a *gin.Context and a pointer to an
+// entities.ExchangeRate as parameters. It returns an error if something goes
+// wrong. Orders already placed keep the snapshot of the rate they were placed
+// with.
+//
+// The update is based on the Version of the exchange rate, which is
+// incremented. It returns gorm.ErrRecordNotFound if the exchange rate does not
+// exist, or ErrVersionConflict if it has been changed since that version.
+//
+// When fields are given, only those fields of the exchange rate, named as in
+// its struct, are saved, as done for partial updates.
+func (r *exchangeRateRepository) Update(ctx *gin.Context, exchangeRate *entities.ExchangeRate, fields ...string) error {
+	return updateVersioned(r.db.WithContext(ctx), exchangeRate, exchangeRate.ID, &exchangeRate.Version, fields...)
+}
+
+// Deletes an exchange rate by its ID from the database.
+//
+// The method takes a pointer to a *gin.Context and a uint as parameters. It
+// returns an error if something goes wrong.
+//
+// The exchange rate is only deleted if it is still at the given version, or
+// whatever its version is with AnyVersion. It returns gorm.ErrRecordNotFound if
+// the exchange rate does not exist, or ErrVersionConflict if it has been
+// changed since that version.
+func (r *exchangeRateRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return deleteVersioned[entities.ExchangeRate](r.db.WithContext(ctx), id, version)
+}
+
+// Deletes multiple exchange rates from the database by their IDs.
+//
+// The method takes a pointer to a *gin.Context and a slice of uints as
+// parameters. It deletes the exchange rates in a single transaction and returns
+// the outcome for each id: deleted, not found or blocked. It returns ErrNoIDs
+// if no id is given, or an error if something goes wrong, in which case nothing
+// is deleted.
+func (r *exchangeRateRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	return deleteAll(r.db.WithContext(ctx), &entities.ExchangeRate{}, ids)
+}
+
+// Imports multiple exchange rates into the database in a single transaction.
+//
+// The method takes a pointer to a *gin.Context and a slice of pointers to
+// entities.ExchangeRate as parameters. A rate for a currency pair and date that
+// already exists, even if deleted, is replaced by the imported one and gets a
+// new version. If any rate fails, nothing is imported and the method returns
+// the error.
+func (r *exchangeRateRepository) Import(ctx *gin.Context, exchangeRates []*entities.ExchangeRate) error {
+	if len(exchangeRates) == 0 {
+		return nil
+	}
+	updates := clause.AssignmentColumns([]string{"rate", "updated_at", "deleted_at"})
+	updates = append(updates, clause.Assignment{
+		Column: clause.Column{Name: "version"},
+		Value:  gorm.Expr("sales.exchange_rates.version + 1"),
+	})
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "effective_date"}},
+			DoUpdates: updates,
+		}).
+		Create(&exchangeRates).
+		Error
+}
+
+// Retrieves the exchange rate from base to quote effective on the given date.
+//
+// The method takes a pointer to a *gin.Context, the base and quote currencies
+// and the date as parameters. It returns the rate with the latest effective date
+// not after the given date, or gorm.ErrRecordNotFound if there is none.
+func (r *exchangeRateRepository) GetEffective(ctx *gin.Context, base, quote string, date time.Time) (*entities.ExchangeRate, error) {
+	var exchangeRate entities.ExchangeRate
+	err := r.db.WithContext(ctx).
+		Where("base_currency = ? AND quote_currency = ? AND effective_date <= ?", base, quote, date).
+		Order("effective_date DESC").
+		First(&exchangeRate).
+		Error
+	return &exchangeRate, err
+}
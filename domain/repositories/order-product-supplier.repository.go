@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -14,12 +15,15 @@ import (
 // It provides methods for creating a new orderProductSupplier, getting a orderProductSupplier by its ID,
 // getting all orderProductSuppliers, updating a orderProductSupplier, and deleting a orderProductSupplier.
 type OrderProductSupplierRepository interface {
-	Create(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error // Create a new orderProductSupplier
-	GetByID(ctx *gin.Context, id uint) (*entities.OrderProductSupplier, error)          // Get a orderProductSupplier by ID
-	GetAll(ctx *gin.Context) ([]*entities.OrderProductSupplier, error)                  // Get all orderProductSuppliers
-	Update(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error // Update a orderProductSupplier
-	Delete(ctx *gin.Context, id uint) error                                             // Delete a orderProductSupplier
-	DeleteAll(ctx *gin.Context, ids []uint) error                                       // Delete multiple orderProductSuppliers
+	Create(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error                                      // Create a new orderProductSupplier
+	CreateAll(ctx *gin.Context, orderProductSuppliers []*entities.OrderProductSupplier) error                                // Create several orderProductSuppliers at once
+	GetByID(ctx *gin.Context, id uint) (*entities.OrderProductSupplier, error)                                               // Get a orderProductSupplier by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.OrderProductSupplier], error)                        // Get all orderProductSuppliers
+	Update(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier, fields ...string) error                    // Update a orderProductSupplier
+	Delete(ctx *gin.Context, id uint, version uint) error                                                                    // Delete a orderProductSupplier
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                                                          // Delete multiple orderProductSuppliers
+	GetAllByOrderID(ctx *gin.Context, orderID uint, q *query.ListQuery) (*query.Page[*entities.OrderProductSupplier], error) // Get the orderProductSuppliers of an order
+	TrashRepository[entities.OrderProductSupplier]                                                                           // Get, restore and purge deleted orderProductSuppliers
 }
 
 // orderProductSupplierRepository is a struct that contains a pointer to a gorm DB instance and
@@ -29,6 +33,7 @@ type OrderProductSupplierRepository interface {
 // with the order_product_suppliers table in the database.
 type orderProductSupplierRepository struct {
 	db *gorm.DB
+	trashRepository[entities.OrderProductSupplier]
 }
 
 // NewOrderProductSupplierRepository creates a new instance of orderProductSupplierRepository with the provided
@@ -36,7 +41,13 @@ type orderProductSupplierRepository struct {
 // This function is used to initialize a new orderProductSupplier repository that can perform
 // CRUD operations and other queries on the order_product_suppliers table.
 func NewOrderProductSupplierRepository(db *gorm.DB) OrderProductSupplierRepository {
-	return &orderProductSupplierRepository{db: db}
+	return &orderProductSupplierRepository{
+		db: db,
+		trashRepository: trashRepository[entities.OrderProductSupplier]{
+			db:     db,
+			purges: []dependent{{model: &entities.StockReservation{}, column: "order_product_supplier_id"}},
+		},
+	}
 }
 
 // Creates a new orderProductSupplier in the database.
@@ -48,10 +59,44 @@ func NewOrderProductSupplierRepository(db *gorm.DB) OrderProductSupplierReposito
 // The orderProductSupplier object is passed as a pointer and the method is responsible for creating
 // a new orderProductSupplier in the database with the given attributes.
 //
+// The version of the order is incremented along with the creation, since the
+// lines are part of the order, and the stock reservations of the order are
+// renewed to hold the quantity of the new line, as described in reserveOrders.
+// It returns ErrInsufficientStock if not enough units are available.
+//
 // The method returns an error if something goes wrong. If the orderProductSupplier is created
 // successfully, the method returns nil.
 func (r *orderProductSupplierRepository) Create(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error {
-	return r.db.WithContext(ctx).Create(orderProductSupplier).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(orderProductSupplier).Error; err != nil {
+			return err
+		}
+		if err := touchOrders(tx, []uint{orderProductSupplier.ID}); err != nil {
+			return err
+		}
+		return reserveOrdersOf(tx, []uint{orderProductSupplier.ID})
+	})
+}
+
+// Creates several orderProductSuppliers in a single transaction.
+//
+// The version of their orders is incremented once and their stock reservations
+// are renewed, as done by Create. Either every line is created or none is, and
+// it returns ErrInsufficientStock if not enough units are available for them.
+func (r *orderProductSupplierRepository) CreateAll(ctx *gin.Context, orderProductSuppliers []*entities.OrderProductSupplier) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(orderProductSuppliers).Error; err != nil {
+			return err
+		}
+		ids := make([]uint, len(orderProductSuppliers))
+		for i, orderProductSupplier := range orderProductSuppliers {
+			ids[i] = orderProductSupplier.ID
+		}
+		if err := touchOrders(tx, ids); err != nil {
+			return err
+		}
+		return reserveOrdersOf(tx, ids)
+	})
 }
 
 // Retrieves an orderProductSupplier by its ID from the database.
@@ -70,20 +115,24 @@ func (r *orderProductSupplierRepository) GetByID(ctx *gin.Context, id uint) (*en
 	return &orderProductSupplier, err
 }
 
-// Retrieves all orderProductSuppliers from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.OrderProductSupplier and an error. If something goes wrong,
-// the method returns an empty slice and an error.
-//
-// The method fetches all orderProductSuppliers from the database. If the
-// orderProductSuppliers are found, the method returns the orderProductSuppliers and
-// nil. If no orderProductSuppliers are found or an error occurs, the method returns
-// an empty slice and an error.
-func (r *orderProductSupplierRepository) GetAll(ctx *gin.Context) ([]*entities.OrderProductSupplier, error) {
-	var orderProductSuppliers []*entities.OrderProductSupplier
-	err := r.db.WithContext(ctx).Find(&orderProductSuppliers).Error
-	return orderProductSuppliers, err
+// OrderProductSupplierListSchema lists the fields of an order product supplier
+// that can be used to filter and sort the order product suppliers in a list
+// query.
+var OrderProductSupplierListSchema = query.Model(query.Schema{
+	"order_id":            {Column: "order_id", Type: query.Number},
+	"product_supplier_id": {Column: "product_supplier_id", Type: query.Number},
+	"quantity":            {Column: "quantity", Type: query.Number},
+})
+
+// Retrieves a page of order product suppliers from the database.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against OrderProductSupplierListSchema. It returns the
+// page of order product suppliers matching the filters of the query, in the
+// requested order, along with the total count and the link to the next page. If
+// something goes wrong, the method returns nil and an error.
+func (r *orderProductSupplierRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.OrderProductSupplier], error) {
+	return query.Find[entities.OrderProductSupplier](r.db.WithContext(ctx), q)
 }
 
 // Updates an orderProductSupplier in the database.
@@ -95,10 +144,27 @@ func (r *orderProductSupplierRepository) GetAll(ctx *gin.Context) ([]*entities.O
 // The orderProductSupplier object is passed as a pointer and the method is responsible for updating
 // an orderProductSupplier in the database with the given attributes.
 //
+// The update is based on the Version of the orderProductSupplier and increments
+// the version of the orderProductSupplier and of its order. It returns
+// ErrVersionConflict if the orderProductSupplier has been changed since that
+// version. The stock reservations of the order are renewed as on creation.
+//
 // The method returns an error if something goes wrong. If the orderProductSupplier is updated
 // successfully, the method returns nil.
-func (r *orderProductSupplierRepository) Update(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error {
-	return r.db.WithContext(ctx).Save(orderProductSupplier).Error
+//
+// When fields are given, only those fields of the orderProductSupplier, named
+// as in its struct, are saved, as done for partial updates.
+func (r *orderProductSupplierRepository) Update(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier, fields ...string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := updateVersioned(tx, orderProductSupplier, orderProductSupplier.ID, &orderProductSupplier.Version, fields...)
+		if err != nil {
+			return err
+		}
+		if err := touchOrders(tx, []uint{orderProductSupplier.ID}); err != nil {
+			return err
+		}
+		return reserveOrdersOf(tx, []uint{orderProductSupplier.ID})
+	})
 }
 
 // Deletes an orderProductSupplier by its ID from the database.
@@ -110,18 +176,78 @@ func (r *orderProductSupplierRepository) Update(ctx *gin.Context, orderProductSu
 // given ID. If the orderProductSupplier is deleted successfully, the method returns
 // nil. If the orderProductSupplier is not found or an error occurs, the method returns
 // an error.
-func (r *orderProductSupplierRepository) Delete(ctx *gin.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.OrderProductSupplier{}, id).Error
+//
+// The orderProductSupplier is only deleted if it is still at the given version,
+// otherwise ErrVersionConflict is returned, and the version of its order is
+// incremented. The stock reservations of the order are renewed without the
+// line.
+func (r *orderProductSupplierRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteVersioned[entities.OrderProductSupplier](tx, id, version); err != nil {
+			return err
+		}
+		if err := touchOrders(tx, []uint{id}); err != nil {
+			return err
+		}
+		return reserveOrdersOf(tx, []uint{id})
+	})
 }
 
 // Deletes multiple orderProductSuppliers from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple orderProductSuppliers from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the orderProductSuppliers are
-// deleted successfully, the method returns nil.
-func (r *orderProductSupplierRepository) DeleteAll(ctx *gin.Context, ids []uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.OrderProductSupplier{}, ids).Error
+// The method takes a pointer to a *gin.Context and a slice of uints as
+// parameters. It deletes the orderProductSuppliers in a single transaction and
+// returns the outcome for each id: deleted, not found or blocked. Lines of
+// orders that are no longer drafts are kept, and the stock reservations of the
+// orders of the deleted lines are renewed. It returns ErrNoIDs if no id is
+// given, or an error if something goes wrong, in which case nothing is deleted.
+func (r *orderProductSupplierRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	var results []DeleteResult
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		results, err = deleteAll(tx, &entities.OrderProductSupplier{}, ids, orderNotDraft)
+		if err != nil {
+			return err
+		}
+
+		var deleted []uint
+		for _, result := range results {
+			if result.Status == DeleteStatusDeleted {
+				deleted = append(deleted, result.ID)
+			}
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+		if err := touchOrders(tx, deleted); err != nil {
+			return err
+		}
+		return reserveOrdersOf(tx, deleted)
+	})
+	return results, err
+}
+
+// Retrieves a page of the orderProductSuppliers of the given order, that is
+// the lines of the order.
+//
+// The method takes a pointer to a *gin.Context, the ID of the order and the
+// list query parsed from the request, validated against
+// OrderProductSupplierListSchema. It returns the page of orderProductSuppliers
+// or an error if something goes wrong.
+func (r *orderProductSupplierRepository) GetAllByOrderID(ctx *gin.Context, orderID uint, q *query.ListQuery) (*query.Page[*entities.OrderProductSupplier], error) {
+	return query.Find[entities.OrderProductSupplier](r.db.WithContext(ctx).Where("order_id = ?", orderID), q)
+}
+
+// touchOrders increments the version of the orders of the given
+// orderProductSuppliers, deleted or not, so the ETag of an order changes
+// whenever its lines change. It must be called inside a transaction.
+func touchOrders(tx *gorm.DB, ids []uint) error {
+	orderIDs := tx.Unscoped().
+		Model(&entities.OrderProductSupplier{}).
+		Select("order_id").
+		Where("id IN ?", ids)
+	return tx.Model(&entities.Order{}).
+		Where("id IN (?)", orderIDs).
+		UpdateColumn("version", nextVersion).
+		Error
 }
@@ -1,10 +1,39 @@
 package repositories
 
 import (
+	"fmt"
+	"store/domain/apperrors"
 	"store/domain/entities"
+	"store/domain/query"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInsufficientStock is returned when an order line requests more units
+	// than the referenced ProductSupplier has in stock.
+	ErrInsufficientStock = apperrors.Conflict("insufficient_stock", "insufficient stock")
+	// ErrInvalidQuantity is returned when an order line has a quantity lower than one.
+	ErrInvalidQuantity = apperrors.BadRequest("invalid_quantity", "order line quantity must be greater than zero")
+	// ErrEmptyOrder is returned when an order is placed without any order line.
+	ErrEmptyOrder = apperrors.BadRequest("empty_order", "order must have at least one order line")
+	// ErrStatusChanged is returned when the status of an order was changed by
+	// someone else while a transition was being applied.
+	ErrStatusChanged = apperrors.Conflict("status_changed", "order status was changed concurrently")
+	// ErrOrderInProgress is returned when an order that is neither a draft nor
+	// cancelled is deleted, since its stock has been consumed.
+	ErrOrderInProgress = apperrors.Conflict("order_in_progress", "only draft and cancelled orders can be deleted")
+)
+
+// StockEffect describes what a status change does to the stock of the order lines.
+type StockEffect int
+
+const (
+	StockUnchanged StockEffect = iota // the stock is not touched
+	StockConsumed                     // the order lines are taken from stock
+	StockRestored                     // the order lines are given back to stock
 )
 
 // OrderRepository is an interface that defines the methods that must
@@ -14,13 +43,20 @@ import (
 // It provides methods for creating a new order, getting an order by its ID, getting all orders,
 // updating an order, deleting an order, and getting an order with its order products.
 type OrderRepository interface {
-	Create(ctx *gin.Context, order *entities.Order) error                         // Create a new order
-	GetByID(ctx *gin.Context, id uint) (*entities.Order, error)                   // Get an order by ID
-	GetAll(ctx *gin.Context) ([]*entities.Order, error)                           // Get all orders
-	Update(ctx *gin.Context, order *entities.Order) error                         // Update an order
-	Delete(ctx *gin.Context, id uint) error                                       // Delete an order
-	DeleteAll(ctx *gin.Context, ids []uint) error                                 // Delete multiple orders
-	GetOrderWithOrderProducts(ctx *gin.Context, id uint) (*entities.Order, error) // Get an order with its order products
+	Create(ctx *gin.Context, order *entities.Order) error                                                                 // Create a new order
+	GetByID(ctx *gin.Context, id uint) (*entities.Order, error)                                                           // Get an order by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Order], error)                                    // Get all orders
+	Update(ctx *gin.Context, order *entities.Order, fields ...string) error                                               // Update an order
+	Delete(ctx *gin.Context, id uint, version uint) error                                                                 // Delete an order
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                                                       // Delete multiple orders
+	GetOrderWithOrderProducts(ctx *gin.Context, id uint) (*entities.Order, error)                                         // Get an order with its order products
+	PlaceOrder(ctx *gin.Context, order *entities.Order) error                                                             // Place an order consuming the stock of its order products
+	ChangeStatus(ctx *gin.Context, order *entities.Order, history *entities.OrderStatusHistory, effect StockEffect) error // Change the status of an order
+	GetStatusHistory(ctx *gin.Context, id uint) ([]*entities.OrderStatusHistory, error)                                   // Get the status history of an order
+	GetByOrderNumber(ctx *gin.Context, number string) (*entities.Order, error)                                            // Get an order by its order number
+	NextOrderNumber(ctx *gin.Context, year int) (int64, error)                                                            // Issue the next order sequence number of a year
+	AddExchangeRates(ctx *gin.Context, exchangeRates []entities.OrderExchangeRate) error                                  // Snapshot more exchange rates on an order
+	TrashRepository[entities.Order]                                                                                       // Get, restore and purge deleted orders
 }
 
 // orderRepository is a struct that contains a pointer to a gorm DB instance
@@ -30,6 +66,7 @@ type OrderRepository interface {
 // with the orders table in the database.
 type orderRepository struct {
 	db *gorm.DB
+	trashRepository[entities.Order]
 }
 
 // NewOrderRepository creates a new instance of orderRepository with the provided
@@ -37,7 +74,19 @@ type orderRepository struct {
 // This function is used to initialize a new order repository that can perform
 // CRUD operations and other queries on the orders table.
 func NewOrderRepository(db *gorm.DB) OrderRepository {
-	return &orderRepository{db: db}
+	return &orderRepository{
+		db: db,
+		trashRepository: trashRepository[entities.Order]{
+			db: db,
+			purges: []dependent{
+				{model: &entities.OrderStatusHistory{}, column: "order_id"},
+				{model: &entities.OrderExchangeRate{}, column: "order_id"},
+				{model: &entities.OrderAddress{}, column: "order_id"},
+				{model: &entities.StockReservation{}, column: "order_id"},
+				{model: &entities.OrderProductSupplier{}, column: "order_id"},
+			},
+		},
+	}
 }
 
 // Creates a new order in the database.
@@ -54,8 +103,18 @@ func NewOrderRepository(db *gorm.DB) OrderRepository {
 //
 // The method returns an error if something goes wrong. If the order is created
 // successfully, the method returns nil.
+//
+// Orders created by this method are drafts, whose lines are not taken from
+// stock yet: the quantity of every line is held by a stock reservation instead,
+// in the same transaction, and ErrInsufficientStock is returned if a
+// ProductSupplier has not enough units available.
 func (r *orderRepository) Create(ctx *gin.Context, order *entities.Order) error {
-	return r.db.WithContext(ctx).Create(order).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		return reserveOrders(tx, []uint{order.ID})
+	})
 }
 
 // Retrieves an order by its ID from the database.
@@ -77,22 +136,27 @@ func (r *orderRepository) GetByID(ctx *gin.Context, id uint) (*entities.Order, e
 	return &order, err
 }
 
-// Retrieves all orders from the database.
-//
-// This method is part of the OrderRepository and is used to retrieve all
-// orders from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.Order and an error. If something goes wrong, the method
-// returns an empty slice and an error.
+// OrderListSchema lists the fields of an order that can be used to filter and
+// sort the orders in a list query.
+var OrderListSchema = query.Model(query.Schema{
+	"customer_id":   {Column: "customer_id", Type: query.Number},
+	"order_date":    {Column: "order_date", Type: query.Time},
+	"delivery_date": {Column: "delivery_date", Type: query.Time},
+	"order_number":  {Column: "uk_order_number", Type: query.String},
+	"status":        {Column: "status", Type: query.String},
+	"currency":      {Column: "currency", Type: query.String},
+	"warehouse_id":  {Column: "warehouse_id", Type: query.Number},
+})
+
+// Retrieves a page of orders from the database.
 //
-// The method fetches all orders from the database. If the orders are successfully
-// retrieved, the method returns the orders and nil. If no orders are found or
-// an error occurs, the method returns an empty slice and an error.
-func (r *orderRepository) GetAll(ctx *gin.Context) ([]*entities.Order, error) {
-	var orders []*entities.Order
-	err := r.db.WithContext(ctx).Find(&orders).Error
-	return orders, err
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against OrderListSchema. It returns the page of orders
+// matching the filters of the query, in the requested order, along with the
+// total count and the link to the next page. If something goes wrong, the
+// method returns nil and an error.
+func (r *orderRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Order], error) {
+	return query.Find[entities.Order](r.db.WithContext(ctx), q)
 }
 
 // Updates an order in the database.
@@ -109,8 +173,15 @@ func (r *orderRepository) GetAll(ctx *gin.Context) ([]*entities.Order, error) {
 //
 // The method returns an error if something goes wrong. If the order is updated
 // successfully, the method returns nil.
-func (r *orderRepository) Update(ctx *gin.Context, order *entities.Order) error {
-	return r.db.WithContext(ctx).Save(order).Error
+//
+// The update is based on the Version of the order, which is incremented. It
+// returns gorm.ErrRecordNotFound if the order does not exist, or
+// ErrVersionConflict if it has been changed since that version.
+//
+// When fields are given, only those fields of the order, named as in its
+// struct, are saved, as done for partial updates.
+func (r *orderRepository) Update(ctx *gin.Context, order *entities.Order, fields ...string) error {
+	return updateVersioned(r.db.WithContext(ctx), order, order.ID, &order.Version, fields...)
 }
 
 // Deletes an order by its ID from the database.
@@ -121,20 +192,52 @@ func (r *orderRepository) Update(ctx *gin.Context, order *entities.Order) error
 // The method deletes an order by its ID from the database using the given ID.
 // If the order is deleted successfully, the method returns nil. If the order is
 // not found or an error occurs, the method returns an error.
-func (r *orderRepository) Delete(ctx *gin.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.Order{}, id).Error
+//
+// The order is only deleted if it is still at the given version, or whatever
+// its version is with AnyVersion. It returns gorm.ErrRecordNotFound if the
+// order does not exist, ErrVersionConflict if it has been changed since that
+// version, or ErrOrderInProgress if it is neither a draft nor cancelled. The
+// stock reservations of a deleted draft are released.
+func (r *orderRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteUnlessBlocked[entities.Order](tx, id, version, ErrOrderInProgress, orderInProgress); err != nil {
+			return err
+		}
+		_, err := releaseReservations(tx, entities.StockReservationReleased, "order_id = ?", id)
+		return err
+	})
 }
 
 // Deletes multiple orders from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple orders from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the orders are
-// deleted successfully, the method returns nil.
-func (r *orderRepository) DeleteAll(ctx *gin.Context, ids []uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.Order{}, ids).Error
+// The method takes a pointer to a *gin.Context and a slice of uints as
+// parameters. It deletes the orders in a single transaction and returns the
+// outcome for each id: deleted, not found or blocked. Orders that are neither
+// drafts nor cancelled are kept, and the stock reservations of the deleted
+// drafts are released. It returns ErrNoIDs if no id is given, or an
+// error if something goes wrong, in which case nothing is deleted.
+func (r *orderRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	var results []DeleteResult
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		results, err = deleteAll(tx, &entities.Order{}, ids, orderInProgress)
+		if err != nil {
+			return err
+		}
+
+		var deleted []uint
+		for _, result := range results {
+			if result.Status == DeleteStatusDeleted {
+				deleted = append(deleted, result.ID)
+			}
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+		_, err = releaseReservations(tx, entities.StockReservationReleased, "order_id IN ?", deleted)
+		return err
+	})
+	return results, err
 }
 
 // Retrieves an order by its ID from the database, including its order products.
@@ -144,10 +247,312 @@ func (r *orderRepository) DeleteAll(ctx *gin.Context, ids []uint) error {
 // the method returns nil and an error.
 //
 // The method gets an order by its ID from the database using the given ID, and
-// preloads the OrderProducts field. The method returns a pointer to an
-// entities.Order and an error. If the order is found, the method returns the
+// preloads the OrderProducts, ExchangeRates and Addresses fields. The method returns a
+// pointer to an entities.Order and an error. If the order is found, the method
+// returns the order and nil.
 func (r *orderRepository) GetOrderWithOrderProducts(ctx *gin.Context, id uint) (*entities.Order, error) {
 	var order entities.Order
-	err := r.db.WithContext(ctx).Preload("OrderProducts").First(&order, id).Error
+	err := r.db.WithContext(ctx).Preload("OrderProducts").Preload("ExchangeRates").Preload("Addresses").First(&order, id).Error
 	return &order, err
 }
+
+// Places an order in a single database transaction.
+//
+// The method takes a pointer to a *gin.Context and a pointer to an entities.Order
+// with its OrderProducts as parameters. It returns an error if something goes wrong.
+//
+// For every order line the referenced ProductSupplier is locked and checked for
+// enough quantity. The quantity of the ProductSupplier and the stock of its
+// Supplier are decremented, and the sales counters of the ProductSupplier, the
+// Product and the Supplier are incremented. A sale of the line is recorded in
+// the stock ledger, taking the units from the warehouse the order is fulfilled
+// from. Lines without a value inherit the current value of the
+// ProductSupplier. The order and its lines are inserted only after every line
+// was validated.
+//
+// If any line fails, the whole transaction is rolled back and the method returns
+// ErrInvalidQuantity, ErrInsufficientStock, gorm.ErrRecordNotFound or the
+// database error. If the order is placed successfully, the method returns nil.
+func (r *orderRepository) PlaceOrder(ctx *gin.Context, order *entities.Order) error {
+	if len(order.OrderProducts) == 0 {
+		return ErrEmptyOrder
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		movement := orderMovement(order, entities.StockMovementSale, "order placed", "")
+		for i := range order.OrderProducts {
+			line := &order.OrderProducts[i]
+			productSupplier, err := consumeStock(tx, line.ProductSupplierID, line.Quantity, movement)
+			if err != nil {
+				return err
+			}
+			if line.Value.IsZero() {
+				line.Value = productSupplier.Value
+			}
+		}
+
+		return tx.Create(order).Error
+	})
+}
+
+// Changes the status of an order in a single database transaction.
+//
+// The method takes a pointer to a *gin.Context, the order with its OrderProducts
+// in its current status, the history entry describing the change and the effect
+// the change has on stock. It returns an error if something goes wrong.
+//
+// The status is only updated if the order is still in history.FromStatus,
+// otherwise ErrStatusChanged is returned, and the warehouse the order is
+// fulfilled from is saved along with it. The stock reservations of a draft are
+// converted when it is placed, or released when it is cancelled, before its
+// units are checked for availability. Depending on the effect, the stock of
+// every order line is consumed or restored in that warehouse, recording a sale
+// or a return in the stock ledger on behalf of history.ChangedBy, and the history entry is
+// persisted along with the addresses of the order that are not saved yet,
+// snapshotted when a draft is placed. If anything fails, the whole transaction
+// is rolled back. On success the order status is set to history.ToStatus and
+// the method returns nil.
+func (r *orderRepository) ChangeStatus(ctx *gin.Context, order *entities.Order, history *entities.OrderStatusHistory, effect StockEffect) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.Order{}).
+			Where("id = ? AND status = ?", order.ID, history.FromStatus).
+			Updates(map[string]interface{}{"status": history.ToStatus, "warehouse_id": order.WarehouseID, "version": nextVersion})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+
+		if history.FromStatus == entities.OrderStatusDraft {
+			status := entities.StockReservationReleased
+			if effect == StockConsumed {
+				status = entities.StockReservationConverted
+			}
+			if _, err := releaseReservations(tx, status, "order_id = ?", order.ID); err != nil {
+				return err
+			}
+		}
+
+		reason := "order " + string(history.ToStatus)
+		for _, line := range order.OrderProducts {
+			var err error
+			switch effect {
+			case StockConsumed:
+				movement := orderMovement(order, entities.StockMovementSale, reason, history.ChangedBy)
+				_, err = consumeStock(tx, line.ProductSupplierID, line.Quantity, movement)
+			case StockRestored:
+				movement := orderMovement(order, entities.StockMovementReturn, reason, history.ChangedBy)
+				err = restoreStock(tx, line.ProductSupplierID, line.Quantity, movement)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		history.OrderID = order.ID
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+		for i := range order.Addresses {
+			address := &order.Addresses[i]
+			if address.ID != 0 {
+				continue
+			}
+			address.OrderID = order.ID
+			if err := tx.Create(address).Error; err != nil {
+				return err
+			}
+		}
+
+		order.Status = history.ToStatus
+		order.Version++
+		return nil
+	})
+}
+
+// Retrieves the status history of an order from the database.
+//
+// The method takes a pointer to a *gin.Context and the ID of the order as
+// parameters. It returns the history entries ordered from the oldest to the
+// newest change, or an error if something goes wrong.
+func (r *orderRepository) GetStatusHistory(ctx *gin.Context, id uint) ([]*entities.OrderStatusHistory, error) {
+	var history []*entities.OrderStatusHistory
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", id).
+		Order("changed_at, id").
+		Find(&history).
+		Error
+	return history, err
+}
+
+// Retrieves an order by its order number from the database.
+//
+// The method takes a pointer to a *gin.Context and the order number as
+// parameters. It returns the order with its order products, exchange rates and
+// addresses, or gorm.ErrRecordNotFound if no order has the given number.
+func (r *orderRepository) GetByOrderNumber(ctx *gin.Context, number string) (*entities.Order, error) {
+	var order entities.Order
+	err := r.db.WithContext(ctx).
+		Preload("OrderProducts").
+		Preload("ExchangeRates").
+		Preload("Addresses").
+		Where("uk_order_number = ?", number).
+		First(&order).
+		Error
+	return &order, err
+}
+
+// Issues the next order sequence number of the given year.
+//
+// The method takes a pointer to a *gin.Context and the year as parameters. The
+// counter of the year is created or incremented by a single upsert statement,
+// which locks the counter row, so concurrent calls never get the same number.
+// Numbers issued for orders that fail to be placed are not reused, so the
+// sequence may have gaps. The method returns the issued number or an error if
+// something goes wrong.
+func (r *orderRepository) NextOrderNumber(ctx *gin.Context, year int) (int64, error) {
+	var sequence entities.OrderNumberSequence
+	err := r.db.WithContext(ctx).
+		Raw(`INSERT INTO sales.order_number_sequences (year, last_value) VALUES (?, 1)
+			ON CONFLICT (year) DO UPDATE SET last_value = sales.order_number_sequences.last_value + 1
+			RETURNING year, last_value`, year).
+		Scan(&sequence).
+		Error
+	return sequence.LastValue, err
+}
+
+// Stores the snapshots of exchange rates taken after an order was created,
+// such as the rates of the currency of a line added to a draft. Every snapshot
+// holds the ID of its order.
+func (r *orderRepository) AddExchangeRates(ctx *gin.Context, exchangeRates []entities.OrderExchangeRate) error {
+	if len(exchangeRates) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&exchangeRates).Error
+}
+
+// orderMovement returns the template of the stock movements of the lines of an
+// order, referencing the order by its number and taking place in the warehouse
+// the order is fulfilled from.
+func orderMovement(order *entities.Order, movementType entities.StockMovementType, reason, user string) entities.StockMovement {
+	return entities.StockMovement{
+		WarehouseID:   order.WarehouseID,
+		Type:          movementType,
+		Reason:        reason,
+		ReferenceType: "order",
+		Reference:     order.UKOrderNumber,
+		CreatedBy:     user,
+	}
+}
+
+// consumeStock locks the ProductSupplier with the given ID, checks it has at
+// least quantity units available, not held by reservations nor in expired lots,
+// as described in checkSellable, and moves them from
+// stock to sales, updating the counters of the ProductSupplier, its Product and
+// its Supplier, and records the given movement in the stock ledger, taking the
+// units from lots that are not expired, or returns ErrExpiredStock. It must be
+// called inside a transaction and returns the ProductSupplier as it was before
+// the update.
+func consumeStock(tx *gorm.DB, productSupplierID uint, quantity int, movement entities.StockMovement) (*entities.ProductSupplier, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	var productSupplier entities.ProductSupplier
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productSupplier, productSupplierID).Error
+	if err != nil {
+		return nil, fmt.Errorf("product supplier %d: %w", productSupplierID, err)
+	}
+	if err := checkSellable(tx, &productSupplier, quantity); err != nil {
+		return nil, err
+	}
+
+	err = tx.Model(&entities.ProductSupplier{}).
+		Where("id = ?", productSupplier.ID).
+		UpdateColumns(map[string]interface{}{
+			"quantity": gorm.Expr("quantity - ?", quantity),
+			"sales":    gorm.Expr("sales + ?", quantity),
+			"version":  nextVersion,
+		}).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Model(&entities.Product{}).
+		Where("id = ?", productSupplier.ProductID).
+		UpdateColumns(map[string]interface{}{
+			"sales":   gorm.Expr("sales + ?", quantity),
+			"version": nextVersion,
+		}).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Model(&entities.Supplier{}).
+		Where("id = ?", productSupplier.SupplierID).
+		UpdateColumns(map[string]interface{}{
+			"quantity_stock": gorm.Expr("quantity_stock - ?", quantity),
+			"sales":          gorm.Expr("sales + ?", quantity),
+			"version":        nextVersion,
+		}).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordMovement(tx, &productSupplier, &movement, -quantity); err != nil {
+		return nil, err
+	}
+	return &productSupplier, nil
+}
+
+// restoreStock gives quantity units back to the stock of the ProductSupplier with
+// the given ID, reverting the counters updated by consumeStock, and records the
+// given movement in the stock ledger. It must be called inside a transaction.
+func restoreStock(tx *gorm.DB, productSupplierID uint, quantity int, movement entities.StockMovement) error {
+	var productSupplier entities.ProductSupplier
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productSupplier, productSupplierID).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&entities.ProductSupplier{}).
+		Where("id = ?", productSupplier.ID).
+		UpdateColumns(map[string]interface{}{
+			"quantity": gorm.Expr("quantity + ?", quantity),
+			"sales":    gorm.Expr("sales - ?", quantity),
+			"version":  nextVersion,
+		}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&entities.Product{}).
+		Where("id = ?", productSupplier.ProductID).
+		UpdateColumns(map[string]interface{}{
+			"sales":   gorm.Expr("sales - ?", quantity),
+			"version": nextVersion,
+		}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&entities.Supplier{}).
+		Where("id = ?", productSupplier.SupplierID).
+		UpdateColumns(map[string]interface{}{
+			"quantity_stock": gorm.Expr("quantity_stock + ?", quantity),
+			"sales":          gorm.Expr("sales - ?", quantity),
+			"version":        nextVersion,
+		}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	return recordMovement(tx, &productSupplier, &movement, quantity)
+}
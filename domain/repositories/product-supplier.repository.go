@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"slices"
 	"store/domain/entities"
+	"store/domain/query"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductSupplierRepository is an interface that defines the methods that must
@@ -14,12 +17,16 @@ import (
 // It provides methods for creating a new productSupplier, getting a productSupplier by its ID, getting all productSuppliers,
 // updating a productSupplier, and deleting a productSupplier.
 type ProductSupplierRepository interface {
-	Create(ctx *gin.Context, productSupplier *entities.ProductSupplier) error // Create a new productSupplier
-	GetByID(ctx *gin.Context, id uint) (*entities.ProductSupplier, error)     // Get a productSupplier by ID
-	GetAll(ctx *gin.Context) ([]*entities.ProductSupplier, error)             // Get all productSuppliers
-	Update(ctx *gin.Context, productSupplier *entities.ProductSupplier) error // Update a productSupplier
-	Delete(ctx *gin.Context, id uint) error                                   // Delete a productSupplier
-	DeleteAll(ctx *gin.Context, ids []uint) error                             // Delete multiple productSuppliers
+	Create(ctx *gin.Context, productSupplier *entities.ProductSupplier) error                                                 // Create a new productSupplier
+	GetByID(ctx *gin.Context, id uint) (*entities.ProductSupplier, error)                                                     // Get a productSupplier by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error)                              // Get all productSuppliers
+	Update(ctx *gin.Context, productSupplier *entities.ProductSupplier, fields ...string) error                               // Update a productSupplier
+	Delete(ctx *gin.Context, id uint, version uint) error                                                                     // Delete a productSupplier
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                                                           // Delete multiple productSuppliers
+	GetAllByProductID(ctx *gin.Context, productID uint, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error)   // Get the productSuppliers of a product
+	GetAllBySupplierID(ctx *gin.Context, supplierID uint, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error) // Get the productSuppliers of a supplier
+	GetOffers(ctx *gin.Context, productID uint) ([]*entities.ProductSupplier, error)                                          // Get every productSupplier of a product
+	TrashRepository[entities.ProductSupplier]                                                                                 // Get, restore and purge deleted productSuppliers
 }
 
 // productSupplierRepository is a struct that contains a pointer to a gorm DB instance and
@@ -28,6 +35,7 @@ type ProductSupplierRepository interface {
 // CRUD operations and other queries on the products table.
 type productSupplierRepository struct {
 	db *gorm.DB
+	trashRepository[entities.ProductSupplier]
 }
 
 // NewProductSupplierRepository creates a new instance of productSupplierRepository with the provided
@@ -35,7 +43,19 @@ type productSupplierRepository struct {
 // This function is used to initialize a new productSupplier repository that can perform
 // CRUD operations and other queries on the product_suppliers table.
 func NewProductSupplierRepository(db *gorm.DB) ProductSupplierRepository {
-	return &productSupplierRepository{db: db}
+	return &productSupplierRepository{
+		db: db,
+		trashRepository: trashRepository[entities.ProductSupplier]{
+			db: db,
+			purges: []dependent{
+				{model: &entities.StockMovementLot{}, column: "product_supplier_id"},
+				{model: &entities.StockMovement{}, column: "product_supplier_id"},
+				{model: &entities.StockReservation{}, column: "product_supplier_id"},
+				{model: &entities.StockLot{}, column: "product_supplier_id"},
+				{model: &entities.WarehouseStock{}, column: "product_supplier_id"},
+			},
+		},
+	}
 }
 
 // Creates a new productSupplier in the database.
@@ -49,8 +69,27 @@ func NewProductSupplierRepository(db *gorm.DB) ProductSupplierRepository {
 //
 // The method returns an error if something goes wrong. If the productSupplier is created
 // successfully, the method returns nil.
+//
+// The initial quantity of the productSupplier is added to the stock of its
+// Supplier and recorded in the stock ledger as an adjustment, in the same
+// transaction.
 func (r *productSupplierRepository) Create(ctx *gin.Context, productSupplier *entities.ProductSupplier) error {
-	return r.db.WithContext(ctx).Create(productSupplier).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(productSupplier).Error; err != nil {
+			return err
+		}
+		if productSupplier.Quantity == 0 {
+			return nil
+		}
+
+		if err := moveSupplierStock(tx, productSupplier.SupplierID, productSupplier.Quantity); err != nil {
+			return err
+		}
+		opening := *productSupplier
+		opening.Quantity = 0
+		movement := &entities.StockMovement{Type: entities.StockMovementAdjustment, Reason: "initial stock"}
+		return recordMovement(tx, &opening, movement, productSupplier.Quantity)
+	})
 }
 
 // Retrieves a productSupplier by its ID from the database.
@@ -69,19 +108,30 @@ func (r *productSupplierRepository) GetByID(ctx *gin.Context, id uint) (*entitie
 	return &productSupplier, err
 }
 
-// Retrieves all productSuppliers from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.ProductSupplier and an error. If something goes wrong,
-// the method returns an empty slice and an error.
+// ProductSupplierListSchema lists the fields of a product supplier that can be
+// used to filter and sort the product suppliers in a list query.
+var ProductSupplierListSchema = query.Model(query.Schema{
+	"product_id":            {Column: "product_id", Type: query.Number},
+	"supplier_id":           {Column: "supplier_id", Type: query.Number},
+	"quantity":              {Column: "quantity", Type: query.Number},
+	"reserved":              {Column: "reserved", Type: query.Number},
+	"reorder_point":         {Column: "reorder_point", Type: query.Number},
+	"safety_stock":          {Column: "safety_stock", Type: query.Number},
+	"reorder_quantity":      {Column: "reorder_quantity", Type: query.Number},
+	"sales":                 {Column: "sales", Type: query.Number},
+	"supplier_product_code": {Column: "supplier_product_code", Type: query.String},
+	"supplier_product_name": {Column: "supplier_product_name", Type: query.String},
+})
+
+// Retrieves a page of product suppliers from the database.
 //
-// The method fetches all productSuppliers from the database. If the productSuppliers
-// are found, the method returns the productSuppliers and nil. If no productSuppliers
-// are found or an error occurs, the method returns an empty slice and an error.
-func (r *productSupplierRepository) GetAll(ctx *gin.Context) ([]*entities.ProductSupplier, error) {
-	var productSuppliers []*entities.ProductSupplier
-	err := r.db.WithContext(ctx).Find(&productSuppliers).Error
-	return productSuppliers, err
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against ProductSupplierListSchema. It returns the page
+// of product suppliers matching the filters of the query, in the requested
+// order, along with the total count and the link to the next page. If something
+// goes wrong, the method returns nil and an error.
+func (r *productSupplierRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error) {
+	return query.Find[entities.ProductSupplier](r.db.WithContext(ctx), q)
 }
 
 // Updates a productSupplier in the database.
@@ -95,8 +145,50 @@ func (r *productSupplierRepository) GetAll(ctx *gin.Context) ([]*entities.Produc
 //
 // The method returns an error if something goes wrong. If the productSupplier is updated
 // successfully, the method returns nil.
-func (r *productSupplierRepository) Update(ctx *gin.Context, productSupplier *entities.ProductSupplier) error {
-	return r.db.WithContext(ctx).Save(productSupplier).Error
+//
+// The update is based on the Version of the productSupplier, which is
+// incremented. It returns gorm.ErrRecordNotFound if the productSupplier does
+// not exist, or ErrVersionConflict if it has been changed since that version.
+//
+// When fields are given, only those fields of the productSupplier, named as in
+// its struct, are saved, as done for partial updates.
+//
+// A change of the quantity is applied to the stock of the Supplier and recorded
+// in the stock ledger as an adjustment, in the same transaction. The reserved
+// quantity is managed by the stock reservations and is never saved by an
+// update.
+func (r *productSupplierRepository) Update(ctx *gin.Context, productSupplier *entities.ProductSupplier, fields ...string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored entities.ProductSupplier
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stored, productSupplier.ID).Error
+		if err != nil {
+			return err
+		}
+
+		productSupplier.Reserved = stored.Reserved
+		if len(fields) > 0 {
+			fields = slices.DeleteFunc(fields, func(field string) bool { return field == "Reserved" })
+			if len(fields) == 0 {
+				return nil
+			}
+		}
+		if err := updateVersioned(tx, productSupplier, productSupplier.ID, &productSupplier.Version, fields...); err != nil {
+			return err
+		}
+		if !slices.Contains(fields, "Quantity") && len(fields) > 0 {
+			return nil
+		}
+		delta := productSupplier.Quantity - stored.Quantity
+		if delta == 0 {
+			return nil
+		}
+
+		if err := moveSupplierStock(tx, stored.SupplierID, delta); err != nil {
+			return err
+		}
+		movement := &entities.StockMovement{Type: entities.StockMovementAdjustment, Reason: "product supplier updated"}
+		return recordMovement(tx, &stored, movement, delta)
+	})
 }
 
 // Deletes a productSupplier by its ID from the database.
@@ -107,18 +199,69 @@ func (r *productSupplierRepository) Update(ctx *gin.Context, productSupplier *en
 // The method deletes a productSupplier from the database using the given ID.
 // If the productSupplier is deleted successfully, the method returns nil. If the
 // productSupplier is not found or an error occurs, the method returns an error.
-func (r *productSupplierRepository) Delete(ctx *gin.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.ProductSupplier{}, id).Error
+//
+// The productSupplier is only deleted if it is still at the given version, or
+// whatever its version is with AnyVersion. It returns gorm.ErrRecordNotFound if
+// the productSupplier does not exist, ErrVersionConflict if it has been
+// changed since that version, or ErrStillReferenced if it is used by order
+// lines or purchase order lines.
+func (r *productSupplierRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return deleteUnlessBlocked[entities.ProductSupplier](r.db.WithContext(ctx), id, version, ErrStillReferenced, productSupplierBlockers...)
 }
 
 // Deletes multiple productSuppliers from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
+// The method takes a pointer to a *gin.Context and a slice of uints as
+// parameters. It deletes the productSuppliers in a single transaction and
+// returns the outcome for each id: deleted, not found or blocked.
+// ProductSuppliers used by order lines or purchase order lines are kept. It
+// returns ErrNoIDs if no id is given, or an error if something goes wrong, in
+// which case nothing is deleted.
+func (r *productSupplierRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	return deleteAll(r.db.WithContext(ctx), &entities.ProductSupplier{}, ids, productSupplierBlockers...)
+}
+
+// productSupplierBlockers keep the productSuppliers used by order lines or
+// purchase order lines.
+var productSupplierBlockers = []blocker{
+	referencedBy(&entities.OrderProductSupplier{}, "product_supplier_id", "order lines"),
+	referencedBy(&entities.PurchaseOrderLine{}, "product_supplier_id", "purchase order lines"),
+}
+
+// Retrieves a page of the productSuppliers of the given product, that is the
+// offers of every supplier of the product.
+//
+// The method takes a pointer to a *gin.Context, the ID of the product and the
+// list query parsed from the request, validated against
+// ProductSupplierListSchema. It returns the page of productSuppliers or an
+// error if something goes wrong.
+func (r *productSupplierRepository) GetAllByProductID(ctx *gin.Context, productID uint, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error) {
+	return query.Find[entities.ProductSupplier](r.db.WithContext(ctx).Where("product_id = ?", productID), q)
+}
+
+// Retrieves every productSupplier of the given product, the offers an order
+// line can be fulfilled from, ordered by ID, with the units of their expired
+// lots loaded in Expired, so their Sellable quantity can be compared.
+//
+// The method takes a pointer to a *gin.Context and the ID of the product. It
+// returns the productSuppliers, none if the product is not offered, or an error
+// if something goes wrong.
+func (r *productSupplierRepository) GetOffers(ctx *gin.Context, productID uint) ([]*entities.ProductSupplier, error) {
+	var productSuppliers []*entities.ProductSupplier
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("id").Find(&productSuppliers).Error
+	if err != nil {
+		return nil, err
+	}
+	return productSuppliers, loadExpired(r.db.WithContext(ctx), productSuppliers...)
+}
+
+// Retrieves a page of the productSuppliers of the given supplier, that is the
+// products offered by the supplier.
 //
-// The method deletes multiple productSuppliers from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the productSuppliers
-// are deleted successfully, the method returns nil.
-func (r *productSupplierRepository) DeleteAll(ctx *gin.Context, ids []uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.ProductSupplier{}, ids).Error
+// The method takes a pointer to a *gin.Context, the ID of the supplier and the
+// list query parsed from the request, validated against
+// ProductSupplierListSchema. It returns the page of productSuppliers or an
+// error if something goes wrong.
+func (r *productSupplierRepository) GetAllBySupplierID(ctx *gin.Context, supplierID uint, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error) {
+	return query.Find[entities.ProductSupplier](r.db.WithContext(ctx).Where("supplier_id = ?", supplierID), q)
 }
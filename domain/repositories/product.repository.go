@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -14,12 +15,13 @@ import (
 // It provides methods for creating a new product, getting a product by its ID, getting all products,
 // updating a product, and deleting a product.
 type ProductRepository interface {
-	Create(ctx *gin.Context, product *entities.Product) error     // Create a new product
-	GetByID(ctx *gin.Context, id uint) (*entities.Product, error) // Get a product by its ID
-	GetAll(ctx *gin.Context) ([]*entities.Product, error)         // Get all products
-	Update(ctx *gin.Context, product *entities.Product) error     // Update a product
-	Delete(ctx *gin.Context, id uint) error                       // Delete a product
-	DeleteAll(ctx *gin.Context, ids []uint) error                 // Delete multiple products
+	Create(ctx *gin.Context, product *entities.Product) error                            // Create a new product
+	GetByID(ctx *gin.Context, id uint) (*entities.Product, error)                        // Get a product by its ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Product], error) // Get all products
+	Update(ctx *gin.Context, product *entities.Product, fields ...string) error          // Update a product
+	Delete(ctx *gin.Context, id uint, version uint) error                                // Delete a product
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                      // Delete multiple products
+	TrashRepository[entities.Product]                                                    // Get, restore and purge deleted products
 }
 
 // productRepository is a struct that contains a pointer to a gorm DB instance and
@@ -29,6 +31,7 @@ type ProductRepository interface {
 // with the products table in the database.
 type productRepository struct {
 	db *gorm.DB
+	trashRepository[entities.Product]
 }
 
 // NewProductRepository creates a new instance of productRepository with the provided
@@ -36,7 +39,7 @@ type productRepository struct {
 // This function is used to initialize a new product repository that can perform
 // CRUD operations and other queries on the products table.
 func NewProductRepository(db *gorm.DB) ProductRepository {
-	return &productRepository{db: db}
+	return &productRepository{db: db, trashRepository: trashRepository[entities.Product]{db: db}}
 }
 
 // Creates a new product in the database.
@@ -70,20 +73,24 @@ func (r *productRepository) GetByID(ctx *gin.Context, id uint) (*entities.Produc
 	return &product, err
 }
 
-// GetAll gets all products from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.Product and an error. If something goes wrong, the
-// method returns an empty slice and an error.
-//
-// The method fetches all products from the database. If the products are
-// successfully retrieved, the method returns the products and nil. If no
-// products are found or an error occurs, the method returns an empty slice and
-// an error.
-func (r *productRepository) GetAll(ctx *gin.Context) ([]*entities.Product, error) {
-	var products []*entities.Product
-	err := r.db.WithContext(ctx).Find(&products).Error
-	return products, err
+// ProductListSchema lists the fields of a product that can be used to filter
+// and sort the products in a list query.
+var ProductListSchema = query.Model(query.Schema{
+	"name":                  {Column: "name", Type: query.String},
+	"code":                  {Column: "code", Type: query.String},
+	"sales":                 {Column: "sales", Type: query.Number},
+	"market_value_currency": {Column: "market_value_currency", Type: query.String},
+})
+
+// Retrieves a page of products from the database.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against ProductListSchema. It returns the page of
+// products matching the filters of the query, in the requested order, along
+// with the total count and the link to the next page. If something goes wrong,
+// the method returns nil and an error.
+func (r *productRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Product], error) {
+	return query.Find[entities.Product](r.db.WithContext(ctx), q)
 }
 
 // Updates a product in the database.
@@ -97,8 +104,15 @@ func (r *productRepository) GetAll(ctx *gin.Context) ([]*entities.Product, error
 //
 // The method returns an error if something goes wrong. If the product is updated
 // successfully, the method returns nil.
-func (r *productRepository) Update(ctx *gin.Context, product *entities.Product) error {
-	return r.db.WithContext(ctx).Save(product).Error
+//
+// The update is based on the Version of the product, which is incremented. It
+// returns gorm.ErrRecordNotFound if the product does not exist, or
+// ErrVersionConflict if it has been changed since that version.
+//
+// When fields are given, only those fields of the product, named as in its
+// struct, are saved, as done for partial updates.
+func (r *productRepository) Update(ctx *gin.Context, product *entities.Product, fields ...string) error {
+	return updateVersioned(r.db.WithContext(ctx), product, product.ID, &product.Version, fields...)
 }
 
 // Deletes a product from the database by its ID.
@@ -109,18 +123,25 @@ func (r *productRepository) Update(ctx *gin.Context, product *entities.Product)
 // The method deletes a product from the database using the given ID. If the
 // product is deleted successfully, the method returns nil. If the product is not
 // found or an error occurs, the method returns an error.
-func (r *productRepository) Delete(ctx *gin.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.Product{}, id).Error
+//
+// The product is only deleted if it is still at the given version, or whatever
+// its version is with AnyVersion. It returns gorm.ErrRecordNotFound if the
+// product does not exist, ErrVersionConflict if it has been changed since that
+// version, or ErrStillReferenced if it is offered by a supplier.
+func (r *productRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return deleteUnlessBlocked[entities.Product](r.db.WithContext(ctx), id, version, ErrStillReferenced, productBlockers...)
 }
 
 // Deletes multiple products from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple products from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the products are
-// deleted successfully, the method returns nil.
-func (r *productRepository) DeleteAll(ctx *gin.Context, ids []uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.Product{}, ids).Error
+// The method takes a pointer to a *gin.Context and a slice of uints as
+// parameters. It deletes the products in a single transaction and returns the
+// outcome for each id: deleted, not found or blocked. Products offered by a
+// supplier are kept. It returns ErrNoIDs if no id is given, or an error if
+// something goes wrong, in which case nothing is deleted.
+func (r *productRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	return deleteAll(r.db.WithContext(ctx), &entities.Product{}, ids, productBlockers...)
 }
+
+// productBlockers keep the products offered by a supplier.
+var productBlockers = []blocker{referencedBy(&entities.ProductSupplier{}, "product_id", "product suppliers")}
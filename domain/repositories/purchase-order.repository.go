@@ -0,0 +1,313 @@
+package repositories
+
+import (
+	"slices"
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/query"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPurchaseOrderNotOpen is returned when goods are received for a purchase
+// order that is not waiting for goods, because it is still a draft or it was
+// closed.
+var ErrPurchaseOrderNotOpen = apperrors.Conflict("purchase_order_not_open", "goods can only be received for sent or partially received purchase orders")
+
+// openPurchaseOrderStatuses lists the statuses of the purchase orders that are
+// waiting for goods.
+var openPurchaseOrderStatuses = []entities.PurchaseOrderStatus{
+	entities.PurchaseOrderStatusSent,
+	entities.PurchaseOrderStatusPartiallyReceived,
+}
+
+// PurchaseOrderRepository is an interface that defines the methods that must
+// be implemented by any data store that wants to interact with the
+// purchase_orders table in the database.
+//
+// It provides methods for creating a new purchase order with its lines,
+// getting a purchase order by its ID, getting all purchase orders, updating a
+// draft, deleting purchase orders, changing the status of a purchase order and
+// receiving its goods.
+type PurchaseOrderRepository interface {
+	Create(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error                                            // Create a new purchase order
+	GetByID(ctx *gin.Context, id uint) (*entities.PurchaseOrder, error)                                              // Get a purchase order with its lines and receipts by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.PurchaseOrder], error)                       // Get all purchase orders
+	Update(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error                                            // Update a purchase order and replace its lines
+	Delete(ctx *gin.Context, id uint, version uint) error                                                            // Delete a purchase order
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                                                  // Delete multiple purchase orders
+	ChangeStatus(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder, status entities.PurchaseOrderStatus) error // Change the status of a purchase order
+	Receive(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder, receipt *entities.GoodsReceipt) error           // Receive goods for a purchase order
+	GetReceipts(ctx *gin.Context, purchaseOrderID uint) ([]*entities.GoodsReceipt, error)                            // Get the goods receipts of a purchase order
+	TrashRepository[entities.PurchaseOrder]                                                                          // Get, restore and purge deleted purchase orders
+}
+
+// purchaseOrderRepository is a struct that contains a pointer to a gorm DB
+// instance and implements the PurchaseOrderRepository.
+type purchaseOrderRepository struct {
+	db *gorm.DB
+	trashRepository[entities.PurchaseOrder]
+}
+
+// NewPurchaseOrderRepository creates a new instance of purchaseOrderRepository
+// with the provided database instance and returns it as a
+// PurchaseOrderRepository.
+func NewPurchaseOrderRepository(db *gorm.DB) PurchaseOrderRepository {
+	return &purchaseOrderRepository{
+		db: db,
+		trashRepository: trashRepository[entities.PurchaseOrder]{
+			db:     db,
+			purges: []dependent{{model: &entities.PurchaseOrderLine{}, column: "purchase_order_id"}},
+		},
+	}
+}
+
+// Creates a new purchase order with its lines in the database.
+//
+// The method takes a pointer to a *gin.Context and a pointer to an
+// entities.PurchaseOrder as parameters. The purchase order and its lines are
+// inserted in a single transaction. It returns an error if something goes
+// wrong.
+func (r *purchaseOrderRepository) Create(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error {
+	return r.db.WithContext(ctx).Create(purchaseOrder).Error
+}
+
+// Retrieves a purchase order by its ID from the database.
+//
+// The method takes a pointer to a *gin.Context and the ID of the purchase
+// order. It returns the purchase order with its lines and its goods receipts
+// with their lines, or gorm.ErrRecordNotFound if the purchase order does not
+// exist.
+func (r *purchaseOrderRepository) GetByID(ctx *gin.Context, id uint) (*entities.PurchaseOrder, error) {
+	var purchaseOrder entities.PurchaseOrder
+	err := r.db.WithContext(ctx).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Receipts", func(db *gorm.DB) *gorm.DB { return db.Order("received_at, id") }).
+		Preload("Receipts.Lines").
+		First(&purchaseOrder, id).
+		Error
+	return &purchaseOrder, err
+}
+
+// PurchaseOrderListSchema lists the fields of a purchase order that can be
+// used to filter and sort the purchase orders in a list query.
+var PurchaseOrderListSchema = query.Model(query.Schema{
+	"supplier_id":   {Column: "supplier_id", Type: query.Number},
+	"status":        {Column: "status", Type: query.String},
+	"order_date":    {Column: "order_date", Type: query.Time},
+	"expected_date": {Column: "expected_date", Type: query.Time},
+})
+
+// Retrieves a page of purchase orders from the database.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against PurchaseOrderListSchema. It returns the page
+// of purchase orders, without their lines, or an error if something goes
+// wrong.
+func (r *purchaseOrderRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.PurchaseOrder], error) {
+	return query.Find[entities.PurchaseOrder](r.db.WithContext(ctx), q)
+}
+
+// Updates a purchase order and replaces its lines in the database.
+//
+// The method saves the order date, expected date and note of the purchase
+// order, and replaces its lines with the given ones, in a single transaction.
+// The update is based on the Version of the purchase order, which is
+// incremented. It returns gorm.ErrRecordNotFound if the purchase order does
+// not exist, or ErrVersionConflict if it has been changed since that version.
+func (r *purchaseOrderRepository) Update(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := updateVersioned(tx, purchaseOrder, purchaseOrder.ID, &purchaseOrder.Version, "OrderDate", "ExpectedDate", "Note")
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Where("purchase_order_id = ?", purchaseOrder.ID).Delete(&entities.PurchaseOrderLine{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range purchaseOrder.Lines {
+			purchaseOrder.Lines[i].ID = 0
+			purchaseOrder.Lines[i].PurchaseOrderID = purchaseOrder.ID
+		}
+		if len(purchaseOrder.Lines) == 0 {
+			return nil
+		}
+		return tx.Create(&purchaseOrder.Lines).Error
+	})
+}
+
+// Deletes a purchase order by its ID from the database.
+//
+// The purchase order is only deleted if it is still at the given version, or
+// whatever its version is with AnyVersion. It returns gorm.ErrRecordNotFound
+// if the purchase order does not exist, or ErrVersionConflict if it has been
+// changed since that version.
+func (r *purchaseOrderRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return deleteVersioned[entities.PurchaseOrder](r.db.WithContext(ctx), id, version)
+}
+
+// Deletes multiple purchase orders from the database by their IDs.
+//
+// The method deletes the purchase orders in a single transaction and returns
+// the outcome for each id: deleted, not found or blocked. Purchase orders that
+// are no longer drafts are kept. It returns ErrNoIDs if no id is given, or an
+// error if something goes wrong, in which case nothing is deleted.
+func (r *purchaseOrderRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	return deleteAll(r.db.WithContext(ctx), &entities.PurchaseOrder{}, ids, purchaseOrderNotDraft)
+}
+
+// Changes the status of a purchase order.
+//
+// The status is only updated if the purchase order is still in its current
+// status, otherwise ErrStatusChanged is returned. Sending a purchase order
+// sets its SentAt, and closing it its ClosedAt. On success the status and the
+// version of the purchase order are updated and the method returns nil.
+func (r *purchaseOrderRepository) ChangeStatus(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder, status entities.PurchaseOrderStatus) error {
+	now := time.Now()
+	updates := map[string]interface{}{"status": status, "version": nextVersion, "updated_at": now}
+	switch status {
+	case entities.PurchaseOrderStatusSent:
+		updates["sent_at"] = now
+		purchaseOrder.SentAt = &now
+	case entities.PurchaseOrderStatusClosed:
+		updates["closed_at"] = now
+		purchaseOrder.ClosedAt = &now
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&entities.PurchaseOrder{}).
+		Where("id = ? AND status = ?", purchaseOrder.ID, purchaseOrder.Status).
+		UpdateColumns(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusChanged
+	}
+
+	purchaseOrder.Status = status
+	purchaseOrder.Version++
+	return nil
+}
+
+// Receives goods for a purchase order in a single database transaction.
+//
+// The method takes a pointer to a *gin.Context, the purchase order with its
+// lines and the goods receipt, whose lines reference lines of the purchase
+// order. The purchase order is locked and must still be sent or partially
+// received, otherwise ErrPurchaseOrderNotOpen is returned, and its lines are
+// read again once it is locked, so concurrent receipts of the same purchase
+// order add up instead of deciding its status from stale quantities. The
+// quantity of
+// every receipt line is added to the received quantity of its purchase order
+// line, which may exceed the quantity ordered, and to the stock of the ordered
+// ProductSupplier in the warehouse of the receipt, the default warehouse when
+// it has none, in the lot of the receipt line when it has one, recorded in the
+// stock ledger as a receipt referencing the purchase order. The purchase
+// order becomes received once every line has received its ordered quantity,
+// and partially received otherwise.
+//
+// If anything fails, the whole transaction is rolled back. On success the
+// receipt is persisted and the purchase order and its lines are updated.
+func (r *purchaseOrderRepository) Receive(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder, receipt *entities.GoodsReceipt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked entities.PurchaseOrder
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id, status, version").
+			First(&locked, purchaseOrder.ID).
+			Error
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(openPurchaseOrderStatuses, locked.Status) {
+			return ErrPurchaseOrderNotOpen.With("status", locked.Status)
+		}
+		err = tx.Where("purchase_order_id = ?", purchaseOrder.ID).Order("id").Find(&purchaseOrder.Lines).Error
+		if err != nil {
+			return err
+		}
+
+		lines := make(map[uint]*entities.PurchaseOrderLine, len(purchaseOrder.Lines))
+		for i := range purchaseOrder.Lines {
+			lines[purchaseOrder.Lines[i].ID] = &purchaseOrder.Lines[i]
+		}
+		for _, received := range receipt.Lines {
+			line := lines[received.PurchaseOrderLineID]
+			err := tx.Model(&entities.PurchaseOrderLine{}).
+				Where("id = ?", line.ID).
+				UpdateColumns(map[string]interface{}{
+					"received_quantity": gorm.Expr("received_quantity + ?", received.Quantity),
+					"version":           nextVersion,
+				}).
+				Error
+			if err != nil {
+				return err
+			}
+			line.ReceivedQuantity += received.Quantity
+			line.Version++
+
+			movement := &entities.StockMovement{
+				ProductSupplierID: line.ProductSupplierID,
+				WarehouseID:       receipt.WarehouseID,
+				Type:              entities.StockMovementReceipt,
+				Quantity:          received.Quantity,
+				Reason:            "goods receipt",
+				ReferenceType:     "purchase_order",
+				Reference:         strconv.FormatUint(uint64(purchaseOrder.ID), 10),
+				CreatedBy:         receipt.ReceivedBy,
+			}
+			if received.LotNumber != "" {
+				movement.Lots = []entities.StockMovementLot{{LotNumber: received.LotNumber, ExpiresAt: received.ExpiresAt}}
+			}
+			if err := adjustStock(tx, movement); err != nil {
+				return err
+			}
+			receipt.WarehouseID = movement.WarehouseID
+		}
+
+		receipt.PurchaseOrderID = purchaseOrder.ID
+		if err := tx.Create(receipt).Error; err != nil {
+			return err
+		}
+
+		status := entities.PurchaseOrderStatusReceived
+		for _, line := range purchaseOrder.Lines {
+			if line.Outstanding() > 0 {
+				status = entities.PurchaseOrderStatusPartiallyReceived
+			}
+		}
+		err = tx.Model(&entities.PurchaseOrder{}).
+			Where("id = ?", purchaseOrder.ID).
+			UpdateColumns(map[string]interface{}{"status": status, "version": nextVersion, "updated_at": time.Now()}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		purchaseOrder.Status = status
+		purchaseOrder.Version = locked.Version + 1
+		purchaseOrder.Receipts = append(purchaseOrder.Receipts, *receipt)
+		return nil
+	})
+}
+
+// Retrieves the goods receipts of a purchase order, with their lines, from the
+// oldest to the newest one.
+//
+// The method takes a pointer to a *gin.Context and the ID of the purchase
+// order. It returns the receipts or an error if something goes wrong.
+func (r *purchaseOrderRepository) GetReceipts(ctx *gin.Context, purchaseOrderID uint) ([]*entities.GoodsReceipt, error) {
+	var receipts []*entities.GoodsReceipt
+	err := r.db.WithContext(ctx).
+		Preload("Lines").
+		Where("purchase_order_id = ?", purchaseOrderID).
+		Order("received_at, id").
+		Find(&receipts).
+		Error
+	return receipts, err
+}
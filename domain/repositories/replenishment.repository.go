@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"store/domain/entities"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// salesStatuses lists the statuses of the orders whose lines took units out of
+// stock and count as sales of their product suppliers.
+var salesStatuses = []entities.OrderStatus{
+	entities.OrderStatusPlaced,
+	entities.OrderStatusPaid,
+	entities.OrderStatusPicking,
+	entities.OrderStatusShipped,
+	entities.OrderStatusDelivered,
+}
+
+// StockPosition is the stock of a product supplier along with the units sold
+// recently and the units still expected from its supplier, from which its
+// replenishment is planned.
+type StockPosition struct {
+	ProductSupplier *entities.ProductSupplier // product supplier, with its quantity on hand and reserved
+	Sold            int                       // units sold by the orders placed since the start of the sales window
+	Incoming        int                       // units still outstanding on the purchase orders sent to the supplier
+}
+
+// ReplenishmentRepository is an interface that defines the methods that must
+// be implemented by any data store that wants to read the stock positions the
+// replenishment of the product suppliers is planned from.
+type ReplenishmentRepository interface {
+	GetStockPositions(ctx *gin.Context, soldSince time.Time) ([]StockPosition, error) // Get the stock position of every product supplier
+}
+
+// replenishmentRepository is a struct that contains a pointer to a gorm DB
+// instance and implements the ReplenishmentRepository.
+type replenishmentRepository struct {
+	db *gorm.DB
+}
+
+// NewReplenishmentRepository creates a new instance of replenishmentRepository
+// with the provided database instance and returns it as a
+// ReplenishmentRepository.
+func NewReplenishmentRepository(db *gorm.DB) ReplenishmentRepository {
+	return &replenishmentRepository{db: db}
+}
+
+// Retrieves the stock position of every product supplier, ordered by supplier.
+//
+// The method takes a pointer to a *gin.Context and the start of the sales
+// window. The units sold are the quantities of the lines of the orders dated
+// since then that consumed stock and were neither cancelled nor returned, and
+// the units incoming the quantities still outstanding on the sent and
+// partially received purchase orders. It returns an error if something goes
+// wrong.
+func (r *replenishmentRepository) GetStockPositions(ctx *gin.Context, soldSince time.Time) ([]StockPosition, error) {
+	db := r.db.WithContext(ctx)
+
+	var productSuppliers []*entities.ProductSupplier
+	if err := db.Order("supplier_id, id").Find(&productSuppliers).Error; err != nil {
+		return nil, err
+	}
+
+	var sales []struct {
+		ProductSupplierID uint
+		Quantity          int
+	}
+	err := db.Model(&entities.OrderProductSupplier{}).
+		Select("order_product_suppliers.product_supplier_id, SUM(order_product_suppliers.quantity) AS quantity").
+		Joins("JOIN sales.orders ON orders.id = order_product_suppliers.order_id AND orders.deleted_at IS NULL").
+		Where("orders.status IN ? AND orders.order_date >= ?", salesStatuses, soldSince).
+		Group("order_product_suppliers.product_supplier_id").
+		Scan(&sales).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var incoming []struct {
+		ProductSupplierID uint
+		Quantity          int
+	}
+	err = db.Model(&entities.PurchaseOrderLine{}).
+		Select("purchase_order_lines.product_supplier_id, SUM(GREATEST(purchase_order_lines.quantity - purchase_order_lines.received_quantity, 0)) AS quantity").
+		Joins("JOIN sales.purchase_orders ON purchase_orders.id = purchase_order_lines.purchase_order_id AND purchase_orders.deleted_at IS NULL").
+		Where("purchase_orders.status IN ?", openPurchaseOrderStatuses).
+		Group("purchase_order_lines.product_supplier_id").
+		Scan(&incoming).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	sold := make(map[uint]int, len(sales))
+	for _, sale := range sales {
+		sold[sale.ProductSupplierID] = sale.Quantity
+	}
+	outstanding := make(map[uint]int, len(incoming))
+	for _, line := range incoming {
+		outstanding[line.ProductSupplierID] = line.Quantity
+	}
+
+	positions := make([]StockPosition, len(productSuppliers))
+	for i, productSupplier := range productSuppliers {
+		positions[i] = StockPosition{
+			ProductSupplier: productSupplier,
+			Sold:            sold[productSupplier.ID],
+			Incoming:        outstanding[productSupplier.ID],
+		}
+	}
+	return positions, nil
+}
@@ -0,0 +1,335 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/query"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrExpiredStock is returned when an order takes more units of a product
+	// supplier than the warehouse has in lots that are not expired.
+	ErrExpiredStock = apperrors.Conflict("expired_stock", "expired units cannot be sold")
+	// ErrUnknownLot is returned when a movement takes units out of a lot that
+	// does not exist in the warehouse.
+	ErrUnknownLot = apperrors.Invalid("unknown_lot", "the lot does not exist in the warehouse")
+	// ErrLotExpiryMismatch is returned when units are received in an existing
+	// lot with another expiry date.
+	ErrLotExpiryMismatch = apperrors.Invalid("lot_expiry_mismatch", "the lot already exists with another expiry date")
+)
+
+// StockLotRepository is an interface that defines the methods that must be
+// implemented by any data store that wants to interact with the stock_lots
+// table in the database.
+//
+// It provides methods for listing the lots of a product supplier and the lots
+// expiring up to a date. Lots are only changed by the movements of the stock.
+type StockLotRepository interface {
+	GetAllByProductSupplierID(ctx *gin.Context, productSupplierID uint, q *query.ListQuery) (*query.Page[*entities.StockLot], error) // Get the lots of a product supplier
+	GetExpiring(ctx *gin.Context, until time.Time) ([]*entities.StockLot, error)                                                     // Get the lots on hand expiring up to a date
+}
+
+// stockLotRepository is a struct that contains a pointer to a gorm DB instance
+// and implements the StockLotRepository.
+type stockLotRepository struct {
+	db *gorm.DB
+}
+
+// NewStockLotRepository creates a new instance of stockLotRepository with the
+// provided database instance and returns it as a StockLotRepository.
+func NewStockLotRepository(db *gorm.DB) StockLotRepository {
+	return &stockLotRepository{db: db}
+}
+
+// StockLotListSchema lists the fields of a lot that can be used to filter and
+// sort the lots of a product supplier in a list query.
+var StockLotListSchema = query.Model(query.Schema{
+	"warehouse_id": {Column: "warehouse_id", Type: query.Number},
+	"lot_number":   {Column: "lot_number", Type: query.String},
+	"expires_at":   {Column: "expires_at", Type: query.Time},
+	"quantity":     {Column: "quantity", Type: query.Number},
+	"received_at":  {Column: "received_at", Type: query.Time},
+})
+
+// Retrieves a page of the lots of a product supplier in every warehouse.
+//
+// The method takes a pointer to a *gin.Context, the ID of the product supplier
+// and the list query parsed from the request, validated against
+// StockLotListSchema. It returns the page of lots, emptied ones included, or an
+// error if something goes wrong.
+func (r *stockLotRepository) GetAllByProductSupplierID(ctx *gin.Context, productSupplierID uint, q *query.ListQuery) (*query.Page[*entities.StockLot], error) {
+	return query.Find[entities.StockLot](r.db.WithContext(ctx).Where("product_supplier_id = ?", productSupplierID), q)
+}
+
+// Retrieves the lots with units on hand whose expiry date is up to the given
+// one, the already expired ones included, from the first to expire.
+func (r *stockLotRepository) GetExpiring(ctx *gin.Context, until time.Time) ([]*entities.StockLot, error) {
+	var lots []*entities.StockLot
+	err := r.db.WithContext(ctx).
+		Where("quantity > 0 AND expires_at <= ?", until).
+		Order("expires_at, warehouse_id, product_supplier_id, id").
+		Find(&lots).
+		Error
+	return lots, err
+}
+
+// moveLotStock splits the quantity units of a movement, negative when they
+// leave the stock, between the lots of its product supplier in its warehouse,
+// and sets the Lots of the movement to the units moved in every lot. It must
+// be called inside the transaction recording the movement, after its
+// warehouse is set.
+//
+// A movement given a single lot by its caller moves the units in that lot,
+// created with its expiry date when units are received in it. Otherwise the
+// units leave the lots first expired, first out: the lots expiring first go
+// first and the lots without expiry date last, expired lots being skipped for
+// sales, which returns ErrExpiredStock when the remaining lots are not enough.
+// Units coming back to the stock go back to the lots the movements with the
+// same reference took them from, such as the sales of a cancelled order or the
+// shipment of a stock transfer, and to the lot without number otherwise.
+func moveLotStock(tx *gorm.DB, movement *entities.StockMovement, quantity int) error {
+	warehouseID, productSupplierID := *movement.WarehouseID, movement.ProductSupplierID
+
+	var lots []entities.StockMovementLot
+	var err error
+	switch {
+	case len(movement.Lots) == 1:
+		lots = []entities.StockMovementLot{{
+			LotNumber: movement.Lots[0].LotNumber,
+			ExpiresAt: movement.Lots[0].ExpiresAt,
+			Quantity:  quantity,
+		}}
+		err = receiveLot(tx, warehouseID, productSupplierID, &lots[0], quantity > 0)
+	case quantity > 0:
+		lots, err = returnedLots(tx, movement, quantity)
+		for i := 0; err == nil && i < len(lots); i++ {
+			err = receiveLot(tx, warehouseID, productSupplierID, &lots[i], false)
+		}
+	default:
+		lots, err = pickLots(tx, warehouseID, productSupplierID, -quantity, movement.Type == entities.StockMovementSale)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range lots {
+		lots[i].ProductSupplierID = productSupplierID
+	}
+	movement.Lots = lots
+	return nil
+}
+
+// receiveLot moves the units of a movement lot in the lot with its number,
+// locking it, and sets the ID and the expiry date of the lot on the movement
+// lot. Units received in a lot that does not exist yet create it, while taking
+// units out of it returns ErrUnknownLot. When checkExpiry is true, units
+// received in an existing lot with another expiry date return
+// ErrLotExpiryMismatch. It returns ErrInsufficientStock if the lot has fewer
+// units than the movement takes.
+func receiveLot(tx *gorm.DB, warehouseID, productSupplierID uint, movementLot *entities.StockMovementLot, checkExpiry bool) error {
+	if movementLot.Quantity > 0 {
+		lot := entities.StockLot{
+			WarehouseID:       warehouseID,
+			ProductSupplierID: productSupplierID,
+			LotNumber:         movementLot.LotNumber,
+			ExpiresAt:         movementLot.ExpiresAt,
+			ReceivedAt:        time.Now(),
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lot).Error; err != nil {
+			return err
+		}
+	}
+
+	var lot entities.StockLot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND product_supplier_id = ? AND lot_number = ?", warehouseID, productSupplierID, movementLot.LotNumber).
+		First(&lot).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUnknownLot.With("lot_number", movementLot.LotNumber)
+	}
+	if err != nil {
+		return err
+	}
+	if checkExpiry && !sameDate(lot.ExpiresAt, movementLot.ExpiresAt) {
+		return ErrLotExpiryMismatch.With("lot_number", lot.LotNumber).With("expires_at", lot.ExpiresAt)
+	}
+	if lot.Quantity+movementLot.Quantity < 0 {
+		return fmt.Errorf("%w: lot %q has %d units of product supplier %d, %d requested",
+			ErrInsufficientStock, lot.LotNumber, lot.Quantity, productSupplierID, -movementLot.Quantity)
+	}
+
+	movementLot.StockLotID = lot.ID
+	movementLot.ExpiresAt = lot.ExpiresAt
+	return updateLotQuantity(tx, lot.ID, movementLot.Quantity)
+}
+
+// pickLots takes quantity units out of the lots of a product supplier in a
+// warehouse, first expired, first out, locking them, and returns the units
+// taken from every lot as negative movement lots. Expired lots are skipped
+// when sale is true, which returns ErrExpiredStock if the other lots have
+// fewer units than taken, and ErrInsufficientStock otherwise.
+func pickLots(tx *gorm.DB, warehouseID, productSupplierID uint, quantity int, sale bool) ([]entities.StockMovementLot, error) {
+	var lots []entities.StockLot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND product_supplier_id = ? AND quantity > 0", warehouseID, productSupplierID).
+		Order("expires_at NULLS LAST, id").
+		Find(&lots).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var picked []entities.StockMovementLot
+	expired := 0
+	for _, lot := range lots {
+		if quantity == 0 {
+			break
+		}
+		if sale && lot.Expired(now) {
+			expired += lot.Quantity
+			continue
+		}
+		taken := min(quantity, lot.Quantity)
+		if err := updateLotQuantity(tx, lot.ID, -taken); err != nil {
+			return nil, err
+		}
+		picked = append(picked, entities.StockMovementLot{
+			StockLotID: lot.ID,
+			LotNumber:  lot.LotNumber,
+			ExpiresAt:  lot.ExpiresAt,
+			Quantity:   -taken,
+		})
+		quantity -= taken
+	}
+
+	if quantity > 0 && expired > 0 {
+		return nil, fmt.Errorf("%w: warehouse %d has %d expired units of product supplier %d, %d more requested",
+			ErrExpiredStock, warehouseID, expired, productSupplierID, quantity)
+	}
+	if quantity > 0 {
+		return nil, fmt.Errorf("%w: the lots of warehouse %d lack %d units of product supplier %d",
+			ErrInsufficientStock, warehouseID, quantity, productSupplierID)
+	}
+	return picked, nil
+}
+
+// returnedLots returns the lots quantity units coming back to the stock go
+// to: the lots the earlier movements of the product supplier with the same
+// reference took units from and did not give back yet, from the first to
+// expire, and the lot without number for the remaining units.
+func returnedLots(tx *gorm.DB, movement *entities.StockMovement, quantity int) ([]entities.StockMovementLot, error) {
+	var taken []entities.StockMovementLot
+	if movement.Reference != "" {
+		err := tx.Model(&entities.StockMovementLot{}).
+			Select("stock_movement_lots.lot_number, stock_movement_lots.expires_at, -SUM(stock_movement_lots.quantity) AS quantity").
+			Joins("JOIN sales.stock_movements ON stock_movements.id = stock_movement_lots.stock_movement_id").
+			Where("stock_movements.product_supplier_id = ? AND stock_movements.reference_type = ? AND stock_movements.reference = ?",
+				movement.ProductSupplierID, movement.ReferenceType, movement.Reference).
+			Group("stock_movement_lots.lot_number, stock_movement_lots.expires_at").
+			Having("SUM(stock_movement_lots.quantity) < 0").
+			Order("stock_movement_lots.expires_at NULLS LAST, stock_movement_lots.lot_number").
+			Scan(&taken).
+			Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var lots []entities.StockMovementLot
+	for _, lot := range taken {
+		if quantity == 0 {
+			break
+		}
+		returned := min(quantity, lot.Quantity)
+		lots = append(lots, entities.StockMovementLot{LotNumber: lot.LotNumber, ExpiresAt: lot.ExpiresAt, Quantity: returned})
+		quantity -= returned
+	}
+	if quantity > 0 {
+		lots = append(lots, entities.StockMovementLot{Quantity: quantity})
+	}
+	return lots, nil
+}
+
+// checkSellable returns ErrInsufficientStock if the product supplier has fewer
+// than quantity units available to promise, and ErrExpiredStock if it only has
+// them counting the units of its expired lots, loaded by loadExpired. It must
+// be called with the product supplier locked.
+func checkSellable(tx *gorm.DB, productSupplier *entities.ProductSupplier, quantity int) error {
+	if productSupplier.Available() < quantity {
+		return fmt.Errorf("%w: product supplier %d has %d units available, %d requested",
+			ErrInsufficientStock, productSupplier.ID, productSupplier.Available(), quantity)
+	}
+	if err := loadExpired(tx, productSupplier); err != nil {
+		return err
+	}
+	if productSupplier.Sellable() < quantity {
+		return fmt.Errorf("%w: product supplier %d has %d units available, %d of them expired, %d requested",
+			ErrExpiredStock, productSupplier.ID, productSupplier.Available(), productSupplier.Expired, quantity)
+	}
+	return nil
+}
+
+// loadExpired sets the Expired quantity of the product suppliers to the units
+// on hand in their expired lots, in every warehouse.
+func loadExpired(db *gorm.DB, productSuppliers ...*entities.ProductSupplier) error {
+	if len(productSuppliers) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(productSuppliers))
+	for i, productSupplier := range productSuppliers {
+		ids[i] = productSupplier.ID
+	}
+
+	var expired []struct {
+		ProductSupplierID uint
+		Quantity          int
+	}
+	err := db.Model(&entities.StockLot{}).
+		Select("product_supplier_id, SUM(quantity) AS quantity").
+		Where("product_supplier_id IN ? AND quantity > 0 AND expires_at < ?", ids, time.Now().UTC().Truncate(24*time.Hour)).
+		Group("product_supplier_id").
+		Scan(&expired).
+		Error
+	if err != nil {
+		return err
+	}
+
+	quantities := make(map[uint]int, len(expired))
+	for _, row := range expired {
+		quantities[row.ProductSupplierID] = row.Quantity
+	}
+	for _, productSupplier := range productSuppliers {
+		productSupplier.Expired = quantities[productSupplier.ID]
+	}
+	return nil
+}
+
+// updateLotQuantity adds quantity units, negative to remove them, to the lot
+// with the given ID.
+func updateLotQuantity(tx *gorm.DB, lotID uint, quantity int) error {
+	return tx.Model(&entities.StockLot{}).
+		Where("id = ?", lotID).
+		UpdateColumns(map[string]interface{}{
+			"quantity": gorm.Expr("quantity + ?", quantity),
+			"version":  nextVersion,
+		}).
+		Error
+}
+
+// sameDate reports whether both expiry dates are empty or fall on the same
+// day.
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.UTC().Format(time.DateOnly) == b.UTC().Format(time.DateOnly)
+}
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"store/domain/entities"
+	"store/domain/query"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockDrift is a stock counter that differs from the sum of the quantities of
+// the movements of the stock ledger it is reconciled with.
+type StockDrift struct {
+	ProductSupplierID uint `json:"product_supplier_id,omitempty"` // product supplier whose quantity drifted, empty for a supplier
+	SupplierID        uint `json:"supplier_id"`                   // supplier of the product supplier, or whose stock drifted
+	Quantity          int  `json:"quantity"`                      // quantity held by the counter
+	LedgerQuantity    int  `json:"ledger_quantity"`               // quantity derived from the stock ledger
+	Drift             int  `json:"drift"`                         // quantity minus ledger quantity
+}
+
+// StockMovementRepository is an interface that defines the methods that must
+// be implemented by any data store that wants to interact with the
+// stock_movements table in the database.
+//
+// It provides methods for recording a movement of the stock of a product
+// supplier, listing the movements of a product supplier and reconciling the
+// stock counters with the ledger. Movements are never updated nor deleted.
+type StockMovementRepository interface {
+	Create(ctx *gin.Context, movement *entities.StockMovement) error                                                                      // Record a movement of stock
+	GetAllByProductSupplierID(ctx *gin.Context, productSupplierID uint, q *query.ListQuery) (*query.Page[*entities.StockMovement], error) // Get the movements of a product supplier
+	Reconcile(ctx *gin.Context) ([]StockDrift, []StockDrift, error)                                                                       // Get the product suppliers and suppliers whose stock drifted
+}
+
+// stockMovementRepository is a struct that contains a pointer to a gorm DB
+// instance and implements the StockMovementRepository.
+type stockMovementRepository struct {
+	db *gorm.DB
+}
+
+// NewStockMovementRepository creates a new instance of stockMovementRepository
+// with the provided database instance and returns it as a
+// StockMovementRepository.
+func NewStockMovementRepository(db *gorm.DB) StockMovementRepository {
+	return &stockMovementRepository{db: db}
+}
+
+// Records a movement of the stock of a product supplier.
+//
+// The method takes a pointer to a *gin.Context and the movement, whose signed
+// Quantity is added to the quantity of its ProductSupplier and to the stock of
+// the Supplier, in a single transaction along with the insertion of the
+// movement. The BalanceAfter and MovedAt of the movement are set by the
+// method. It returns gorm.ErrRecordNotFound if the product supplier does not
+// exist, ErrInsufficientStock if the movement takes more units than it has, or
+// ErrUnknownLot or ErrLotExpiryMismatch if the lot of the movement is wrong.
+func (r *stockMovementRepository) Create(ctx *gin.Context, movement *entities.StockMovement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return adjustStock(tx, movement)
+	})
+}
+
+// StockMovementListSchema lists the fields of a stock movement that can be used
+// to filter and sort the movements in a list query.
+var StockMovementListSchema = query.Model(query.Schema{
+	"type":           {Column: "type", Type: query.String},
+	"quantity":       {Column: "quantity", Type: query.Number},
+	"reason":         {Column: "reason", Type: query.String},
+	"reference_type": {Column: "reference_type", Type: query.String},
+	"reference":      {Column: "reference", Type: query.String},
+	"warehouse_id":   {Column: "warehouse_id", Type: query.Number},
+	"created_by":     {Column: "created_by", Type: query.String},
+	"moved_at":       {Column: "moved_at", Type: query.Time},
+})
+
+// Retrieves a page of the movements of the stock of a product supplier.
+//
+// The method takes a pointer to a *gin.Context, the ID of the product supplier
+// and the list query parsed from the request, validated against
+// StockMovementListSchema. It returns the page of movements with their lots,
+// from the oldest one unless sorted otherwise, or an error if something goes
+// wrong.
+func (r *stockMovementRepository) GetAllByProductSupplierID(ctx *gin.Context, productSupplierID uint, q *query.ListQuery) (*query.Page[*entities.StockMovement], error) {
+	db := r.db.WithContext(ctx)
+	page, err := query.Find[entities.StockMovement](db.Where("product_supplier_id = ?", productSupplierID), q)
+	if err != nil || len(page.Data) == 0 {
+		return page, err
+	}
+
+	movements := make(map[uint]*entities.StockMovement, len(page.Data))
+	for _, movement := range page.Data {
+		movement.Lots = []entities.StockMovementLot{}
+		movements[movement.ID] = movement
+	}
+	var lots []entities.StockMovementLot
+	err = db.Where("stock_movement_id IN ?", slices.Collect(maps.Keys(movements))).Order("id").Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, lot := range lots {
+		movement := movements[lot.StockMovementID]
+		movement.Lots = append(movement.Lots, lot)
+	}
+	return page, nil
+}
+
+// Reconciles the stock counters with the stock ledger.
+//
+// The method returns the product suppliers whose quantity differs from the sum
+// of the quantities of their movements, and the suppliers whose stock differs
+// from the sum of the quantities of the movements of all of their product
+// suppliers, ordered by ID. Deleted product suppliers and suppliers are not
+// reconciled, but the movements of deleted product suppliers still count for
+// the stock of their supplier.
+func (r *stockMovementRepository) Reconcile(ctx *gin.Context) ([]StockDrift, []StockDrift, error) {
+	db := r.db.WithContext(ctx)
+
+	var productSuppliers []StockDrift
+	err := db.Table("sales.product_suppliers AS ps").
+		Select(`ps.id AS product_supplier_id, ps.supplier_id, ps.quantity,
+			COALESCE(SUM(m.quantity), 0) AS ledger_quantity,
+			ps.quantity - COALESCE(SUM(m.quantity), 0) AS drift`).
+		Joins("LEFT JOIN sales.stock_movements AS m ON m.product_supplier_id = ps.id AND m.deleted_at IS NULL").
+		Where("ps.deleted_at IS NULL").
+		Group("ps.id, ps.supplier_id, ps.quantity").
+		Having("ps.quantity <> COALESCE(SUM(m.quantity), 0)").
+		Order("ps.id").
+		Scan(&productSuppliers).
+		Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var suppliers []StockDrift
+	err = db.Table("sales.suppliers AS s").
+		Select(`s.id AS supplier_id, s.quantity_stock AS quantity,
+			COALESCE(SUM(m.quantity), 0) AS ledger_quantity,
+			s.quantity_stock - COALESCE(SUM(m.quantity), 0) AS drift`).
+		Joins("LEFT JOIN sales.product_suppliers AS ps ON ps.supplier_id = s.id").
+		Joins("LEFT JOIN sales.stock_movements AS m ON m.product_supplier_id = ps.id AND m.deleted_at IS NULL").
+		Where("s.deleted_at IS NULL").
+		Group("s.id, s.quantity_stock").
+		Having("s.quantity_stock <> COALESCE(SUM(m.quantity), 0)").
+		Order("s.id").
+		Scan(&suppliers).
+		Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return productSuppliers, suppliers, nil
+}
+
+// adjustStock locks the ProductSupplier of the movement, checks it has enough
+// units for a negative movement and adds the quantity of the movement to the
+// quantity of the ProductSupplier and to the stock of its Supplier, then
+// records the movement. Unlike consumeStock, the sales counters are untouched.
+// It must be called inside a transaction.
+func adjustStock(tx *gorm.DB, movement *entities.StockMovement) error {
+	var productSupplier entities.ProductSupplier
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productSupplier, movement.ProductSupplierID).Error
+	if err != nil {
+		return err
+	}
+	if productSupplier.Quantity+movement.Quantity < 0 {
+		return fmt.Errorf("%w: product supplier %d has %d units, %d requested",
+			ErrInsufficientStock, productSupplier.ID, productSupplier.Quantity, -movement.Quantity)
+	}
+
+	err = tx.Model(&entities.ProductSupplier{}).
+		Where("id = ?", productSupplier.ID).
+		UpdateColumns(map[string]interface{}{
+			"quantity": gorm.Expr("quantity + ?", movement.Quantity),
+			"version":  nextVersion,
+		}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	if err := moveSupplierStock(tx, productSupplier.SupplierID, movement.Quantity); err != nil {
+		return err
+	}
+	return recordMovement(tx, &productSupplier, movement, movement.Quantity)
+}
+
+// moveSupplierStock adds quantity units, negative to remove them, to the stock
+// of the Supplier with the given ID. It must be called inside a transaction.
+func moveSupplierStock(tx *gorm.DB, supplierID uint, quantity int) error {
+	return tx.Model(&entities.Supplier{}).
+		Where("id = ?", supplierID).
+		UpdateColumns(map[string]interface{}{
+			"quantity_stock": gorm.Expr("quantity_stock + ?", quantity),
+			"version":        nextVersion,
+		}).
+		Error
+}
+
+// recordMovement inserts the movement of quantity units, negative when they
+// leave the stock, of the given ProductSupplier, as it was before its quantity
+// was updated. The movement is taken as a template giving its warehouse, type,
+// reason, reference, user and, optionally, lot. The units are moved in the
+// stock of the warehouse of the movement, the default warehouse when it has
+// none, and in its lots as described in moveLotStock. It must be called inside
+// the transaction updating the quantity.
+func recordMovement(tx *gorm.DB, productSupplier *entities.ProductSupplier, movement *entities.StockMovement, quantity int) error {
+	if movement.WarehouseID == nil {
+		warehouseID, err := defaultWarehouseID(tx)
+		if err != nil {
+			return err
+		}
+		movement.WarehouseID = &warehouseID
+	}
+	if err := moveWarehouseStock(tx, *movement.WarehouseID, productSupplier.ID, quantity); err != nil {
+		return err
+	}
+
+	movement.ProductSupplierID = productSupplier.ID
+	if err := moveLotStock(tx, movement, quantity); err != nil {
+		return err
+	}
+
+	movement.Quantity = quantity
+	movement.BalanceAfter = productSupplier.Quantity + quantity
+	movement.MovedAt = time.Now()
+	return tx.Create(movement).Error
+}
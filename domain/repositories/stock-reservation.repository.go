@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"fmt"
+	"store/domain/entities"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockReservationRepository is an interface that defines the methods that
+// must be implemented by any data store that wants to interact with the
+// stock_reservations table in the database.
+//
+// Reservations are held and released along with the draft orders and their
+// lines by the OrderRepository and the OrderProductSupplierRepository. This
+// repository lists them and expires the ones held for too long.
+type StockReservationRepository interface {
+	GetAllByOrderID(ctx *gin.Context, orderID uint) ([]*entities.StockReservation, error) // Get the reservations of an order
+	ExpireReservedBefore(ctx *gin.Context, cutoff time.Time) (int64, error)               // Expire the active reservations held before a moment
+}
+
+// stockReservationRepository is a struct that contains a pointer to a gorm DB
+// instance and implements the StockReservationRepository.
+type stockReservationRepository struct {
+	db *gorm.DB
+}
+
+// NewStockReservationRepository creates a new instance of
+// stockReservationRepository with the provided database instance and returns
+// it as a StockReservationRepository.
+func NewStockReservationRepository(db *gorm.DB) StockReservationRepository {
+	return &stockReservationRepository{db: db}
+}
+
+// Retrieves the reservations of an order, active or not, from the oldest to
+// the newest one.
+//
+// The method takes a pointer to a *gin.Context and the ID of the order. It
+// returns the reservations or an error if something goes wrong.
+func (r *stockReservationRepository) GetAllByOrderID(ctx *gin.Context, orderID uint) ([]*entities.StockReservation, error) {
+	var reservations []*entities.StockReservation
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("reserved_at, id").
+		Find(&reservations).
+		Error
+	return reservations, err
+}
+
+// Expires the active reservations held before the given moment.
+//
+// The method gives the units of the expired reservations back to the units
+// available of their product suppliers in a single transaction, and returns
+// the number of reservations expired. Their draft orders are kept, and their
+// units are reserved again when their lines change, or checked for
+// availability when they are placed.
+func (r *stockReservationRepository) ExpireReservedBefore(ctx *gin.Context, cutoff time.Time) (int64, error) {
+	var expired int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		expired, err = releaseReservations(tx, entities.StockReservationExpired, "reserved_at < ?", cutoff)
+		return err
+	})
+	return expired, err
+}
+
+// reserveOrders releases the active reservations of the draft orders among
+// the orders with the given IDs, and reserves again the quantity of every
+// line of them, so the reservations of a draft follow its lines and are
+// renewed whenever they change. Orders that are no longer drafts are skipped.
+// It must be called inside a transaction and returns ErrInsufficientStock if a
+// product supplier has not enough units available for a line.
+func reserveOrders(tx *gorm.DB, orderIDs []uint) error {
+	var drafts []uint
+	err := tx.Model(&entities.Order{}).
+		Where("id IN ? AND status = ?", orderIDs, entities.OrderStatusDraft).
+		Pluck("id", &drafts).
+		Error
+	if err != nil || len(drafts) == 0 {
+		return err
+	}
+	if _, err := releaseReservations(tx, entities.StockReservationReleased, "order_id IN ?", drafts); err != nil {
+		return err
+	}
+
+	var lines []entities.OrderProductSupplier
+	err = tx.Where("order_id IN ?", drafts).Order("product_supplier_id, id").Find(&lines).Error
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, line := range lines {
+		if err := reserveLine(tx, &line, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserveOrdersOf reserves the lines of the orders of the given
+// orderProductSuppliers, deleted or not, as done by reserveOrders. It must be
+// called inside a transaction.
+func reserveOrdersOf(tx *gorm.DB, ids []uint) error {
+	var orderIDs []uint
+	err := tx.Unscoped().
+		Model(&entities.OrderProductSupplier{}).
+		Where("id IN ?", ids).
+		Distinct().
+		Pluck("order_id", &orderIDs).
+		Error
+	if err != nil {
+		return err
+	}
+	return reserveOrders(tx, orderIDs)
+}
+
+// reserveLine locks the ProductSupplier of an order line, checks it has at
+// least the quantity of the line available outside its expired lots, as
+// described in checkSellable, and holds it with an active reservation. It must be called inside a transaction.
+func reserveLine(tx *gorm.DB, line *entities.OrderProductSupplier, reservedAt time.Time) error {
+	if line.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	var productSupplier entities.ProductSupplier
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productSupplier, line.ProductSupplierID).Error
+	if err != nil {
+		return fmt.Errorf("product supplier %d: %w", line.ProductSupplierID, err)
+	}
+	if err := checkSellable(tx, &productSupplier, line.Quantity); err != nil {
+		return err
+	}
+
+	err = tx.Model(&entities.ProductSupplier{}).
+		Where("id = ?", productSupplier.ID).
+		UpdateColumns(map[string]interface{}{
+			"reserved": gorm.Expr("reserved + ?", line.Quantity),
+			"version":  nextVersion,
+		}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&entities.StockReservation{
+		OrderID:                line.OrderID,
+		OrderProductSupplierID: line.ID,
+		ProductSupplierID:      productSupplier.ID,
+		Quantity:               line.Quantity,
+		Status:                 entities.StockReservationActive,
+		ReservedAt:             reservedAt,
+	}).Error
+}
+
+// releaseReservations ends the active reservations matching the given
+// condition with the given status, and gives their units back to the units
+// available of their product suppliers. It must be called inside a
+// transaction and returns the number of reservations released.
+func releaseReservations(tx *gorm.DB, status entities.StockReservationStatus, condition string, args ...interface{}) (int64, error) {
+	var reservations []entities.StockReservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status = ?", entities.StockReservationActive).
+		Where(condition, args...).
+		Order("product_supplier_id, id").
+		Find(&reservations).
+		Error
+	if err != nil || len(reservations) == 0 {
+		return 0, err
+	}
+
+	ids := make([]uint, len(reservations))
+	reserved := make(map[uint]int)
+	var productSupplierIDs []uint
+	for i, reservation := range reservations {
+		ids[i] = reservation.ID
+		if _, ok := reserved[reservation.ProductSupplierID]; !ok {
+			productSupplierIDs = append(productSupplierIDs, reservation.ProductSupplierID)
+		}
+		reserved[reservation.ProductSupplierID] += reservation.Quantity
+	}
+
+	for _, productSupplierID := range productSupplierIDs {
+		err := tx.Model(&entities.ProductSupplier{}).
+			Where("id = ?", productSupplierID).
+			UpdateColumns(map[string]interface{}{
+				"reserved": gorm.Expr("GREATEST(reserved - ?, 0)", reserved[productSupplierID]),
+				"version":  nextVersion,
+			}).
+			Error
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Model(&entities.StockReservation{}).
+		Where("id IN ?", ids).
+		UpdateColumns(map[string]interface{}{
+			"status":      status,
+			"released_at": time.Now(),
+			"version":     nextVersion,
+		}).
+		Error
+	return int64(len(ids)), err
+}
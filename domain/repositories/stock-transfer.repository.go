@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"fmt"
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/query"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTransferNotInTransit is returned when a stock transfer that was already
+// received or cancelled is received or cancelled.
+var ErrTransferNotInTransit = apperrors.Conflict("transfer_not_in_transit", "only stock transfers in transit can be received or cancelled")
+
+// StockTransferRepository is an interface that defines the methods that must
+// be implemented by any data store that wants to interact with the
+// stock_transfers table in the database.
+//
+// It provides methods for shipping a stock transfer, getting a stock transfer
+// by its ID, getting all stock transfers, and receiving or cancelling a stock
+// transfer in transit. Stock transfers are never updated otherwise nor deleted.
+type StockTransferRepository interface {
+	Create(ctx *gin.Context, transfer *entities.StockTransfer) error                                                     // Ship a stock transfer
+	GetByID(ctx *gin.Context, id uint) (*entities.StockTransfer, error)                                                  // Get a stock transfer with its lines by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.StockTransfer], error)                           // Get all stock transfers
+	Complete(ctx *gin.Context, transfer *entities.StockTransfer, status entities.StockTransferStatus, user string) error // Receive or cancel a stock transfer
+	GetInTransit(ctx *gin.Context, productSupplierID uint) (map[uint]int, error)                                         // Get the units of a product supplier in transit to every warehouse
+}
+
+// stockTransferRepository is a struct that contains a pointer to a gorm DB
+// instance and implements the StockTransferRepository.
+type stockTransferRepository struct {
+	db *gorm.DB
+}
+
+// NewStockTransferRepository creates a new instance of stockTransferRepository
+// with the provided database instance and returns it as a
+// StockTransferRepository.
+func NewStockTransferRepository(db *gorm.DB) StockTransferRepository {
+	return &stockTransferRepository{db: db}
+}
+
+// Ships a stock transfer with its lines in a single database transaction.
+//
+// The method takes a pointer to a *gin.Context and the stock transfer. For
+// every line, the ProductSupplier is locked and checked for enough units
+// available, not held by reservations, and the units are taken from its stock
+// in the source warehouse, recorded in the stock ledger as a transfer
+// referencing the stock transfer. The units are in transit, out of the stock
+// on hand, until the transfer is received or cancelled.
+//
+// If any line fails, the whole transaction is rolled back and the method
+// returns ErrInsufficientStock, ErrUnknownWarehouse, gorm.ErrRecordNotFound or
+// the database error.
+func (r *stockTransferRepository) Create(ctx *gin.Context, transfer *entities.StockTransfer) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		transfer.Status = entities.StockTransferStatusInTransit
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+
+		for _, line := range transfer.Lines {
+			var productSupplier entities.ProductSupplier
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productSupplier, line.ProductSupplierID).Error
+			if err != nil {
+				return fmt.Errorf("product supplier %d: %w", line.ProductSupplierID, err)
+			}
+			if productSupplier.Available() < line.Quantity {
+				return fmt.Errorf("%w: product supplier %d has %d units available, %d requested",
+					ErrInsufficientStock, productSupplier.ID, productSupplier.Available(), line.Quantity)
+			}
+
+			movement := transferMovement(transfer, transfer.FromWarehouseID, line, -line.Quantity, "transfer shipped", transfer.CreatedBy)
+			if err := adjustStock(tx, movement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Retrieves a stock transfer by its ID from the database.
+//
+// The method takes a pointer to a *gin.Context and the ID of the stock
+// transfer. It returns the stock transfer with its lines, or
+// gorm.ErrRecordNotFound if it does not exist.
+func (r *stockTransferRepository) GetByID(ctx *gin.Context, id uint) (*entities.StockTransfer, error) {
+	var transfer entities.StockTransfer
+	err := r.db.WithContext(ctx).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&transfer, id).
+		Error
+	return &transfer, err
+}
+
+// StockTransferListSchema lists the fields of a stock transfer that can be
+// used to filter and sort the stock transfers in a list query.
+var StockTransferListSchema = query.Model(query.Schema{
+	"from_warehouse_id": {Column: "from_warehouse_id", Type: query.Number},
+	"to_warehouse_id":   {Column: "to_warehouse_id", Type: query.Number},
+	"status":            {Column: "status", Type: query.String},
+	"created_by":        {Column: "created_by", Type: query.String},
+	"shipped_at":        {Column: "shipped_at", Type: query.Time},
+	"received_at":       {Column: "received_at", Type: query.Time},
+})
+
+// Retrieves a page of stock transfers from the database.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against StockTransferListSchema. It returns the page
+// of stock transfers, without their lines, or an error if something goes
+// wrong.
+func (r *stockTransferRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.StockTransfer], error) {
+	return query.Find[entities.StockTransfer](r.db.WithContext(ctx), q)
+}
+
+// Receives or cancels a stock transfer in a single database transaction.
+//
+// The method takes a pointer to a *gin.Context, the stock transfer with its
+// lines, the status it ends in, received or cancelled, and the user completing
+// it. The status is only updated if the transfer is still in transit,
+// otherwise ErrTransferNotInTransit is returned. The units of every line are
+// added to the stock of the destination warehouse when the transfer is
+// received, or given back to the source warehouse when it is cancelled, and
+// recorded in the stock ledger as a transfer referencing the stock transfer.
+// If anything fails, the whole transaction is rolled back. On success the
+// status, the version and the moment of the completion of the transfer are
+// updated.
+func (r *stockTransferRepository) Complete(ctx *gin.Context, transfer *entities.StockTransfer, status entities.StockTransferStatus, user string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updates := map[string]interface{}{"status": status, "version": nextVersion, "updated_at": now}
+		warehouseID, reason := transfer.ToWarehouseID, "transfer received"
+		if status == entities.StockTransferStatusCancelled {
+			updates["cancelled_at"] = now
+			warehouseID, reason = transfer.FromWarehouseID, "transfer cancelled"
+		} else {
+			updates["received_at"] = now
+		}
+
+		result := tx.Model(&entities.StockTransfer{}).
+			Where("id = ? AND status = ?", transfer.ID, entities.StockTransferStatusInTransit).
+			UpdateColumns(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTransferNotInTransit
+		}
+
+		for _, line := range transfer.Lines {
+			movement := transferMovement(transfer, warehouseID, line, line.Quantity, reason, user)
+			if err := adjustStock(tx, movement); err != nil {
+				return err
+			}
+		}
+
+		transfer.Status = status
+		transfer.Version++
+		if status == entities.StockTransferStatusCancelled {
+			transfer.CancelledAt = &now
+		} else {
+			transfer.ReceivedAt = &now
+		}
+		return nil
+	})
+}
+
+// Retrieves the units of a product supplier in transit, shipped by stock
+// transfers that are neither received nor cancelled, keyed by the ID of the
+// warehouse they go to.
+func (r *stockTransferRepository) GetInTransit(ctx *gin.Context, productSupplierID uint) (map[uint]int, error) {
+	var rows []struct {
+		WarehouseID uint
+		Quantity    int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&entities.StockTransferLine{}).
+		Select("stock_transfers.to_warehouse_id AS warehouse_id, SUM(stock_transfer_lines.quantity) AS quantity").
+		Joins("JOIN sales.stock_transfers ON stock_transfers.id = stock_transfer_lines.stock_transfer_id").
+		Where("stock_transfer_lines.product_supplier_id = ? AND stock_transfers.status = ?",
+			productSupplierID, entities.StockTransferStatusInTransit).
+		Group("stock_transfers.to_warehouse_id").
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	inTransit := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		inTransit[row.WarehouseID] = row.Quantity
+	}
+	return inTransit, nil
+}
+
+// transferMovement returns the movement of quantity units of the product
+// supplier of a line of a stock transfer in the given warehouse, referencing
+// the transfer by its ID.
+func transferMovement(transfer *entities.StockTransfer, warehouseID uint, line entities.StockTransferLine, quantity int, reason, user string) *entities.StockMovement {
+	return &entities.StockMovement{
+		ProductSupplierID: line.ProductSupplierID,
+		WarehouseID:       &warehouseID,
+		Type:              entities.StockMovementTransfer,
+		Quantity:          quantity,
+		Reason:            reason,
+		ReferenceType:     "stock_transfer",
+		Reference:         strconv.FormatUint(uint64(transfer.ID), 10),
+		CreatedBy:         user,
+	}
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -14,12 +15,13 @@ import (
 // It provides methods for creating a new supplier, getting a supplier by its ID, getting all suppliers,
 // updating a supplier, and deleting a supplier.
 type SupplierRepository interface {
-	Create(ctx *gin.Context, supplier *entities.Supplier) error    // Create a new supplier
-	GetByID(ctx *gin.Context, id uint) (*entities.Supplier, error) // Get a supplier by ID
-	GetAll(ctx *gin.Context) ([]*entities.Supplier, error)         // Get all suppliers
-	Update(ctx *gin.Context, supplier *entities.Supplier) error    // Update a supplier
-	Delete(ctx *gin.Context, id uint) error                        // Delete a supplier
-	DeleteAll(ctx *gin.Context, ids []uint) error                  // Delete multiple suppliers
+	Create(ctx *gin.Context, supplier *entities.Supplier) error                           // Create a new supplier
+	GetByID(ctx *gin.Context, id uint) (*entities.Supplier, error)                        // Get a supplier by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Supplier], error) // Get all suppliers
+	Update(ctx *gin.Context, supplier *entities.Supplier, fields ...string) error         // Update a supplier
+	Delete(ctx *gin.Context, id uint, version uint) error                                 // Delete a supplier
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                       // Delete multiple suppliers
+	TrashRepository[entities.Supplier]                                                    // Get, restore and purge deleted suppliers
 }
 
 // supplierRepository is a struct that contains a pointer to a gorm DB instance and
@@ -29,6 +31,7 @@ type SupplierRepository interface {
 // with the suppliers table in the database.
 type supplierRepository struct {
 	db *gorm.DB
+	trashRepository[entities.Supplier]
 }
 
 // NewSupplierRepository creates a new instance of supplierRepository with the provided
@@ -36,7 +39,14 @@ type supplierRepository struct {
 // This function is used to initialize a new supplier repository that can perform
 // CRUD operations and other queries on the suppliers table.
 func NewSupplierRepository(db *gorm.DB) SupplierRepository {
-	return &supplierRepository{db: db}
+	return &supplierRepository{
+		db: db,
+		trashRepository: trashRepository[entities.Supplier]{
+			db:       db,
+			cascades: []dependent{{model: &entities.Contact{}, column: "supplier_id"}},
+			purges:   []dependent{{model: &entities.Contact{}, column: "supplier_id"}},
+		},
+	}
 }
 
 // Creates a new supplier in the database.
@@ -70,19 +80,25 @@ func (r *supplierRepository) GetByID(ctx *gin.Context, id uint) (*entities.Suppl
 	return &supplier, err
 }
 
-// Retrieves all suppliers from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a
-// slice of pointers to entities.Supplier and an error. If something goes wrong,
-// the method returns an empty slice and an error.
-//
-// The method retrieves all suppliers from the database. If the suppliers are
-// found, the method returns the suppliers and nil. If no suppliers are found or
-// an error occurs, the method returns an empty slice and an error.
-func (r *supplierRepository) GetAll(ctx *gin.Context) ([]*entities.Supplier, error) {
-	var suppliers []*entities.Supplier
-	err := r.db.WithContext(ctx).Find(&suppliers).Error
-	return suppliers, err
+// SupplierListSchema lists the fields of a supplier that can be used to filter
+// and sort the suppliers in a list query.
+var SupplierListSchema = query.Model(query.Schema{
+	"name":           {Column: "name", Type: query.String},
+	"tax_id":         {Column: "tax_id", Type: query.String, Normalize: normalizeTaxID},
+	"fantasy_name":   {Column: "fantasy_name", Type: query.String},
+	"sales":          {Column: "sales", Type: query.Number},
+	"quantity_stock": {Column: "quantity_stock", Type: query.Number},
+})
+
+// Retrieves a page of suppliers from the database.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against SupplierListSchema. It returns the page of
+// suppliers matching the filters of the query, in the requested order, along
+// with the total count and the link to the next page. If something goes wrong,
+// the method returns nil and an error.
+func (r *supplierRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Supplier], error) {
+	return query.Find[entities.Supplier](r.db.WithContext(ctx), q)
 }
 
 // Updates a supplier in the database.
@@ -96,8 +112,15 @@ func (r *supplierRepository) GetAll(ctx *gin.Context) ([]*entities.Supplier, err
 //
 // The method returns an error if something goes wrong. If the supplier is updated
 // successfully, the method returns nil.
-func (r *supplierRepository) Update(ctx *gin.Context, supplier *entities.Supplier) error {
-	return r.db.WithContext(ctx).Save(supplier).Error
+//
+// The update is based on the Version of the supplier, which is incremented. It
+// returns gorm.ErrRecordNotFound if the supplier does not exist, or
+// ErrVersionConflict if it has been changed since that version.
+//
+// When fields are given, only those fields of the supplier, named as in its
+// struct, are saved, as done for partial updates.
+func (r *supplierRepository) Update(ctx *gin.Context, supplier *entities.Supplier, fields ...string) error {
+	return updateVersioned(r.db.WithContext(ctx), supplier, supplier.ID, &supplier.Version, fields...)
 }
 
 // Deletes a supplier by its ID from the database.
@@ -108,18 +131,55 @@ func (r *supplierRepository) Update(ctx *gin.Context, supplier *entities.Supplie
 // The method deletes a supplier by its ID from the database using the given ID.
 // If the supplier is deleted successfully, the method returns nil. If the supplier
 // is not found or an error occurs, the method returns an error.
-func (r *supplierRepository) Delete(ctx *gin.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.Supplier{}, id).Error
+//
+// The supplier is only deleted if it is still at the given version, or whatever
+// its version is with AnyVersion. It returns gorm.ErrRecordNotFound if the
+// supplier does not exist, ErrVersionConflict if it has been changed since
+// that version, or ErrStillReferenced if it has offers or purchase orders.
+// The contacts of the supplier are deleted along with it, and restored with
+// it.
+func (r *supplierRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteUnlessBlocked[entities.Supplier](tx, id, version, ErrStillReferenced, supplierBlockers...); err != nil {
+			return err
+		}
+		return r.deleteCascades(tx, []uint{id})
+	})
 }
 
 // Deletes multiple suppliers from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple suppliers from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the suppliers
-// are deleted successfully, the method returns nil.
-func (r *supplierRepository) DeleteAll(ctx *gin.Context, ids []uint) error {
-	return r.db.WithContext(ctx).Delete(&entities.Supplier{}, ids).Error
+// The method takes a pointer to a *gin.Context and a slice of uints as
+// parameters. It deletes the suppliers in a single transaction and returns the
+// outcome for each id: deleted, not found or blocked. Suppliers with offers or
+// purchase orders are kept, and the contacts of the deleted ones are deleted
+// along with them. It returns ErrNoIDs if no id is given, or an error
+// if something goes wrong, in which case nothing is deleted.
+func (r *supplierRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	var results []DeleteResult
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		results, err = deleteAll(tx, &entities.Supplier{}, ids, supplierBlockers...)
+		if err != nil {
+			return err
+		}
+
+		var deleted []uint
+		for _, result := range results {
+			if result.Status == DeleteStatusDeleted {
+				deleted = append(deleted, result.ID)
+			}
+		}
+		return r.deleteCascades(tx, deleted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// supplierBlockers keep the suppliers with offers or purchase orders.
+var supplierBlockers = []blocker{
+	referencedBy(&entities.ProductSupplier{}, "supplier_id", "product suppliers"),
+	referencedBy(&entities.PurchaseOrder{}, "supplier_id", "purchase orders"),
 }
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"errors"
+	"store/domain/apperrors"
+	"store/domain/query"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStillReferenced is returned when an entity cannot be deleted or purged
+// because other rows still reference it.
+var ErrStillReferenced = apperrors.Conflict("still_referenced", "entity is still referenced by other rows")
+
+// TrashRepository is an interface that defines the methods to manage the
+// soft-deleted entities of type E, which every entity repository provides.
+//
+// Entities deleted through the Delete and DeleteAll methods of a repository
+// only have their DeletedAt set. They stay in the trash until they are
+// restored, purged one by one or purged by the retention job.
+type TrashRepository[E any] interface {
+	GetTrash(ctx *gin.Context, q *query.ListQuery) (*query.Page[*E], error) // Get a page of the deleted entities
+	Restore(ctx *gin.Context, id uint) error                                // Restore a deleted entity
+	Purge(ctx *gin.Context, id uint) error                                  // Permanently delete an entity
+	PurgeDeletedBefore(ctx *gin.Context, cutoff time.Time) (int64, error)   // Permanently delete the entities deleted before cutoff
+}
+
+// dependent is a table whose rows belong to the entity referenced by column.
+type dependent struct {
+	model  interface{} // entity of the dependent rows
+	column string      // column referencing the owner of the rows
+}
+
+// trashRepository implements the TrashRepository for the entities of type E.
+//
+// The dependents listed in cascades, such as the contacts of a customer, are
+// deleted along with the entity by deleteCascades, with the same DeletedAt, and
+// restoring the entity restores the dependents deleted with it, but not the
+// ones deleted on their own before, such as a replaced default contact.
+// Purging an entity first permanently deletes its dependents listed in purges,
+// such as the status history of an order.
+type trashRepository[E any] struct {
+	db       *gorm.DB
+	cascades []dependent
+	purges   []dependent
+}
+
+// Retrieves a page of the deleted entities.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against the list schema of the entity. It returns the
+// page of entities that are soft deleted, or an error if something goes wrong.
+func (r *trashRepository[E]) GetTrash(ctx *gin.Context, q *query.ListQuery) (*query.Page[*E], error) {
+	return query.Find[E](r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL"), q)
+}
+
+// Restores a deleted entity by its ID.
+//
+// The method clears the DeletedAt of the entity and of the dependents deleted
+// along with it, those with the same DeletedAt, in a single transaction. It
+// returns gorm.ErrRecordNotFound if no deleted entity has the given ID, or an
+// error if something goes wrong.
+func (r *trashRepository[E]) Restore(ctx *gin.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var deletedAt []time.Time
+		err := tx.Unscoped().
+			Model(new(E)).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Pluck("deleted_at", &deletedAt).
+			Error
+		if err != nil {
+			return err
+		}
+		if len(deletedAt) == 0 {
+			return notFoundOf[E]()
+		}
+
+		err = tx.Unscoped().
+			Model(new(E)).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": nextVersion}).
+			Error
+		if err != nil {
+			return err
+		}
+		for _, d := range r.cascades {
+			err := tx.Unscoped().
+				Model(d.model).
+				Where(d.column+" = ? AND deleted_at = ?", id, deletedAt[0]).
+				Updates(map[string]interface{}{"deleted_at": nil, "version": nextVersion}).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteCascades soft deletes the dependents listed in cascades of the
+// entities with the given ids, just deleted in the same transaction, with the
+// DeletedAt of their entity, so they are restored along with it.
+func (r *trashRepository[E]) deleteCascades(tx *gorm.DB, ids []uint) error {
+	if len(r.cascades) == 0 || len(ids) == 0 {
+		return nil
+	}
+	var owners []struct {
+		ID        uint
+		DeletedAt time.Time
+	}
+	err := tx.Unscoped().
+		Model(new(E)).
+		Select("id, deleted_at").
+		Where("id IN ? AND deleted_at IS NOT NULL", ids).
+		Scan(&owners).
+		Error
+	if err != nil {
+		return err
+	}
+
+	byDeletedAt := map[time.Time][]uint{}
+	for _, owner := range owners {
+		byDeletedAt[owner.DeletedAt] = append(byDeletedAt[owner.DeletedAt], owner.ID)
+	}
+	for deletedAt, ownerIDs := range byDeletedAt {
+		for _, d := range r.cascades {
+			err := tx.Model(d.model).
+				Where(d.column+" IN ?", ownerIDs).
+				Updates(map[string]interface{}{"deleted_at": deletedAt, "version": nextVersion}).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Permanently deletes an entity by its ID, whether it is deleted or not.
+//
+// The method deletes the dependents of the entity and the entity itself in a
+// single transaction. It returns gorm.ErrRecordNotFound if no entity has the
+// given ID, ErrStillReferenced if other rows still reference it, or an error
+// if something goes wrong.
+func (r *trashRepository[E]) Purge(ctx *gin.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return r.purge(tx, id)
+	})
+}
+
+// Permanently deletes the entities deleted before the given time.
+//
+// The method purges the entities one by one, each in its own transaction, so
+// the entities still referenced by other rows are skipped without stopping the
+// others. It returns the number of entities purged, or an error if something
+// other than a reference goes wrong.
+func (r *trashRepository[E]) PurgeDeletedBefore(ctx *gin.Context, cutoff time.Time) (int64, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(new(E)).
+		Where("deleted_at < ?", cutoff).
+		Pluck("id", &ids).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, id := range ids {
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return r.purge(tx, id)
+		})
+		switch {
+		case errors.Is(err, ErrStillReferenced), errors.Is(err, gorm.ErrRecordNotFound):
+			continue
+		case err != nil:
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge permanently deletes the dependents of the entity and the entity.
+func (r *trashRepository[E]) purge(tx *gorm.DB, id uint) error {
+	for _, d := range r.purges {
+		if err := tx.Unscoped().Where(d.column+" = ?", id).Delete(d.model).Error; err != nil {
+			return err
+		}
+	}
+
+	result := tx.Unscoped().Delete(new(E), id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFoundOf[E]()
+	}
+	return nil
+}
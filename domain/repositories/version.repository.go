@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"store/domain/apperrors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AnyVersion is the version given to Update and Delete to change an entity
+// whatever its current version is.
+const AnyVersion uint = 0
+
+// ErrVersionConflict is returned when an entity is updated or deleted based on
+// a version that is no longer its current version, because someone else changed
+// it in the meantime.
+var ErrVersionConflict = apperrors.PreconditionFailed("version_conflict", "entity was changed by another request, reload it and try again")
+
+// lockVersion locks the entity of type E with the given ID and checks that its
+// current version is the expected one, unless AnyVersion is expected. It must
+// be called inside a transaction and returns the current version,
+// gorm.ErrRecordNotFound if the entity does not exist, or ErrVersionConflict if
+// the version does not match.
+func lockVersion[E any](tx *gorm.DB, id uint, expected uint) (uint, error) {
+	var versions []uint
+	err := tx.Model(new(E)).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		Pluck("version", &versions).
+		Error
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, notFoundOf[E]()
+	}
+	if expected != AnyVersion && versions[0] != expected {
+		return 0, ErrVersionConflict
+	}
+	return versions[0], nil
+}
+
+// updateVersioned saves the given fields of entity, or every field except its
+// creation time when no field is given, if the stored entity with the given ID
+// is still at the version pointed by version, and increments the version.
+//
+// Fields are named as in the struct of the entity, and a field holding an
+// embedded struct, such as a money value, saves all of its columns.
+//
+// The stored entity is locked while it is checked and saved, so two concurrent
+// updates based on the same version never both succeed. On success version
+// points to the new version of the entity. It returns gorm.ErrRecordNotFound if
+// the entity does not exist, or ErrVersionConflict if it has been changed since
+// the given version.
+func updateVersioned[E any](db *gorm.DB, entity *E, id uint, version *uint, fields ...string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockVersion[E](tx, id, *version)
+		if err != nil {
+			return err
+		}
+
+		update := tx.Model(entity)
+		if len(fields) == 0 {
+			update = update.Select("*").Omit("created_at")
+		} else {
+			columns, err := columnsOf(tx, entity, fields)
+			if err != nil {
+				return err
+			}
+			update = update.Select(append(columns, "updated_at", "version"))
+		}
+
+		expected := *version
+		*version = current + 1
+		if err := update.Updates(entity).Error; err != nil {
+			*version = expected
+			return err
+		}
+		return nil
+	})
+}
+
+// columnsOf returns the columns that can be updated of the given struct fields
+// of entity.
+func columnsOf(db *gorm.DB, entity interface{}, fields []string) ([]string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(entity); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		selected[field] = true
+	}
+
+	var columns []string
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName != "" && field.Updatable && selected[field.BindNames[0]] {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, nil
+}
+
+// deleteVersioned soft deletes the entity of type E with the given ID if it is
+// still at the given version. It returns gorm.ErrRecordNotFound if the entity
+// does not exist, or ErrVersionConflict if it has been changed since the given
+// version.
+func deleteVersioned[E any](db *gorm.DB, id uint, version uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockVersion[E](tx, id, version); err != nil {
+			return err
+		}
+		return tx.Delete(new(E), id).Error
+	})
+}
+
+// nextVersion is the expression incrementing the version of the rows changed by
+// an update statement.
+var nextVersion = gorm.Expr("version + 1")
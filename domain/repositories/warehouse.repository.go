@@ -0,0 +1,284 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/query"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrUnknownWarehouse is returned when the stock is moved in, or an order
+	// is fulfilled from, a warehouse that does not exist.
+	ErrUnknownWarehouse = apperrors.Invalid("unknown_warehouse", "the warehouse does not exist")
+	// ErrNoDefaultWarehouse is returned when the stock is moved without
+	// warehouse while no warehouse is the default one.
+	ErrNoDefaultWarehouse = apperrors.Conflict("no_default_warehouse", "no warehouse is the default one")
+	// ErrDefaultWarehouseRequired is returned when the default warehouse is
+	// updated to no longer be the default one.
+	ErrDefaultWarehouseRequired = apperrors.Conflict("default_warehouse_required", "another warehouse must be made the default one instead")
+	// ErrWarehouseInUse is returned when a warehouse cannot be deleted because
+	// it is the default one, has stock or has transfers in transit.
+	ErrWarehouseInUse = apperrors.Conflict("warehouse_in_use", "the warehouse is the default one, has stock or has transfers in transit")
+)
+
+// WarehouseRepository is an interface that defines the methods that must be
+// implemented by any data store that wants to interact with the warehouses
+// table in the database.
+//
+// It provides methods for creating a new warehouse, getting a warehouse by its
+// ID, getting all warehouses, updating a warehouse, deleting warehouses and
+// getting the stock on hand in the warehouses.
+type WarehouseRepository interface {
+	Create(ctx *gin.Context, warehouse *entities.Warehouse) error                                                   // Create a new warehouse
+	GetByID(ctx *gin.Context, id uint) (*entities.Warehouse, error)                                                 // Get a warehouse by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Warehouse], error)                          // Get all warehouses
+	List(ctx *gin.Context) ([]*entities.Warehouse, error)                                                           // Get every warehouse, the default one first
+	Update(ctx *gin.Context, warehouse *entities.Warehouse, fields ...string) error                                 // Update a warehouse
+	Delete(ctx *gin.Context, id uint, version uint) error                                                           // Delete a warehouse
+	DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error)                                                 // Delete multiple warehouses
+	GetStock(ctx *gin.Context, warehouseID uint, q *query.ListQuery) (*query.Page[*entities.WarehouseStock], error) // Get the stock on hand in a warehouse
+	GetStockOf(ctx *gin.Context, productSupplierIDs []uint) ([]*entities.WarehouseStock, error)                     // Get the stock on hand of product suppliers in every warehouse
+	TrashRepository[entities.Warehouse]                                                                             // Get, restore and purge deleted warehouses
+}
+
+// warehouseRepository is a struct that contains a pointer to a gorm DB
+// instance and implements the WarehouseRepository.
+type warehouseRepository struct {
+	db *gorm.DB
+	trashRepository[entities.Warehouse]
+}
+
+// NewWarehouseRepository creates a new instance of warehouseRepository with
+// the provided database instance and returns it as a WarehouseRepository.
+func NewWarehouseRepository(db *gorm.DB) WarehouseRepository {
+	return &warehouseRepository{
+		db: db,
+		trashRepository: trashRepository[entities.Warehouse]{
+			db: db,
+			purges: []dependent{
+				{model: &entities.StockLot{}, column: "warehouse_id"},
+				{model: &entities.WarehouseStock{}, column: "warehouse_id"},
+			},
+		},
+	}
+}
+
+// Creates a new warehouse in the database.
+//
+// The method takes a pointer to a *gin.Context and a pointer to an
+// entities.Warehouse. A warehouse created as the default one replaces the
+// current default warehouse, in the same transaction. It returns an error if
+// something goes wrong.
+func (r *warehouseRepository) Create(ctx *gin.Context, warehouse *entities.Warehouse) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if warehouse.IsDefault {
+			if err := clearDefaultWarehouse(tx, 0); err != nil {
+				return err
+			}
+		}
+		return tx.Create(warehouse).Error
+	})
+}
+
+// Retrieves a warehouse by its ID from the database.
+//
+// The method takes a pointer to a *gin.Context and the ID of the warehouse. It
+// returns the warehouse, or gorm.ErrRecordNotFound if it does not exist.
+func (r *warehouseRepository) GetByID(ctx *gin.Context, id uint) (*entities.Warehouse, error) {
+	var warehouse entities.Warehouse
+	err := r.db.WithContext(ctx).First(&warehouse, id).Error
+	return &warehouse, err
+}
+
+// WarehouseListSchema lists the fields of a warehouse that can be used to
+// filter and sort the warehouses in a list query.
+var WarehouseListSchema = query.Model(query.Schema{
+	"code":        {Column: "code", Type: query.String},
+	"name":        {Column: "name", Type: query.String},
+	"postal_code": {Column: "postal_code", Type: query.String},
+	"city":        {Column: "city", Type: query.String},
+	"state":       {Column: "state", Type: query.String},
+	"is_default":  {Column: "is_default", Type: query.Bool},
+})
+
+// Retrieves a page of warehouses from the database.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request, validated against WarehouseListSchema. It returns the page of
+// warehouses matching the filters of the query, in the requested order, or an
+// error if something goes wrong.
+func (r *warehouseRepository) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Warehouse], error) {
+	return query.Find[entities.Warehouse](r.db.WithContext(ctx), q)
+}
+
+// Retrieves every warehouse from the database, the default one first and the
+// others by ID, as the candidates to fulfill an order from.
+func (r *warehouseRepository) List(ctx *gin.Context) ([]*entities.Warehouse, error) {
+	var warehouses []*entities.Warehouse
+	err := r.db.WithContext(ctx).Order("is_default DESC, id").Find(&warehouses).Error
+	return warehouses, err
+}
+
+// Updates a warehouse in the database.
+//
+// The update is based on the Version of the warehouse, which is incremented.
+// It returns gorm.ErrRecordNotFound if the warehouse does not exist, or
+// ErrVersionConflict if it has been changed since that version. When fields
+// are given, only those fields of the warehouse, named as in its struct, are
+// saved, as done for partial updates.
+//
+// A warehouse made the default one replaces the current default warehouse, in
+// the same transaction, while the default warehouse cannot stop being the
+// default one, which returns ErrDefaultWarehouseRequired.
+func (r *warehouseRepository) Update(ctx *gin.Context, warehouse *entities.Warehouse, fields ...string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(fields) == 0 || slices.Contains(fields, "IsDefault") {
+			var stored entities.Warehouse
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stored, warehouse.ID).Error
+			if err != nil {
+				return err
+			}
+			switch {
+			case stored.IsDefault && !warehouse.IsDefault:
+				return ErrDefaultWarehouseRequired
+			case !stored.IsDefault && warehouse.IsDefault:
+				if err := clearDefaultWarehouse(tx, warehouse.ID); err != nil {
+					return err
+				}
+			}
+		}
+		return updateVersioned(tx, warehouse, warehouse.ID, &warehouse.Version, fields...)
+	})
+}
+
+// Deletes a warehouse by its ID from the database.
+//
+// The warehouse is only deleted if it is still at the given version, or
+// whatever its version is with AnyVersion. It returns gorm.ErrRecordNotFound
+// if the warehouse does not exist, ErrVersionConflict if it has been changed
+// since that version, or ErrWarehouseInUse if it is the default warehouse, has
+// stock or has transfers in transit.
+func (r *warehouseRepository) Delete(ctx *gin.Context, id uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, block := range []blocker{defaultWarehouse, warehouseHasStock} {
+			reasons, err := block(tx, []uint{id})
+			if err != nil {
+				return err
+			}
+			if reason, ok := reasons[id]; ok {
+				return ErrWarehouseInUse.With("reason", reason)
+			}
+		}
+		return deleteVersioned[entities.Warehouse](tx, id, version)
+	})
+}
+
+// Deletes multiple warehouses from the database by their IDs.
+//
+// The method deletes the warehouses in a single transaction and returns the
+// outcome for each id: deleted, not found or blocked. The default warehouse
+// and the warehouses with stock or with transfers in transit are kept. It
+// returns ErrNoIDs if no id is given, or an error if something goes wrong, in
+// which case nothing is deleted.
+func (r *warehouseRepository) DeleteAll(ctx *gin.Context, ids []uint) ([]DeleteResult, error) {
+	return deleteAll(r.db.WithContext(ctx), &entities.Warehouse{}, ids, defaultWarehouse, warehouseHasStock)
+}
+
+// WarehouseStockListSchema lists the fields of the stock of a warehouse that
+// can be used to filter and sort it in a list query.
+var WarehouseStockListSchema = query.Model(query.Schema{
+	"warehouse_id":        {Column: "warehouse_id", Type: query.Number},
+	"product_supplier_id": {Column: "product_supplier_id", Type: query.Number},
+	"quantity":            {Column: "quantity", Type: query.Number},
+})
+
+// Retrieves a page of the stock on hand in a warehouse.
+//
+// The method takes a pointer to a *gin.Context, the ID of the warehouse and
+// the list query parsed from the request, validated against
+// WarehouseStockListSchema. It returns a page of the quantities of the product
+// suppliers in the warehouse, or an error if something goes wrong.
+func (r *warehouseRepository) GetStock(ctx *gin.Context, warehouseID uint, q *query.ListQuery) (*query.Page[*entities.WarehouseStock], error) {
+	return query.Find[entities.WarehouseStock](r.db.WithContext(ctx).Where("warehouse_id = ?", warehouseID), q)
+}
+
+// Retrieves the stock on hand of the given product suppliers in every
+// warehouse, ordered by warehouse and product supplier.
+func (r *warehouseRepository) GetStockOf(ctx *gin.Context, productSupplierIDs []uint) ([]*entities.WarehouseStock, error) {
+	var stocks []*entities.WarehouseStock
+	err := r.db.WithContext(ctx).
+		Where("product_supplier_id IN ?", productSupplierIDs).
+		Order("warehouse_id, product_supplier_id").
+		Find(&stocks).
+		Error
+	return stocks, err
+}
+
+// clearDefaultWarehouse makes the default warehouse, unless it has the given
+// ID, no longer the default one. It must be called inside the transaction
+// making another warehouse the default one.
+func clearDefaultWarehouse(tx *gorm.DB, exceptID uint) error {
+	return tx.Model(&entities.Warehouse{}).
+		Where("is_default AND id <> ?", exceptID).
+		UpdateColumns(map[string]interface{}{"is_default": false, "version": nextVersion}).
+		Error
+}
+
+// defaultWarehouseID returns the ID of the default warehouse, or
+// ErrNoDefaultWarehouse if no warehouse is the default one.
+func defaultWarehouseID(tx *gorm.DB) (uint, error) {
+	var ids []uint
+	if err := tx.Model(&entities.Warehouse{}).Where("is_default").Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, ErrNoDefaultWarehouse
+	}
+	return ids[0], nil
+}
+
+// moveWarehouseStock adds quantity units, negative to remove them, to the
+// stock of the ProductSupplier with the given ID in the Warehouse with the
+// given ID, creating it on its first movement. It returns ErrUnknownWarehouse
+// if the warehouse does not exist, or ErrInsufficientStock if the warehouse has
+// fewer units than it removes. It must be called inside a transaction.
+func moveWarehouseStock(tx *gorm.DB, warehouseID, productSupplierID uint, quantity int) error {
+	err := tx.Select("id").First(&entities.Warehouse{}, warehouseID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUnknownWarehouse.With("warehouse_id", warehouseID)
+	}
+	if err != nil {
+		return err
+	}
+
+	stock := entities.WarehouseStock{WarehouseID: warehouseID, ProductSupplierID: productSupplierID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&stock).Error; err != nil {
+		return err
+	}
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND product_supplier_id = ?", warehouseID, productSupplierID).
+		First(&stock).
+		Error
+	if err != nil {
+		return err
+	}
+	if stock.Quantity+quantity < 0 {
+		return fmt.Errorf("%w: warehouse %d has %d units of product supplier %d, %d requested",
+			ErrInsufficientStock, warehouseID, stock.Quantity, productSupplierID, -quantity)
+	}
+
+	return tx.Model(&entities.WarehouseStock{}).
+		Where("id = ?", stock.ID).
+		UpdateColumns(map[string]interface{}{
+			"quantity": gorm.Expr("quantity + ?", quantity),
+			"version":  nextVersion,
+		}).
+		Error
+}
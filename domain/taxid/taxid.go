@@ -0,0 +1,164 @@
+// Package taxid validates, normalizes and formats the Brazilian tax IDs of the
+// customers and suppliers: the CPF of individuals and the CNPJ of companies,
+// including the alphanumeric CNPJ, whose first twelve characters may be
+// letters.
+//
+// Tax IDs are stored in their canonical form, without the punctuation of the
+// mask, e.g. `12345678909`, and written in JSON with it, e.g.
+// `123.456.789-09`.
+package taxid
+
+import (
+	"encoding/json"
+	"store/domain/apperrors"
+	"strings"
+)
+
+// ErrInvalidTaxID is returned when a tax ID is neither a valid CPF nor a valid
+// CNPJ.
+var ErrInvalidTaxID = apperrors.BadRequest("invalid_tax_id", "taxid: invalid CPF or CNPJ")
+
+// Kind is the kind of a tax ID.
+type Kind string
+
+const (
+	CPF  Kind = "cpf"  // Cadastro de Pessoas Físicas, the 11 digits of an individual
+	CNPJ Kind = "cnpj" // Cadastro Nacional da Pessoa Jurídica, the 14 characters of a company
+)
+
+// cpfLength and cnpjLength are the lengths of the canonical CPF and CNPJ.
+const (
+	cpfLength  = 11
+	cnpjLength = 14
+)
+
+// maskReplacer removes the punctuation of the masks of the CPF and the CNPJ.
+var maskReplacer = strings.NewReplacer(".", "", "-", "", "/", "", " ", "")
+
+// TaxID is a CPF or a CNPJ in its canonical form: the digits, and the upper
+// case letters of an alphanumeric CNPJ, without the punctuation of its mask.
+type TaxID string
+
+// Normalize removes the punctuation of the mask of a tax ID and converts its
+// letters to upper case, without validating it. It is used for values that
+// only need to be compared with stored tax IDs, such as list filters.
+func Normalize(value string) TaxID {
+	return TaxID(strings.ToUpper(maskReplacer.Replace(strings.TrimSpace(value))))
+}
+
+// Parse normalizes a tax ID, masked or not, and validates its check digits. It
+// returns ErrInvalidTaxID if it is neither a valid CPF nor a valid CNPJ.
+func Parse(value string) (TaxID, error) {
+	taxID := Normalize(value)
+	if taxID.Kind() == "" {
+		return "", ErrInvalidTaxID
+	}
+	return taxID, nil
+}
+
+// Kind returns whether the tax ID is a valid CPF or CNPJ, or an empty kind if
+// it is neither.
+func (t TaxID) Kind() Kind {
+	switch {
+	case len(t) == cpfLength && validCPF(string(t)):
+		return CPF
+	case len(t) == cnpjLength && validCNPJ(string(t)):
+		return CNPJ
+	default:
+		return ""
+	}
+}
+
+// Format returns the tax ID with the punctuation of its mask, e.g.
+// `123.456.789-09` for a CPF and `12.345.678/0001-95` for a CNPJ. A tax ID that
+// is not valid is returned as it is stored.
+func (t TaxID) Format() string {
+	s := string(t)
+	switch t.Kind() {
+	case CPF:
+		return s[:3] + "." + s[3:6] + "." + s[6:9] + "-" + s[9:]
+	case CNPJ:
+		return s[:2] + "." + s[2:5] + "." + s[5:8] + "/" + s[8:12] + "-" + s[12:]
+	default:
+		return s
+	}
+}
+
+// MarshalJSON encodes the tax ID with the punctuation of its mask.
+func (t TaxID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Format())
+}
+
+// UnmarshalJSON decodes a tax ID, masked or not, into its canonical form. It
+// does not validate it, which is left to the validation of the requests.
+func (t *TaxID) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*t = Normalize(value)
+	return nil
+}
+
+// validCPF reports whether s is 11 digits ending with the two check digits of
+// the first nine.
+func validCPF(s string) bool {
+	if !allDigits(s) || repeated(s) {
+		return false
+	}
+	return checkDigit(s[:9], 10) == s[9] && checkDigit(s[:10], 11) == s[10]
+}
+
+// validCNPJ reports whether s is 12 digits or upper case letters followed by
+// the two check digits of them.
+func validCNPJ(s string) bool {
+	if !allDigits(s[12:]) || repeated(s) {
+		return false
+	}
+	for i := 0; i < 12; i++ {
+		if !isDigit(s[i]) && (s[i] < 'A' || s[i] > 'Z') {
+			return false
+		}
+	}
+	return checkDigit(s[:12], 5) == s[12] && checkDigit(s[:13], 6) == s[13]
+}
+
+// checkDigit computes the modulo 11 check digit of s, weighting its characters
+// from the given weight down to 2, starting over at 9 for the long CNPJ. The
+// value of a character is its ASCII code minus 48, which is the digit itself
+// for digits and 17 to 42 for the letters of an alphanumeric CNPJ.
+func checkDigit(s string, weight int) byte {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		sum += int(s[i]-'0') * weight
+		weight--
+		if weight < 2 {
+			weight = 9
+		}
+	}
+	if remainder := sum % 11; remainder >= 2 {
+		return byte('0' + 11 - remainder)
+	}
+	return '0'
+}
+
+// allDigits reports whether s only has decimal digits.
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigit reports whether c is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// repeated reports whether s is a single character repeated, such as
+// 00000000000, which passes the check digits but is never issued.
+func repeated(s string) bool {
+	return strings.Count(s, s[:1]) == len(s)
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log"
-	"net/http"
+	"os"
+	"store/commands"
 	"store/controllers"
+	"store/domain/apperrors"
 	"store/domain/entities"
+	"store/domain/repositories"
+	"store/migrations"
+	"store/services"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -14,14 +22,76 @@ import (
 )
 
 // Main starts the Gin server with the API routes and database connection.
+//
+// When arguments are given, it runs the named maintenance command of the
+// commands package instead, such as `go run . import-exchange-rates rates.csv`.
 func main() {
+	if len(os.Args) > 1 {
+		if err := commands.Run(GetDB(), os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	app := gin.Default()
-	app.Use(JSONMiddleware())
+	app.Use(RequestIDMiddleware(), ErrorMiddleware(), JSONMiddleware())
+	app.NoRoute(func(c *gin.Context) {
+		c.Error(apperrors.NotFound("route_not_found", "no route matches "+c.Request.Method+" "+c.Request.URL.Path))
+	})
 	db := GetDB()
 	controllers.InitRoutes(app, db)
+	if retention := services.TrashRetentionFromEnv(); retention > 0 {
+		go PurgeTrashDaily(db, retention)
+	}
+	if ttl := services.ReservationTTLFromEnv(); ttl > 0 {
+		go SweepReservations(db, ttl)
+	}
+	if interval := services.LowStockReportIntervalFromEnv(); interval > 0 {
+		go ReportLowStock(db, interval)
+	}
 	app.Run(":8080")
 }
 
+// PurgeTrashDaily permanently deletes, once a day, the entities that have been
+// in the trash for longer than the retention read from TRASH_RETENTION_DAYS.
+// Failures are logged and retried on the next day.
+func PurgeTrashDaily(db *gorm.DB, retention time.Duration) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		if err := commands.PurgeTrash(db, retention); err != nil {
+			log.Printf("Failed to purge the trash: %v", err)
+		}
+	}
+}
+
+// SweepReservations expires, every minute, the stock reservations of the draft
+// orders held for longer than the TTL read from RESERVATION_TTL_MINUTES.
+// Failures are logged and retried on the next sweep.
+func SweepReservations(db *gorm.DB, ttl time.Duration) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		if err := commands.ExpireReservations(db, ttl); err != nil {
+			log.Printf("Failed to expire the stock reservations: %v", err)
+		}
+	}
+}
+
+// ReportLowStock writes the low-stock report of the day, with the quantities
+// suggested to replenish the product suppliers, every interval read from
+// REPLENISHMENT_REPORT_HOURS. Failures are logged and retried on the next
+// report.
+func ReportLowStock(db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		if err := commands.WriteLowStockReport(db, commands.LowStockReportPath(time.Now())); err != nil {
+			log.Printf("Failed to write the low-stock report: %v", err)
+		}
+	}
+}
+
 var (
 	db   *gorm.DB
 	once sync.Once
@@ -40,6 +110,9 @@ func GetDB() *gorm.DB {
 		if err != nil {
 			log.Fatalf("Failed to connect to database: %v", err)
 		}
+		if err := conn.Use(repositories.ErrorTranslator{}); err != nil {
+			log.Fatalf("Failed to register the error translator: %v", err)
+		}
 		db = conn
 		AutoMigrate()
 		log.Println("Database connection established")
@@ -47,17 +120,29 @@ func GetDB() *gorm.DB {
 	return db
 }
 
-// AutoMigrate performs the auto-migration of the tables in the database. It is
-// called by the GetDB method when the database connection is established. It
-// auto-migrates the tables for the Customer, Supplier, Product, Order, Contact,
-// ProductSupplier, and OrderProductSupplier entities. The method checks if the
-// database connection is initialized and logs a fatal error if it is not. It
-// also logs a fatal error if the migration fails. If the migration is successful,
-// it logs a message to the console.
+// AutoMigrate performs the auto-migration of the tables in the database. It is called
+// by the GetDB method when the database connection is established. It auto-migrates the
+// tables for every entity of the domain, such as Customer, Supplier, Product, Order,
+// Contact, ProductSupplier and OrderProductSupplier. Legacy columns, tax IDs and order
+// numbers are converted by the data migrations of the migrations package before the
+// tables are auto-migrated, and the default contacts, the opening balances of the stock
+// ledger, the default warehouse and the opening stock lots are set after. The method checks if the
+// database connection is initialized and logs a fatal error if it is not. It also logs a
+// fatal error if the migration fails. If the migration is successful, it logs a message
+// to the console.
 func AutoMigrate() {
 	if db == nil {
 		log.Fatal("Database connection is not initialized")
 	}
+	if err := migrations.ConvertMoneyColumns(db); err != nil {
+		log.Fatalf("Money columns migration failed: %v", err)
+	}
+	if err := migrations.NormalizeTaxIDs(db); err != nil {
+		log.Fatalf("Tax IDs migration failed: %v", err)
+	}
+	if err := migrations.NumberOrders(db, services.OrderNumberPatternFromEnv().Format); err != nil {
+		log.Fatalf("Order numbers migration failed: %v", err)
+	}
 	err := db.AutoMigrate(
 		&entities.Customer{},             // Add the Customer entity
 		&entities.Supplier{},             // Add the Supplier entity
@@ -66,22 +151,61 @@ func AutoMigrate() {
 		&entities.Contact{},              // Add the Contact entity
 		&entities.ProductSupplier{},      // Add the ProductSupplier entity
 		&entities.OrderProductSupplier{}, // Add the OrderProductSupplier entity
+		&entities.OrderStatusHistory{},   // Add the OrderStatusHistory entity
+		&entities.ExchangeRate{},         // Add the ExchangeRate entity
+		&entities.OrderExchangeRate{},    // Add the OrderExchangeRate entity
+		&entities.OrderNumberSequence{},  // Add the OrderNumberSequence entity
+		&entities.Address{},              // Add the Address entity
+		&entities.OrderAddress{},         // Add the OrderAddress entity
+		&entities.StockMovement{},        // Add the StockMovement entity
+		&entities.StockReservation{},     // Add the StockReservation entity
+		&entities.PurchaseOrder{},        // Add the PurchaseOrder entity
+		&entities.PurchaseOrderLine{},    // Add the PurchaseOrderLine entity
+		&entities.GoodsReceipt{},         // Add the GoodsReceipt entity
+		&entities.GoodsReceiptLine{},     // Add the GoodsReceiptLine entity
+		&entities.Warehouse{},            // Add the Warehouse entity
+		&entities.WarehouseStock{},       // Add the WarehouseStock entity
+		&entities.StockTransfer{},        // Add the StockTransfer entity
+		&entities.StockTransferLine{},    // Add the StockTransferLine entity
+		&entities.StockLot{},             // Add the StockLot entity
+		&entities.StockMovementLot{},     // Add the StockMovementLot entity
 	)
 	if err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
+	if err := migrations.DefaultContacts(db); err != nil {
+		log.Fatalf("Default contacts migration failed: %v", err)
+	}
+	if err := migrations.OpenStockLedger(db); err != nil {
+		log.Fatalf("Stock ledger migration failed: %v", err)
+	}
+	if err := migrations.DefaultWarehouse(db); err != nil {
+		log.Fatalf("Default warehouse migration failed: %v", err)
+	}
+	if err := migrations.OpenStockLots(db); err != nil {
+		log.Fatalf("Stock lots migration failed: %v", err)
+	}
 	log.Println("AutoMigrate completed successfully")
 }
 
+// jsonTypes are the media types accepted in the body of a request: JSON, and
+// the JSON Merge Patch and JSON Patch documents of the PATCH endpoints.
+var jsonTypes = map[string]bool{
+	"application/json":             true,
+	"application/merge-patch+json": true,
+	"application/json-patch+json":  true,
+}
+
 // JSONMiddleware is a middleware function that sets the Accept header to "application/json"
 // and the Content-Type header to "application/json". It is used to ensure that the responses
 // are in JSON format.
 //
-// Its aborts the request if the Content-Type header is not "application/json".
+// Its aborts the request with a 415 error if it has a body whose Content-Type header is not
+// "application/json", or one of the patch document types, parameters such as the charset aside.
 func JSONMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if c.GetHeader("Content-Type") != "application/json" {
-			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/json"})
+		if c.Request.ContentLength != 0 && !jsonTypes[c.ContentType()] {
+			c.Error(apperrors.UnsupportedMediaType("unsupported_media_type", "Content-Type must be application/json"))
 			c.Abort()
 			return
 		}
@@ -90,3 +214,73 @@ func JSONMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// requestIDHeader is the header carrying the id of a request, sent back in
+// every response and in the problem details of its errors.
+const requestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware gives every request an id, the one sent by the client in
+// the X-Request-ID header when it is a short printable value, or a random one
+// otherwise. The id is sent back in the X-Request-ID header of the response
+// and stored in the context under the "request_id" key.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set("request_id", id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID reports whether id, sent by a client, can be used as the id
+// of its request: at most 128 printable ASCII characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random request id of 32 hexadecimal digits.
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
+
+// ErrorMiddleware renders the last error added to the context by a handler,
+// with ctx.Error, as an RFC 7807 problem details document of the
+// "application/problem+json" media type, e.g.
+//
+//	{"type": "about:blank", "title": "Not Found", "status": 404, "detail": "Order not found",
+//	 "instance": "/orders/42", "code": "not_found", "request_id": "3f9c..."}
+//
+// The status and code are the ones of the typed error of the apperrors package,
+// and any other error is a 500 error whose cause is logged but never sent to
+// the client. Nothing is rendered if the handler already wrote a response.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		requestID := c.GetString("request_id")
+		err := apperrors.From(c.Errors.Last().Err)
+		if err.Kind == apperrors.KindInternal {
+			log.Printf("Request %s %s %s failed: %v", requestID, c.Request.Method, c.Request.URL.Path, err.Err)
+		}
+		c.Header("Content-Type", apperrors.ProblemMediaType)
+		c.JSON(err.Kind.Status(), err.Problem(c.Request.URL.Path, requestID))
+	}
+}
@@ -0,0 +1,68 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// contactOwners lists the tables that referenced their single contact through
+// a legacy contact_id column, before they could own several contacts, with
+// the owner column of the contacts that replaces it.
+var contactOwners = []struct {
+	table  string // table of the owners
+	column string // column of the contacts referencing their owner
+}{
+	{table: "sales.customers", column: "customer_id"},
+	{table: "sales.suppliers", column: "supplier_id"},
+}
+
+// DefaultContacts makes the oldest contact of every type of each customer and
+// supplier its default contact of that type, when it has none, in a single
+// transaction, after the contacts are migrated with their type and default
+// flag. Legacy contacts are migrated as billing contacts, so the single
+// contact of an owner becomes its default billing contact.
+//
+// The legacy contact_id columns of the customers and suppliers are replaced by
+// the owner columns of the contacts: the contacts without owner first take the
+// customer or supplier referencing them, the oldest one when several do, and
+// the legacy columns are then dropped. Running the migration again does
+// nothing.
+func DefaultContacts(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, owner := range contactOwners {
+			if !migrator.HasColumn(owner.table, "contact_id") {
+				continue
+			}
+			err := tx.Exec(`UPDATE sales.contacts c SET ` + owner.column + ` = (
+					SELECT min(o.id) FROM ` + owner.table + ` o WHERE o.contact_id = c.id
+				), version = version + 1
+				WHERE COALESCE(c.` + owner.column + `, 0) = 0 AND EXISTS (
+					SELECT 1 FROM ` + owner.table + ` o WHERE o.contact_id = c.id
+				)`).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Exec(`UPDATE sales.contacts SET is_default = true, version = version + 1
+			WHERE id IN (
+				SELECT DISTINCT ON (customer_id, supplier_id, type) id FROM sales.contacts c
+				WHERE deleted_at IS NULL AND NOT EXISTS (
+					SELECT 1 FROM sales.contacts d
+					WHERE d.customer_id = c.customer_id AND d.supplier_id = c.supplier_id
+						AND d.type = c.type AND d.is_default AND d.deleted_at IS NULL
+				)
+				ORDER BY customer_id, supplier_id, type, id
+			)`).Error
+		if err != nil {
+			return err
+		}
+
+		for _, owner := range contactOwners {
+			if migrator.HasColumn(owner.table, "contact_id") {
+				if err := migrator.DropColumn(owner.table, "contact_id"); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// OpenStockLots puts the stock kept before there were lots into a lot without
+// number nor expiry date, after the lots are migrated and the stock is moved
+// into the warehouses. The units of every product supplier in a warehouse
+// without any lot are put in such a lot, so the quantities of the lots of a
+// warehouse add up to its stock. Running the migration again does nothing.
+func OpenStockLots(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO sales.stock_lots
+			(created_at, updated_at, version, warehouse_id, product_supplier_id, lot_number, quantity, received_at)
+		SELECT now(), now(), 1, s.warehouse_id, s.product_supplier_id, '', s.quantity, now()
+		FROM sales.warehouse_stocks s
+		WHERE s.quantity <> 0 AND NOT EXISTS (
+			SELECT 1 FROM sales.stock_lots l
+			WHERE l.warehouse_id = s.warehouse_id AND l.product_supplier_id = s.product_supplier_id
+		)`).Error
+}
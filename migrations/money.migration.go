@@ -0,0 +1,131 @@
+package migrations
+
+import (
+	"fmt"
+	"store/domain/money"
+
+	"gorm.io/gorm"
+)
+
+// moneyColumn describes a legacy float column converted into a money.Money
+// pair of columns, `<prefix>amount` in minor units and `<prefix>currency`.
+type moneyColumn struct {
+	table          string // table holding the legacy column
+	column         string // legacy float column
+	prefix         string // prefix of the new amount and currency columns
+	percentageType bool   // whether a discount_type column may mark the value as a percentage
+}
+
+// moneyColumns lists every float price converted into money.Money.
+var moneyColumns = []moneyColumn{
+	{table: "sales.product_suppliers", column: "cost", prefix: "cost_"},
+	{table: "sales.product_suppliers", column: "value", prefix: "value_"},
+	{table: "sales.products", column: "market_value", prefix: "market_value_"},
+	{table: "sales.orders", column: "discount", prefix: "discount_", percentageType: true},
+	{table: "sales.order_product_suppliers", column: "value", prefix: "value_"},
+	{table: "sales.order_product_suppliers", column: "discount", prefix: "discount_", percentageType: true},
+}
+
+// ConvertMoneyColumns converts the legacy float32 price columns into exact
+// money columns, in a single transaction, before the entities are migrated.
+//
+// Every legacy value is read as double precision, so the stored float is not
+// truncated, with missing values read as zero, and rounded to cents with
+// banker's rounding into a bigint amount column, with DefaultCurrency as its
+// currency. Legacy discounts are absolute values, so they become the absolute
+// discounts of the orders and their lines, except the discounts marked as
+// percentage by a discount_type column, as stored by databases migrated before
+// the percentage and absolute discounts were split, which are moved into the
+// discount_percentage column, in hundredths of a percent, instead. The legacy
+// columns are dropped once converted, so running the migration again does
+// nothing.
+func ConvertMoneyColumns(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, mc := range moneyColumns {
+			if !migrator.HasColumn(mc.table, mc.column) {
+				if mc.percentageType && migrator.HasColumn(mc.table, "discount_type") {
+					if err := splitConvertedDiscounts(tx, mc); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			err := tx.Exec(fmt.Sprintf(
+				`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %samount bigint NOT NULL DEFAULT 0,
+				ADD COLUMN IF NOT EXISTS %scurrency char(3) NOT NULL DEFAULT '%s'`,
+				mc.table, mc.prefix, mc.prefix, money.DefaultCurrency,
+			)).Error
+			if err != nil {
+				return err
+			}
+
+			cents := halfEven(fmt.Sprintf("COALESCE(%s, 0)::double precision::numeric * 100", mc.column))
+			if mc.percentageType && migrator.HasColumn(mc.table, "discount_type") {
+				err = tx.Exec(fmt.Sprintf(
+					`ALTER TABLE %s ADD COLUMN IF NOT EXISTS discount_percentage bigint NOT NULL DEFAULT 0`,
+					mc.table,
+				)).Error
+				if err != nil {
+					return err
+				}
+				err = tx.Exec(fmt.Sprintf(
+					`UPDATE %s SET
+						discount_percentage = CASE WHEN discount_type = 'percentage' THEN %s ELSE 0 END,
+						%samount = CASE WHEN discount_type = 'percentage' THEN 0 ELSE %s END`,
+					mc.table, cents, mc.prefix, cents,
+				)).Error
+				if err != nil {
+					return err
+				}
+				if err := migrator.DropColumn(mc.table, "discount_type"); err != nil {
+					return err
+				}
+			} else {
+				err = tx.Exec(fmt.Sprintf(`UPDATE %s SET %samount = %s`, mc.table, mc.prefix, cents)).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := migrator.DropColumn(mc.table, mc.column); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// splitConvertedDiscounts moves the discounts marked as percentage by a
+// leftover discount_type column, whose legacy column was already converted as
+// an absolute amount, into the discount_percentage column, and drops the
+// discount_type column. An amount in cents and a percentage in hundredths of a
+// percent are both the legacy value times 100, so no value is lost.
+func splitConvertedDiscounts(tx *gorm.DB, mc moneyColumn) error {
+	err := tx.Exec(fmt.Sprintf(
+		`ALTER TABLE %s ADD COLUMN IF NOT EXISTS discount_percentage bigint NOT NULL DEFAULT 0`,
+		mc.table,
+	)).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Exec(fmt.Sprintf(
+		`UPDATE %s SET discount_percentage = %samount, %samount = 0 WHERE discount_type = 'percentage'`,
+		mc.table, mc.prefix, mc.prefix,
+	)).Error
+	if err != nil {
+		return err
+	}
+	return tx.Migrator().DropColumn(mc.table, "discount_type")
+}
+
+// halfEven returns a SQL expression rounding the numeric expression to an
+// integer with banker's rounding, since round(numeric) rounds halves away
+// from zero.
+func halfEven(expression string) string {
+	return fmt.Sprintf(
+		"(CASE WHEN abs((%[1]s) - trunc(%[1]s)) = 0.5 THEN 2 * round((%[1]s) / 2) ELSE round(%[1]s) END)::bigint",
+		expression,
+	)
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"store/domain/entities"
+
+	"gorm.io/gorm"
+)
+
+// NumberOrders gives a generated order number to the legacy orders that are
+// not deleted and have no order number, or share it with an earlier order, in
+// a single transaction, before the entities are migrated with a unique index
+// on the order numbers.
+//
+// Order numbers used to be sent by the clients, so they could be empty or
+// duplicated. The first order with a number keeps it, and the others are given
+// the next number of the year of their order date, formatted by format and
+// issued from the same counters as the new orders, skipping the numbers
+// already used by other orders. The migration only runs on databases without
+// the order number counters, so the orders are not scanned on every start.
+func NumberOrders(db *gorm.DB, format func(year int, sequence int64) string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if !tx.Migrator().HasTable(&entities.Order{}) || tx.Migrator().HasTable(&entities.OrderNumberSequence{}) {
+			return nil
+		}
+		if err := tx.Migrator().CreateTable(&entities.OrderNumberSequence{}); err != nil {
+			return err
+		}
+
+		var used []string
+		if err := tx.Table("sales.orders").Distinct().Pluck("uk_order_number", &used).Error; err != nil {
+			return err
+		}
+		taken := make(map[string]bool, len(used))
+		for _, number := range used {
+			taken[number] = true
+		}
+
+		var rows []entities.Order
+		err := tx.Select("id, order_date, uk_order_number").Order("id").Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		kept := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			if row.UKOrderNumber != "" && !kept[row.UKOrderNumber] {
+				kept[row.UKOrderNumber] = true
+				continue
+			}
+
+			var number string
+			for number == "" || taken[number] {
+				var sequence entities.OrderNumberSequence
+				err := tx.Raw(`INSERT INTO sales.order_number_sequences (year, last_value) VALUES (?, 1)
+						ON CONFLICT (year) DO UPDATE SET last_value = sales.order_number_sequences.last_value + 1
+						RETURNING year, last_value`, row.OrderDate.Year()).
+					Scan(&sequence).
+					Error
+				if err != nil {
+					return err
+				}
+				number = format(sequence.Year, sequence.LastValue)
+			}
+			taken[number] = true
+
+			err := tx.Model(&entities.Order{}).Where("id = ?", row.ID).UpdateColumn("uk_order_number", number).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
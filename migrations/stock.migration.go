@@ -0,0 +1,21 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// OpenStockLedger opens the stock ledger of every product supplier that has no
+// movement yet, after the stock movements are migrated, by recording its
+// current quantity as an opening balance adjustment. The ledger of a product
+// supplier then matches its quantity, and every later change of the quantity
+// is recorded as a movement. Product suppliers without stock are skipped.
+// Running the migration again does nothing.
+func OpenStockLedger(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO sales.stock_movements
+			(created_at, updated_at, version, product_supplier_id, type, quantity, balance_after,
+				reason, reference_type, reference, created_by, moved_at)
+		SELECT now(), now(), 1, ps.id, 'adjustment', ps.quantity, ps.quantity,
+			'opening balance', '', '', '', now()
+		FROM sales.product_suppliers ps
+		WHERE ps.quantity <> 0 AND NOT EXISTS (
+			SELECT 1 FROM sales.stock_movements m WHERE m.product_supplier_id = ps.id
+		)`).Error
+}
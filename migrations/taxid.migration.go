@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"fmt"
+	"store/domain/taxid"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// taxIDTables lists the tables whose tax_id column holds a CPF or CNPJ.
+var taxIDTables = []string{"sales.customers", "sales.suppliers"}
+
+// NormalizeTaxIDs converts the legacy free-form tax IDs of the customers and
+// suppliers into their canonical unmasked form, in a single transaction,
+// before the entities are migrated with a unique index on them.
+//
+// Only valid CPFs and CNPJs are converted; other values are kept as they are,
+// and must be fixed by the next update of their entity, which validates them.
+// The migration fails, listing the duplicates, if two entities that are not
+// deleted share a tax ID once normalized, since the unique index could not be
+// created. Running the migration again does nothing.
+func NormalizeTaxIDs(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range taxIDTables {
+			if !tx.Migrator().HasTable(table) {
+				continue
+			}
+
+			var rows []struct {
+				ID    uint
+				TaxID string
+			}
+			if err := tx.Table(table).Select("id, tax_id").Scan(&rows).Error; err != nil {
+				return err
+			}
+			for _, row := range rows {
+				normalized, err := taxid.Parse(row.TaxID)
+				if err != nil || string(normalized) == row.TaxID {
+					continue
+				}
+				err = tx.Table(table).Where("id = ?", row.ID).Update("tax_id", string(normalized)).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			var duplicates []string
+			err := tx.Table(table).
+				Select("tax_id").
+				Where("deleted_at IS NULL").
+				Group("tax_id").
+				Having("count(*) > 1").
+				Scan(&duplicates).
+				Error
+			if err != nil {
+				return err
+			}
+			if len(duplicates) > 0 {
+				return fmt.Errorf("%s share the tax IDs %s, which must be fixed before they are made unique",
+					table, strings.Join(duplicates, ", "))
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// DefaultWarehouse moves the stock kept before there were warehouses into a
+// default warehouse, in a single transaction, after the warehouses are
+// migrated and the stock ledger is opened.
+//
+// A default warehouse with the MAIN code is created when there is none, and
+// the quantity of every product supplier without stock in any warehouse is
+// put in it. The movements, goods receipts and placed orders without a
+// warehouse are then assigned to the default one, where they took place.
+// Running the migration again does nothing.
+func DefaultWarehouse(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO sales.warehouses (created_at, updated_at, version, code, name, is_default)
+			SELECT now(), now(), 1, 'MAIN', 'Main warehouse', true
+			WHERE NOT EXISTS (
+				SELECT 1 FROM sales.warehouses WHERE is_default AND deleted_at IS NULL
+			)`).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO sales.warehouse_stocks
+				(created_at, updated_at, version, warehouse_id, product_supplier_id, quantity)
+			SELECT now(), now(), 1, w.id, ps.id, ps.quantity
+			FROM sales.product_suppliers ps
+			JOIN sales.warehouses w ON w.is_default AND w.deleted_at IS NULL
+			WHERE ps.quantity <> 0 AND NOT EXISTS (
+				SELECT 1 FROM sales.warehouse_stocks s WHERE s.product_supplier_id = ps.id
+			)`).Error
+		if err != nil {
+			return err
+		}
+
+		for _, statement := range []string{
+			`UPDATE sales.stock_movements SET warehouse_id = w.id
+				FROM sales.warehouses w
+				WHERE stock_movements.warehouse_id IS NULL AND w.is_default AND w.deleted_at IS NULL`,
+			`UPDATE sales.goods_receipts SET warehouse_id = w.id
+				FROM sales.warehouses w
+				WHERE goods_receipts.warehouse_id IS NULL AND w.is_default AND w.deleted_at IS NULL`,
+			`UPDATE sales.orders SET warehouse_id = w.id
+				FROM sales.warehouses w
+				WHERE orders.warehouse_id IS NULL AND orders.status <> 'draft' AND w.is_default AND w.deleted_at IS NULL`,
+		} {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/postalcode"
+	"store/domain/repositories"
+	"store/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AddressMode is how the addresses of the contacts are checked against the
+// postal code dataset.
+type AddressMode string
+
+const (
+	AddressModeOff      AddressMode = "off"      // addresses are saved as given
+	AddressModeFill     AddressMode = "fill"     // the city, state and country of the postal code replace the given ones
+	AddressModeValidate AddressMode = "validate" // addresses whose city or state differ from the postal code are rejected
+)
+
+// AddressModeFromEnv returns the AddressMode read from the ADDRESS_LOOKUP
+// environment variable, AddressModeFill by default or for an unknown mode.
+func AddressModeFromEnv() AddressMode {
+	switch mode := AddressMode(utils.GetEnv("ADDRESS_LOOKUP", string(AddressModeFill))); mode {
+	case AddressModeOff, AddressModeValidate:
+		return mode
+	default:
+		return AddressModeFill
+	}
+}
+
+// AddressService is an interface that defines methods for looking up the
+// addresses of the Brazilian postal codes.
+//
+// It provides methods to find the address of a postal code, complete the
+// address of a contact from its postal code, and import a postal code dataset.
+type AddressService interface {
+	GetByPostalCode(ctx *gin.Context, postalCode postalcode.CEP) (*entities.Address, error) // Get the address of a postal code
+	CompleteContact(ctx *gin.Context, contact *entities.Contact) ([]string, error)          // Fill and check the address of a contact
+	ImportCSV(ctx *gin.Context, reader io.Reader) (int, error)                              // Import addresses from CSV
+}
+
+// addressService is a struct that implements the AddressService interface. It
+// contains an AddressRepository which is used to interact with the addresses
+// table in the database, and the mode the addresses of the contacts are
+// checked with.
+type addressService struct {
+	addressRepository repositories.AddressRepository
+	mode              AddressMode
+}
+
+// NewAddressService creates a new AddressService with the given
+// AddressRepository, checking the addresses of the contacts with the given
+// mode.
+func NewAddressService(addressRepository repositories.AddressRepository, mode AddressMode) AddressService {
+	return &addressService{addressRepository: addressRepository, mode: mode}
+}
+
+// Retrieves the address of a postal code.
+//
+// The method delegates the retrieval to the addressRepository and returns
+// gorm.ErrRecordNotFound if the postal code is not in the dataset.
+func (s *addressService) GetByPostalCode(ctx *gin.Context, postalCode postalcode.CEP) (*entities.Address, error) {
+	return s.addressRepository.GetByPostalCode(ctx, postalCode)
+}
+
+// Completes the address of a contact from its postal code.
+//
+// When the contact is in Brazil, or has no country, and its postal code is a
+// valid CEP, the postal code is formatted with its mask and looked up in the
+// dataset. If it is found, the empty street (area) and district of the contact
+// are filled from it, and its city, state and country are set to the ones of
+// the postal code. In AddressModeValidate, a contact whose city or state
+// differs from the ones of the postal code, regardless of case and accents, is
+// rejected with a validation error instead. Postal codes missing from the
+// dataset, foreign addresses and AddressModeOff leave the address as given.
+//
+// The area, district, city, state and country of the completed address are
+// required. The method returns the names of the struct fields it changed, to
+// be saved along with the other fields of an update.
+func (s *addressService) CompleteContact(ctx *gin.Context, contact *entities.Contact) ([]string, error) {
+	var changed []string
+	if s.mode != AddressModeOff && postalcode.IsBrazil(contact.Country) {
+		if cep, err := postalcode.Parse(contact.PostalCode); err == nil {
+			changed = setField(changed, &contact.PostalCode, cep.Format(), "PostalCode")
+
+			address, err := s.addressRepository.GetByPostalCode(ctx, cep)
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+			case err != nil:
+				return nil, err
+			default:
+				if s.mode == AddressModeValidate {
+					if err := matchAddress(contact, address); err != nil {
+						return nil, err
+					}
+				}
+				if contact.Area == "" {
+					changed = setField(changed, &contact.Area, address.Street, "Area")
+				}
+				if contact.District == "" {
+					changed = setField(changed, &contact.District, address.District, "District")
+				}
+				changed = setField(changed, &contact.City, address.City, "City")
+				changed = setField(changed, &contact.State, address.State, "State")
+				changed = setField(changed, &contact.Country, postalcode.Country, "Country")
+			}
+		}
+	}
+
+	var missing []apperrors.FieldError
+	for _, field := range []struct{ name, value string }{
+		{"area", contact.Area},
+		{"district", contact.District},
+		{"city", contact.City},
+		{"state", contact.State},
+		{"country", contact.Country},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			missing = append(missing, apperrors.FieldError{Field: field.name, Rule: "required", Message: "is required"})
+		}
+	}
+	if len(missing) > 0 {
+		return nil, apperrors.Validation(missing)
+	}
+	return changed, nil
+}
+
+// Imports the addresses of a postal code dataset from CSV.
+//
+// The CSV must have five columns: postal_code, street, district, city and
+// state, with an optional header row. The postal code may be masked and the
+// state given by its abbreviation or its name. A postal code listed twice is
+// imported with its last row. Addresses that already exist for a postal code
+// are replaced. Either every row is imported or none is, and an invalid row is
+// reported with its line number.
+func (s *addressService) ImportCSV(ctx *gin.Context, reader io.Reader) (int, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 5
+	csvReader.TrimLeadingSpace = true
+	csvReader.ReuseRecord = true
+
+	var addresses []*entities.Address
+	indexes := make(map[postalcode.CEP]int)
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if line == 1 && (strings.EqualFold(record[0], "postal_code") || strings.EqualFold(record[0], "cep")) {
+			continue
+		}
+
+		cep, err := postalcode.Parse(record[0])
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		state, ok := postalcode.ParseState(record[4])
+		if !ok {
+			return 0, fmt.Errorf("line %d: unknown state %q", line, record[4])
+		}
+		city := strings.TrimSpace(record[3])
+		if city == "" {
+			return 0, fmt.Errorf("line %d: the city is required", line)
+		}
+
+		address := &entities.Address{
+			PostalCode: cep,
+			Street:     strings.TrimSpace(record[1]),
+			District:   strings.TrimSpace(record[2]),
+			City:       city,
+			State:      state,
+		}
+		if i, ok := indexes[cep]; ok {
+			addresses[i] = address
+			continue
+		}
+		indexes[cep] = len(addresses)
+		addresses = append(addresses, address)
+	}
+
+	if err := s.addressRepository.Import(ctx, addresses); err != nil {
+		return 0, err
+	}
+	return len(addresses), nil
+}
+
+// matchAddress returns a validation error if the city or the state given for
+// a contact differ from the ones of the address of its postal code.
+func matchAddress(contact *entities.Contact, address *entities.Address) error {
+	postalCode := address.PostalCode.Format()
+
+	var fields []apperrors.FieldError
+	if contact.City != "" && !postalcode.SameName(contact.City, address.City) {
+		fields = append(fields, apperrors.FieldError{
+			Field:   "city",
+			Rule:    "postal_code",
+			Message: fmt.Sprintf("must be %s, the city of the postal code %s", address.City, postalCode),
+		})
+	}
+	if state, ok := postalcode.ParseState(contact.State); contact.State != "" && (!ok || state != address.State) {
+		fields = append(fields, apperrors.FieldError{
+			Field:   "state",
+			Rule:    "postal_code",
+			Message: fmt.Sprintf("must be %s, the state of the postal code %s", address.State, postalCode),
+		})
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation(fields)
+	}
+	return nil
+}
+
+// setField sets *field to value and appends the name of the field to changed
+// if its value changes.
+func setField(changed []string, field *string, value, name string) []string {
+	if *field == value {
+		return changed
+	}
+	*field = value
+	return append(changed, name)
+}
+
+// nestedFieldErrors returns err with the invalid fields of a validation error
+// prefixed with the path of the nested request they belong to, e.g.
+// `contacts[0].city` for the first contact created along with a customer.
+func nestedFieldErrors(err error, prefix string) error {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || len(appErr.Fields) == 0 {
+		return err
+	}
+	nested := *appErr
+	nested.Fields = make([]apperrors.FieldError, len(appErr.Fields))
+	for i, field := range appErr.Fields {
+		field.Field = prefix + field.Field
+		nested.Fields[i] = field
+	}
+	return &nested
+}
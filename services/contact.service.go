@@ -1,7 +1,12 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"store/domain/apperrors"
 	"store/domain/entities"
+	"store/domain/phone"
+	"store/domain/query"
 	"store/domain/repositories"
 
 	"github.com/gin-gonic/gin"
@@ -15,12 +20,14 @@ import (
 type ContactService interface {
 	Create(ctx *gin.Context, contact *entities.Contact) error
 	GetByID(ctx *gin.Context, id uint) (*entities.Contact, error)
-	GetAll(ctx *gin.Context) ([]*entities.Contact, error)
-	Update(ctx *gin.Context, contact *entities.Contact) error
-	Delete(ctx *gin.Context, id uint) error
-	DeleteAll(ctx *gin.Context, ids []uint) error
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Contact], error)
+	Update(ctx *gin.Context, contact *entities.Contact, fields ...string) error
+	Delete(ctx *gin.Context, id uint, version uint) error
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)
 	GetAllByCustomerID(ctx *gin.Context, customerID uint) ([]*entities.Contact, error)
 	GetAllBySupplierID(ctx *gin.Context, supplierID uint) ([]*entities.Contact, error)
+	NormalizePhones(ctx *gin.Context) (int, []PhoneFailure, error)
+	TrashService[entities.Contact] // Get, restore and purge deleted contacts
 }
 
 // contactService is a struct that contains a pointer to a contactRepository and
@@ -29,14 +36,17 @@ type ContactService interface {
 // database.
 type contactService struct {
 	contactRepository repositories.ContactRepository
+	addressService    AddressService
+	TrashService[entities.Contact]
 }
 
 // NewContactService creates a new instance of contactService with the provided
 // contactRepository and returns it as a ContactService. This function is used to
 // initialize a new contact service that can perform CRUD operations and other
-// queries on the contacts table.
-func NewContactService(contactRepository repositories.ContactRepository) ContactService {
-	return &contactService{contactRepository: contactRepository}
+// queries on the contacts table. The addresses of the contacts are completed
+// from their postal code by the addressService.
+func NewContactService(contactRepository repositories.ContactRepository, addressService AddressService) ContactService {
+	return &contactService{contactRepository: contactRepository, addressService: addressService, TrashService: contactRepository}
 }
 
 // Creates a new contact in the database.
@@ -50,7 +60,13 @@ func NewContactService(contactRepository repositories.ContactRepository) Contact
 //
 // The method returns an error if something goes wrong. If the contact is created
 // successfully, the method returns nil.
+//
+// The address of the contact is first completed from its postal code and its
+// phones are normalized, as described in completeContact.
 func (s *contactService) Create(ctx *gin.Context, contact *entities.Contact) error {
+	if _, err := completeContact(ctx, s.addressService, contact); err != nil {
+		return err
+	}
 	return s.contactRepository.Create(ctx, contact)
 }
 
@@ -68,17 +84,14 @@ func (s *contactService) GetByID(ctx *gin.Context, id uint) (*entities.Contact,
 	return s.contactRepository.GetByID(ctx, id)
 }
 
-// Retrieves all contacts from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.Contact and an error. If something goes wrong, the
-// method returns an empty slice and an error.
+// Retrieves a page of contacts.
 //
-// The method retrieves all contacts from the database. If the contacts are found,
-// the method returns the contacts and nil. If no contacts are found or an error
-// occurs, the method returns an empty slice and an error.
-func (s *contactService) GetAll(ctx *gin.Context) ([]*entities.Contact, error) {
-	return s.contactRepository.GetAll(ctx)
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request. It delegates the retrieval to the contactRepository, which
+// applies the pagination, sorting and filters of the query, and returns the
+// page of contacts or an error if the retrieval fails.
+func (s *contactService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Contact], error) {
+	return s.contactRepository.GetAll(ctx, q)
 }
 
 // Updates a contact in the database.
@@ -92,8 +105,22 @@ func (s *contactService) GetAll(ctx *gin.Context) ([]*entities.Contact, error) {
 //
 // The method returns an error if something goes wrong. If the contact is updated
 // successfully, the method returns nil.
-func (s *contactService) Update(ctx *gin.Context, contact *entities.Contact) error {
-	return s.contactRepository.Update(ctx, contact)
+//
+// When fields are given, only those fields of the contact, named as in its
+// struct, are saved, as done for partial updates.
+//
+// The address of the contact is first completed from its postal code and its
+// phones are normalized, as described in completeContact, and the fields they
+// change are saved along with the given ones.
+func (s *contactService) Update(ctx *gin.Context, contact *entities.Contact, fields ...string) error {
+	changed, err := completeContact(ctx, s.addressService, contact)
+	if err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		fields = append(fields, changed...)
+	}
+	return s.contactRepository.Update(ctx, contact, fields...)
 }
 
 // Deletes a contact from the database.
@@ -104,19 +131,20 @@ func (s *contactService) Update(ctx *gin.Context, contact *entities.Contact) err
 // The method deletes a contact from the database using the given ID.
 // If the contact is deleted successfully, the method returns nil. If the contact
 // is not found or an error occurs, the method returns an error.
-func (s *contactService) Delete(ctx *gin.Context, id uint) error {
-	return s.contactRepository.Delete(ctx, id)
+//
+// The contact is only deleted if it is still at the given version, otherwise
+// repositories.ErrVersionConflict is returned.
+func (s *contactService) Delete(ctx *gin.Context, id uint, version uint) error {
+	return s.contactRepository.Delete(ctx, id, version)
 }
 
 // Deletes multiple contacts from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple contacts from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the contacts are
-// deleted successfully, the method returns nil.
-func (s *contactService) DeleteAll(ctx *gin.Context, ids []uint) error {
+// The method takes a context and a slice of uints as parameters. It delegates
+// the deletion to the contactRepository, which deletes the contacts that exist
+// and are not referenced anymore in a single transaction. It returns the
+// outcome for each id, or an error if the deletion process fails.
+func (s *contactService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
 	return s.contactRepository.DeleteAll(ctx, ids)
 }
 
@@ -147,3 +175,157 @@ func (s *contactService) GetAllByCustomerID(ctx *gin.Context, customerID uint) (
 func (s *contactService) GetAllBySupplierID(ctx *gin.Context, supplierID uint) ([]*entities.Contact, error) {
 	return s.contactRepository.GetAllBySupplierID(ctx, supplierID)
 }
+
+// PhoneFailure is a phone of a contact that could not be normalized by
+// NormalizePhones.
+type PhoneFailure struct {
+	ContactID uint   // ID of the contact
+	Field     string // JSON name of the phone field, phone or secondary_phone
+	Phone     string // phone as stored
+	Country   string // country of the contact the phone was parsed for
+}
+
+// phoneBatchSize is the number of contacts loaded at once by NormalizePhones.
+const phoneBatchSize = 500
+
+// Normalizes the phones of the existing contacts into E.164.
+//
+// The method goes through every contact that is not deleted, in batches, and
+// saves the phones that are not in E.164 yet in their canonical form,
+// incrementing the version of their contact. Phones that cannot be parsed for
+// the country of their contact are kept as they are and returned, to be fixed
+// by hand; the other phone of their contact is still normalized. It returns
+// the number of contacts updated, or an error if the retrieval or an update
+// fails, in which case the contacts already updated are kept.
+func (s *contactService) NormalizePhones(ctx *gin.Context) (int, []PhoneFailure, error) {
+	updated := 0
+	var failures []PhoneFailure
+	err := s.contactRepository.FindInBatches(ctx, phoneBatchSize, func(contacts []*entities.Contact) error {
+		for _, contact := range contacts {
+			changed, invalid := normalizePhones(contact)
+			for _, field := range invalid {
+				failure := PhoneFailure{ContactID: contact.ID, Field: field.Field, Phone: contact.Phone, Country: contact.Country}
+				if field.Field == "secondary_phone" {
+					failure.Phone = contact.SecondaryPhone
+				}
+				failures = append(failures, failure)
+			}
+			if len(changed) == 0 {
+				continue
+			}
+			if err := s.contactRepository.Update(ctx, contact, changed...); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, failures, err
+}
+
+// completeContact completes the address of a contact from its postal code, as
+// described in AddressService.CompleteContact, and normalizes its phones into
+// E.164 for its country, which may have been filled from the postal code. A
+// contact without type is a billing contact. It returns the names of the
+// struct fields it changed, or a validation error listing both the invalid
+// address and phone fields.
+func completeContact(ctx *gin.Context, addressService AddressService, contact *entities.Contact) ([]string, error) {
+	var typed []string
+	if contact.Type == "" {
+		contact.Type = entities.ContactTypeBilling
+		typed = append(typed, "Type")
+	}
+
+	changed, err := addressService.CompleteContact(ctx, contact)
+	var appErr *apperrors.Error
+	if err != nil && (!errors.As(err, &appErr) || len(appErr.Fields) == 0) {
+		return nil, err
+	}
+
+	phones, invalid := normalizePhones(contact)
+	if appErr != nil {
+		invalid = append(appErr.Fields, invalid...)
+	}
+	if len(invalid) > 0 {
+		return nil, apperrors.Validation(invalid)
+	}
+	return append(append(typed, changed...), phones...), nil
+}
+
+// completeContacts completes the contacts created along with their customer or
+// supplier as done by completeContact, reporting the invalid fields with the
+// index of their contact, e.g. `contacts[1].phone`.
+//
+// The first contact of each type becomes the default of its type, unless
+// another contact of that type is marked as default. Marking two contacts of
+// the same type as default is a validation error.
+func completeContacts(ctx *gin.Context, addressService AddressService, contacts []entities.Contact) error {
+	var fields []apperrors.FieldError
+	for i := range contacts {
+		if _, err := completeContact(ctx, addressService, &contacts[i]); err != nil {
+			var appErr *apperrors.Error
+			if !errors.As(nestedFieldErrors(err, fmt.Sprintf("contacts[%d].", i)), &appErr) || len(appErr.Fields) == 0 {
+				return err
+			}
+			fields = append(fields, appErr.Fields...)
+		}
+	}
+
+	defaults := map[entities.ContactType]int{}
+	for i, contact := range contacts {
+		if !contact.IsDefault {
+			continue
+		}
+		if _, ok := defaults[contact.Type]; ok {
+			fields = append(fields, apperrors.FieldError{
+				Field:   fmt.Sprintf("contacts[%d].is_default", i),
+				Rule:    "default",
+				Message: fmt.Sprintf("only one %s contact can be the default", contact.Type),
+			})
+			continue
+		}
+		defaults[contact.Type] = i
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation(fields)
+	}
+
+	for i, contact := range contacts {
+		if _, ok := defaults[contact.Type]; !ok {
+			defaults[contact.Type] = i
+			contacts[i].IsDefault = true
+		}
+	}
+	return nil
+}
+
+// normalizePhones replaces the phone and the secondary phone of a contact by
+// their E.164 form for the country of the contact. It returns the names of the
+// struct fields it changed, and the fields whose phone could not be parsed,
+// which are left unchanged. The secondary phone is optional.
+func normalizePhones(contact *entities.Contact) ([]string, []apperrors.FieldError) {
+	var changed []string
+	var invalid []apperrors.FieldError
+	for _, field := range []struct {
+		value       *string
+		name, field string
+	}{
+		{&contact.Phone, "Phone", "phone"},
+		{&contact.SecondaryPhone, "SecondaryPhone", "secondary_phone"},
+	} {
+		if field.name == "SecondaryPhone" && *field.value == "" {
+			continue
+		}
+		number, err := phone.Parse(*field.value, contact.Country)
+		if err != nil {
+			invalid = append(invalid, apperrors.FieldError{
+				Field:   field.field,
+				Rule:    "phone",
+				Message: "must be a valid phone number of the country of the contact, or in international format",
+			})
+			continue
+		}
+		changed = setField(changed, field.value, number.E164, field.name)
+	}
+	return changed, invalid
+}
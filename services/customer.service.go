@@ -2,7 +2,9 @@ package services
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
 	"store/domain/repositories"
+	"store/domain/taxid"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,10 +17,12 @@ import (
 type CustomerService interface {
 	Create(ctx *gin.Context, customer *entities.Customer) error
 	GetByID(ctx *gin.Context, id uint) (*entities.Customer, error)
-	GetAll(ctx *gin.Context) ([]*entities.Customer, error)
-	Update(ctx *gin.Context, customer *entities.Customer) error
-	Delete(ctx *gin.Context, id uint) error
-	DeleteAll(ctx *gin.Context, ids []uint) error
+	GetByTaxID(ctx *gin.Context, taxID taxid.TaxID) (*entities.Customer, error)
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Customer], error)
+	Update(ctx *gin.Context, customer *entities.Customer, fields ...string) error
+	Delete(ctx *gin.Context, id uint, version uint) error
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)
+	TrashService[entities.Customer] // Get, restore and purge deleted customers
 }
 
 // customerService is a struct that contains a pointer to a customerRepository and
@@ -27,14 +31,17 @@ type CustomerService interface {
 // database.
 type customerService struct {
 	customerRepository repositories.CustomerRepository
+	addressService     AddressService
+	TrashService[entities.Customer]
 }
 
 // NewCustomerService creates a new instance of customerService with the provided
 // customerRepository and returns it as a CustomerService. This function is used to
 // initialize a new customer service that can perform CRUD operations and other
-// queries on the customers table.
-func NewCustomerService(customerRepository repositories.CustomerRepository) CustomerService {
-	return &customerService{customerRepository: customerRepository}
+// queries on the customers table. The addresses of the contacts created along
+// with a customer are completed from their postal code by the addressService.
+func NewCustomerService(customerRepository repositories.CustomerRepository, addressService AddressService) CustomerService {
+	return &customerService{customerRepository: customerRepository, addressService: addressService, TrashService: customerRepository}
 }
 
 // Creates a new customer in the database.
@@ -48,7 +55,15 @@ func NewCustomerService(customerRepository repositories.CustomerRepository) Cust
 //
 // The method returns an error if something goes wrong. If the customer is created
 // successfully, the method returns nil.
+//
+// The addresses of the contacts created along with the customer are first
+// completed from their postal code and their phones are normalized, as
+// described in completeContacts, which also picks the default contact of each
+// type.
 func (s *customerService) Create(ctx *gin.Context, customer *entities.Customer) error {
+	if err := completeContacts(ctx, s.addressService, customer.Contacts); err != nil {
+		return err
+	}
 	return s.customerRepository.Create(ctx, customer)
 }
 
@@ -66,17 +81,23 @@ func (s *customerService) GetByID(ctx *gin.Context, id uint) (*entities.Customer
 	return s.customerRepository.GetByID(ctx, id)
 }
 
-// Retrieves all customers from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.Customer and an error. If something goes wrong, the
-// method returns an empty slice and an error.
+// Retrieves a customer by its tax ID.
 //
-// The method retrieves all customers from the database. If the customers are found,
-// the method returns the customers and nil. If no customers are found or an error
-// occurs, the method returns an empty slice and an error.
-func (s *customerService) GetAll(ctx *gin.Context) ([]*entities.Customer, error) {
-	return s.customerRepository.GetAll(ctx)
+// The method takes a pointer to a *gin.Context and the canonical CPF or CNPJ of
+// the customer. It returns the customer, or gorm.ErrRecordNotFound if no
+// customer has the given tax ID.
+func (s *customerService) GetByTaxID(ctx *gin.Context, taxID taxid.TaxID) (*entities.Customer, error) {
+	return s.customerRepository.GetByTaxID(ctx, taxID)
+}
+
+// Retrieves a page of customers.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request. It delegates the retrieval to the customerRepository, which
+// applies the pagination, sorting and filters of the query, and returns the
+// page of customers or an error if the retrieval fails.
+func (s *customerService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Customer], error) {
+	return s.customerRepository.GetAll(ctx, q)
 }
 
 // Updates a customer in the database.
@@ -90,8 +111,11 @@ func (s *customerService) GetAll(ctx *gin.Context) ([]*entities.Customer, error)
 //
 // The method returns an error if something goes wrong. If the customer is updated
 // successfully, the method returns nil.
-func (s *customerService) Update(ctx *gin.Context, customer *entities.Customer) error {
-	return s.customerRepository.Update(ctx, customer)
+//
+// When fields are given, only those fields of the customer, named as in its
+// struct, are saved, as done for partial updates.
+func (s *customerService) Update(ctx *gin.Context, customer *entities.Customer, fields ...string) error {
+	return s.customerRepository.Update(ctx, customer, fields...)
 }
 
 // Deletes a customer from the database.
@@ -102,18 +126,19 @@ func (s *customerService) Update(ctx *gin.Context, customer *entities.Customer)
 // The method deletes a customer from the database using the given ID.
 // If the customer is deleted successfully, the method returns nil. If the customer
 // is not found or an error occurs, the method returns an error.
-func (s *customerService) Delete(ctx *gin.Context, id uint) error {
-	return s.customerRepository.Delete(ctx, id)
+//
+// The customer is only deleted if it is still at the given version, otherwise
+// repositories.ErrVersionConflict is returned.
+func (s *customerService) Delete(ctx *gin.Context, id uint, version uint) error {
+	return s.customerRepository.Delete(ctx, id, version)
 }
 
 // Deletes multiple customers from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple customers from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the customers are
-// deleted successfully, the method returns nil.
-func (s *customerService) DeleteAll(ctx *gin.Context, ids []uint) error {
+// The method takes a context and a slice of uints as parameters. It delegates
+// the deletion to the customerRepository, which deletes the customers that
+// exist and are not referenced anymore in a single transaction. It returns the
+// outcome for each id, or an error if the deletion process fails.
+func (s *customerService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
 	return s.customerRepository.DeleteAll(ctx, ids)
 }
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/money"
+	"store/domain/query"
+	"store/domain/repositories"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exchangeRateDateLayout is the layout of the effective dates in CSV imports.
+const exchangeRateDateLayout = "2006-01-02"
+
+// ErrMissingExchangeRate is returned when no rate is effective for a currency
+// pair on a given date.
+var ErrMissingExchangeRate = apperrors.Invalid("missing_exchange_rate", "no exchange rate effective for the currency pair")
+
+// ExchangeRateService is an interface that defines the methods that a service
+// must implement to manage exchange rates in the application. It provides
+// methods to create, retrieve, update, delete and import exchange rates.
+type ExchangeRateService interface {
+	Create(ctx *gin.Context, exchangeRate *entities.ExchangeRate) error                       // Create a new exchange rate
+	GetByID(ctx *gin.Context, id uint) (*entities.ExchangeRate, error)                        // Get an exchange rate by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.ExchangeRate], error) // Get all exchange rates
+	Update(ctx *gin.Context, exchangeRate *entities.ExchangeRate, fields ...string) error     // Update an exchange rate
+	Delete(ctx *gin.Context, id uint, version uint) error                                     // Delete an exchange rate
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)              // Delete multiple exchange rates
+	Import(ctx *gin.Context, exchangeRates []*entities.ExchangeRate) error                    // Import multiple exchange rates
+	ImportCSV(ctx *gin.Context, reader io.Reader) (int, error)                                // Import exchange rates from CSV
+	TrashService[entities.ExchangeRate]                                                       // Get, restore and purge deleted exchange rates
+}
+
+// exchangeRateService is a struct that implements the ExchangeRateService
+// interface. It contains an ExchangeRateRepository which is used to interact
+// with the exchange_rates table in the database.
+type exchangeRateService struct {
+	exchangeRateRepository repositories.ExchangeRateRepository
+	TrashService[entities.ExchangeRate]
+}
+
+// NewExchangeRateService creates a new ExchangeRateService with the given
+// ExchangeRateRepository. It returns an instance of exchangeRateService that
+// implements the ExchangeRateService interface.
+func NewExchangeRateService(exchangeRateRepository repositories.ExchangeRateRepository) ExchangeRateService {
+	return &exchangeRateService{exchangeRateRepository: exchangeRateRepository, TrashService: exchangeRateRepository}
+}
+
+// Creates a new exchange rate after normalizing its currencies.
+//
+// The method returns money.ErrInvalidCurrency if a currency is not a valid ISO
+// 4217 code, or an error if the creation fails.
+func (s *exchangeRateService) Create(ctx *gin.Context, exchangeRate *entities.ExchangeRate) error {
+	if err := normalizeExchangeRate(exchangeRate); err != nil {
+		return err
+	}
+	return s.exchangeRateRepository.Create(ctx, exchangeRate)
+}
+
+// Retrieves an exchange rate by its ID.
+//
+// The method delegates the retrieval to the exchangeRateRepository and returns
+// an error if the retrieval fails.
+func (s *exchangeRateService) GetByID(ctx *gin.Context, id uint) (*entities.ExchangeRate, error) {
+	return s.exchangeRateRepository.GetByID(ctx, id)
+}
+
+// Retrieves a page of exchange rates.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request. It delegates the retrieval to the exchangeRateRepository, which
+// applies the pagination, sorting and filters of the query, and returns the
+// page of exchange rates or an error if the retrieval fails.
+func (s *exchangeRateService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.ExchangeRate], error) {
+	return s.exchangeRateRepository.GetAll(ctx, q)
+}
+
+// Updates an exchange rate after normalizing its currencies.
+//
+// The method returns money.ErrInvalidCurrency if a currency is not a valid ISO
+// 4217 code, or an error if the update fails.
+//
+// When fields are given, only those fields of the exchange rate, named as in
+// its struct, are saved, as done for partial updates.
+func (s *exchangeRateService) Update(ctx *gin.Context, exchangeRate *entities.ExchangeRate, fields ...string) error {
+	if err := normalizeExchangeRate(exchangeRate); err != nil {
+		return err
+	}
+	return s.exchangeRateRepository.Update(ctx, exchangeRate, fields...)
+}
+
+// Deletes an exchange rate by its ID.
+//
+// The method delegates the deletion to the exchangeRateRepository and returns
+// an error if the deletion fails.
+//
+// The exchange rate is only deleted if it is still at the given version,
+// otherwise repositories.ErrVersionConflict is returned.
+func (s *exchangeRateService) Delete(ctx *gin.Context, id uint, version uint) error {
+	return s.exchangeRateRepository.Delete(ctx, id, version)
+}
+
+// Deletes multiple exchange rates by their IDs.
+//
+// The method takes a context and a slice of uints as parameters. It delegates
+// the deletion to the exchangeRateRepository, which deletes the exchange rates
+// that exist and are not referenced anymore in a single transaction. It returns
+// the outcome for each id, or an error if the deletion process fails.
+func (s *exchangeRateService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
+	return s.exchangeRateRepository.DeleteAll(ctx, ids)
+}
+
+// Imports multiple exchange rates in a single transaction.
+//
+// Every rate is validated before anything is imported, and a rate for an
+// existing currency pair and date replaces the stored one. The method returns
+// money.ErrInvalidCurrency or money.ErrInvalidRate for invalid rates, or an
+// error if the import fails.
+func (s *exchangeRateService) Import(ctx *gin.Context, exchangeRates []*entities.ExchangeRate) error {
+	for _, exchangeRate := range exchangeRates {
+		if err := normalizeExchangeRate(exchangeRate); err != nil {
+			return err
+		}
+	}
+	return s.exchangeRateRepository.Import(ctx, exchangeRates)
+}
+
+// Imports exchange rates from CSV.
+//
+// The CSV must have a header row followed by rows with the base currency, the
+// quote currency, the rate as a decimal and the effective date as YYYY-MM-DD:
+//
+//	base_currency,quote_currency,rate,effective_date
+//	USD,BRL,5.4321,2024-01-02
+//
+// The rows are imported with the Import method, so either every row or none is
+// imported. The method returns the number of imported rates, or an error that
+// mentions the line of the first invalid row.
+func (s *exchangeRateService) ImportCSV(ctx *gin.Context, reader io.Reader) (int, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 4
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var exchangeRates []*entities.ExchangeRate
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "base_currency") {
+			continue
+		}
+
+		rate, err := money.ParseRate(record[2])
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		effectiveDate, err := time.Parse(exchangeRateDateLayout, strings.TrimSpace(record[3]))
+		if err != nil {
+			return 0, fmt.Errorf("line %d: invalid effective date: %w", i+1, err)
+		}
+
+		exchangeRate := &entities.ExchangeRate{
+			BaseCurrency:  record[0],
+			QuoteCurrency: record[1],
+			Rate:          rate,
+			EffectiveDate: effectiveDate,
+		}
+		if err := normalizeExchangeRate(exchangeRate); err != nil {
+			return 0, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		exchangeRates = append(exchangeRates, exchangeRate)
+	}
+
+	if err := s.exchangeRateRepository.Import(ctx, exchangeRates); err != nil {
+		return 0, err
+	}
+	return len(exchangeRates), nil
+}
+
+// normalizeExchangeRate validates and upper-cases the currencies of a rate and
+// checks the rate is positive.
+func normalizeExchangeRate(exchangeRate *entities.ExchangeRate) error {
+	base, err := money.ParseCurrency(exchangeRate.BaseCurrency)
+	if err != nil {
+		return err
+	}
+	quote, err := money.ParseCurrency(exchangeRate.QuoteCurrency)
+	if err != nil {
+		return err
+	}
+	if exchangeRate.Rate <= 0 {
+		return money.ErrInvalidRate
+	}
+
+	exchangeRate.BaseCurrency = base
+	exchangeRate.QuoteCurrency = quote
+	return nil
+}
+
+// effectiveExchangeRate returns the snapshot of the rate from base to quote
+// effective on the given date. If only the opposite pair is stored, its
+// inverse is used. It returns ErrMissingExchangeRate if neither exists.
+func effectiveExchangeRate(ctx *gin.Context, exchangeRateRepository repositories.ExchangeRateRepository, base, quote string, date time.Time) (*entities.OrderExchangeRate, error) {
+	exchangeRate, err := exchangeRateRepository.GetEffective(ctx, base, quote, date)
+	if err == nil {
+		return &entities.OrderExchangeRate{
+			BaseCurrency:  base,
+			QuoteCurrency: quote,
+			Rate:          exchangeRate.Rate,
+			EffectiveDate: exchangeRate.EffectiveDate,
+		}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	inverse, err := exchangeRateRepository.GetEffective(ctx, quote, base, date)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s to %s on %s", ErrMissingExchangeRate, base, quote, date.Format(exchangeRateDateLayout))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entities.OrderExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          inverse.Rate.Inverse(),
+		EffectiveDate: inverse.EffectiveDate,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/money"
+	"store/utils"
+)
+
+// ErrNoOffers is returned when a line is added for a product that no supplier
+// offers.
+var ErrNoOffers = apperrors.Invalid("no_offers", "the product is not offered by any supplier")
+
+// OfferStrategy is how the offers of the suppliers of a product are ranked
+// when an order line is added for the product instead of a product supplier.
+type OfferStrategy string
+
+const (
+	OfferStrategyCheapest  OfferStrategy = "cheapest"  // lowest value first
+	OfferStrategyMargin    OfferStrategy = "margin"    // highest value minus cost first
+	OfferStrategyStock     OfferStrategy = "stock"     // most units available first
+	OfferStrategyPreferred OfferStrategy = "preferred" // offers of the preferred supplier first, then the cheapest
+)
+
+// OfferStrategyFromEnv returns the OfferStrategy read from the OFFER_STRATEGY
+// environment variable, OfferStrategyCheapest by default or for an unknown
+// strategy. The preferred strategy needs a supplier and is only chosen per
+// request.
+func OfferStrategyFromEnv() OfferStrategy {
+	switch strategy := OfferStrategy(utils.GetEnv("OFFER_STRATEGY", string(OfferStrategyCheapest))); strategy {
+	case OfferStrategyMargin, OfferStrategyStock:
+		return strategy
+	default:
+		return OfferStrategyCheapest
+	}
+}
+
+// OfferRequest is an order line asked for a product, to be fulfilled from the
+// offers chosen by a strategy.
+type OfferRequest struct {
+	ProductID           uint          // product ordered
+	Quantity            int           // quantity ordered
+	Strategy            OfferStrategy // strategy ranking the offers, the default one when empty
+	PreferredSupplierID uint          // supplier whose offers come first with OfferStrategyPreferred
+	Split               bool          // whether the quantity may be split across several offers
+}
+
+// OfferChoice is an offer chosen to fulfill part or all of an order line
+// asked for a product, and why it was chosen.
+type OfferChoice struct {
+	ProductSupplierID uint   `json:"product_supplier_id"` // offer chosen
+	SupplierID        uint   `json:"supplier_id"`         // supplier of the offer
+	Quantity          int    `json:"quantity"`            // quantity taken from the offer
+	Rank              int    `json:"rank"`                // rank of the offer for the strategy, 1 for the best one
+	Reason            string `json:"reason"`              // why the offer was chosen
+}
+
+// OfferSelection is the result of adding an order line for a product: the
+// lines created and the offers they were taken from.
+type OfferSelection struct {
+	Strategy OfferStrategy                    `json:"strategy"` // strategy the offers were ranked with
+	Lines    []*entities.OrderProductSupplier `json:"lines"`    // lines created, one per offer chosen
+	Choices  []OfferChoice                    `json:"choices"`  // offers chosen, in the order of the lines
+}
+
+// rankedOffer is an offer of a product with its value and cost converted into
+// the currency of the order.
+type rankedOffer struct {
+	productSupplier *entities.ProductSupplier
+	value           money.Money // unit value in the currency of the order
+	margin          money.Money // unit value minus unit cost in the currency of the order
+}
+
+// rankOffers sorts the offers from the best to the worst one for the strategy.
+// Ties are broken by the lowest value, then by the most units available, then
+// by the oldest offer.
+func rankOffers(offers []rankedOffer, strategy OfferStrategy, preferredSupplierID uint) {
+	slices.SortStableFunc(offers, func(a, b rankedOffer) int {
+		var order int
+		switch strategy {
+		case OfferStrategyMargin:
+			order = cmp.Compare(b.margin.Amount, a.margin.Amount)
+		case OfferStrategyStock:
+			order = cmp.Compare(b.productSupplier.Sellable(), a.productSupplier.Sellable())
+		case OfferStrategyPreferred:
+			order = cmp.Compare(preferenceOf(b, preferredSupplierID), preferenceOf(a, preferredSupplierID))
+		}
+		return cmp.Or(
+			order,
+			cmp.Compare(a.value.Amount, b.value.Amount),
+			cmp.Compare(b.productSupplier.Sellable(), a.productSupplier.Sellable()),
+			cmp.Compare(a.productSupplier.ID, b.productSupplier.ID),
+		)
+	})
+}
+
+// preferenceOf returns 1 for an offer of the preferred supplier and 0 for the
+// other ones.
+func preferenceOf(offer rankedOffer, preferredSupplierID uint) int {
+	if offer.productSupplier.SupplierID == preferredSupplierID {
+		return 1
+	}
+	return 0
+}
+
+// chooseOffers takes the quantity from the ranked offers. Without split, the
+// best offer with enough units available for the whole quantity is chosen.
+// With split, the units available of the offers are taken from the best one
+// down until the quantity is covered. The units of the expired lots of an offer
+// are not counted as available. It returns false if the offers cannot cover
+// the quantity.
+func chooseOffers(offers []rankedOffer, request OfferRequest) ([]OfferChoice, bool) {
+	var choices []OfferChoice
+	remaining := request.Quantity
+	for i, offer := range offers {
+		available := offer.productSupplier.Sellable()
+		if available <= 0 || (!request.Split && available < remaining) {
+			continue
+		}
+		taken := min(available, remaining)
+		choices = append(choices, OfferChoice{
+			ProductSupplierID: offer.productSupplier.ID,
+			SupplierID:        offer.productSupplier.SupplierID,
+			Quantity:          taken,
+			Rank:              i + 1,
+			Reason:            offerReason(offer, i+1, request, taken < request.Quantity),
+		})
+		remaining -= taken
+		if remaining == 0 {
+			return choices, true
+		}
+	}
+	return nil, false
+}
+
+// offerReason explains why an offer of the given rank was chosen.
+func offerReason(offer rankedOffer, rank int, request OfferRequest, split bool) string {
+	var reason string
+	switch {
+	case request.Strategy == OfferStrategyMargin:
+		reason = fmt.Sprintf("highest margin, %s %s per unit", offer.margin, offer.margin.Currency)
+	case request.Strategy == OfferStrategyStock:
+		reason = fmt.Sprintf("most units available, %d", offer.productSupplier.Sellable())
+	case request.Strategy == OfferStrategyPreferred && offer.productSupplier.SupplierID == request.PreferredSupplierID:
+		reason = "offer of the preferred supplier"
+	default:
+		reason = fmt.Sprintf("lowest value, %s %s per unit", offer.value, offer.value.Currency)
+	}
+
+	switch {
+	case rank > 1 && split:
+		reason += "; the better offers only cover part of the quantity"
+	case rank > 1:
+		reason += "; the better offers have not enough units available"
+	case split:
+		reason += "; the offer only covers part of the quantity"
+	}
+	return reason
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"fmt"
+	"store/utils"
+	"strings"
+)
+
+// OrderNumberPattern describes how the order numbers are generated, e.g. the
+// default pattern generates "UK-2024-000123-8": the prefix, the year, the
+// sequence of the order in the year zero padded and a check digit.
+type OrderNumberPattern struct {
+	Prefix     string // text before the year, omitted when empty
+	Width      int    // minimum number of digits of the sequence, zero padded
+	CheckDigit bool   // whether a Luhn check digit of the year and sequence is appended
+}
+
+// OrderNumberPatternFromEnv returns the order number pattern configured by the
+// ORDER_NUMBER_PREFIX, ORDER_NUMBER_WIDTH and ORDER_NUMBER_CHECK_DIGIT
+// environment variables, defaulting to "UK", 6 and true.
+func OrderNumberPatternFromEnv() OrderNumberPattern {
+	return OrderNumberPattern{
+		Prefix:     utils.GetEnv("ORDER_NUMBER_PREFIX", "UK"),
+		Width:      utils.GetEnvInt("ORDER_NUMBER_WIDTH", 6),
+		CheckDigit: utils.GetEnvBool("ORDER_NUMBER_CHECK_DIGIT", true),
+	}
+}
+
+// Format returns the order number of the given sequence number in the year.
+func (p OrderNumberPattern) Format(year int, sequence int64) string {
+	digits := fmt.Sprintf("%04d%0*d", year, p.Width, sequence)
+
+	parts := make([]string, 0, 4)
+	if p.Prefix != "" {
+		parts = append(parts, p.Prefix)
+	}
+	parts = append(parts, digits[:4], digits[4:])
+	if p.CheckDigit {
+		parts = append(parts, fmt.Sprint(luhnCheckDigit(digits)))
+	}
+	return strings.Join(parts, "-")
+}
+
+// luhnCheckDigit returns the Luhn check digit of a string of decimal digits.
+func luhnCheckDigit(digits string) int {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
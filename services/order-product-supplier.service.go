@@ -1,12 +1,21 @@
 package services
 
 import (
+	"fmt"
+	"store/domain/apperrors"
 	"store/domain/entities"
+	"store/domain/money"
+	"store/domain/query"
 	"store/domain/repositories"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ErrOrderNotDraft is returned when the lines of an order that is no longer a
+// draft are changed. Lines of placed orders are fixed, as their stock has
+// already been consumed and their totals are final.
+var ErrOrderNotDraft = apperrors.Conflict("order_not_draft", "order lines can only be changed while the order is a draft")
+
 // OrderProductSupplierService is an interface that defines the methods that must
 // be implemented by any service that wants to interact with the order_product_suppliers
 // table in the database.
@@ -15,37 +24,77 @@ import (
 // getting all orderProductSuppliers, updating a orderProductSupplier, deleting a orderProductSupplier,
 // and deleting multiple orderProductSuppliers.
 type OrderProductSupplierService interface {
-	Create(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error // Create a new orderProductSupplier
-	GetByID(ctx *gin.Context, id uint) (*entities.OrderProductSupplier, error)          // Get a orderProductSupplier by ID
-	GetAll(ctx *gin.Context) ([]*entities.OrderProductSupplier, error)                  // Get all orderProductSuppliers
-	Update(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error // Update a orderProductSupplier
-	Delete(ctx *gin.Context, id uint) error                                             // Delete a orderProductSupplier
-	DeleteAll(ctx *gin.Context, ids []uint) error                                       // Delete a orderProductSupplier
+	Create(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error                                      // Create a new orderProductSupplier
+	GetByID(ctx *gin.Context, id uint) (*entities.OrderProductSupplier, error)                                               // Get a orderProductSupplier by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.OrderProductSupplier], error)                        // Get all orderProductSuppliers
+	GetAllByOrderID(ctx *gin.Context, orderID uint, q *query.ListQuery) (*query.Page[*entities.OrderProductSupplier], error) // Get the orderProductSuppliers of an order
+	Update(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier, fields ...string) error                    // Update a orderProductSupplier
+	Delete(ctx *gin.Context, id uint, version uint) error                                                                    // Delete a orderProductSupplier
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)                                             // Delete a orderProductSupplier
+	AddBestOffer(ctx *gin.Context, orderID uint, request OfferRequest) (*OfferSelection, error)                              // Add lines for a product from the offers chosen by a strategy
+	TrashService[entities.OrderProductSupplier]                                                                              // Get, restore and purge deleted orderProductSuppliers
 }
 
 // orderProductSupplierService is a struct that implements the OrderProductSupplierService interface.
 // It contains a pointer to a OrderProductSupplierRepository and provides methods for creating a new orderProductSupplier,
 // getting a orderProductSupplier by its ID, getting all orderProductSuppliers, updating a orderProductSupplier,
 // deleting a orderProductSupplier, and deleting multiple orderProductSuppliers.
+//
+// The OrderRepository is used to check that the order of a line is still a
+// draft, the ProductSupplierRepository to price lines without a value and find
+// the offers of a product, and the ExchangeRateRepository to compare offers in
+// different currencies. Offers are ranked with the default strategy unless a
+// line asks for another one.
 type orderProductSupplierService struct {
 	orderProductSupplierRepository repositories.OrderProductSupplierRepository
+	orderRepository                repositories.OrderRepository
+	productSupplierRepository      repositories.ProductSupplierRepository
+	exchangeRateRepository         repositories.ExchangeRateRepository
+	offerStrategy                  OfferStrategy
+	TrashService[entities.OrderProductSupplier]
 }
 
 // NewOrderProductSupplierService creates a new OrderProductSupplierService with the given
-// OrderProductSupplierRepository. The OrderProductSupplierService is an interface that defines
-// methods for creating, retrieving, updating, and deleting orderProductSuppliers in the
-// application. It returns an instance of orderProductSupplierService that implements the
+// OrderProductSupplierRepository, the OrderRepository and ProductSupplierRepository
+// used to validate and price the lines, and the ExchangeRateRepository and default
+// OfferStrategy used to choose the offers of a product. The OrderProductSupplierService is an interface
+// that defines methods for creating, retrieving, updating, and deleting orderProductSuppliers
+// in the application. It returns an instance of orderProductSupplierService that implements the
 // OrderProductSupplierService interface.
-func NewOrderProductSupplierService(orderProductSupplierRepository repositories.OrderProductSupplierRepository) OrderProductSupplierService {
-	return &orderProductSupplierService{orderProductSupplierRepository: orderProductSupplierRepository}
+func NewOrderProductSupplierService(
+	orderProductSupplierRepository repositories.OrderProductSupplierRepository,
+	orderRepository repositories.OrderRepository,
+	productSupplierRepository repositories.ProductSupplierRepository,
+	exchangeRateRepository repositories.ExchangeRateRepository,
+	offerStrategy OfferStrategy,
+) OrderProductSupplierService {
+	return &orderProductSupplierService{
+		orderProductSupplierRepository: orderProductSupplierRepository,
+		orderRepository:                orderRepository,
+		productSupplierRepository:      productSupplierRepository,
+		exchangeRateRepository:         exchangeRateRepository,
+		offerStrategy:                  offerStrategy,
+		TrashService:                   orderProductSupplierRepository,
+	}
 }
 
 // Creates a new orderProductSupplier to the database.
 //
 // The method takes a context and an orderProductSupplier entity as parameters.
-// It delegates the creation of the orderProductSupplier to the repository and
-// returns an error if the creation process fails. If successful, it returns nil.
+// Lines can only be added to draft orders, so the stock they need is reserved
+// until the order is placed, and consumed then. A line without a value takes
+// the current value of its ProductSupplier, and the exchange rates of its
+// currencies are snapshotted on the order, as described in snapshotRates. It
+// returns ErrOrderNotDraft if the
+// order is no longer a draft, repositories.ErrInvalidQuantity if the quantity
+// is not positive, or an error if the order or the ProductSupplier do not exist
+// or the creation process fails, or ErrMissingExchangeRate if its amounts
+// cannot be converted into the currency of the order. If successful, it
+// returns nil.
 func (s *orderProductSupplierService) Create(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error {
+	if err := s.validate(ctx, orderProductSupplier); err != nil {
+		return err
+	}
 	return s.orderProductSupplierRepository.Create(ctx, orderProductSupplier)
 }
 
@@ -59,39 +108,248 @@ func (s *orderProductSupplierService) GetByID(ctx *gin.Context, id uint) (*entit
 	return s.orderProductSupplierRepository.GetByID(ctx, id)
 }
 
-// Retrieves all orderProductSuppliers from the database.
+// Retrieves a page of order product suppliers.
 //
-// The method takes a context as a parameter. It delegates the retrieval of the
-// orderProductSuppliers to the repository and returns an error if the retrieval
-// process fails. If successful, it returns a slice of orderProductSuppliers and
-// nil.
-func (s *orderProductSupplierService) GetAll(ctx *gin.Context) ([]*entities.OrderProductSupplier, error) {
-	return s.orderProductSupplierRepository.GetAll(ctx)
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request. It delegates the retrieval to the
+// orderProductSupplierRepository, which applies the pagination, sorting and
+// filters of the query, and returns the page of order product suppliers or an
+// error if the retrieval fails.
+func (s *orderProductSupplierService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.OrderProductSupplier], error) {
+	return s.orderProductSupplierRepository.GetAll(ctx, q)
+}
+
+// Retrieves a page of the lines of an order.
+//
+// The method takes a context, the ID of the order and the list query parsed
+// from the request. It delegates the retrieval to the repository and returns
+// an error if the retrieval process fails.
+func (s *orderProductSupplierService) GetAllByOrderID(ctx *gin.Context, orderID uint, q *query.ListQuery) (*query.Page[*entities.OrderProductSupplier], error) {
+	return s.orderProductSupplierRepository.GetAllByOrderID(ctx, orderID, q)
 }
 
 // Updates an orderProductSupplier in the database.
 //
 // The method takes a context and an orderProductSupplier entity as parameters.
-// It delegates the update of the orderProductSupplier to the repository and
-// returns an error if the update process fails. If successful, it returns nil.
-func (s *orderProductSupplierService) Update(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier) error {
-	return s.orderProductSupplierRepository.Update(ctx, orderProductSupplier)
+// The line keeps the order it belongs to, which must still be a draft. It
+// returns the same errors as Create, or an error if the line does not exist or
+// the update process fails. If successful, it returns nil.
+//
+// When fields are given, only those fields of the orderProductSupplier, named
+// as in its struct, are saved, as done for partial updates.
+func (s *orderProductSupplierService) Update(ctx *gin.Context, orderProductSupplier *entities.OrderProductSupplier, fields ...string) error {
+	stored, err := s.orderProductSupplierRepository.GetByID(ctx, orderProductSupplier.ID)
+	if err != nil {
+		return err
+	}
+	orderProductSupplier.OrderID = stored.OrderID
+	orderProductSupplier.CreatedAt = stored.CreatedAt
+
+	if err := s.validate(ctx, orderProductSupplier); err != nil {
+		return err
+	}
+	return s.orderProductSupplierRepository.Update(ctx, orderProductSupplier, fields...)
 }
 
 // Deletes an orderProductSupplier by its ID from the database.
 //
 // The method takes a context and the ID of the orderProductSupplier as parameters.
-// It delegates the deletion of the orderProductSupplier to the repository and
-// returns an error if the deletion process fails. If successful, it returns nil.
-func (s *orderProductSupplierService) Delete(ctx *gin.Context, id uint) error {
-	return s.orderProductSupplierRepository.Delete(ctx, id)
+// It returns ErrOrderNotDraft if the order of the line is no longer a draft, or
+// an error if the line does not exist or the deletion process fails. If
+// successful, it returns nil.
+//
+// The orderProductSupplier is only deleted if it is still at the given version,
+// otherwise repositories.ErrVersionConflict is returned.
+func (s *orderProductSupplierService) Delete(ctx *gin.Context, id uint, version uint) error {
+	stored, err := s.orderProductSupplierRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkDraft(ctx, stored.OrderID); err != nil {
+		return err
+	}
+	return s.orderProductSupplierRepository.Delete(ctx, id, version)
 }
 
 // Deletes multiple orderProductSuppliers by their IDs from the database.
 //
-// The method takes a context and a slice of uints as parameters.
-// It delegates the deletion of the orderProductSuppliers to the repository and
-// returns an error if the deletion process fails. If successful, it returns nil.
-func (s *orderProductSupplierService) DeleteAll(ctx *gin.Context, ids []uint) error {
+// The method takes a context and a slice of uints as parameters. It delegates
+// the deletion to the orderProductSupplierRepository, which deletes the lines
+// that exist and belong to draft orders in a single transaction. It returns the
+// outcome for each id, or an error if the deletion process fails.
+func (s *orderProductSupplierService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
 	return s.orderProductSupplierRepository.DeleteAll(ctx, ids)
 }
+
+// Adds lines for a product to a draft order, from the offers of its suppliers
+// chosen by a strategy.
+//
+// The offers of the product are ranked by the strategy of the request, or the
+// default one, comparing their value and margin in the currency of the order
+// at the exchange rates effective on its order date, as described in
+// rankOffers. The whole quantity is taken from the best offer with enough
+// units available, or split across the best offers when the request allows
+// it, as described in chooseOffers. One line is created per offer chosen, at
+// the value of the offer, in a single transaction, and the exchange rates of
+// their currencies are snapshotted on the order, as described in
+// snapshotRates.
+//
+// It returns ErrOrderNotDraft if the order is no longer a draft, ErrNoOffers if
+// the product is not offered, ErrMissingExchangeRate if an offer cannot be
+// converted into the currency of the order, and
+// repositories.ErrInsufficientStock if the offers cannot cover the quantity.
+// On success it returns the lines created and the offers chosen, with why.
+func (s *orderProductSupplierService) AddBestOffer(ctx *gin.Context, orderID uint, request OfferRequest) (*OfferSelection, error) {
+	if request.Strategy == "" {
+		request.Strategy = s.offerStrategy
+	}
+	if request.Quantity <= 0 {
+		return nil, repositories.ErrInvalidQuantity
+	}
+	order, err := s.orderRepository.GetOrderWithOrderProducts(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("order %d: %w", orderID, err)
+	}
+	if order.Status != entities.OrderStatusDraft {
+		return nil, ErrOrderNotDraft
+	}
+
+	productSuppliers, err := s.productSupplierRepository.GetOffers(ctx, request.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if len(productSuppliers) == 0 {
+		return nil, ErrNoOffers.With("product_id", request.ProductID)
+	}
+
+	offers := make([]rankedOffer, len(productSuppliers))
+	rates := map[string]money.Rate{}
+	for i, productSupplier := range productSuppliers {
+		value, err := s.convertOffer(ctx, order, productSupplier.Value, rates)
+		if err != nil {
+			return nil, err
+		}
+		cost, err := s.convertOffer(ctx, order, productSupplier.Cost, rates)
+		if err != nil {
+			return nil, err
+		}
+		offers[i] = rankedOffer{productSupplier: productSupplier, value: value, margin: value.Sub(cost)}
+	}
+	rankOffers(offers, request.Strategy, request.PreferredSupplierID)
+
+	choices, ok := chooseOffers(offers, request)
+	if !ok {
+		return nil, repositories.ErrInsufficientStock.With("product_id", request.ProductID)
+	}
+
+	selection := &OfferSelection{Strategy: request.Strategy, Choices: choices}
+	for _, choice := range choices {
+		for _, offer := range offers {
+			if offer.productSupplier.ID == choice.ProductSupplierID {
+				selection.Lines = append(selection.Lines, &entities.OrderProductSupplier{
+					OrderID:           orderID,
+					ProductSupplierID: choice.ProductSupplierID,
+					Quantity:          choice.Quantity,
+					Value:             offer.productSupplier.Value,
+				})
+			}
+		}
+	}
+	if err := s.snapshotRates(ctx, order, selection.Lines...); err != nil {
+		return nil, err
+	}
+	if err := s.orderProductSupplierRepository.CreateAll(ctx, selection.Lines); err != nil {
+		return nil, err
+	}
+	return selection, nil
+}
+
+// convertOffer converts an amount of an offer into the currency of the order,
+// at the exchange rate effective on its order date, caching the rates found by
+// currency.
+func (s *orderProductSupplierService) convertOffer(ctx *gin.Context, order *entities.Order, amount money.Money, rates map[string]money.Rate) (money.Money, error) {
+	amount = money.New(amount.Amount, amount.Currency)
+	currency := money.New(0, order.Currency).Currency
+	if amount.Currency == currency {
+		return amount, nil
+	}
+	rate, ok := rates[amount.Currency]
+	if !ok {
+		exchangeRate, err := effectiveExchangeRate(ctx, s.exchangeRateRepository, amount.Currency, currency, order.OrderDate)
+		if err != nil {
+			return money.Money{}, err
+		}
+		rate = exchangeRate.Rate
+		rates[amount.Currency] = rate
+	}
+	return amount.Convert(currency, rate), nil
+}
+
+// validate checks that the order of the line is a draft and that its quantity
+// is positive, fills the value of a line without one with the value of its
+// ProductSupplier and snapshots the exchange rates of its currencies on the
+// order.
+func (s *orderProductSupplierService) validate(ctx *gin.Context, line *entities.OrderProductSupplier) error {
+	order, err := s.orderRepository.GetOrderWithOrderProducts(ctx, line.OrderID)
+	if err != nil {
+		return fmt.Errorf("order %d: %w", line.OrderID, err)
+	}
+	if order.Status != entities.OrderStatusDraft {
+		return ErrOrderNotDraft
+	}
+	if line.Quantity <= 0 {
+		return fmt.Errorf("product supplier %d: %w", line.ProductSupplierID, repositories.ErrInvalidQuantity)
+	}
+	if line.Value.IsZero() {
+		productSupplier, err := s.productSupplierRepository.GetByID(ctx, line.ProductSupplierID)
+		if err != nil {
+			return fmt.Errorf("product supplier %d: %w", line.ProductSupplierID, err)
+		}
+		line.Value = productSupplier.Value
+	}
+	return s.snapshotRates(ctx, order, line)
+}
+
+// snapshotRates stores on the order the exchange rates effective on its order
+// date for the currencies of the amounts of the lines it has no rate for yet,
+// as done for the lines given when the order is created, so its totals can
+// still be computed once the lines are added. It returns
+// ErrMissingExchangeRate if a currency has no rate into the order currency.
+func (s *orderProductSupplierService) snapshotRates(ctx *gin.Context, order *entities.Order, lines ...*entities.OrderProductSupplier) error {
+	currency := money.New(0, order.Currency).Currency
+	snapshotted := map[string]bool{currency: true}
+	for _, exchangeRate := range order.ExchangeRates {
+		snapshotted[exchangeRate.BaseCurrency] = true
+	}
+
+	var missing []entities.OrderExchangeRate
+	for _, line := range lines {
+		for _, amount := range []money.Money{line.Value, line.Discount} {
+			amount = money.New(amount.Amount, amount.Currency)
+			if amount.IsZero() || snapshotted[amount.Currency] {
+				continue
+			}
+			exchangeRate, err := effectiveExchangeRate(ctx, s.exchangeRateRepository, amount.Currency, currency, order.OrderDate)
+			if err != nil {
+				return err
+			}
+			exchangeRate.OrderID = order.ID
+			missing = append(missing, *exchangeRate)
+			snapshotted[amount.Currency] = true
+		}
+	}
+	return s.orderRepository.AddExchangeRates(ctx, missing)
+}
+
+// checkDraft returns ErrOrderNotDraft unless the order with the given ID is a
+// draft.
+func (s *orderProductSupplierService) checkDraft(ctx *gin.Context, orderID uint) error {
+	order, err := s.orderRepository.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("order %d: %w", orderID, err)
+	}
+	if order.Status != entities.OrderStatusDraft {
+		return ErrOrderNotDraft
+	}
+	return nil
+}
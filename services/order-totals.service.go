@@ -0,0 +1,149 @@
+package services
+
+import (
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/money"
+)
+
+var (
+	// ErrInvalidDiscount is returned when a discount is negative or a percentage
+	// is above 100.
+	ErrInvalidDiscount = apperrors.BadRequest("invalid_discount", "invalid discount")
+	// ErrCurrencyMismatch is returned when an amount of an order is in a currency
+	// that has no exchange rate snapshot into the currency of the order.
+	ErrCurrencyMismatch = apperrors.Invalid("currency_mismatch", "order amount has no exchange rate into the order currency")
+)
+
+// OrderLineTotals holds the computed amounts of a single order line.
+type OrderLineTotals struct {
+	OrderProductSupplierID uint        `json:"order_product_supplier_id"` // id of the order line
+	Quantity               int         `json:"quantity"`                  // quantity of the order line
+	OriginalUnitValue      money.Money `json:"original_unit_value"`       // value of a single unit in the currency of the offer
+	UnitValue              money.Money `json:"unit_value"`                // value of a single unit in the currency of the order
+	Gross                  money.Money `json:"gross"`                     // unit value times quantity
+	Discount               money.Money `json:"discount"`                  // discount applied to the line
+	Total                  money.Money `json:"total"`                     // gross minus discount
+}
+
+// OrderTotals holds the computed amounts of an order.
+type OrderTotals struct {
+	Lines          []OrderLineTotals `json:"lines"`           // totals of every order line
+	Gross          money.Money       `json:"gross"`           // sum of the line gross amounts
+	LineDiscounts  money.Money       `json:"line_discounts"`  // sum of the line discounts
+	Subtotal       money.Money       `json:"subtotal"`        // sum of the line totals
+	HeaderDiscount money.Money       `json:"header_discount"` // order discount applied to the subtotal
+	TotalDiscount  money.Money       `json:"total_discount"`  // line discounts plus header discount
+	GrandTotal     money.Money       `json:"grand_total"`     // subtotal minus header discount
+}
+
+// OrderDetails is an order together with its computed totals.
+type OrderDetails struct {
+	*entities.Order
+	Totals OrderTotals `json:"totals"`
+}
+
+// ComputeOrderTotals computes the line totals, subtotal, discounts and grand
+// total of an order with its OrderProducts loaded.
+//
+// Amounts are computed in minor units of the currency of the order. Amounts in
+// any other currency are first converted with the exchange rates snapshotted
+// on the order, rounding to the minor unit with banker's rounding. The rules
+// are:
+//
+// - the gross amount of a line is its value times its quantity;
+//
+// - the line discounts are applied first: the percentage discount of the
+// gross amount, then the absolute discount;
+//
+// - the order discounts are applied to the subtotal, the sum of the line
+// totals, in the same order: percentage first, then absolute;
+//
+// - percentages are rounded to the minor unit with banker's rounding, and
+// discounts never exceed the amount they are applied to, so totals are never
+// negative.
+//
+// The function returns ErrInvalidDiscount if any discount is invalid and
+// ErrCurrencyMismatch if an amount has no exchange rate snapshot into the
+// currency of the order.
+func ComputeOrderTotals(order *entities.Order) (OrderTotals, error) {
+	currency := money.New(0, order.Currency).Currency
+	rates := make(map[string]money.Rate, len(order.ExchangeRates))
+	for _, exchangeRate := range order.ExchangeRates {
+		rates[exchangeRate.BaseCurrency] = exchangeRate.Rate
+	}
+	convert := func(m money.Money) (money.Money, error) {
+		m = money.New(m.Amount, m.Currency)
+		if m.Currency == currency || m.IsZero() {
+			return money.New(m.Amount, currency), nil
+		}
+		rate, ok := rates[m.Currency]
+		if !ok {
+			return money.Money{}, ErrCurrencyMismatch
+		}
+		return m.Convert(currency, rate), nil
+	}
+
+	totals := OrderTotals{
+		Lines:         make([]OrderLineTotals, 0, len(order.OrderProducts)),
+		Gross:         money.Zero(currency),
+		LineDiscounts: money.Zero(currency),
+		Subtotal:      money.Zero(currency),
+	}
+
+	for _, line := range order.OrderProducts {
+		unitValue, err := convert(line.Value)
+		if err != nil {
+			return OrderTotals{}, err
+		}
+		lineDiscount, err := convert(line.Discount)
+		if err != nil {
+			return OrderTotals{}, err
+		}
+		gross := unitValue.Mul(int64(line.Quantity))
+		discount, err := applyDiscount(gross, line.DiscountPercentage, lineDiscount)
+		if err != nil {
+			return OrderTotals{}, err
+		}
+
+		lineTotals := OrderLineTotals{
+			OrderProductSupplierID: line.ID,
+			Quantity:               line.Quantity,
+			OriginalUnitValue:      money.New(line.Value.Amount, line.Value.Currency),
+			UnitValue:              unitValue,
+			Gross:                  gross,
+			Discount:               discount,
+			Total:                  gross.Sub(discount),
+		}
+		totals.Lines = append(totals.Lines, lineTotals)
+		totals.Gross = totals.Gross.Add(lineTotals.Gross)
+		totals.LineDiscounts = totals.LineDiscounts.Add(lineTotals.Discount)
+		totals.Subtotal = totals.Subtotal.Add(lineTotals.Total)
+	}
+
+	orderDiscount, err := convert(order.Discount)
+	if err != nil {
+		return OrderTotals{}, err
+	}
+	headerDiscount, err := applyDiscount(totals.Subtotal, order.DiscountPercentage, orderDiscount)
+	if err != nil {
+		return OrderTotals{}, err
+	}
+	totals.HeaderDiscount = headerDiscount
+	totals.TotalDiscount = totals.LineDiscounts.Add(headerDiscount)
+	totals.GrandTotal = totals.Subtotal.Sub(headerDiscount)
+
+	return totals, nil
+}
+
+// applyDiscount returns the discount to subtract from base: the percentage of
+// base followed by the absolute amount, capped at base. The absolute amount
+// must already be in the currency of base.
+func applyDiscount(base money.Money, percentage money.Percentage, amount money.Money) (money.Money, error) {
+	if percentage < 0 || percentage > money.OneHundredPercent || amount.IsNegative() {
+		return money.Money{}, ErrInvalidDiscount
+	}
+
+	discount := base.Percent(percentage).Add(amount)
+	return discount.Min(base), nil
+}
@@ -1,51 +1,241 @@
 package services
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"store/domain/apperrors"
 	"store/domain/entities"
+	"store/domain/money"
+	"store/domain/postalcode"
+	"store/domain/query"
 	"store/domain/repositories"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrUnknownTransition is returned when a transition name is not part of the
+	// order lifecycle.
+	ErrUnknownTransition = apperrors.BadRequest("unknown_transition", "unknown order transition")
+	// ErrInvalidInitialStatus is returned when an order is created in a status
+	// other than draft or placed.
+	ErrInvalidInitialStatus = apperrors.BadRequest("invalid_initial_status", "orders can only be created as draft or placed")
+	// ErrInvalidTransition is the conflict an InvalidTransitionError is reported
+	// as.
+	ErrInvalidTransition = apperrors.Conflict("invalid_transition", "transition not allowed from the current status of the order")
+	// ErrInvalidContact is returned when the shipping or billing contact chosen
+	// for an order is not a contact of its customer.
+	ErrInvalidContact = apperrors.Invalid("invalid_contact", "the contact is not a contact of the customer of the order")
+)
+
+// InvalidTransitionError is returned when a transition is not allowed from the
+// current status of an order.
+type InvalidTransitionError struct {
+	Transition string               // name of the requested transition
+	Current    entities.OrderStatus // status the order is in
+	Requested  entities.OrderStatus // status the transition leads to
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot %s an order in status %q: transition to %q is not allowed", e.Transition, e.Current, e.Requested)
+}
+
+// Unwrap returns ErrInvalidTransition with the current and requested status of
+// the order, reported as the `current_status` and `requested_status` members of
+// the problem details.
+func (e *InvalidTransitionError) Unwrap() error {
+	return ErrInvalidTransition.With("current_status", e.Current).With("requested_status", e.Requested)
+}
+
+// orderTransition describes a named transition of the order lifecycle, the
+// status it leads to and the statuses it can be applied from.
+type orderTransition struct {
+	to   entities.OrderStatus
+	from []entities.OrderStatus
+}
+
+// orderTransitions is the state machine of the order lifecycle, keyed by the
+// transition name used in the `POST /orders/:id/transitions/:transition` route.
+var orderTransitions = map[string]orderTransition{
+	"place": {to: entities.OrderStatusPlaced, from: []entities.OrderStatus{
+		entities.OrderStatusDraft,
+	}},
+	"pay": {to: entities.OrderStatusPaid, from: []entities.OrderStatus{
+		entities.OrderStatusPlaced,
+	}},
+	"pick": {to: entities.OrderStatusPicking, from: []entities.OrderStatus{
+		entities.OrderStatusPaid,
+	}},
+	"ship": {to: entities.OrderStatusShipped, from: []entities.OrderStatus{
+		entities.OrderStatusPicking,
+	}},
+	"deliver": {to: entities.OrderStatusDelivered, from: []entities.OrderStatus{
+		entities.OrderStatusShipped,
+	}},
+	"cancel": {to: entities.OrderStatusCancelled, from: []entities.OrderStatus{
+		entities.OrderStatusDraft,
+		entities.OrderStatusPlaced,
+		entities.OrderStatusPaid,
+		entities.OrderStatusPicking,
+	}},
+	"return": {to: entities.OrderStatusReturned, from: []entities.OrderStatus{
+		entities.OrderStatusDelivered,
+	}},
+}
+
+// allows reports whether the transition can be applied from the given status.
+func (t orderTransition) allows(status entities.OrderStatus) bool {
+	for _, from := range t.from {
+		if from == status {
+			return true
+		}
+	}
+	return false
+}
+
+// stockEffect returns what moving an order from the given status to the
+// transition target does to the stock of its order lines. Placing a draft
+// consumes stock, while cancelling a placed order or returning a delivered
+// one gives the units back.
+func (t orderTransition) stockEffect(from entities.OrderStatus) repositories.StockEffect {
+	switch {
+	case t.to == entities.OrderStatusPlaced:
+		return repositories.StockConsumed
+	case t.to == entities.OrderStatusCancelled && from != entities.OrderStatusDraft:
+		return repositories.StockRestored
+	case t.to == entities.OrderStatusReturned:
+		return repositories.StockRestored
+	}
+	return repositories.StockUnchanged
+}
+
 // OrderService defines the methods that a service must implement to manage
 // orders in the application. It provides methods to create, retrieve, update,
 // and delete order entities.
 type OrderService interface {
-	Create(ctx *gin.Context, order *entities.Order) error       // Create a new order
-	GetByID(ctx *gin.Context, id uint) (*entities.Order, error) // Get an order by ID
-	GetAll(ctx *gin.Context) ([]*entities.Order, error)         // Get all orders
-	Update(ctx *gin.Context, order *entities.Order) error       // Update an order
-	Delete(ctx *gin.Context, id uint) error                     // Delete an order
-	DeleteAll(ctx *gin.Context, ids []uint) error               // Delete multiple orders
+	Create(ctx *gin.Context, order *entities.Order) error                                              // Create a new order
+	GetByID(ctx *gin.Context, id uint) (*entities.Order, error)                                        // Get an order by ID
+	GetDetails(ctx *gin.Context, id uint) (*OrderDetails, error)                                       // Get an order with its order products and totals
+	GetByOrderNumber(ctx *gin.Context, number string) (*OrderDetails, error)                           // Get an order with its totals by its order number
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Order], error)                 // Get all orders
+	Update(ctx *gin.Context, order *entities.Order, fields ...string) error                            // Update an order
+	Delete(ctx *gin.Context, id uint, version uint) error                                              // Delete an order
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)                       // Delete multiple orders
+	Transition(ctx *gin.Context, id uint, transition, changedBy, note string) (*entities.Order, error) // Move an order through its lifecycle
+	GetStatusHistory(ctx *gin.Context, id uint) ([]*entities.OrderStatusHistory, error)                // Get the status history of an order
+	TrashService[entities.Order]                                                                       // Get, restore and purge deleted orders
 }
 
 // orderService is a struct that contains a pointer to an OrderRepository
 // and implements the OrderService interface.
 // It is used to manage orders in the application.
 type orderService struct {
-	orderRepository repositories.OrderRepository
+	orderRepository           repositories.OrderRepository
+	customerRepository        repositories.CustomerRepository
+	contactRepository         repositories.ContactRepository
+	productSupplierRepository repositories.ProductSupplierRepository
+	exchangeRateRepository    repositories.ExchangeRateRepository
+	warehouseRepository       repositories.WarehouseRepository
+	orderNumberPattern        OrderNumberPattern
+	TrashService[entities.Order]
 }
 
-// NewOrderService creates a new OrderService with the given OrderRepository.
+// NewOrderService creates a new OrderService with the given OrderRepository,
+// the CustomerRepository, ProductSupplierRepository and ExchangeRateRepository
+// used to price orders in the billing currency of their customer, the
+// ContactRepository the shipping and billing addresses of the orders are taken
+// from, the WarehouseRepository the orders are fulfilled from, and the pattern
+// used to generate order numbers.
 // It returns an instance of orderService that implements the OrderService interface,
 // allowing for the management of orders in the application.
-func NewOrderService(orderRepository repositories.OrderRepository) OrderService {
-	return &orderService{orderRepository: orderRepository}
+func NewOrderService(
+	orderRepository repositories.OrderRepository,
+	customerRepository repositories.CustomerRepository,
+	contactRepository repositories.ContactRepository,
+	productSupplierRepository repositories.ProductSupplierRepository,
+	exchangeRateRepository repositories.ExchangeRateRepository,
+	warehouseRepository repositories.WarehouseRepository,
+	orderNumberPattern OrderNumberPattern,
+) OrderService {
+	return &orderService{
+		orderRepository:           orderRepository,
+		customerRepository:        customerRepository,
+		contactRepository:         contactRepository,
+		productSupplierRepository: productSupplierRepository,
+		exchangeRateRepository:    exchangeRateRepository,
+		warehouseRepository:       warehouseRepository,
+		orderNumberPattern:        orderNumberPattern,
+		TrashService:              orderRepository,
+	}
 }
 
-// Create a new order in the database.
+// Create places a new order in the database.
 //
 // The method takes a pointer to a *gin.Context and a pointer to an entities.Order
 // as parameters. It returns an error if something goes wrong.
 //
-// The method creates a new order in the database using the given order object.
-// The order object is passed as a pointer and the method is responsible for creating
-// a new order in the database with the given attributes.
+// Orders are created as placed unless the draft status is requested. Placed
+// orders go through the PlaceOrder method of the order repository, so the
+// order, its order products, the stock and the sales counters of every
+// referenced ProductSupplier are persisted in a single transaction. Drafts are
+// stored without touching the stock on hand until they are placed with the
+// "place" transition, but the quantity of their lines is held by stock
+// reservations, which reduce the units available to other orders. In both cases
+// the initial status is recorded in the status history.
 //
-// The method returns an error if something goes wrong. If the order is created
-// successfully, the method returns nil.
+// The order is priced in the billing currency of its customer. Lines without a
+// value take the current value of their ProductSupplier, and the exchange rates
+// effective on the order date for every other currency are stored on the order,
+// so its totals never change afterwards. The order number is always generated
+// from the order number pattern and the year of the order date.
+//
+// The shipping and billing contacts of the order are chosen as described in
+// chooseAddresses, and the addresses of a placed order are snapshotted. The
+// warehouse a placed order is fulfilled from is chosen as described in
+// chooseWarehouse.
+//
+// The method returns ErrInvalidInitialStatus for any other status,
+// ErrInvalidDiscount if a discount of the order or its lines is invalid, or an error
+// if something goes wrong, in which case nothing is persisted. If the order is
+// created successfully, the method returns nil.
 func (s *orderService) Create(ctx *gin.Context, order *entities.Order) error {
-	return s.orderRepository.Create(ctx, order)
+	if order.Status == "" {
+		order.Status = entities.OrderStatusPlaced
+	}
+	if order.Status != entities.OrderStatusDraft && order.Status != entities.OrderStatusPlaced {
+		return ErrInvalidInitialStatus
+	}
+	if err := s.priceOrder(ctx, order); err != nil {
+		return err
+	}
+	if err := s.chooseAddresses(ctx, order, order.Status == entities.OrderStatusPlaced); err != nil {
+		return err
+	}
+	if err := s.chooseWarehouse(ctx, order, order.Status == entities.OrderStatusPlaced); err != nil {
+		return err
+	}
+	if _, err := ComputeOrderTotals(order); err != nil {
+		return err
+	}
+	sequence, err := s.orderRepository.NextOrderNumber(ctx, order.OrderDate.Year())
+	if err != nil {
+		return err
+	}
+	order.UKOrderNumber = s.orderNumberPattern.Format(order.OrderDate.Year(), sequence)
+	order.StatusHistory = []entities.OrderStatusHistory{{
+		ToStatus:  order.Status,
+		ChangedAt: time.Now(),
+	}}
+
+	if order.Status == entities.OrderStatusDraft {
+		return s.orderRepository.Create(ctx, order)
+	}
+	return s.orderRepository.PlaceOrder(ctx, order)
 }
 
 // Retrieves an order from the database by its ID.
@@ -62,17 +252,266 @@ func (s *orderService) GetByID(ctx *gin.Context, id uint) (*entities.Order, erro
 	return s.orderRepository.GetByID(ctx, id)
 }
 
-// Retrieves all orders from the database.
+// priceOrder sets the currency of the order to the billing currency of its
+// customer, fills the value of the lines that have none with the value of
+// their ProductSupplier and snapshots the exchange rates effective on the order
+// date for every currency found in the order. An order without a date is dated
+// now.
+func (s *orderService) priceOrder(ctx *gin.Context, order *entities.Order) error {
+	if order.OrderDate.IsZero() {
+		order.OrderDate = time.Now()
+	}
+
+	customer, err := s.customerRepository.GetByID(ctx, order.CustomerID)
+	if err != nil {
+		return fmt.Errorf("customer %d: %w", order.CustomerID, err)
+	}
+	order.Currency = money.New(0, customer.BillingCurrency).Currency
+
+	currencies := map[string]bool{}
+	for i := range order.OrderProducts {
+		line := &order.OrderProducts[i]
+		if line.Value.IsZero() {
+			productSupplier, err := s.productSupplierRepository.GetByID(ctx, line.ProductSupplierID)
+			if err != nil {
+				return fmt.Errorf("product supplier %d: %w", line.ProductSupplierID, err)
+			}
+			line.Value = productSupplier.Value
+		}
+		currencies[money.New(0, line.Value.Currency).Currency] = true
+		if !line.Discount.IsZero() {
+			currencies[money.New(0, line.Discount.Currency).Currency] = true
+		}
+	}
+	if !order.Discount.IsZero() {
+		currencies[money.New(0, order.Discount.Currency).Currency] = true
+	}
+
+	order.ExchangeRates = nil
+	for currency := range currencies {
+		if currency == order.Currency {
+			continue
+		}
+		exchangeRate, err := effectiveExchangeRate(ctx, s.exchangeRateRepository, currency, order.Currency, order.OrderDate)
+		if err != nil {
+			return err
+		}
+		order.ExchangeRates = append(order.ExchangeRates, *exchangeRate)
+	}
+	return nil
+}
+
+// chooseAddresses sets the shipping and billing contacts of an order, and
+// snapshots their address into the order when snapshot is true, as done when
+// the order is placed, so later changes of the contacts never change it.
 //
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.Order and an error. If something goes wrong, the
-// method returns an empty slice and an error.
+// A contact chosen for the order must be a contact of its customer, otherwise
+// ErrInvalidContact is returned. An order without shipping or billing contact
+// gets the default contact of that type of its customer, or its default
+// contact of the other type when it has none, and no address of that type
+// when it has neither.
+func (s *orderService) chooseAddresses(ctx *gin.Context, order *entities.Order, snapshot bool) error {
+	for _, choice := range []struct {
+		contactID   *uint
+		contactType entities.ContactType
+		fallback    entities.ContactType
+	}{
+		{&order.ShippingContactID, entities.ContactTypeShipping, entities.ContactTypeBilling},
+		{&order.BillingContactID, entities.ContactTypeBilling, entities.ContactTypeShipping},
+	} {
+		contact, err := s.chooseContact(ctx, order.CustomerID, *choice.contactID, choice.contactType, choice.fallback)
+		if err != nil {
+			return err
+		}
+		if contact == nil {
+			continue
+		}
+		*choice.contactID = contact.ID
+		if snapshot {
+			order.Addresses = append(order.Addresses, snapshotAddress(choice.contactType, contact))
+		}
+	}
+	return nil
+}
+
+// chooseContact returns the contact with the given ID, which must be a contact
+// of the customer, or the first default contact of the customer of the given
+// types when no ID is given. It returns nil if the customer has no default
+// contact of any of the types.
+func (s *orderService) chooseContact(ctx *gin.Context, customerID, contactID uint, types ...entities.ContactType) (*entities.Contact, error) {
+	if contactID != 0 {
+		contact, err := s.contactRepository.GetByID(ctx, contactID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && contact.CustomerID != customerID) {
+			return nil, ErrInvalidContact.With("contact_id", contactID)
+		}
+		return contact, err
+	}
+
+	for _, contactType := range types {
+		contact, err := s.contactRepository.GetDefaultByCustomerID(ctx, customerID, contactType)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		return contact, err
+	}
+	return nil, nil
+}
+
+// chooseWarehouse sets the warehouse an order is fulfilled from when place is
+// true, as done when the order is placed.
 //
-// The method fetches all orders from the database. If the orders are found,
-// the method returns the orders and nil. If no orders are found or an error
-// occurs, the method returns an empty slice and an error.
-func (s *orderService) GetAll(ctx *gin.Context) ([]*entities.Order, error) {
-	return s.orderRepository.GetAll(ctx)
+// A warehouse chosen for the order must exist, otherwise
+// repositories.ErrUnknownWarehouse is returned, and is kept as it is. An order
+// without warehouse is fulfilled from the warehouse nearest to the postal code
+// of its shipping address, or of its billing address when it has none, that
+// has enough units on hand for every line, or from the nearest warehouse when
+// none has. The warehouses are compared by the distance between their postal
+// codes, as given by postalcode.CEP.Distance, the default warehouse first when
+// the order has no Brazilian postal code.
+func (s *orderService) chooseWarehouse(ctx *gin.Context, order *entities.Order, place bool) error {
+	if order.WarehouseID != nil {
+		return checkWarehouse(ctx, s.warehouseRepository, *order.WarehouseID)
+	}
+	if !place {
+		return nil
+	}
+
+	warehouses, err := s.warehouseRepository.List(ctx)
+	if err != nil || len(warehouses) == 0 {
+		return err
+	}
+	if destination, ok := orderPostalCode(order); ok {
+		slices.SortStableFunc(warehouses, func(a, b *entities.Warehouse) int {
+			distanceA, okA := destination.Distance(a.PostalCode)
+			distanceB, okB := destination.Distance(b.PostalCode)
+			switch {
+			case okA && !okB:
+				return -1
+			case !okA && okB:
+				return 1
+			}
+			return cmp.Compare(distanceA, distanceB)
+		})
+	}
+
+	needed := map[uint]int{}
+	for _, line := range order.OrderProducts {
+		needed[line.ProductSupplierID] += line.Quantity
+	}
+	stocks, err := s.warehouseRepository.GetStockOf(ctx, slices.Collect(maps.Keys(needed)))
+	if err != nil {
+		return err
+	}
+	onHand := map[uint]map[uint]int{}
+	for _, stock := range stocks {
+		if onHand[stock.WarehouseID] == nil {
+			onHand[stock.WarehouseID] = map[uint]int{}
+		}
+		onHand[stock.WarehouseID][stock.ProductSupplierID] = stock.Quantity
+	}
+
+	chosen := warehouses[0]
+	for _, warehouse := range warehouses {
+		stocked := true
+		for productSupplierID, quantity := range needed {
+			if onHand[warehouse.ID][productSupplierID] < quantity {
+				stocked = false
+				break
+			}
+		}
+		if stocked {
+			chosen = warehouse
+			break
+		}
+	}
+	order.WarehouseID = &chosen.ID
+	return nil
+}
+
+// orderPostalCode returns the postal code of the shipping address of an order,
+// or of its billing address when it has none, and false if neither is a valid
+// Brazilian postal code.
+func orderPostalCode(order *entities.Order) (postalcode.CEP, bool) {
+	for _, addressType := range []entities.ContactType{entities.ContactTypeShipping, entities.ContactTypeBilling} {
+		for _, address := range order.Addresses {
+			if address.Type != addressType || !postalcode.IsBrazil(address.Country) {
+				continue
+			}
+			if cep, err := postalcode.Parse(address.PostalCode); err == nil {
+				return cep, true
+			}
+		}
+	}
+	return "", false
+}
+
+// snapshotAddress returns the copy of the address of a contact stored as the
+// address of the given type of an order.
+func snapshotAddress(addressType entities.ContactType, contact *entities.Contact) entities.OrderAddress {
+	return entities.OrderAddress{
+		Type:          addressType,
+		ContactID:     contact.ID,
+		Phone:         contact.Phone,
+		PostalCode:    contact.PostalCode,
+		Area:          contact.Area,
+		District:      contact.District,
+		AddressNumber: contact.AddressNumber,
+		City:          contact.City,
+		State:         contact.State,
+		Country:       contact.Country,
+		Email:         contact.Email,
+	}
+}
+
+// Retrieves an order with its order products and computed totals.
+//
+// The method takes a pointer to a *gin.Context and the ID of the order. It loads
+// the order through the GetOrderWithOrderProducts method of the order repository
+// and computes its line totals, subtotal, discounts and grand total with
+// ComputeOrderTotals. It returns an error if the order is not found, if its
+// discounts are invalid or if something goes wrong during the retrieval.
+func (s *orderService) GetDetails(ctx *gin.Context, id uint) (*OrderDetails, error) {
+	order, err := s.orderRepository.GetOrderWithOrderProducts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := ComputeOrderTotals(order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrderDetails{Order: order, Totals: totals}, nil
+}
+
+// Retrieves an order with its order products and computed totals by its order
+// number.
+//
+// The method takes a pointer to a *gin.Context and the order number. It returns
+// gorm.ErrRecordNotFound if no order has the given number, or an error if its
+// totals cannot be computed.
+func (s *orderService) GetByOrderNumber(ctx *gin.Context, number string) (*OrderDetails, error) {
+	order, err := s.orderRepository.GetByOrderNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := ComputeOrderTotals(order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrderDetails{Order: order, Totals: totals}, nil
+}
+
+// Retrieves a page of orders.
+//
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request. It delegates the retrieval to the orderRepository, which applies
+// the pagination, sorting and filters of the query, and returns the page of
+// orders or an error if the retrieval fails.
+func (s *orderService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Order], error) {
+	return s.orderRepository.GetAll(ctx, q)
 }
 
 // Updates an order in the database.
@@ -84,10 +523,50 @@ func (s *orderService) GetAll(ctx *gin.Context) ([]*entities.Order, error) {
 // The order object is passed as a pointer and the method is responsible for updating
 // an order in the database with the given attributes.
 //
+// The status, the order date, the order number and the shipping and billing
+// contacts of the order are kept as they are stored in the database, since the
+// status can only be changed through the Transition method, and the order
+// date, the order number and the contacts are chosen once. A delivery date
+// before the order date is answered with a validation error.
+//
+// The totals of the updated order are computed with its stored lines and
+// exchange rates before it is saved, so an order that cannot be priced is
+// never stored: a discount in a currency the order has no exchange rate for
+// returns ErrCurrencyMismatch, and an invalid discount ErrInvalidDiscount.
+//
 // The method returns an error if something goes wrong. If the order is updated
 // successfully, the method returns nil.
-func (s *orderService) Update(ctx *gin.Context, order *entities.Order) error {
-	return s.orderRepository.Update(ctx, order)
+//
+// When fields are given, only those fields of the order, named as in its
+// struct, are saved, as done for partial updates.
+func (s *orderService) Update(ctx *gin.Context, order *entities.Order, fields ...string) error {
+	current, err := s.orderRepository.GetOrderWithOrderProducts(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	order.Status = current.Status
+	order.OrderDate = current.OrderDate
+	order.UKOrderNumber = current.UKOrderNumber
+	order.ShippingContactID = current.ShippingContactID
+	order.BillingContactID = current.BillingContactID
+	order.WarehouseID = current.WarehouseID
+	order.Currency = current.Currency
+	if order.DeliveryDate.Before(order.OrderDate) {
+		return apperrors.Validation([]apperrors.FieldError{{
+			Field:   "delivery_date",
+			Rule:    "gtefield",
+			Message: "must not be before order_date",
+		}})
+	}
+
+	priced := *order
+	priced.OrderProducts = current.OrderProducts
+	priced.ExchangeRates = current.ExchangeRates
+	if _, err := ComputeOrderTotals(&priced); err != nil {
+		return err
+	}
+
+	return s.orderRepository.Update(ctx, order, fields...)
 }
 
 // Deletes an order by its ID from the database.
@@ -98,18 +577,82 @@ func (s *orderService) Update(ctx *gin.Context, order *entities.Order) error {
 // The method deletes an order by its ID from the database using the given ID.
 // If the order is deleted successfully, the method returns nil. If the order is
 // not found or an error occurs, the method returns an error.
-func (s *orderService) Delete(ctx *gin.Context, id uint) error {
-	return s.orderRepository.Delete(ctx, id)
+//
+// The order is only deleted if it is still at the given version, otherwise
+// repositories.ErrVersionConflict is returned.
+func (s *orderService) Delete(ctx *gin.Context, id uint, version uint) error {
+	return s.orderRepository.Delete(ctx, id, version)
 }
 
 // Deletes multiple orders by their IDs from the database.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple orders from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the orders are
-// deleted successfully, the method returns nil.
-func (s *orderService) DeleteAll(ctx *gin.Context, ids []uint) error {
+// The method takes a context and a slice of uints as parameters. It delegates
+// the deletion to the orderRepository, which deletes the orders that exist and
+// are not referenced anymore in a single transaction. It returns the outcome
+// for each id, or an error if the deletion process fails.
+func (s *orderService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
 	return s.orderRepository.DeleteAll(ctx, ids)
 }
+
+// Moves an order through its lifecycle.
+//
+// The method takes a pointer to a *gin.Context, the ID of the order, the name of
+// the transition (place, pay, pick, ship, deliver, cancel or return), the user
+// applying it and an optional note. It returns the order in its new status.
+//
+// The method returns ErrUnknownTransition if the transition does not exist and
+// an *InvalidTransitionError if it cannot be applied from the current status.
+// Placing a draft converts its stock reservations and consumes the stock of its
+// order lines, while cancelling a draft releases its reservations. Cancelling a
+// placed order or returning a delivered one restores its stock. Placing a draft
+// also snapshots its shipping and billing addresses, as described in
+// chooseAddresses, and chooses the warehouse it is fulfilled from, as
+// described in chooseWarehouse. Every successful transition is recorded in the status
+// history.
+func (s *orderService) Transition(ctx *gin.Context, id uint, transition, changedBy, note string) (*entities.Order, error) {
+	t, ok := orderTransitions[transition]
+	if !ok {
+		return nil, ErrUnknownTransition
+	}
+
+	order, err := s.orderRepository.GetOrderWithOrderProducts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.allows(order.Status) {
+		return nil, &InvalidTransitionError{Transition: transition, Current: order.Status, Requested: t.to}
+	}
+	if t.to == entities.OrderStatusPlaced {
+		if err := s.chooseAddresses(ctx, order, true); err != nil {
+			return nil, err
+		}
+		if err := s.chooseWarehouse(ctx, order, true); err != nil {
+			return nil, err
+		}
+	}
+
+	history := &entities.OrderStatusHistory{
+		FromStatus: order.Status,
+		ToStatus:   t.to,
+		ChangedBy:  changedBy,
+		ChangedAt:  time.Now(),
+		Note:       note,
+	}
+	if err := s.orderRepository.ChangeStatus(ctx, order, history, t.stockEffect(order.Status)); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Retrieves the status history of an order.
+//
+// The method takes a pointer to a *gin.Context and the ID of the order. It
+// returns the history entries from the oldest to the newest change, or
+// gorm.ErrRecordNotFound if the order does not exist.
+func (s *orderService) GetStatusHistory(ctx *gin.Context, id uint) ([]*entities.OrderStatusHistory, error) {
+	if _, err := s.orderRepository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.orderRepository.GetStatusHistory(ctx, id)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
 	"store/domain/repositories"
 
 	"github.com/gin-gonic/gin"
@@ -11,12 +12,15 @@ import (
 // implement to manage product suppliers in the application. It provides methods to
 // create, retrieve, update, and delete productSupplier entities.
 type ProductSupplierService interface {
-	Create(ctx *gin.Context, productSupplier *entities.ProductSupplier) error // Creates a productSupplier
-	GetByID(ctx *gin.Context, id uint) (*entities.ProductSupplier, error)     // Retrieves a productSupplier
-	GetAll(ctx *gin.Context) ([]*entities.ProductSupplier, error)             // Retrieves all productSuppliers
-	Update(ctx *gin.Context, productSupplier *entities.ProductSupplier) error // Updates a productSupplier
-	Delete(ctx *gin.Context, id uint) error                                   // Deletes a productSupplier
-	DeleteAll(ctx *gin.Context, ids []uint) error                             // Deletes multiple productSuppliers
+	Create(ctx *gin.Context, productSupplier *entities.ProductSupplier) error                                                 // Creates a productSupplier
+	GetByID(ctx *gin.Context, id uint) (*entities.ProductSupplier, error)                                                     // Retrieves a productSupplier
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error)                              // Retrieves all productSuppliers
+	Update(ctx *gin.Context, productSupplier *entities.ProductSupplier, fields ...string) error                               // Updates a productSupplier
+	Delete(ctx *gin.Context, id uint, version uint) error                                                                     // Deletes a productSupplier
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)                                              // Deletes multiple productSuppliers
+	GetAllByProductID(ctx *gin.Context, productID uint, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error)   // Retrieves the productSuppliers of a product
+	GetAllBySupplierID(ctx *gin.Context, supplierID uint, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error) // Retrieves the productSuppliers of a supplier
+	TrashService[entities.ProductSupplier]                                                                                    // Get, restore and purge deleted productSuppliers
 }
 
 // productSupplierService is a struct that implements the ProductSupplierService interface.
@@ -24,6 +28,7 @@ type ProductSupplierService interface {
 // with the product_suppliers table in the database.
 type productSupplierService struct {
 	productSupplierRepository repositories.ProductSupplierRepository
+	TrashService[entities.ProductSupplier]
 }
 
 // NewProductSupplierService creates a new ProductSupplierService with the given productSupplierRepository.
@@ -31,7 +36,7 @@ type productSupplierService struct {
 // updating, and deleting productSupplier entities in the application.
 // It returns an instance of productSupplierService that implements the ProductSupplierService interface.
 func NewProductSupplierService(productSupplierRepository repositories.ProductSupplierRepository) ProductSupplierService {
-	return &productSupplierService{productSupplierRepository: productSupplierRepository}
+	return &productSupplierService{productSupplierRepository: productSupplierRepository, TrashService: productSupplierRepository}
 }
 
 // Creates a new productSupplier in the database.
@@ -53,14 +58,14 @@ func (s *productSupplierService) GetByID(ctx *gin.Context, id uint) (*entities.P
 	return s.productSupplierRepository.GetByID(ctx, id)
 }
 
-// Retrieves all productSuppliers from the database.
+// Retrieves a page of product suppliers.
 //
-// The method takes a context as a parameter. It delegates the retrieval of the
-// productSuppliers to the productSupplierRepository and returns an error if the retrieval
-// process fails. If successful, it returns a slice of productSuppliers and
-// nil.
-func (s *productSupplierService) GetAll(ctx *gin.Context) ([]*entities.ProductSupplier, error) {
-	return s.productSupplierRepository.GetAll(ctx)
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request. It delegates the retrieval to the productSupplierRepository,
+// which applies the pagination, sorting and filters of the query, and returns
+// the page of product suppliers or an error if the retrieval fails.
+func (s *productSupplierService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error) {
+	return s.productSupplierRepository.GetAll(ctx, q)
 }
 
 // Updates a productSupplier in the database.
@@ -68,8 +73,11 @@ func (s *productSupplierService) GetAll(ctx *gin.Context) ([]*entities.ProductSu
 // The method takes a context and an entities.ProductSupplier as parameters.
 // It delegates the update of the productSupplier to the productSupplierRepository and
 // returns an error if the update process fails. If successful, it returns nil.
-func (s *productSupplierService) Update(ctx *gin.Context, productSupplier *entities.ProductSupplier) error {
-	return s.productSupplierRepository.Update(ctx, productSupplier)
+//
+// When fields are given, only those fields of the productSupplier, named as in
+// its struct, are saved, as done for partial updates.
+func (s *productSupplierService) Update(ctx *gin.Context, productSupplier *entities.ProductSupplier, fields ...string) error {
+	return s.productSupplierRepository.Update(ctx, productSupplier, fields...)
 }
 
 // Deletes a productSupplier by its ID from the database.
@@ -77,16 +85,38 @@ func (s *productSupplierService) Update(ctx *gin.Context, productSupplier *entit
 // The method takes a context and the ID of the productSupplier as parameters.
 // It delegates the deletion of the productSupplier to the productSupplierRepository and
 // returns an error if the deletion process fails. If successful, it returns nil.
-func (s *productSupplierService) Delete(ctx *gin.Context, id uint) error {
-	return s.productSupplierRepository.Delete(ctx, id)
+//
+// The productSupplier is only deleted if it is still at the given version,
+// otherwise repositories.ErrVersionConflict is returned.
+func (s *productSupplierService) Delete(ctx *gin.Context, id uint, version uint) error {
+	return s.productSupplierRepository.Delete(ctx, id, version)
 }
 
 // Deletes multiple productSuppliers by their IDs from the database.
 //
-// The method takes a context and a slice of uints as parameters.
-// It delegates the deletion of the productSuppliers to the productSupplierRepository and
-// returns an error if the deletion process fails. If successful, it returns nil.
-
-func (s *productSupplierService) DeleteAll(ctx *gin.Context, ids []uint) error {
+// The method takes a context and a slice of uints as parameters. It delegates
+// the deletion to the productSupplierRepository, which deletes the
+// productSuppliers that exist and are not referenced anymore in a single
+// transaction. It returns the outcome for each id, or an error if the deletion
+// process fails.
+func (s *productSupplierService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
 	return s.productSupplierRepository.DeleteAll(ctx, ids)
 }
+
+// Retrieves a page of the productSuppliers of a product.
+//
+// The method takes a context, the ID of the product and the list query parsed
+// from the request. It delegates the retrieval to the
+// productSupplierRepository and returns an error if the retrieval fails.
+func (s *productSupplierService) GetAllByProductID(ctx *gin.Context, productID uint, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error) {
+	return s.productSupplierRepository.GetAllByProductID(ctx, productID, q)
+}
+
+// Retrieves a page of the productSuppliers of a supplier, that is its offers.
+//
+// The method takes a context, the ID of the supplier and the list query parsed
+// from the request. It delegates the retrieval to the
+// productSupplierRepository and returns an error if the retrieval fails.
+func (s *productSupplierService) GetAllBySupplierID(ctx *gin.Context, supplierID uint, q *query.ListQuery) (*query.Page[*entities.ProductSupplier], error) {
+	return s.productSupplierRepository.GetAllBySupplierID(ctx, supplierID, q)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
 	"store/domain/repositories"
 
 	"github.com/gin-gonic/gin"
@@ -11,12 +12,13 @@ import (
 // products in the application. It provides methods to create, retrieve, update,
 // and delete product entities.
 type ProductService interface {
-	Create(ctx *gin.Context, supplier *entities.Product) error    // Create a new product
-	GetByID(ctx *gin.Context, id uint) (*entities.Product, error) // Get a product by ID
-	GetAll(ctx *gin.Context) ([]*entities.Product, error)         // Get all products
-	Update(ctx *gin.Context, supplier *entities.Product) error    // Update a product
-	Delete(ctx *gin.Context, id uint) error                       // Delete a product
-	DeleteAll(ctx *gin.Context, ids []uint) error                 // Delete a product
+	Create(ctx *gin.Context, supplier *entities.Product) error                           // Create a new product
+	GetByID(ctx *gin.Context, id uint) (*entities.Product, error)                        // Get a product by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Product], error) // Get all products
+	Update(ctx *gin.Context, supplier *entities.Product, fields ...string) error         // Update a product
+	Delete(ctx *gin.Context, id uint, version uint) error                                // Delete a product
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)         // Delete a product
+	TrashService[entities.Product]                                                       // Get, restore and purge deleted products
 }
 
 // productService is a struct that contains a pointer to a repositories.ProductRepository
@@ -25,13 +27,14 @@ type ProductService interface {
 // database.
 type productService struct {
 	productRepository repositories.ProductRepository
+	TrashService[entities.Product]
 }
 
 // NewProductService creates a new ProductService with the given productRepository.
 // The ProductService is an interface that defines methods for creating, retrieving,
 // updating, and deleting products in the application.
 func NewProductService(productRepository repositories.ProductRepository) ProductService {
-	return &productService{productRepository: productRepository}
+	return &productService{productRepository: productRepository, TrashService: productRepository}
 }
 
 // Creates a new product to the database.
@@ -60,17 +63,14 @@ func (s *productService) GetByID(ctx *gin.Context, id uint) (*entities.Product,
 	return s.productRepository.GetByID(ctx, id)
 }
 
-// Retrieves all products from the database.
+// Retrieves a page of products.
 //
-// The method takes a pointer to a *gin.Context as a parameter. It returns a slice
-// of pointers to entities.Product and an error. If something goes wrong, the
-// method returns an empty slice and an error.
-//
-// The method fetches all products from the database. If the products are found,
-// the method returns the products and nil. If no products are found or an error
-// occurs, the method returns an empty slice and an error.
-func (s *productService) GetAll(ctx *gin.Context) ([]*entities.Product, error) {
-	return s.productRepository.GetAll(ctx)
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request. It delegates the retrieval to the productRepository, which
+// applies the pagination, sorting and filters of the query, and returns the
+// page of products or an error if the retrieval fails.
+func (s *productService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Product], error) {
+	return s.productRepository.GetAll(ctx, q)
 }
 
 // Updates an existing product in the database.
@@ -81,8 +81,11 @@ func (s *productService) GetAll(ctx *gin.Context) ([]*entities.Product, error) {
 //
 // This method ensures that the product is updated in the database with the provided
 // attributes. If successful, it returns nil; otherwise, it returns the encountered error.
-func (s *productService) Update(ctx *gin.Context, product *entities.Product) error {
-	return s.productRepository.Update(ctx, product)
+//
+// When fields are given, only those fields of the product, named as in its
+// struct, are saved, as done for partial updates.
+func (s *productService) Update(ctx *gin.Context, product *entities.Product, fields ...string) error {
+	return s.productRepository.Update(ctx, product, fields...)
 }
 
 // Deletes a product from the database by its ID.
@@ -94,18 +97,19 @@ func (s *productService) Update(ctx *gin.Context, product *entities.Product) err
 // The method deletes a product from the database using the given ID. If the
 // product is deleted successfully, the method returns nil. If the product is not
 // found or an error occurs, the method returns an error.
-func (s *productService) Delete(ctx *gin.Context, id uint) error {
-	return s.productRepository.Delete(ctx, id)
+//
+// The product is only deleted if it is still at the given version, otherwise
+// repositories.ErrVersionConflict is returned.
+func (s *productService) Delete(ctx *gin.Context, id uint, version uint) error {
+	return s.productRepository.Delete(ctx, id, version)
 }
 
 // Deletes multiple products from the database by their IDs.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple products from the database using the given slice
-// of IDs. The method returns an error if something goes wrong. If the products are
-// deleted successfully, the method returns nil.
-func (s *productService) DeleteAll(ctx *gin.Context, ids []uint) error {
+// The method takes a context and a slice of uints as parameters. It delegates
+// the deletion to the productRepository, which deletes the products that exist
+// and are not referenced anymore in a single transaction. It returns the
+// outcome for each id, or an error if the deletion process fails.
+func (s *productService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
 	return s.productRepository.DeleteAll(ctx, ids)
 }
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"fmt"
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/query"
+	"store/domain/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrPurchaseOrderNotDraft is returned when a purchase order that was
+	// already sent is updated or deleted.
+	ErrPurchaseOrderNotDraft = apperrors.Conflict("purchase_order_not_draft", "purchase orders can only be changed while they are drafts")
+	// ErrUnknownPurchaseOrderTransition is returned when a transition name is
+	// not part of the purchase order lifecycle.
+	ErrUnknownPurchaseOrderTransition = apperrors.BadRequest("unknown_transition", "unknown purchase order transition")
+	// ErrInvalidPurchaseOrderTransition is returned, with the current and
+	// requested status of the purchase order, when a transition is not allowed
+	// from its current status.
+	ErrInvalidPurchaseOrderTransition = apperrors.Conflict("invalid_transition", "transition not allowed from the current status of the purchase order")
+	// ErrSupplierMismatch is returned when a line of a purchase order orders a
+	// product supplier of another supplier.
+	ErrSupplierMismatch = apperrors.Invalid("supplier_mismatch", "the product supplier is not offered by the supplier of the purchase order")
+	// ErrUnknownPurchaseOrderLine is returned when a goods receipt receives a
+	// line that is not a line of its purchase order.
+	ErrUnknownPurchaseOrderLine = apperrors.Invalid("unknown_purchase_order_line", "the line is not a line of the purchase order")
+)
+
+// purchaseOrderTransition describes a named transition of the purchase order
+// lifecycle, the status it leads to and the statuses it can be applied from.
+// Purchase orders become partially received and received through their goods
+// receipts only.
+type purchaseOrderTransition struct {
+	to   entities.PurchaseOrderStatus
+	from []entities.PurchaseOrderStatus
+}
+
+// purchaseOrderTransitions is the state machine of the purchase order
+// lifecycle, keyed by the transition name used in the
+// `POST /purchase-orders/:id/transitions/:transition` route.
+var purchaseOrderTransitions = map[string]purchaseOrderTransition{
+	"send": {to: entities.PurchaseOrderStatusSent, from: []entities.PurchaseOrderStatus{
+		entities.PurchaseOrderStatusDraft,
+	}},
+	"close": {to: entities.PurchaseOrderStatusClosed, from: []entities.PurchaseOrderStatus{
+		entities.PurchaseOrderStatusSent,
+		entities.PurchaseOrderStatusPartiallyReceived,
+		entities.PurchaseOrderStatusReceived,
+	}},
+}
+
+// allows reports whether the transition can be applied from the given status.
+func (t purchaseOrderTransition) allows(status entities.PurchaseOrderStatus) bool {
+	for _, from := range t.from {
+		if from == status {
+			return true
+		}
+	}
+	return false
+}
+
+// PurchaseOrderService defines the methods that a service must implement to
+// manage the purchase orders placed with the suppliers. It provides methods to
+// create, retrieve, update and delete purchase orders, move them through their
+// lifecycle and receive their goods.
+type PurchaseOrderService interface {
+	Create(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error                               // Create a new purchase order
+	GetByID(ctx *gin.Context, id uint) (*entities.PurchaseOrder, error)                                 // Get a purchase order by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.PurchaseOrder], error)          // Get all purchase orders
+	Update(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error                               // Update a draft purchase order
+	Delete(ctx *gin.Context, id uint, version uint) error                                               // Delete a draft purchase order
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)                        // Delete multiple draft purchase orders
+	Transition(ctx *gin.Context, id uint, transition string) (*entities.PurchaseOrder, error)           // Move a purchase order through its lifecycle
+	Receive(ctx *gin.Context, id uint, receipt *entities.GoodsReceipt) (*entities.PurchaseOrder, error) // Receive goods for a purchase order
+	GetReceipts(ctx *gin.Context, id uint) ([]*entities.GoodsReceipt, error)                            // Get the goods receipts of a purchase order
+	TrashService[entities.PurchaseOrder]                                                                // Get, restore and purge deleted purchase orders
+}
+
+// purchaseOrderService is a struct that implements the PurchaseOrderService
+// interface. It contains a PurchaseOrderRepository which is used to interact
+// with the purchase_orders table in the database, and the SupplierRepository
+// and ProductSupplierRepository used to check the supplier and the lines of
+// the purchase orders.
+type purchaseOrderService struct {
+	purchaseOrderRepository   repositories.PurchaseOrderRepository
+	supplierRepository        repositories.SupplierRepository
+	productSupplierRepository repositories.ProductSupplierRepository
+	TrashService[entities.PurchaseOrder]
+}
+
+// NewPurchaseOrderService creates a new PurchaseOrderService with the given
+// PurchaseOrderRepository, and the SupplierRepository and
+// ProductSupplierRepository used to check the supplier and the lines of the
+// purchase orders.
+func NewPurchaseOrderService(
+	purchaseOrderRepository repositories.PurchaseOrderRepository,
+	supplierRepository repositories.SupplierRepository,
+	productSupplierRepository repositories.ProductSupplierRepository,
+) PurchaseOrderService {
+	return &purchaseOrderService{
+		purchaseOrderRepository:   purchaseOrderRepository,
+		supplierRepository:        supplierRepository,
+		productSupplierRepository: productSupplierRepository,
+		TrashService:              purchaseOrderRepository,
+	}
+}
+
+// Creates a new purchase order as a draft.
+//
+// The supplier of the purchase order must exist, and every line must order a
+// product supplier of that supplier, otherwise ErrSupplierMismatch is
+// returned. Lines without cost take the current cost of their product
+// supplier, and a purchase order without date is dated now. The stock is only
+// changed when the goods are received.
+func (s *purchaseOrderService) Create(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error {
+	if _, err := s.supplierRepository.GetByID(ctx, purchaseOrder.SupplierID); err != nil {
+		return fmt.Errorf("supplier %d: %w", purchaseOrder.SupplierID, err)
+	}
+	if purchaseOrder.OrderDate.IsZero() {
+		purchaseOrder.OrderDate = time.Now()
+	}
+	if err := s.priceLines(ctx, purchaseOrder); err != nil {
+		return err
+	}
+	purchaseOrder.Status = entities.PurchaseOrderStatusDraft
+	return s.purchaseOrderRepository.Create(ctx, purchaseOrder)
+}
+
+// Retrieves a purchase order with its lines and its goods receipts by its ID.
+//
+// The method returns gorm.ErrRecordNotFound if the purchase order does not
+// exist.
+func (s *purchaseOrderService) GetByID(ctx *gin.Context, id uint) (*entities.PurchaseOrder, error) {
+	return s.purchaseOrderRepository.GetByID(ctx, id)
+}
+
+// Retrieves a page of purchase orders.
+//
+// The method delegates the retrieval to the purchaseOrderRepository, which
+// applies the pagination, sorting and filters of the query.
+func (s *purchaseOrderService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.PurchaseOrder], error) {
+	return s.purchaseOrderRepository.GetAll(ctx, q)
+}
+
+// Updates a draft purchase order and replaces its lines.
+//
+// The supplier and the status of the purchase order are kept as they are
+// stored. It returns ErrPurchaseOrderNotDraft if the purchase order was
+// already sent, and ErrSupplierMismatch if a line orders a product supplier of
+// another supplier. Lines without cost take the current cost of their product
+// supplier.
+func (s *purchaseOrderService) Update(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error {
+	current, err := s.purchaseOrderRepository.GetByID(ctx, purchaseOrder.ID)
+	if err != nil {
+		return err
+	}
+	if current.Status != entities.PurchaseOrderStatusDraft {
+		return ErrPurchaseOrderNotDraft
+	}
+	purchaseOrder.SupplierID = current.SupplierID
+	purchaseOrder.Status = current.Status
+	purchaseOrder.SentAt = current.SentAt
+	purchaseOrder.ClosedAt = current.ClosedAt
+	if err := s.priceLines(ctx, purchaseOrder); err != nil {
+		return err
+	}
+	return s.purchaseOrderRepository.Update(ctx, purchaseOrder)
+}
+
+// Deletes a draft purchase order by its ID.
+//
+// The purchase order is only deleted if it is still at the given version,
+// otherwise repositories.ErrVersionConflict is returned. It returns
+// ErrPurchaseOrderNotDraft if the purchase order was already sent.
+func (s *purchaseOrderService) Delete(ctx *gin.Context, id uint, version uint) error {
+	purchaseOrder, err := s.purchaseOrderRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if purchaseOrder.Status != entities.PurchaseOrderStatusDraft {
+		return ErrPurchaseOrderNotDraft
+	}
+	return s.purchaseOrderRepository.Delete(ctx, id, version)
+}
+
+// Deletes multiple purchase orders by their IDs.
+//
+// The method delegates the deletion to the purchaseOrderRepository, which
+// deletes the draft purchase orders that exist in a single transaction and
+// keeps the other ones. It returns the outcome for each id.
+func (s *purchaseOrderService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
+	return s.purchaseOrderRepository.DeleteAll(ctx, ids)
+}
+
+// Moves a purchase order through its lifecycle.
+//
+// The method takes the ID of the purchase order and the name of the
+// transition: send, which sends a draft to its supplier, or close, which stops
+// waiting for the goods still outstanding. It returns
+// ErrUnknownPurchaseOrderTransition if the transition does not exist, and
+// ErrInvalidPurchaseOrderTransition if it cannot be applied from the current
+// status.
+func (s *purchaseOrderService) Transition(ctx *gin.Context, id uint, transition string) (*entities.PurchaseOrder, error) {
+	t, ok := purchaseOrderTransitions[transition]
+	if !ok {
+		return nil, ErrUnknownPurchaseOrderTransition
+	}
+
+	purchaseOrder, err := s.purchaseOrderRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.allows(purchaseOrder.Status) {
+		return nil, ErrInvalidPurchaseOrderTransition.
+			With("current_status", purchaseOrder.Status).
+			With("requested_status", t.to)
+	}
+	if err := s.purchaseOrderRepository.ChangeStatus(ctx, purchaseOrder, t.to); err != nil {
+		return nil, err
+	}
+	return purchaseOrder, nil
+}
+
+// Receives goods for a purchase order.
+//
+// Every line of the receipt must receive a line of the purchase order,
+// otherwise ErrUnknownPurchaseOrderLine is returned. Partial receipts and
+// receipts of more than the quantity ordered are both accepted. The receipt,
+// the received quantities and the stock of the ordered product suppliers are
+// persisted in a single transaction by the purchaseOrderRepository, which
+// returns repositories.ErrPurchaseOrderNotOpen unless the purchase order is
+// sent or partially received. It returns the purchase order with its new
+// status.
+func (s *purchaseOrderService) Receive(ctx *gin.Context, id uint, receipt *entities.GoodsReceipt) (*entities.PurchaseOrder, error) {
+	purchaseOrder, err := s.purchaseOrderRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[uint]bool, len(purchaseOrder.Lines))
+	for _, line := range purchaseOrder.Lines {
+		lines[line.ID] = true
+	}
+	for _, line := range receipt.Lines {
+		if !lines[line.PurchaseOrderLineID] {
+			return nil, ErrUnknownPurchaseOrderLine.With("purchase_order_line_id", line.PurchaseOrderLineID)
+		}
+	}
+	if receipt.ReceivedAt.IsZero() {
+		receipt.ReceivedAt = time.Now()
+	}
+
+	if err := s.purchaseOrderRepository.Receive(ctx, purchaseOrder, receipt); err != nil {
+		return nil, err
+	}
+	return purchaseOrder, nil
+}
+
+// Retrieves the goods receipts of a purchase order.
+//
+// The method returns gorm.ErrRecordNotFound if the purchase order does not
+// exist, otherwise its receipts from the oldest to the newest one.
+func (s *purchaseOrderService) GetReceipts(ctx *gin.Context, id uint) ([]*entities.GoodsReceipt, error) {
+	if _, err := s.purchaseOrderRepository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.purchaseOrderRepository.GetReceipts(ctx, id)
+}
+
+// priceLines checks that every line of a purchase order orders a product
+// supplier of its supplier, and fills the cost of the lines that have none
+// with the cost of their product supplier.
+func (s *purchaseOrderService) priceLines(ctx *gin.Context, purchaseOrder *entities.PurchaseOrder) error {
+	for i := range purchaseOrder.Lines {
+		line := &purchaseOrder.Lines[i]
+		productSupplier, err := s.productSupplierRepository.GetByID(ctx, line.ProductSupplierID)
+		if err != nil {
+			return fmt.Errorf("product supplier %d: %w", line.ProductSupplierID, err)
+		}
+		if productSupplier.SupplierID != purchaseOrder.SupplierID {
+			return ErrSupplierMismatch.With("product_supplier_id", line.ProductSupplierID)
+		}
+		if line.Cost.IsZero() {
+			line.Cost = productSupplier.Cost
+		}
+	}
+	return nil
+}
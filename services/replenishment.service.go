@@ -0,0 +1,213 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"store/domain/money"
+	"store/domain/repositories"
+	"store/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReplenishmentPolicy is how the replenishment of the product suppliers is
+// planned from their recent sales.
+type ReplenishmentPolicy struct {
+	SalesWindow time.Duration // period of the recent sales the sales velocity is measured on
+	LeadTime    time.Duration // time the suppliers take to deliver a purchase order
+}
+
+// ReplenishmentPolicyFromEnv returns the ReplenishmentPolicy read as numbers of
+// days from the REPLENISHMENT_WINDOW_DAYS and REPLENISHMENT_LEAD_DAYS
+// environment variables, 30 and 7 days by default.
+func ReplenishmentPolicyFromEnv() ReplenishmentPolicy {
+	return ReplenishmentPolicy{
+		SalesWindow: time.Duration(max(utils.GetEnvInt("REPLENISHMENT_WINDOW_DAYS", 30), 1)) * 24 * time.Hour,
+		LeadTime:    time.Duration(max(utils.GetEnvInt("REPLENISHMENT_LEAD_DAYS", 7), 0)) * 24 * time.Hour,
+	}
+}
+
+// LowStockReportIntervalFromEnv returns how often the server writes the
+// low-stock report, read as a number of hours from the
+// REPLENISHMENT_REPORT_HOURS environment variable, once a day by default. An
+// interval of zero disables the report.
+func LowStockReportIntervalFromEnv() time.Duration {
+	return time.Duration(utils.GetEnvInt("REPLENISHMENT_REPORT_HOURS", 24)) * time.Hour
+}
+
+// ReplenishmentSuggestion is the quantity suggested to purchase of a product
+// supplier whose stock reached its reorder point, along with the figures it
+// was computed from.
+type ReplenishmentSuggestion struct {
+	ProductSupplierID   uint        `json:"product_supplier_id"`   // product supplier to replenish
+	ProductID           uint        `json:"product_id"`            // product offered by the product supplier
+	SupplierProductCode string      `json:"supplier_product_code"` // code of the product for the supplier
+	SupplierProductName string      `json:"supplier_product_name"` // name of the product for the supplier
+	OnHand              int         `json:"on_hand"`               // quantity on hand
+	Available           int         `json:"available"`             // quantity on hand not reserved by draft orders
+	Incoming            int         `json:"incoming"`              // quantity outstanding on the purchase orders sent
+	DailySales          float64     `json:"daily_sales"`           // units sold per day over the sales window
+	ReorderPoint        int         `json:"reorder_point"`         // position at or below which the product supplier is replenished
+	SuggestedQuantity   int         `json:"suggested_quantity"`    // quantity suggested to purchase
+	Cost                money.Money `json:"cost"`                  // unit cost paid to the supplier
+}
+
+// SupplierReplenishment groups the suggestions of the product suppliers of a
+// supplier, to be purchased with a single purchase order.
+type SupplierReplenishment struct {
+	SupplierID   uint                      `json:"supplier_id"`   // supplier to purchase from
+	SupplierName string                    `json:"supplier_name"` // name of the supplier
+	Suggestions  []ReplenishmentSuggestion `json:"suggestions"`   // quantities suggested to purchase
+}
+
+// ReplenishmentService is an interface that defines the methods that a service
+// must implement to plan the replenishment of the stock of the product
+// suppliers.
+type ReplenishmentService interface {
+	Suggest(ctx *gin.Context) ([]SupplierReplenishment, error) // Suggest the quantities to purchase, grouped by supplier
+}
+
+// replenishmentService is a struct that implements the ReplenishmentService
+// interface. It contains a ReplenishmentRepository which is used to read the
+// stock positions of the product suppliers, the SupplierRepository the names
+// of the suppliers are read from, and the policy the suggestions are computed
+// with.
+type replenishmentService struct {
+	replenishmentRepository repositories.ReplenishmentRepository
+	supplierRepository      repositories.SupplierRepository
+	policy                  ReplenishmentPolicy
+}
+
+// NewReplenishmentService creates a new ReplenishmentService with the given
+// ReplenishmentRepository and SupplierRepository, planning the replenishment
+// with the given policy.
+func NewReplenishmentService(
+	replenishmentRepository repositories.ReplenishmentRepository,
+	supplierRepository repositories.SupplierRepository,
+	policy ReplenishmentPolicy,
+) ReplenishmentService {
+	return &replenishmentService{
+		replenishmentRepository: replenishmentRepository,
+		supplierRepository:      supplierRepository,
+		policy:                  policy,
+	}
+}
+
+// Suggests the quantities to purchase of the product suppliers whose stock
+// reached their reorder point, grouped by supplier.
+//
+// The sales velocity of a product supplier is the number of units sold per day
+// over the sales window of the policy. Its reorder point is the one set on it,
+// or, when it is zero, its safety stock plus the units expected to be sold
+// during the lead time. Its position is its quantity available plus the
+// quantity incoming from the purchase orders already sent, and it is
+// replenished when its position is at or below its reorder point. The
+// suggested quantity brings the position above the reorder point and covers
+// the sales of one more sales window, rounded up to a multiple of its reorder
+// quantity when it has one. Product suppliers without reorder point nor sales
+// are never suggested.
+func (s *replenishmentService) Suggest(ctx *gin.Context) ([]SupplierReplenishment, error) {
+	positions, err := s.replenishmentRepository.GetStockPositions(ctx, time.Now().Add(-s.policy.SalesWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	replenishments := []SupplierReplenishment{}
+	for _, position := range positions {
+		suggestion, ok := s.policy.suggest(position)
+		if !ok {
+			continue
+		}
+		supplierID := position.ProductSupplier.SupplierID
+		if n := len(replenishments); n == 0 || replenishments[n-1].SupplierID != supplierID {
+			supplier, err := s.supplierRepository.GetByID(ctx, supplierID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			replenishments = append(replenishments, SupplierReplenishment{SupplierID: supplierID, SupplierName: supplier.Name})
+		}
+		last := &replenishments[len(replenishments)-1]
+		last.Suggestions = append(last.Suggestions, suggestion)
+	}
+	return replenishments, nil
+}
+
+// suggest returns the quantity suggested to purchase of the product supplier of
+// a stock position, and false if it does not need to be replenished.
+func (p ReplenishmentPolicy) suggest(position repositories.StockPosition) (ReplenishmentSuggestion, bool) {
+	productSupplier := position.ProductSupplier
+	dailySales := float64(position.Sold) / p.SalesWindow.Hours() * 24
+
+	reorderPoint := productSupplier.ReorderPoint
+	if reorderPoint == 0 {
+		reorderPoint = productSupplier.SafetyStock + int(math.Ceil(dailySales*p.LeadTime.Hours()/24))
+	}
+	stock := productSupplier.Available() + position.Incoming
+	if reorderPoint == 0 || stock > reorderPoint {
+		return ReplenishmentSuggestion{}, false
+	}
+
+	quantity := reorderPoint - stock + max(position.Sold, 1)
+	if reorderQuantity := productSupplier.ReorderQuantity; reorderQuantity > 0 {
+		quantity = (quantity + reorderQuantity - 1) / reorderQuantity * reorderQuantity
+	}
+
+	return ReplenishmentSuggestion{
+		ProductSupplierID:   productSupplier.ID,
+		ProductID:           productSupplier.ProductID,
+		SupplierProductCode: productSupplier.SupplierProductCode,
+		SupplierProductName: productSupplier.SupplierProductName,
+		OnHand:              productSupplier.Quantity,
+		Available:           productSupplier.Available(),
+		Incoming:            position.Incoming,
+		DailySales:          math.Round(dailySales*100) / 100,
+		ReorderPoint:        reorderPoint,
+		SuggestedQuantity:   quantity,
+		Cost:                productSupplier.Cost,
+	}, true
+}
+
+// lowStockReportHeader is the header row of the low-stock report.
+var lowStockReportHeader = []string{
+	"supplier_id", "supplier_name", "product_supplier_id", "product_id", "supplier_product_code", "supplier_product_name",
+	"on_hand", "available", "incoming", "daily_sales", "reorder_point", "suggested_quantity", "cost", "currency",
+}
+
+// WriteLowStockReport writes the replenishment suggestions as a CSV low-stock
+// report, one row per product supplier to replenish, with a header row.
+func WriteLowStockReport(writer io.Writer, replenishments []SupplierReplenishment) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(lowStockReportHeader); err != nil {
+		return err
+	}
+	for _, replenishment := range replenishments {
+		for _, suggestion := range replenishment.Suggestions {
+			err := csvWriter.Write([]string{
+				strconv.FormatUint(uint64(replenishment.SupplierID), 10),
+				replenishment.SupplierName,
+				strconv.FormatUint(uint64(suggestion.ProductSupplierID), 10),
+				strconv.FormatUint(uint64(suggestion.ProductID), 10),
+				suggestion.SupplierProductCode,
+				suggestion.SupplierProductName,
+				strconv.Itoa(suggestion.OnHand),
+				strconv.Itoa(suggestion.Available),
+				strconv.Itoa(suggestion.Incoming),
+				strconv.FormatFloat(suggestion.DailySales, 'f', 2, 64),
+				strconv.Itoa(suggestion.ReorderPoint),
+				strconv.Itoa(suggestion.SuggestedQuantity),
+				suggestion.Cost.String(),
+				suggestion.Cost.Currency,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
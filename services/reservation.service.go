@@ -0,0 +1,24 @@
+package services
+
+import (
+	"store/domain/repositories"
+	"store/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReservationTTLFromEnv returns how long the stock reservations of the draft
+// orders are held, read as a number of minutes from the RESERVATION_TTL_MINUTES
+// environment variable, 30 minutes by default. A TTL of zero holds them until
+// their draft is placed, cancelled or deleted.
+func ReservationTTLFromEnv() time.Duration {
+	return time.Duration(utils.GetEnvInt("RESERVATION_TTL_MINUTES", 30)) * time.Minute
+}
+
+// ExpireReservations expires the active stock reservations held for longer
+// than ttl, giving their units back to the units available of their product
+// suppliers. It returns the number of reservations expired.
+func ExpireReservations(ctx *gin.Context, ttl time.Duration, stockReservationRepository repositories.StockReservationRepository) (int64, error) {
+	return stockReservationRepository.ExpireReservedBefore(ctx, time.Now().Add(-ttl))
+}
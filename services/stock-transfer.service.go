@@ -0,0 +1,123 @@
+package services
+
+import (
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/query"
+	"store/domain/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrUnknownStockTransferTransition is returned when a transition name is
+	// not part of the stock transfer lifecycle.
+	ErrUnknownStockTransferTransition = apperrors.BadRequest("unknown_transition", "unknown stock transfer transition")
+	// ErrSameWarehouse is returned when a stock transfer moves units to the
+	// warehouse they leave.
+	ErrSameWarehouse = apperrors.Invalid("same_warehouse", "the units of a stock transfer must go to another warehouse")
+)
+
+// stockTransferTransitions maps the transition names used in the
+// `POST /stock-transfers/:id/transitions/:transition` route to the status a
+// stock transfer in transit ends in.
+var stockTransferTransitions = map[string]entities.StockTransferStatus{
+	"receive": entities.StockTransferStatusReceived,
+	"cancel":  entities.StockTransferStatusCancelled,
+}
+
+// StockTransferService defines the methods that a service must implement to
+// move stock between the warehouses. It provides methods to ship, retrieve,
+// receive and cancel stock transfers.
+type StockTransferService interface {
+	Create(ctx *gin.Context, transfer *entities.StockTransfer) error                                // Ship a stock transfer
+	GetByID(ctx *gin.Context, id uint) (*entities.StockTransfer, error)                             // Get a stock transfer by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.StockTransfer], error)      // Get all stock transfers
+	Transition(ctx *gin.Context, id uint, transition, user string) (*entities.StockTransfer, error) // Receive or cancel a stock transfer
+}
+
+// stockTransferService is a struct that implements the StockTransferService
+// interface. It contains a StockTransferRepository which is used to interact
+// with the stock_transfers table in the database, and the WarehouseRepository
+// used to check the warehouses of the transfers.
+type stockTransferService struct {
+	stockTransferRepository repositories.StockTransferRepository
+	warehouseRepository     repositories.WarehouseRepository
+}
+
+// NewStockTransferService creates a new StockTransferService with the given
+// StockTransferRepository, and the WarehouseRepository used to check the
+// warehouses of the transfers.
+func NewStockTransferService(
+	stockTransferRepository repositories.StockTransferRepository,
+	warehouseRepository repositories.WarehouseRepository,
+) StockTransferService {
+	return &stockTransferService{
+		stockTransferRepository: stockTransferRepository,
+		warehouseRepository:     warehouseRepository,
+	}
+}
+
+// Ships a stock transfer.
+//
+// Both warehouses of the transfer must exist, otherwise
+// repositories.ErrUnknownWarehouse is returned, and must differ, otherwise
+// ErrSameWarehouse is returned. The units of the lines leave the stock of the
+// source warehouse right away and are in transit until the transfer is
+// received or cancelled. The stockTransferRepository returns
+// repositories.ErrInsufficientStock if a line moves more units than are
+// available in the source warehouse.
+func (s *stockTransferService) Create(ctx *gin.Context, transfer *entities.StockTransfer) error {
+	if transfer.FromWarehouseID == transfer.ToWarehouseID {
+		return ErrSameWarehouse
+	}
+	for _, id := range []uint{transfer.FromWarehouseID, transfer.ToWarehouseID} {
+		if err := checkWarehouse(ctx, s.warehouseRepository, id); err != nil {
+			return err
+		}
+	}
+	transfer.ShippedAt = time.Now()
+	return s.stockTransferRepository.Create(ctx, transfer)
+}
+
+// Retrieves a stock transfer with its lines by its ID, or
+// gorm.ErrRecordNotFound if it does not exist.
+func (s *stockTransferService) GetByID(ctx *gin.Context, id uint) (*entities.StockTransfer, error) {
+	return s.stockTransferRepository.GetByID(ctx, id)
+}
+
+// Retrieves a page of stock transfers.
+//
+// The method delegates the retrieval to the stockTransferRepository, which
+// applies the pagination, sorting and filters of the query.
+func (s *stockTransferService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.StockTransfer], error) {
+	return s.stockTransferRepository.GetAll(ctx, q)
+}
+
+// Receives or cancels a stock transfer in transit.
+//
+// The method takes the ID of the stock transfer, the name of the transition,
+// receive, which adds the units to the stock of the destination warehouse, or
+// cancel, which gives them back to the source warehouse, and the user applying
+// it. It returns ErrUnknownStockTransferTransition if the transition does not
+// exist, and repositories.ErrTransferNotInTransit if the transfer was already
+// received or cancelled.
+func (s *stockTransferService) Transition(ctx *gin.Context, id uint, transition, user string) (*entities.StockTransfer, error) {
+	status, ok := stockTransferTransitions[transition]
+	if !ok {
+		return nil, ErrUnknownStockTransferTransition
+	}
+
+	transfer, err := s.stockTransferRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != entities.StockTransferStatusInTransit {
+		return nil, repositories.ErrTransferNotInTransit.With("status", transfer.Status)
+	}
+	if err := s.stockTransferRepository.Complete(ctx, transfer, status, user); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
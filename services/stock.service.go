@@ -0,0 +1,178 @@
+package services
+
+import (
+	"store/domain/apperrors"
+	"store/domain/entities"
+	"store/domain/query"
+	"store/domain/repositories"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidMovement is returned when a movement of stock recorded by hand has
+// no quantity, or a receipt takes units out of stock.
+var ErrInvalidMovement = apperrors.Invalid("invalid_movement", "stock movement quantity must be non-zero, and positive for a receipt")
+
+// StockReconciliation lists the stock counters that drifted from the stock
+// ledger.
+type StockReconciliation struct {
+	ProductSuppliers []repositories.StockDrift `json:"product_suppliers"` // product suppliers whose quantity drifted
+	Suppliers        []repositories.StockDrift `json:"suppliers"`         // suppliers whose stock drifted
+}
+
+// ExpiringStockReport lists the lots on hand expiring within a number of days,
+// the expired ones included.
+type ExpiringStockReport struct {
+	Until time.Time     `json:"until"` // last expiry date reported
+	Lots  []ExpiringLot `json:"lots"`  // lots expiring up to the date, from the first to expire
+}
+
+// ExpiringLot is a lot on hand of an ExpiringStockReport.
+type ExpiringLot struct {
+	*entities.StockLot
+	DaysLeft int  `json:"days_left"` // days until the expiry date, negative once expired
+	Expired  bool `json:"expired"`   // whether the units of the lot can no longer be sold
+}
+
+// StockService is an interface that defines the methods that a service must
+// implement to manage the stock ledger of the product suppliers. It provides
+// methods to record a movement of stock, list the movements of a product
+// supplier, reconcile the stock counters with the ledger, list the stock
+// reservations of an order and list the lots of the stock and their expiry.
+type StockService interface {
+	RecordMovement(ctx *gin.Context, movement *entities.StockMovement) error                                                 // Records a movement of stock
+	GetMovements(ctx *gin.Context, productSupplierID uint, q *query.ListQuery) (*query.Page[*entities.StockMovement], error) // Retrieves the movements of a product supplier
+	Reconcile(ctx *gin.Context) (*StockReconciliation, error)                                                                // Reconciles the stock counters with the ledger
+	GetReservations(ctx *gin.Context, orderID uint) ([]*entities.StockReservation, error)                                    // Retrieves the stock reservations of an order
+	GetLots(ctx *gin.Context, productSupplierID uint, q *query.ListQuery) (*query.Page[*entities.StockLot], error)           // Retrieves the lots of a product supplier
+	GetExpiring(ctx *gin.Context, days int) (*ExpiringStockReport, error)                                                    // Retrieves the lots expiring within a number of days
+}
+
+// stockService is a struct that implements the StockService interface. It
+// contains a StockMovementRepository which is used to interact with the
+// stock_movements table in the database, a StockReservationRepository for the
+// stock_reservations table, a StockLotRepository for the stock_lots table, and
+// the ProductSupplierRepository and OrderRepository to check the product
+// suppliers and orders exist.
+type stockService struct {
+	stockMovementRepository    repositories.StockMovementRepository
+	stockReservationRepository repositories.StockReservationRepository
+	stockLotRepository         repositories.StockLotRepository
+	productSupplierRepository  repositories.ProductSupplierRepository
+	orderRepository            repositories.OrderRepository
+}
+
+// NewStockService creates a new StockService with the given
+// StockMovementRepository, StockReservationRepository and StockLotRepository,
+// and the ProductSupplierRepository and OrderRepository used to check the
+// product suppliers and orders exist.
+func NewStockService(
+	stockMovementRepository repositories.StockMovementRepository,
+	stockReservationRepository repositories.StockReservationRepository,
+	stockLotRepository repositories.StockLotRepository,
+	productSupplierRepository repositories.ProductSupplierRepository,
+	orderRepository repositories.OrderRepository,
+) StockService {
+	return &stockService{
+		stockMovementRepository:    stockMovementRepository,
+		stockReservationRepository: stockReservationRepository,
+		stockLotRepository:         stockLotRepository,
+		productSupplierRepository:  productSupplierRepository,
+		orderRepository:            orderRepository,
+	}
+}
+
+// Records a movement of the stock of a product supplier, such as a receipt from
+// the supplier or an adjustment after a count.
+//
+// The quantity of the movement must not be zero, and must be positive for a
+// receipt, otherwise ErrInvalidMovement is returned. The method delegates the
+// recording to the stockMovementRepository, which returns
+// gorm.ErrRecordNotFound if the product supplier does not exist,
+// ErrInsufficientStock if the movement takes more units than it has, and
+// ErrUnknownLot or ErrLotExpiryMismatch if the lot of the movement is wrong.
+func (s *stockService) RecordMovement(ctx *gin.Context, movement *entities.StockMovement) error {
+	if movement.Quantity == 0 || (movement.Type == entities.StockMovementReceipt && movement.Quantity < 0) {
+		return ErrInvalidMovement
+	}
+	return s.stockMovementRepository.Create(ctx, movement)
+}
+
+// Retrieves a page of the movements of the stock of a product supplier.
+//
+// The method returns gorm.ErrRecordNotFound if the product supplier does not
+// exist. Otherwise it delegates the retrieval to the stockMovementRepository,
+// which applies the pagination, sorting and filters of the query.
+func (s *stockService) GetMovements(ctx *gin.Context, productSupplierID uint, q *query.ListQuery) (*query.Page[*entities.StockMovement], error) {
+	if _, err := s.productSupplierRepository.GetByID(ctx, productSupplierID); err != nil {
+		return nil, err
+	}
+	return s.stockMovementRepository.GetAllByProductSupplierID(ctx, productSupplierID, q)
+}
+
+// Reconciles the stock counters with the stock ledger.
+//
+// The method returns the product suppliers and the suppliers whose stock
+// differs from the one derived from their movements, each of them with its
+// drift. Both lists are empty when every counter matches the ledger.
+func (s *stockService) Reconcile(ctx *gin.Context) (*StockReconciliation, error) {
+	productSuppliers, suppliers, err := s.stockMovementRepository.Reconcile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reconciliation := &StockReconciliation{ProductSuppliers: productSuppliers, Suppliers: suppliers}
+	if reconciliation.ProductSuppliers == nil {
+		reconciliation.ProductSuppliers = []repositories.StockDrift{}
+	}
+	if reconciliation.Suppliers == nil {
+		reconciliation.Suppliers = []repositories.StockDrift{}
+	}
+	return reconciliation, nil
+}
+
+// Retrieves the stock reservations of an order, active or not.
+//
+// The method returns gorm.ErrRecordNotFound if the order does not exist.
+// Otherwise it delegates the retrieval to the stockReservationRepository and
+// returns the reservations from the oldest to the newest one.
+func (s *stockService) GetReservations(ctx *gin.Context, orderID uint) ([]*entities.StockReservation, error) {
+	if _, err := s.orderRepository.GetByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return s.stockReservationRepository.GetAllByOrderID(ctx, orderID)
+}
+
+// Retrieves a page of the lots of a product supplier in every warehouse.
+//
+// The method returns gorm.ErrRecordNotFound if the product supplier does not
+// exist. Otherwise it delegates the retrieval to the stockLotRepository, which
+// applies the pagination, sorting and filters of the query.
+func (s *stockService) GetLots(ctx *gin.Context, productSupplierID uint, q *query.ListQuery) (*query.Page[*entities.StockLot], error) {
+	if _, err := s.productSupplierRepository.GetByID(ctx, productSupplierID); err != nil {
+		return nil, err
+	}
+	return s.stockLotRepository.GetAllByProductSupplierID(ctx, productSupplierID, q)
+}
+
+// Retrieves the lots on hand expiring within the given number of days from
+// today, the expired ones included, with the days left until their expiry
+// date.
+func (s *stockService) GetExpiring(ctx *gin.Context, days int) (*ExpiringStockReport, error) {
+	now := time.Now()
+	today := now.UTC().Truncate(24 * time.Hour)
+	report := &ExpiringStockReport{Until: today.AddDate(0, 0, days), Lots: []ExpiringLot{}}
+
+	lots, err := s.stockLotRepository.GetExpiring(ctx, report.Until)
+	if err != nil {
+		return nil, err
+	}
+	for _, lot := range lots {
+		report.Lots = append(report.Lots, ExpiringLot{
+			StockLot: lot,
+			DaysLeft: int(lot.ExpiresAt.UTC().Truncate(24*time.Hour).Sub(today).Hours() / 24),
+			Expired:  lot.Expired(now),
+		})
+	}
+	return report, nil
+}
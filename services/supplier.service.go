@@ -2,6 +2,7 @@ package services
 
 import (
 	"store/domain/entities"
+	"store/domain/query"
 	"store/domain/repositories"
 
 	"github.com/gin-gonic/gin"
@@ -11,12 +12,13 @@ import (
 // suppliers in the application. It provides methods to create, retrieve, update,
 // and delete supplier entities.
 type SupplierService interface {
-	Create(ctx *gin.Context, supplier *entities.Supplier) error    // Create a new supplier
-	GetByID(ctx *gin.Context, id uint) (*entities.Supplier, error) // Get a supplier by ID
-	GetAll(ctx *gin.Context) ([]*entities.Supplier, error)         // Get all suppliers
-	Update(ctx *gin.Context, supplier *entities.Supplier) error    // Update a supplier
-	Delete(ctx *gin.Context, id uint) error                        // Delete a supplier
-	DeleteAll(ctx *gin.Context, ids []uint) error                  // Delete multiple suppliers
+	Create(ctx *gin.Context, supplier *entities.Supplier) error                           // Create a new supplier
+	GetByID(ctx *gin.Context, id uint) (*entities.Supplier, error)                        // Get a supplier by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Supplier], error) // Get all suppliers
+	Update(ctx *gin.Context, supplier *entities.Supplier, fields ...string) error         // Update a supplier
+	Delete(ctx *gin.Context, id uint, version uint) error                                 // Delete a supplier
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)          // Delete multiple suppliers
+	TrashService[entities.Supplier]                                                       // Get, restore and purge deleted suppliers
 }
 
 // supplierService is a struct that implements the SupplierService interface.
@@ -24,13 +26,17 @@ type SupplierService interface {
 // with the suppliers table in the database.
 type supplierService struct {
 	supplierRepository repositories.SupplierRepository
+	addressService     AddressService
+	TrashService[entities.Supplier]
 }
 
 // NewSupplierService creates a new SupplierService with the given supplierRepository.
 // The SupplierService is an interface that defines methods for creating, retrieving,
-// updating, and deleting suppliers in the application.
-func NewSupplierService(supplierRepository repositories.SupplierRepository) SupplierService {
-	return &supplierService{supplierRepository: supplierRepository}
+// updating, and deleting suppliers in the application. The address of the contact
+// created along with a supplier is completed from its postal code by the
+// addressService.
+func NewSupplierService(supplierRepository repositories.SupplierRepository, addressService AddressService) SupplierService {
+	return &supplierService{supplierRepository: supplierRepository, addressService: addressService, TrashService: supplierRepository}
 }
 
 // Create a new supplier in the database.
@@ -44,7 +50,15 @@ func NewSupplierService(supplierRepository repositories.SupplierRepository) Supp
 //
 // The method returns an error if something goes wrong. If the supplier is created
 // successfully, the method returns nil.
+//
+// The addresses of the contacts created along with the supplier are first
+// completed from their postal code and their phones are normalized, as
+// described in completeContacts, which also picks the default contact of each
+// type.
 func (s *supplierService) Create(ctx *gin.Context, supplier *entities.Supplier) error {
+	if err := completeContacts(ctx, s.addressService, supplier.Contacts); err != nil {
+		return err
+	}
 	return s.supplierRepository.Create(ctx, supplier)
 }
 
@@ -62,17 +76,14 @@ func (s *supplierService) GetByID(ctx *gin.Context, id uint) (*entities.Supplier
 	return s.supplierRepository.GetByID(ctx, id)
 }
 
-// Retrieves all suppliers from the database.
-//
-// The method takes a pointer to a *gin.Context as a parameter.
-// It returns a slice of pointers to entities.Supplier and an error.
-// If something goes wrong, the method returns an empty slice and an error.
+// Retrieves a page of suppliers.
 //
-// The method retrieves all suppliers from the database using the supplierRepository.
-// If suppliers are found, it returns the suppliers and nil. If no suppliers
-// are found or an error occurs, it returns an empty slice and an error.
-func (s *supplierService) GetAll(ctx *gin.Context) ([]*entities.Supplier, error) {
-	return s.supplierRepository.GetAll(ctx)
+// The method takes a pointer to a *gin.Context and the list query parsed from
+// the request. It delegates the retrieval to the supplierRepository, which
+// applies the pagination, sorting and filters of the query, and returns the
+// page of suppliers or an error if the retrieval fails.
+func (s *supplierService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Supplier], error) {
+	return s.supplierRepository.GetAll(ctx, q)
 }
 
 // Updates a supplier in the database.
@@ -83,8 +94,11 @@ func (s *supplierService) GetAll(ctx *gin.Context) ([]*entities.Supplier, error)
 // The method updates a supplier in the database using the given supplier object.
 // If the supplier is updated successfully, the method returns nil. If an error
 // occurs, the method returns an error.
-func (s *supplierService) Update(ctx *gin.Context, supplier *entities.Supplier) error {
-	return s.supplierRepository.Update(ctx, supplier)
+//
+// When fields are given, only those fields of the supplier, named as in its
+// struct, are saved, as done for partial updates.
+func (s *supplierService) Update(ctx *gin.Context, supplier *entities.Supplier, fields ...string) error {
+	return s.supplierRepository.Update(ctx, supplier, fields...)
 }
 
 // Deletes a supplier by its ID from the database.
@@ -96,18 +110,19 @@ func (s *supplierService) Update(ctx *gin.Context, supplier *entities.Supplier)
 // given ID. If the supplier is successfully deleted, the method returns
 // nil. If the supplier is not found or an error occurs, the method returns
 // an error.
-func (s *supplierService) Delete(ctx *gin.Context, id uint) error {
-	return s.supplierRepository.Delete(ctx, id)
+//
+// The supplier is only deleted if it is still at the given version, otherwise
+// repositories.ErrVersionConflict is returned.
+func (s *supplierService) Delete(ctx *gin.Context, id uint, version uint) error {
+	return s.supplierRepository.Delete(ctx, id, version)
 }
 
 // Deletes multiple suppliers by their IDs from the database.
 //
-// The method takes a pointer to a *gin.Context and a slice of uints as parameters.
-// It returns an error if something goes wrong.
-//
-// The method deletes multiple suppliers from the database using the given slice
-// of IDs. If the suppliers are deleted successfully, the method returns nil.
-// If an error occurs, the method returns an error.
-func (s *supplierService) DeleteAll(ctx *gin.Context, ids []uint) error {
+// The method takes a context and a slice of uints as parameters. It delegates
+// the deletion to the supplierRepository, which deletes the suppliers that
+// exist and are not referenced anymore in a single transaction. It returns the
+// outcome for each id, or an error if the deletion process fails.
+func (s *supplierService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
 	return s.supplierRepository.DeleteAll(ctx, ids)
 }
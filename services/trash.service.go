@@ -0,0 +1,47 @@
+package services
+
+import (
+	"store/domain/repositories"
+	"store/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashService is an interface that defines the methods to list, restore and
+// purge the soft-deleted entities of type E. Every entity service provides it
+// by delegating to the TrashRepository of its repository.
+type TrashService[E any] interface {
+	repositories.TrashRepository[E]
+}
+
+// Purger is implemented by every TrashRepository and permanently deletes the
+// entities deleted before a given time.
+type Purger interface {
+	PurgeDeletedBefore(ctx *gin.Context, cutoff time.Time) (int64, error)
+}
+
+// TrashRetentionFromEnv returns how long deleted entities are kept in the
+// trash, read as a number of days from the TRASH_RETENTION_DAYS environment
+// variable. A retention of zero, the default, keeps them forever.
+func TrashRetentionFromEnv() time.Duration {
+	return time.Duration(utils.GetEnvInt("TRASH_RETENTION_DAYS", 0)) * 24 * time.Hour
+}
+
+// PurgeTrash permanently deletes the entities deleted more than retention ago
+// with every purger, in the given order, so dependent entities such as order
+// lines are purged before the entities they reference. Entities still
+// referenced by other rows are kept. It returns the number of entities purged.
+func PurgeTrash(ctx *gin.Context, retention time.Duration, purgers ...Purger) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+
+	var purged int64
+	for _, purger := range purgers {
+		n, err := purger.PurgeDeletedBefore(ctx, cutoff)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"store/domain/entities"
+	"store/domain/query"
+	"store/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WarehouseStockLevel is the stock of a product supplier in a warehouse.
+type WarehouseStockLevel struct {
+	WarehouseID   uint   `json:"warehouse_id"`   // warehouse holding the stock
+	WarehouseCode string `json:"warehouse_code"` // short code of the warehouse
+	IsDefault     bool   `json:"is_default"`     // whether the warehouse is the default one
+	Quantity      int    `json:"quantity"`       // units on hand in the warehouse
+	InTransit     int    `json:"in_transit"`     // units shipped to the warehouse by stock transfers not received yet
+}
+
+// WarehouseService defines the methods that a service must implement to manage
+// the warehouses the stock is split between. It provides methods to create,
+// retrieve, update and delete warehouses, and to retrieve the stock on hand in
+// a warehouse or the stock of a product supplier in every warehouse.
+type WarehouseService interface {
+	Create(ctx *gin.Context, warehouse *entities.Warehouse) error                                          // Create a new warehouse
+	GetByID(ctx *gin.Context, id uint) (*entities.Warehouse, error)                                        // Get a warehouse by ID
+	GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Warehouse], error)                 // Get all warehouses
+	Update(ctx *gin.Context, warehouse *entities.Warehouse, fields ...string) error                        // Update a warehouse
+	Delete(ctx *gin.Context, id uint, version uint) error                                                  // Delete a warehouse
+	DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error)                           // Delete multiple warehouses
+	GetStock(ctx *gin.Context, id uint, q *query.ListQuery) (*query.Page[*entities.WarehouseStock], error) // Get the stock on hand in a warehouse
+	GetProductSupplierStock(ctx *gin.Context, productSupplierID uint) ([]WarehouseStockLevel, error)       // Get the stock of a product supplier in every warehouse
+	TrashService[entities.Warehouse]                                                                       // Get, restore and purge deleted warehouses
+}
+
+// warehouseService is a struct that implements the WarehouseService interface.
+// It contains a WarehouseRepository which is used to interact with the
+// warehouses table in the database, the StockTransferRepository giving the
+// units in transit, and the ProductSupplierRepository used to check the
+// product suppliers exist.
+type warehouseService struct {
+	warehouseRepository       repositories.WarehouseRepository
+	stockTransferRepository   repositories.StockTransferRepository
+	productSupplierRepository repositories.ProductSupplierRepository
+	TrashService[entities.Warehouse]
+}
+
+// NewWarehouseService creates a new WarehouseService with the given
+// WarehouseRepository, the StockTransferRepository giving the units in
+// transit, and the ProductSupplierRepository used to check the product
+// suppliers exist.
+func NewWarehouseService(
+	warehouseRepository repositories.WarehouseRepository,
+	stockTransferRepository repositories.StockTransferRepository,
+	productSupplierRepository repositories.ProductSupplierRepository,
+) WarehouseService {
+	return &warehouseService{
+		warehouseRepository:       warehouseRepository,
+		stockTransferRepository:   stockTransferRepository,
+		productSupplierRepository: productSupplierRepository,
+		TrashService:              warehouseRepository,
+	}
+}
+
+// Creates a new warehouse.
+//
+// The method delegates the creation to the warehouseRepository. A warehouse
+// created as the default one replaces the current default warehouse.
+func (s *warehouseService) Create(ctx *gin.Context, warehouse *entities.Warehouse) error {
+	return s.warehouseRepository.Create(ctx, warehouse)
+}
+
+// Retrieves a warehouse by its ID, or gorm.ErrRecordNotFound if it does not
+// exist.
+func (s *warehouseService) GetByID(ctx *gin.Context, id uint) (*entities.Warehouse, error) {
+	return s.warehouseRepository.GetByID(ctx, id)
+}
+
+// Retrieves a page of warehouses.
+//
+// The method delegates the retrieval to the warehouseRepository, which
+// applies the pagination, sorting and filters of the query.
+func (s *warehouseService) GetAll(ctx *gin.Context, q *query.ListQuery) (*query.Page[*entities.Warehouse], error) {
+	return s.warehouseRepository.GetAll(ctx, q)
+}
+
+// Updates a warehouse.
+//
+// When fields are given, only those fields of the warehouse, named as in its
+// struct, are saved, as done for partial updates. A warehouse made the default
+// one replaces the current default warehouse, while the default warehouse
+// cannot stop being the default one, which returns
+// repositories.ErrDefaultWarehouseRequired.
+func (s *warehouseService) Update(ctx *gin.Context, warehouse *entities.Warehouse, fields ...string) error {
+	return s.warehouseRepository.Update(ctx, warehouse, fields...)
+}
+
+// Deletes a warehouse by its ID.
+//
+// The warehouse is only deleted if it is still at the given version, otherwise
+// repositories.ErrVersionConflict is returned. The default warehouse and the
+// warehouses with stock on hand or with transfers in transit are kept, which
+// returns repositories.ErrWarehouseInUse.
+func (s *warehouseService) Delete(ctx *gin.Context, id uint, version uint) error {
+	return s.warehouseRepository.Delete(ctx, id, version)
+}
+
+// Deletes multiple warehouses by their IDs.
+//
+// The method delegates the deletion to the warehouseRepository, which deletes
+// the warehouses that exist and are not in use in a single transaction. It
+// returns the outcome for each id.
+func (s *warehouseService) DeleteAll(ctx *gin.Context, ids []uint) ([]repositories.DeleteResult, error) {
+	return s.warehouseRepository.DeleteAll(ctx, ids)
+}
+
+// Retrieves a page of the stock on hand in a warehouse.
+//
+// The method returns gorm.ErrRecordNotFound if the warehouse does not exist.
+// Otherwise it delegates the retrieval to the warehouseRepository, which
+// applies the pagination, sorting and filters of the query.
+func (s *warehouseService) GetStock(ctx *gin.Context, id uint, q *query.ListQuery) (*query.Page[*entities.WarehouseStock], error) {
+	if _, err := s.warehouseRepository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.warehouseRepository.GetStock(ctx, id, q)
+}
+
+// Retrieves the stock of a product supplier in every warehouse.
+//
+// The method returns gorm.ErrRecordNotFound if the product supplier does not
+// exist. Otherwise it returns one level per warehouse, the default one first,
+// with the units on hand and the units in transit to it. The quantities on
+// hand add up to the quantity of the product supplier.
+func (s *warehouseService) GetProductSupplierStock(ctx *gin.Context, productSupplierID uint) ([]WarehouseStockLevel, error) {
+	if _, err := s.productSupplierRepository.GetByID(ctx, productSupplierID); err != nil {
+		return nil, err
+	}
+	warehouses, err := s.warehouseRepository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stocks, err := s.warehouseRepository.GetStockOf(ctx, []uint{productSupplierID})
+	if err != nil {
+		return nil, err
+	}
+	inTransit, err := s.stockTransferRepository.GetInTransit(ctx, productSupplierID)
+	if err != nil {
+		return nil, err
+	}
+
+	onHand := make(map[uint]int, len(stocks))
+	for _, stock := range stocks {
+		onHand[stock.WarehouseID] = stock.Quantity
+	}
+	levels := make([]WarehouseStockLevel, 0, len(warehouses))
+	for _, warehouse := range warehouses {
+		levels = append(levels, WarehouseStockLevel{
+			WarehouseID:   warehouse.ID,
+			WarehouseCode: warehouse.Code,
+			IsDefault:     warehouse.IsDefault,
+			Quantity:      onHand[warehouse.ID],
+			InTransit:     inTransit[warehouse.ID],
+		})
+	}
+	return levels, nil
+}
+
+// checkWarehouse returns repositories.ErrUnknownWarehouse if no warehouse has
+// the given ID.
+func checkWarehouse(ctx *gin.Context, warehouseRepository repositories.WarehouseRepository, id uint) error {
+	_, err := warehouseRepository.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repositories.ErrUnknownWarehouse.With("warehouse_id", id)
+	}
+	return err
+}
@@ -0,0 +1,10 @@
+package utils
+
+import "github.com/gin-gonic/gin"
+
+// BackgroundContext returns an empty *gin.Context to call repositories and
+// services outside of an HTTP request, such as from commands and background
+// jobs. Like context.Background, it is never cancelled and has no deadline.
+func BackgroundContext() *gin.Context {
+	return &gin.Context{}
+}
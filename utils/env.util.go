@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// GetEnv returns the value of the environment variable named by key, or
+// fallback if the variable is not set or empty.
+func GetEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvInt returns the value of the environment variable named by key as an
+// int, or fallback if the variable is not set or is not a valid integer.
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvBool returns the value of the environment variable named by key as a
+// bool, or fallback if the variable is not set or is not a valid boolean.
+func GetEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}